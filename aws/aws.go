@@ -2,16 +2,29 @@ package aws
 
 import (
 	"fmt"
+	"os"
+	"sync"
 
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/aws/credentials/stscreds"
 	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbiface"
+	"github.com/aws/aws-sdk-go/service/iam"
+	"github.com/aws/aws-sdk-go/service/iam/iamiface"
+	"github.com/aws/aws-sdk-go/service/kms"
+	"github.com/aws/aws-sdk-go/service/kms/kmsiface"
 	"github.com/aws/aws-sdk-go/service/lambda"
 	"github.com/aws/aws-sdk-go/service/lambda/lambdaiface"
 	"github.com/aws/aws-sdk-go/service/s3"
 	"github.com/aws/aws-sdk-go/service/s3/s3iface"
 	"github.com/aws/aws-sdk-go/service/sfn"
 	"github.com/aws/aws-sdk-go/service/sfn/sfniface"
+	"github.com/aws/aws-sdk-go/service/sns"
+	"github.com/aws/aws-sdk-go/service/sns/snsiface"
+	"github.com/aws/aws-sdk-go/service/sts"
+	"github.com/aws/aws-sdk-go/service/sts/stsiface"
+	"github.com/coinbase/step/utils/to"
 )
 
 ////////////
@@ -21,6 +34,11 @@ import (
 type S3API s3iface.S3API
 type LambdaAPI lambdaiface.LambdaAPI
 type SFNAPI sfniface.SFNAPI
+type DynamoDBAPI dynamodbiface.DynamoDBAPI
+type IAMAPI iamiface.IAMAPI
+type SNSAPI snsiface.SNSAPI
+type KMSAPI kmsiface.KMSAPI
+type STSAPI stsiface.STSAPI
 
 type AwsClients interface {
 	S3Client(region *string, account_id *string, role *string) S3API
@@ -28,26 +46,61 @@ type AwsClients interface {
 	SFNClient(region *string, account_id *string, role *string) SFNAPI
 }
 
+// KMSClients is implemented by AwsClients that can also use KMS
+type KMSClients interface {
+	KMSClient(region *string, account_id *string, role *string) KMSAPI
+}
+
+// SNSClients is implemented by AwsClients that can also publish to SNS
+type SNSClients interface {
+	SNSClient(region *string, account_id *string, role *string) SNSAPI
+}
+
+// STSClients is implemented by AwsClients that can also use STS
+type STSClients interface {
+	STSClient(region *string, account_id *string, role *string) STSAPI
+}
+
 ////////////
 // AWS Clients
 ////////////
 
+// EndpointEnv is the environment variable used as the Endpoint if it is not set, e.g. for LocalStack
+const EndpointEnv = "AWS_ENDPOINT_URL"
+
 type Clients struct {
+	// Endpoint overrides the AWS endpoint URL for every client, e.g. http://localhost:4566
+	Endpoint *string
+	// S3ForcePathStyle uses bucket names in the path, it is always used with a custom Endpoint
+	S3ForcePathStyle bool
+	// RoleArn is assumed by clients created without an account_id and role
+	RoleArn *string
+	// ExternalID is sent with every AssumeRole call if set
+	ExternalID *string
+
+	mu      sync.Mutex
 	session *session.Session
 	configs map[string]*aws.Config
 }
 
-func (c Clients) Session() *session.Session {
+func (c *Clients) Session() *session.Session {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.sessionLocked()
+}
+
+func (c *Clients) sessionLocked() *session.Session {
 	if c.session != nil {
 		return c.session
 	}
 	// new session
-	sess := session.Must(session.NewSession())
-	c.session = sess
-	return sess
+	c.session = session.Must(session.NewSession())
+	return c.session
 }
 
-func (c Clients) Config(
+// Config returns the config for region, assuming the role in account_id if both are given,
+// otherwise RoleArn if it is set. Assumed role credentials are cached per region and role
+func (c *Clients) Config(
 	region *string,
 	account_id *string,
 	role *string) *aws.Config {
@@ -58,28 +111,35 @@ func (c Clients) Config(
 		config = config.WithRegion(*region)
 	}
 
-	// return no config for nil inputs
-	if account_id == nil || role == nil {
-		return config
+	if endpoint := c.endpoint(); endpoint != nil {
+		config = config.WithEndpoint(*endpoint)
+	}
+
+	arn := c.RoleArn
+	if account_id != nil && role != nil {
+		arn = to.RoleArn(to.Strp(to.PartitionForRegion(region)), account_id, role)
 	}
 
-	// Assume a role
-	arn := fmt.Sprintf(
-		"arn:aws:iam::%v:role/%v",
-		*account_id,
-		*role,
-	)
+	// return no config for no role
+	if arn == nil {
+		return config
+	}
 
 	// include region in cache key otherwise concurrency errors
-	key := fmt.Sprintf("%v::%v", *region, arn)
+	key := fmt.Sprintf("%v::%v", to.Strs(region), *arn)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
 
 	// check for cached config
-	if c.configs != nil && c.configs[key] != nil {
+	if c.configs[key] != nil {
 		return c.configs[key]
 	}
 
-	// new creds
-	creds := stscreds.NewCredentials(c.Session(), arn)
+	// new creds, refreshed by the provider before they expire
+	creds := stscreds.NewCredentials(c.sessionLocked(), *arn, func(p *stscreds.AssumeRoleProvider) {
+		p.ExternalID = c.ExternalID
+	})
 
 	// new config
 	config = config.WithCredentials(creds)
@@ -92,11 +152,29 @@ func (c Clients) Config(
 	return config
 }
 
+// endpoint returns Endpoint, or EndpointEnv if set
+func (c *Clients) endpoint() *string {
+	if c.Endpoint != nil {
+		return c.Endpoint
+	}
+
+	if endpoint := os.Getenv(EndpointEnv); endpoint != "" {
+		return &endpoint
+	}
+
+	return nil
+}
+
 func (c *Clients) S3Client(
 	region *string,
 	account_id *string,
 	role *string) S3API {
-	return s3.New(c.Session(), c.Config(region, account_id, role))
+	config := c.Config(region, account_id, role)
+	if c.S3ForcePathStyle || c.endpoint() != nil {
+		// Copy so the cached config is not changed for other clients
+		config = config.Copy().WithS3ForcePathStyle(true)
+	}
+	return s3.New(c.Session(), config)
 }
 
 func (c *Clients) LambdaClient(region *string, account_id *string, role *string) LambdaAPI {
@@ -106,3 +184,23 @@ func (c *Clients) LambdaClient(region *string, account_id *string, role *string)
 func (c *Clients) SFNClient(region *string, account_id *string, role *string) SFNAPI {
 	return sfn.New(c.Session(), c.Config(region, account_id, role))
 }
+
+func (c *Clients) DynamoDBClient(region *string, account_id *string, role *string) DynamoDBAPI {
+	return dynamodb.New(c.Session(), c.Config(region, account_id, role))
+}
+
+func (c *Clients) IAMClient(region *string, account_id *string, role *string) IAMAPI {
+	return iam.New(c.Session(), c.Config(region, account_id, role))
+}
+
+func (c *Clients) SNSClient(region *string, account_id *string, role *string) SNSAPI {
+	return sns.New(c.Session(), c.Config(region, account_id, role))
+}
+
+func (c *Clients) KMSClient(region *string, account_id *string, role *string) KMSAPI {
+	return kms.New(c.Session(), c.Config(region, account_id, role))
+}
+
+func (c *Clients) STSClient(region *string, account_id *string, role *string) STSAPI {
+	return sts.New(c.Session(), c.Config(region, account_id, role))
+}
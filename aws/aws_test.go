@@ -0,0 +1,56 @@
+package aws
+
+import (
+	"os"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/lambda"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/coinbase/step/utils/to"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Clients_Endpoint(t *testing.T) {
+	os.Unsetenv(EndpointEnv)
+	region := to.Strp("us-east-1")
+
+	awsc := &Clients{}
+	s3c := awsc.S3Client(region, nil, nil).(*s3.S3)
+	assert.Equal(t, "https://s3.amazonaws.com", s3c.Endpoint)
+	assert.False(t, aws.BoolValue(s3c.Config.S3ForcePathStyle))
+
+	awsc = &Clients{Endpoint: to.Strp("http://localhost:4566")}
+	s3c = awsc.S3Client(region, nil, nil).(*s3.S3)
+	assert.Equal(t, "http://localhost:4566", s3c.Endpoint)
+	assert.True(t, *s3c.Config.S3ForcePathStyle)
+
+	lambdac := awsc.LambdaClient(region, nil, nil).(*lambda.Lambda)
+	assert.Equal(t, "http://localhost:4566", lambdac.Endpoint)
+
+	os.Setenv(EndpointEnv, "http://localstack:4566")
+	defer os.Unsetenv(EndpointEnv)
+
+	awsc = &Clients{}
+	s3c = awsc.S3Client(region, nil, nil).(*s3.S3)
+	assert.Equal(t, "http://localstack:4566", s3c.Endpoint)
+	assert.True(t, *s3c.Config.S3ForcePathStyle)
+}
+
+func Test_Clients_Config_AssumeRole(t *testing.T) {
+	region := to.Strp("us-east-1")
+	awsc := &Clients{}
+
+	assert.Nil(t, awsc.Config(region, nil, nil).Credentials)
+
+	config := awsc.Config(region, to.Strp("000000000000"), to.Strp("step-deployer"))
+	assert.NotNil(t, config.Credentials)
+	assert.Equal(t, config, awsc.Config(region, to.Strp("000000000000"), to.Strp("step-deployer")))
+	assert.NotEqual(t, config, awsc.Config(to.Strp("us-west-2"), to.Strp("000000000000"), to.Strp("step-deployer")))
+
+	awsc = &Clients{RoleArn: to.Strp("arn:aws:iam::000000000000:role/step-deployer"), ExternalID: to.Strp("id")}
+	config = awsc.Config(region, nil, nil)
+	assert.NotNil(t, config.Credentials)
+	assert.Equal(t, config, awsc.Config(region, nil, nil))
+	assert.Len(t, awsc.configs, 1)
+}
@@ -0,0 +1,92 @@
+// dynamodb tools
+package dynamodb
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/coinbase/step/aws"
+	"github.com/coinbase/step/utils/to"
+)
+
+// LockBackend stores locks in a DynamoDB table with the hash key "lock_path".
+// Locks are written with a conditional PutItem so only one UUID can hold a lock,
+// "expires_at" can be used as the table's TTL attribute to clean up expired locks
+type LockBackend struct {
+	DynamoDB aws.DynamoDBAPI
+	Table    *string
+	MaxAge   time.Duration // TTL written into the locks this backend grabs, 0 never expires
+}
+
+// GrabLock puts the lock item if it does not exist, is held by uuid, or has passed the expires_at its holder wrote
+func (b *LockBackend) GrabLock(lockPath string, uuid string) (bool, error) {
+	now := time.Now()
+
+	item := map[string]*dynamodb.AttributeValue{
+		"lock_path":  {S: &lockPath},
+		"uuid":       {S: &uuid},
+		"created_at": {N: to.Strp(strconv.FormatInt(now.Unix(), 10))},
+	}
+
+	// Locks without expires_at never expire, whatever the MaxAge of the grabber
+	condition := "attribute_not_exists(lock_path) OR #uuid = :uuid OR expires_at < :now"
+	values := map[string]*dynamodb.AttributeValue{
+		":uuid": {S: &uuid},
+		":now":  {N: to.Strp(strconv.FormatInt(now.Unix(), 10))},
+	}
+
+	if b.MaxAge > 0 {
+		item["expires_at"] = &dynamodb.AttributeValue{N: to.Strp(strconv.FormatInt(now.Add(b.MaxAge).Unix(), 10))}
+	}
+
+	_, err := b.DynamoDB.PutItem(&dynamodb.PutItemInput{
+		TableName:                 b.Table,
+		Item:                      item,
+		ConditionExpression:       &condition,
+		ExpressionAttributeNames:  map[string]*string{"#uuid": to.Strp("uuid")},
+		ExpressionAttributeValues: values,
+	})
+
+	if isConditionalCheckFailed(err) {
+		// Held by another UUID
+		return false, nil
+	}
+
+	if err != nil {
+		// Might have created the lock
+		return true, err
+	}
+
+	return true, nil
+}
+
+// ReleaseLock deletes the lock item if it is held by uuid
+func (b *LockBackend) ReleaseLock(lockPath string, uuid string) error {
+	_, err := b.DynamoDB.DeleteItem(&dynamodb.DeleteItemInput{
+		TableName: b.Table,
+		Key: map[string]*dynamodb.AttributeValue{
+			"lock_path": {S: &lockPath},
+		},
+		ConditionExpression:      to.Strp("attribute_not_exists(lock_path) OR #uuid = :uuid"),
+		ExpressionAttributeNames: map[string]*string{"#uuid": to.Strp("uuid")},
+		ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{
+			":uuid": {S: &uuid},
+		},
+	})
+
+	if isConditionalCheckFailed(err) {
+		return fmt.Errorf("Release with UUID(%v) is trying to unlock a lock it does not hold at %v", uuid, lockPath)
+	}
+
+	return err
+}
+
+func isConditionalCheckFailed(err error) bool {
+	if aerr, ok := err.(awserr.Error); ok {
+		return aerr.Code() == dynamodb.ErrCodeConditionalCheckFailedException
+	}
+	return false
+}
@@ -0,0 +1,107 @@
+package dynamodb
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/coinbase/step/aws/mocks"
+	"github.com/coinbase/step/utils/to"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_LockBackend_GrabLock_Success(t *testing.T) {
+	b := &LockBackend{DynamoDB: &mocks.MockDynamoDBClient{}, Table: to.Strp("locks")}
+
+	grabbed, err := b.GrabLock("path", "UUID")
+	assert.NoError(t, err)
+	assert.True(t, grabbed)
+
+	// Already has lock
+	grabbed, err = b.GrabLock("path", "UUID")
+	assert.NoError(t, err)
+	assert.True(t, grabbed)
+}
+
+func Test_LockBackend_GrabLock_Failure_Already_Locked(t *testing.T) {
+	b := &LockBackend{DynamoDB: &mocks.MockDynamoDBClient{}, Table: to.Strp("locks")}
+
+	grabbed, err := b.GrabLock("path", "NOT_UUID")
+	assert.NoError(t, err)
+	assert.True(t, grabbed)
+
+	grabbed, err = b.GrabLock("path", "UUID")
+	assert.NoError(t, err)
+	assert.False(t, grabbed)
+}
+
+func Test_LockBackend_GrabLock_Success_Expired(t *testing.T) {
+	db := &mocks.MockDynamoDBClient{}
+	b := &LockBackend{DynamoDB: db, Table: to.Strp("locks"), MaxAge: time.Hour}
+
+	grabbed, err := b.GrabLock("path", "NOT_UUID")
+	assert.NoError(t, err)
+	assert.True(t, grabbed)
+
+	grabbed, err = b.GrabLock("path", "UUID")
+	assert.NoError(t, err)
+	assert.False(t, grabbed)
+
+	db.Items["path"]["expires_at"] = &dynamodb.AttributeValue{N: to.Strp("0")}
+
+	grabbed, err = b.GrabLock("path", "UUID")
+	assert.NoError(t, err)
+	assert.True(t, grabbed)
+}
+
+func Test_LockBackend_GrabLock_Success_Expired_NoMaxAge(t *testing.T) {
+	db := &mocks.MockDynamoDBClient{}
+	holder := &LockBackend{DynamoDB: db, Table: to.Strp("locks"), MaxAge: time.Hour}
+	b := &LockBackend{DynamoDB: db, Table: to.Strp("locks")}
+
+	grabbed, err := holder.GrabLock("path", "NOT_UUID")
+	assert.NoError(t, err)
+	assert.True(t, grabbed)
+
+	grabbed, err = b.GrabLock("path", "UUID")
+	assert.NoError(t, err)
+	assert.False(t, grabbed)
+
+	// The holder's expires_at decides, not the grabber's MaxAge
+	db.Items["path"]["expires_at"] = &dynamodb.AttributeValue{N: to.Strp("0")}
+
+	grabbed, err = b.GrabLock("path", "UUID")
+	assert.NoError(t, err)
+	assert.True(t, grabbed)
+
+	// A lock without expires_at never expires
+	grabbed, err = holder.GrabLock("path", "NOT_UUID")
+	assert.NoError(t, err)
+	assert.False(t, grabbed)
+	assert.Nil(t, db.Items["path"]["expires_at"])
+}
+
+func Test_LockBackend_GrabLock_Failure_Put_Error(t *testing.T) {
+	b := &LockBackend{DynamoDB: &mocks.MockDynamoDBClient{PutItemError: fmt.Errorf("ERRRR")}, Table: to.Strp("locks")}
+
+	grabbed, err := b.GrabLock("path", "UUID")
+	assert.Error(t, err)
+	assert.True(t, grabbed)
+}
+
+func Test_LockBackend_ReleaseLock(t *testing.T) {
+	b := &LockBackend{DynamoDB: &mocks.MockDynamoDBClient{}, Table: to.Strp("locks")}
+
+	assert.NoError(t, b.ReleaseLock("path", "UUID"))
+
+	_, err := b.GrabLock("path", "NOT_UUID")
+	assert.NoError(t, err)
+
+	assert.Error(t, b.ReleaseLock("path", "UUID"))
+	assert.NoError(t, b.ReleaseLock("path", "NOT_UUID"))
+
+	grabbed, err := b.GrabLock("path", "UUID")
+	assert.NoError(t, err)
+	assert.True(t, grabbed)
+}
@@ -0,0 +1,74 @@
+package mocks
+
+import (
+	"strconv"
+
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbiface"
+)
+
+// MockDynamoDBClient simulates the conditional writes used for locks on items keyed by "lock_path"
+type MockDynamoDBClient struct {
+	dynamodbiface.DynamoDBAPI
+	Items           map[string]map[string]*dynamodb.AttributeValue
+	PutItemError    error
+	DeleteItemError error
+}
+
+func (m *MockDynamoDBClient) init() {
+	if m.Items == nil {
+		m.Items = map[string]map[string]*dynamodb.AttributeValue{}
+	}
+}
+
+func conditionalCheckFailedError() error {
+	return awserr.New(dynamodb.ErrCodeConditionalCheckFailedException, "conditional check failed", nil)
+}
+
+// heldByOther returns true if the existing item is held by a different, unexpired, uuid
+func heldByOther(item map[string]*dynamodb.AttributeValue, values map[string]*dynamodb.AttributeValue) bool {
+	if item == nil || *item["uuid"].S == *values[":uuid"].S {
+		return false
+	}
+
+	if values[":now"] != nil && item["expires_at"] != nil {
+		now, _ := strconv.ParseInt(*values[":now"].N, 10, 64)
+		expires, _ := strconv.ParseInt(*item["expires_at"].N, 10, 64)
+		if expires < now {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (m *MockDynamoDBClient) PutItem(in *dynamodb.PutItemInput) (*dynamodb.PutItemOutput, error) {
+	m.init()
+	if m.PutItemError != nil {
+		return nil, m.PutItemError
+	}
+
+	key := *in.Item["lock_path"].S
+	if heldByOther(m.Items[key], in.ExpressionAttributeValues) {
+		return nil, conditionalCheckFailedError()
+	}
+
+	m.Items[key] = in.Item
+	return &dynamodb.PutItemOutput{}, nil
+}
+
+func (m *MockDynamoDBClient) DeleteItem(in *dynamodb.DeleteItemInput) (*dynamodb.DeleteItemOutput, error) {
+	m.init()
+	if m.DeleteItemError != nil {
+		return nil, m.DeleteItemError
+	}
+
+	key := *in.Key["lock_path"].S
+	if heldByOther(m.Items[key], in.ExpressionAttributeValues) {
+		return nil, conditionalCheckFailedError()
+	}
+
+	delete(m.Items, key)
+	return &dynamodb.DeleteItemOutput{}, nil
+}
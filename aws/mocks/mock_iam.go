@@ -0,0 +1,38 @@
+package mocks
+
+import (
+	"github.com/aws/aws-sdk-go/service/iam"
+	"github.com/aws/aws-sdk-go/service/iam/iamiface"
+	"github.com/coinbase/step/utils/to"
+)
+
+type MockIAMClient struct {
+	iamiface.IAMAPI
+	DeniedResources            map[string]bool
+	SimulatePrincipalPolicyErr error
+}
+
+// SimulatePrincipalPolicy allows every action on every resource except DeniedResources
+func (m *MockIAMClient) SimulatePrincipalPolicy(in *iam.SimulatePrincipalPolicyInput) (*iam.SimulatePolicyResponse, error) {
+	if m.SimulatePrincipalPolicyErr != nil {
+		return nil, m.SimulatePrincipalPolicyErr
+	}
+
+	results := []*iam.EvaluationResult{}
+	for _, action := range in.ActionNames {
+		for _, resource := range in.ResourceArns {
+			decision := iam.PolicyEvaluationDecisionTypeAllowed
+			if m.DeniedResources[*resource] {
+				decision = iam.PolicyEvaluationDecisionTypeImplicitDeny
+			}
+
+			results = append(results, &iam.EvaluationResult{
+				EvalActionName:   action,
+				EvalResourceName: resource,
+				EvalDecision:     to.Strp(decision),
+			})
+		}
+	}
+
+	return &iam.SimulatePolicyResponse{EvaluationResults: results, IsTruncated: to.Boolp(false)}, nil
+}
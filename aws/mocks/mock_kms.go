@@ -0,0 +1,42 @@
+package mocks
+
+import (
+	"bytes"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/kms"
+	"github.com/aws/aws-sdk-go/service/kms/kmsiface"
+	"github.com/coinbase/step/utils/to"
+)
+
+// MockKMSClient "signs" by prefixing the message with the key id
+type MockKMSClient struct {
+	kmsiface.KMSAPI
+	VerifyError error
+}
+
+// MockSignature returns the signature MockKMSClient will verify for message
+func MockSignature(keyID string, message []byte) []byte {
+	return append([]byte(keyID+":"), message...)
+}
+
+func (m *MockKMSClient) Verify(in *kms.VerifyInput) (*kms.VerifyOutput, error) {
+	if m.VerifyError != nil {
+		return nil, m.VerifyError
+	}
+
+	valid := bytes.Equal(in.Signature, MockSignature(*in.KeyId, in.Message))
+	return &kms.VerifyOutput{
+		KeyId:            in.KeyId,
+		SignatureValid:   to.Boolp(valid),
+		SigningAlgorithm: in.SigningAlgorithm,
+	}, nil
+}
+
+func (m *MockKMSClient) VerifyWithContext(ctx aws.Context, in *kms.VerifyInput, _ ...request.Option) (*kms.VerifyOutput, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return m.Verify(in)
+}
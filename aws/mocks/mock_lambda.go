@@ -1,29 +1,202 @@
 package mocks
 
 import (
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
 	"github.com/aws/aws-sdk-go/service/lambda"
 	"github.com/aws/aws-sdk-go/service/lambda/lambdaiface"
+	"github.com/coinbase/step/utils/to"
 )
 
 type MockLambdaClient struct {
 	lambdaiface.LambdaAPI
-	UpdateFunctionCodeResp  *lambda.FunctionConfiguration
-	UpdateFunctionCodeError error
-	ListTagsResp            *lambda.ListTagsOutput
+	UpdateFunctionCodeResp   *lambda.FunctionConfiguration
+	UpdateFunctionCodeError  error
+	UpdateFunctionCodeFails  []error // returned in order before UpdateFunctionCodeError
+	UpdateFunctionCodeCalls  int
+	UpdateFunctionCodeInputs []*lambda.UpdateFunctionCodeInput
+	ListTagsResp             *lambda.ListTagsOutput
+	ListTagsError            error
+	ListTagsInputs           []*lambda.ListTagsInput
+	PublishVersionResp       *lambda.FunctionConfiguration
+	PublishVersionError      error
+	UpdateAliasError         error
+	CreateAliasError         error
+	Aliases                  map[string]*string
+	GetFunctionError         error
+	GetFunctionResp          *lambda.GetFunctionOutput
+	MissingFunctions         map[string]bool
+
+	UpdateFunctionConfigurationInput *lambda.UpdateFunctionConfigurationInput
+	UpdateFunctionConfigurationError error
+	UpdateFunctionConfigurationCalls int
+
+	PutFunctionConcurrencyInput *lambda.PutFunctionConcurrencyInput
+
+	TagResourceError error
+
+	LastUpdateStatuses []string // returned in order by GetFunctionConfiguration before Successful
+	CodeSha256         *string  // set by UpdateFunctionCode with a ZipFile
+	Runtime            *string  // returned by GetFunctionConfiguration
+	RevisionId         *string  // returned by GetFunctionConfiguration
+
+	GetFunctionConfigurationError error
 }
 
 func (m *MockLambdaClient) init() {
 	if m.UpdateFunctionCodeResp == nil {
 		m.UpdateFunctionCodeResp = &lambda.FunctionConfiguration{}
 	}
+
+	if m.PublishVersionResp == nil {
+		m.PublishVersionResp = &lambda.FunctionConfiguration{Version: to.Strp("1")}
+	}
+
+	if m.Aliases == nil {
+		m.Aliases = map[string]*string{}
+	}
 }
 
 func (m *MockLambdaClient) UpdateFunctionCode(in *lambda.UpdateFunctionCodeInput) (*lambda.FunctionConfiguration, error) {
 	m.init()
+	m.UpdateFunctionCodeCalls++
+	m.UpdateFunctionCodeInputs = append(m.UpdateFunctionCodeInputs, in)
+	if len(m.UpdateFunctionCodeFails) != 0 {
+		err := m.UpdateFunctionCodeFails[0]
+		m.UpdateFunctionCodeFails = m.UpdateFunctionCodeFails[1:]
+		return nil, err
+	}
+
+	if m.UpdateFunctionCodeError == nil && in.ZipFile != nil {
+		sha, _ := to.HexToBase64(to.SHA256AByte(&in.ZipFile))
+		m.CodeSha256 = &sha
+	}
+
 	return m.UpdateFunctionCodeResp, m.UpdateFunctionCodeError
 }
 
+func (m *MockLambdaClient) UpdateFunctionCodeWithContext(ctx aws.Context, in *lambda.UpdateFunctionCodeInput, _ ...request.Option) (*lambda.FunctionConfiguration, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return m.UpdateFunctionCode(in)
+}
+
+func (m *MockLambdaClient) ListTagsWithContext(ctx aws.Context, in *lambda.ListTagsInput, _ ...request.Option) (*lambda.ListTagsOutput, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return m.ListTags(in)
+}
+
 func (m *MockLambdaClient) ListTags(in *lambda.ListTagsInput) (*lambda.ListTagsOutput, error) {
 	m.init()
+	m.ListTagsInputs = append(m.ListTagsInputs, in)
+	if m.ListTagsError != nil {
+		return nil, m.ListTagsError
+	}
 	return m.ListTagsResp, nil
 }
+
+func (m *MockLambdaClient) PublishVersion(in *lambda.PublishVersionInput) (*lambda.FunctionConfiguration, error) {
+	m.init()
+	return m.PublishVersionResp, m.PublishVersionError
+}
+
+func (m *MockLambdaClient) UpdateAlias(in *lambda.UpdateAliasInput) (*lambda.AliasConfiguration, error) {
+	m.init()
+	if m.UpdateAliasError != nil {
+		return nil, m.UpdateAliasError
+	}
+
+	if m.Aliases[*in.Name] == nil {
+		return nil, awserr.New(lambda.ErrCodeResourceNotFoundException, "alias not found", nil)
+	}
+
+	m.Aliases[*in.Name] = in.FunctionVersion
+	return &lambda.AliasConfiguration{Name: in.Name, FunctionVersion: in.FunctionVersion}, nil
+}
+
+func (m *MockLambdaClient) CreateAlias(in *lambda.CreateAliasInput) (*lambda.AliasConfiguration, error) {
+	m.init()
+	if m.CreateAliasError != nil {
+		return nil, m.CreateAliasError
+	}
+
+	m.Aliases[*in.Name] = in.FunctionVersion
+	return &lambda.AliasConfiguration{Name: in.Name, FunctionVersion: in.FunctionVersion}, nil
+}
+
+func (m *MockLambdaClient) GetFunction(in *lambda.GetFunctionInput) (*lambda.GetFunctionOutput, error) {
+	m.init()
+	if m.GetFunctionError != nil {
+		return nil, m.GetFunctionError
+	}
+
+	if m.MissingFunctions[*in.FunctionName] {
+		return nil, awserr.New(lambda.ErrCodeResourceNotFoundException, "function not found", nil)
+	}
+
+	if m.GetFunctionResp != nil {
+		return m.GetFunctionResp, nil
+	}
+
+	return &lambda.GetFunctionOutput{Configuration: &lambda.FunctionConfiguration{FunctionArn: in.FunctionName}}, nil
+}
+
+func (m *MockLambdaClient) UpdateFunctionConfiguration(in *lambda.UpdateFunctionConfigurationInput) (*lambda.FunctionConfiguration, error) {
+	m.init()
+	m.UpdateFunctionConfigurationInput = in
+	m.UpdateFunctionConfigurationCalls++
+	return &lambda.FunctionConfiguration{}, m.UpdateFunctionConfigurationError
+}
+
+func (m *MockLambdaClient) PutFunctionConcurrency(in *lambda.PutFunctionConcurrencyInput) (*lambda.PutFunctionConcurrencyOutput, error) {
+	m.init()
+	m.PutFunctionConcurrencyInput = in
+	return &lambda.PutFunctionConcurrencyOutput{ReservedConcurrentExecutions: in.ReservedConcurrentExecutions}, nil
+}
+
+// TagResource adds the tags to ListTagsResp so they can be read back
+func (m *MockLambdaClient) TagResource(in *lambda.TagResourceInput) (*lambda.TagResourceOutput, error) {
+	m.init()
+	if m.TagResourceError != nil {
+		return nil, m.TagResourceError
+	}
+
+	if m.ListTagsResp == nil {
+		m.ListTagsResp = &lambda.ListTagsOutput{}
+	}
+
+	if m.ListTagsResp.Tags == nil {
+		m.ListTagsResp.Tags = map[string]*string{}
+	}
+
+	for k, v := range in.Tags {
+		m.ListTagsResp.Tags[k] = v
+	}
+
+	return &lambda.TagResourceOutput{}, nil
+}
+
+func (m *MockLambdaClient) GetFunctionConfiguration(in *lambda.GetFunctionConfigurationInput) (*lambda.FunctionConfiguration, error) {
+	m.init()
+	if m.GetFunctionConfigurationError != nil {
+		return nil, m.GetFunctionConfigurationError
+	}
+
+	status := lambda.LastUpdateStatusSuccessful
+	if len(m.LastUpdateStatuses) != 0 {
+		status = m.LastUpdateStatuses[0]
+		m.LastUpdateStatuses = m.LastUpdateStatuses[1:]
+	}
+	return &lambda.FunctionConfiguration{FunctionArn: in.FunctionName, LastUpdateStatus: &status, CodeSha256: m.CodeSha256, Runtime: m.Runtime, RevisionId: m.RevisionId}, nil
+}
+
+func (m *MockLambdaClient) GetFunctionConfigurationWithContext(ctx aws.Context, in *lambda.GetFunctionConfigurationInput, _ ...request.Option) (*lambda.FunctionConfiguration, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return m.GetFunctionConfiguration(in)
+}
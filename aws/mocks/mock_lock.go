@@ -0,0 +1,52 @@
+package mocks
+
+import "fmt"
+
+// MockLockBackend is an in-memory lock store, it implements bifrost.LockBackend
+type MockLockBackend struct {
+	Locks map[string]string // lock path to the uuid holding it
+
+	GrabLockError    error // returned with grabbed false
+	ReleaseLockError error
+
+	GrabLockCalls    []string // lock paths in order
+	ReleaseLockCalls []string
+}
+
+func (m *MockLockBackend) init() {
+	if m.Locks == nil {
+		m.Locks = map[string]string{}
+	}
+}
+
+func (m *MockLockBackend) GrabLock(lockPath string, uuid string) (bool, error) {
+	m.init()
+	m.GrabLockCalls = append(m.GrabLockCalls, lockPath)
+
+	if m.GrabLockError != nil {
+		return false, m.GrabLockError
+	}
+
+	if holder, ok := m.Locks[lockPath]; ok && holder != uuid {
+		return false, nil
+	}
+
+	m.Locks[lockPath] = uuid
+	return true, nil
+}
+
+func (m *MockLockBackend) ReleaseLock(lockPath string, uuid string) error {
+	m.init()
+	m.ReleaseLockCalls = append(m.ReleaseLockCalls, lockPath)
+
+	if m.ReleaseLockError != nil {
+		return m.ReleaseLockError
+	}
+
+	if holder, ok := m.Locks[lockPath]; ok && holder != uuid {
+		return fmt.Errorf("Lock %v held by %v not %v", lockPath, holder, uuid)
+	}
+
+	delete(m.Locks, lockPath)
+	return nil
+}
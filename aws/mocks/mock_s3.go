@@ -2,13 +2,21 @@ package mocks
 
 import (
 	"bytes"
+	"crypto/md5"
 	"fmt"
 	"io"
 	"io/ioutil"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
 
+	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
 	"github.com/aws/aws-sdk-go/service/s3"
 	"github.com/aws/aws-sdk-go/service/s3/s3iface"
 	"github.com/coinbase/step/utils/to"
@@ -46,6 +54,20 @@ type MockS3Client struct {
 	DeleteObjectResp map[string]*DeleteObjectResponse
 
 	GetBucketTaggingResp map[string]*GetBucketTaggingResponse
+
+	// Inputs of every call in order
+	GetObjectInputs  []*s3.GetObjectInput
+	PutObjectInputs  []*s3.PutObjectInput
+	CopyObjectInputs []*s3.CopyObjectInput
+
+	// Versioned keeps every PutObject body so GetObject can read a VersionId, ids are v1, v2, ...
+	Versioned bool
+	Versions  map[string][]string
+
+	// RangedBodyErrors is how many ranged GetObjectWithContext bodies fail after the first byte, to test retries
+	RangedBodyErrors int
+
+	mu sync.Mutex // every method holds mu, ranged GETs, lock writes and validations are concurrent
 }
 
 func (m *MockS3Client) init() {
@@ -72,10 +94,12 @@ func MakeS3Body(ret string) io.ReadCloser {
 
 func makeS3Resp(ret string, contentType *string, cacheControl *string) *s3.GetObjectOutput {
 	return &s3.GetObjectOutput{
-		Body:         MakeS3Body(ret),
-		ContentType:  contentType,
-		CacheControl: cacheControl,
-		LastModified: to.Timep(time.Now()),
+		Body:          MakeS3Body(ret),
+		ContentLength: to.Int64p(int64(len(ret))),
+		ContentType:   contentType,
+		CacheControl:  cacheControl,
+		LastModified:  to.Timep(time.Now()),
+		ETag:          to.Strp(fmt.Sprintf("%q", fmt.Sprintf("%x", md5.Sum([]byte(ret))))),
 	}
 }
 
@@ -93,10 +117,16 @@ func (m *MockS3Client) addGetObjectWithContentTypeAndCacheControl(key string, bo
 }
 
 func (m *MockS3Client) AddGetObject(key string, body string, err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
 	m.addGetObjectWithContentTypeAndCacheControl(key, body, nil, nil, err)
 }
 
 func (m *MockS3Client) AddPutObject(key string, err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
 	m.init()
 	m.PutObjectResp[key] = &PutObjectResponse{
 		Resp:  &s3.PutObjectOutput{},
@@ -105,6 +135,9 @@ func (m *MockS3Client) AddPutObject(key string, err error) {
 }
 
 func (m *MockS3Client) SetBucketTags(bucket string, tags map[string]string, err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
 	m.init()
 	tagSet := []*s3.Tag{}
 
@@ -121,7 +154,25 @@ func (m *MockS3Client) SetBucketTags(bucket string, tags map[string]string, err
 }
 
 func (m *MockS3Client) GetObject(in *s3.GetObjectInput) (*s3.GetObjectOutput, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.getObject(in)
+}
+
+func (m *MockS3Client) getObject(in *s3.GetObjectInput) (*s3.GetObjectOutput, error) {
 	m.init()
+	m.GetObjectInputs = append(m.GetObjectInputs, in)
+	if in.VersionId != nil {
+		body, ok := m.versionBody(*in.Key, *in.VersionId)
+		if !ok {
+			return nil, awserr.New("NoSuchVersion", "version not found", nil)
+		}
+		resp := makeS3Resp(body, nil, nil)
+		resp.VersionId = in.VersionId
+		return resp, nil
+	}
+
 	resp := m.GetObjectResp[*in.Key]
 
 	if resp == nil {
@@ -132,12 +183,142 @@ func (m *MockS3Client) GetObject(in *s3.GetObjectInput) (*s3.GetObjectOutput, er
 	return resp.Resp, resp.Error
 }
 
+// versionBody returns the body PutObject stored as versionId of key
+func (m *MockS3Client) versionBody(key string, versionId string) (string, bool) {
+	for i, body := range m.Versions[key] {
+		if fmt.Sprintf("v%v", i+1) == versionId {
+			return body, true
+		}
+	}
+	return "", false
+}
+
+// GetObjectWithContext is GetObject supporting a Range of bytes=start-end
+func (m *MockS3Client) GetObjectWithContext(ctx aws.Context, in *s3.GetObjectInput, _ ...request.Option) (*s3.GetObjectOutput, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if in.Range == nil {
+		return m.getObject(in)
+	}
+
+	m.init()
+	m.GetObjectInputs = append(m.GetObjectInputs, in)
+
+	resp := m.GetObjectResp[*in.Key]
+	if resp == nil {
+		return nil, AWSS3NotFoundError()
+	}
+
+	if resp.Error != nil {
+		return nil, resp.Error
+	}
+
+	full := resp.Body
+	if in.VersionId != nil {
+		body, ok := m.versionBody(*in.Key, *in.VersionId)
+		if !ok {
+			return nil, awserr.New("NoSuchVersion", "version not found", nil)
+		}
+		full = body
+	}
+
+	bounds := strings.SplitN(strings.TrimPrefix(*in.Range, "bytes="), "-", 2)
+	start, _ := strconv.Atoi(bounds[0])
+	end, _ := strconv.Atoi(bounds[1])
+	if end >= len(full) {
+		end = len(full) - 1
+	}
+
+	part := full[start : end+1]
+
+	var body io.ReadCloser = MakeS3Body(part)
+	if m.RangedBodyErrors > 0 {
+		m.RangedBodyErrors--
+		body = ioutil.NopCloser(io.MultiReader(strings.NewReader(part[:1]), &errReader{fmt.Errorf("connection reset")}))
+	}
+
+	return &s3.GetObjectOutput{
+		Body:          body,
+		ContentLength: to.Int64p(int64(len(part))),
+		ContentRange:  to.Strp(fmt.Sprintf("bytes %v-%v/%v", start, end, len(full))),
+	}, nil
+}
+
+// MaxRetries is used by the S3 download manager to retry part bodies
+func (m *MockS3Client) MaxRetries() int {
+	return 3
+}
+
+type errReader struct {
+	err error
+}
+
+func (r *errReader) Read([]byte) (int, error) {
+	return 0, r.err
+}
+
+func (m *MockS3Client) HeadObject(in *s3.HeadObjectInput) (*s3.HeadObjectOutput, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.init()
+	resp := m.GetObjectResp[*in.Key]
+	if resp == nil {
+		return nil, awserr.New("NotFound", "Not Found", nil)
+	}
+
+	if resp.Error != nil {
+		return nil, resp.Error
+	}
+
+	if in.VersionId != nil {
+		body, ok := m.versionBody(*in.Key, *in.VersionId)
+		if !ok {
+			return nil, awserr.New("NoSuchVersion", "version not found", nil)
+		}
+		return &s3.HeadObjectOutput{ContentLength: to.Int64p(int64(len(body)))}, nil
+	}
+
+	return &s3.HeadObjectOutput{ContentLength: to.Int64p(int64(len(resp.Body)))}, nil
+}
+
 func (m *MockS3Client) ListObjects(in *s3.ListObjectsInput) (*s3.ListObjectsOutput, error) {
-	return nil, nil
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.init()
+
+	keys := []string{}
+	for key := range m.GetObjectResp {
+		if in.Prefix == nil || strings.HasPrefix(key, *in.Prefix) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	contents := []*s3.Object{}
+	for _, key := range keys {
+		contents = append(contents, &s3.Object{Key: to.Strp(key)})
+	}
+
+	return &s3.ListObjectsOutput{Contents: contents, IsTruncated: to.Boolp(false)}, nil
 }
 
 func (m *MockS3Client) PutObject(in *s3.PutObjectInput) (*s3.PutObjectOutput, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.putObject(in)
+}
+
+func (m *MockS3Client) putObject(in *s3.PutObjectInput) (*s3.PutObjectOutput, error) {
 	m.init()
+	m.PutObjectInputs = append(m.PutObjectInputs, in)
 
 	resp := m.PutObjectResp[*in.Key]
 	// Simulates adding the object
@@ -145,13 +326,108 @@ func (m *MockS3Client) PutObject(in *s3.PutObjectInput) (*s3.PutObjectOutput, er
 	buf.ReadFrom(in.Body)
 	m.addGetObjectWithContentTypeAndCacheControl(*in.Key, buf.String(), in.ContentType, in.CacheControl, nil)
 
+	var versionId *string
+	if m.Versioned {
+		if m.Versions == nil {
+			m.Versions = map[string][]string{}
+		}
+		m.Versions[*in.Key] = append(m.Versions[*in.Key], buf.String())
+		versionId = to.Strp(fmt.Sprintf("v%v", len(m.Versions[*in.Key])))
+		m.GetObjectResp[*in.Key].Resp.VersionId = versionId
+	}
+
 	if resp == nil {
-		return &s3.PutObjectOutput{}, nil
+		return &s3.PutObjectOutput{VersionId: versionId}, nil
 	}
 	return resp.Resp, resp.Error
 }
 
+// PutObjectWithContext is PutObject that checks the If-None-Match and If-Match headers
+// set by request options against the ETag of the object, failing with PreconditionFailed
+func (m *MockS3Client) PutObjectWithContext(ctx aws.Context, in *s3.PutObjectInput, opts ...request.Option) (*s3.PutObjectOutput, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.init()
+
+	r := &request.Request{HTTPRequest: &http.Request{Header: http.Header{}}}
+	r.ApplyOptions(opts...)
+
+	existing := m.GetObjectResp[*in.Key]
+
+	failed := false
+	if r.HTTPRequest.Header.Get("If-None-Match") == "*" && existing != nil {
+		failed = true
+	}
+
+	if ifMatch := r.HTTPRequest.Header.Get("If-Match"); ifMatch != "" {
+		failed = existing == nil || existing.Resp.ETag == nil || *existing.Resp.ETag != ifMatch
+	}
+
+	if failed {
+		return nil, awserr.NewRequestFailure(awserr.New("PreconditionFailed", "At least one of the pre-conditions you specified did not hold", nil), 412, "")
+	}
+
+	return m.putObject(in)
+}
+
+// CopyObject copies the object at the key of CopySource, like GetObject the bucket is ignored
+// and a ?versionId= is read from Versions
+func (m *MockS3Client) CopyObject(in *s3.CopyObjectInput) (*s3.CopyObjectOutput, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.init()
+	m.CopyObjectInputs = append(m.CopyObjectInputs, in)
+
+	source, versionId := *in.CopySource, ""
+	if i := strings.Index(source, "?versionId="); i != -1 {
+		version, err := url.QueryUnescape(source[i+len("?versionId="):])
+		if err != nil {
+			return nil, err
+		}
+		source, versionId = source[:i], version
+	}
+
+	source, err := url.PathUnescape(source)
+	if err != nil {
+		return nil, err
+	}
+
+	key := source[strings.Index(source, "/")+1:]
+
+	if versionId != "" {
+		body, ok := m.versionBody(key, versionId)
+		if !ok {
+			return nil, AWSS3NotFoundError()
+		}
+
+		m.addGetObjectWithContentTypeAndCacheControl(*in.Key, body, nil, nil, nil)
+		return &s3.CopyObjectOutput{}, nil
+	}
+
+	resp := m.GetObjectResp[key]
+	if resp == nil {
+		return nil, AWSS3NotFoundError()
+	}
+
+	if resp.Error != nil {
+		return nil, resp.Error
+	}
+
+	m.addGetObjectWithContentTypeAndCacheControl(*in.Key, resp.Body, resp.Resp.ContentType, resp.Resp.CacheControl, nil)
+
+	return &s3.CopyObjectOutput{}, nil
+}
+
 func (m *MockS3Client) GetBucketTagging(in *s3.GetBucketTaggingInput) (*s3.GetBucketTaggingOutput, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
 	m.init()
 	resp := m.GetBucketTaggingResp[*in.Bucket]
 	if resp == nil {
@@ -161,6 +437,9 @@ func (m *MockS3Client) GetBucketTagging(in *s3.GetBucketTaggingInput) (*s3.GetBu
 }
 
 func (m *MockS3Client) DeleteObject(in *s3.DeleteObjectInput) (*s3.DeleteObjectOutput, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
 	m.init()
 
 	resp := m.DeleteObjectResp[*in.Key]
@@ -1,6 +1,8 @@
 package mocks
 
 import (
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
 	"github.com/aws/aws-sdk-go/service/sfn"
 	"github.com/aws/aws-sdk-go/service/sfn/sfniface"
 	"github.com/coinbase/step/utils/to"
@@ -8,13 +10,27 @@ import (
 
 type MockSFNClient struct {
 	sfniface.SFNAPI
-	UpdateStateMachineResp   *sfn.UpdateStateMachineOutput
-	UpdateStateMachineError  error
-	StartExecutionResp       *sfn.StartExecutionOutput
-	DescribeExecutionResp    *sfn.DescribeExecutionOutput
-	GetExecutionHistoryResp  *sfn.GetExecutionHistoryOutput
-	DescribeStateMachineResp *sfn.DescribeStateMachineOutput
-	ListExecutionsResp       *sfn.ListExecutionsOutput
+	UpdateStateMachineResp     *sfn.UpdateStateMachineOutput
+	UpdateStateMachineError    error
+	UpdateStateMachineFails    []error // returned in order before UpdateStateMachineError
+	UpdateStateMachineCalls    int
+	StartExecutionResp         *sfn.StartExecutionOutput
+	StartExecutionInput        *sfn.StartExecutionInput
+	StartSyncExecutionResp     *sfn.StartSyncExecutionOutput
+	StartSyncExecutionInput    *sfn.StartSyncExecutionInput
+	StopExecutionInput         *sfn.StopExecutionInput
+	DescribeExecutionResp      *sfn.DescribeExecutionOutput
+	GetExecutionHistoryResp    *sfn.GetExecutionHistoryOutput
+	DescribeStateMachineResp   *sfn.DescribeStateMachineOutput
+	DescribeStateMachineError  error
+	DescribeStateMachineInputs []*sfn.DescribeStateMachineInput
+	ListExecutionsResp         *sfn.ListExecutionsOutput
+	ListExecutionsPagesResp    []*sfn.ListExecutionsOutput // pages for ListExecutionsPages, default ListExecutionsResp
+	StopExecutionInputs        []*sfn.StopExecutionInput
+	StopExecutionErrors        map[string]error // by ExecutionArn
+	CreateStateMachineInput    *sfn.CreateStateMachineInput
+	CreateStateMachineError    error
+	TagResourceInput           *sfn.TagResourceInput
 }
 
 func (m *MockSFNClient) init() {
@@ -42,14 +58,64 @@ func (m *MockSFNClient) init() {
 
 func (m *MockSFNClient) UpdateStateMachine(in *sfn.UpdateStateMachineInput) (*sfn.UpdateStateMachineOutput, error) {
 	m.init()
+	m.UpdateStateMachineCalls++
+	if len(m.UpdateStateMachineFails) != 0 {
+		err := m.UpdateStateMachineFails[0]
+		m.UpdateStateMachineFails = m.UpdateStateMachineFails[1:]
+		return nil, err
+	}
 	return m.UpdateStateMachineResp, m.UpdateStateMachineError
 }
 
+func (m *MockSFNClient) UpdateStateMachineWithContext(ctx aws.Context, in *sfn.UpdateStateMachineInput, _ ...request.Option) (*sfn.UpdateStateMachineOutput, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return m.UpdateStateMachine(in)
+}
+
+func (m *MockSFNClient) CreateStateMachine(in *sfn.CreateStateMachineInput) (*sfn.CreateStateMachineOutput, error) {
+	m.init()
+	m.CreateStateMachineInput = in
+	if m.CreateStateMachineError != nil {
+		return nil, m.CreateStateMachineError
+	}
+	return &sfn.CreateStateMachineOutput{StateMachineArn: to.Strp("arn:aws:states:us-east-1:000000000000:stateMachine:" + *in.Name)}, nil
+}
+
+func (m *MockSFNClient) TagResource(in *sfn.TagResourceInput) (*sfn.TagResourceOutput, error) {
+	m.init()
+	m.TagResourceInput = in
+	return &sfn.TagResourceOutput{}, nil
+}
+
 func (m *MockSFNClient) StartExecution(in *sfn.StartExecutionInput) (*sfn.StartExecutionOutput, error) {
 	m.init()
+	m.StartExecutionInput = in
 	return m.StartExecutionResp, nil
 }
 
+func (m *MockSFNClient) StartSyncExecutionWithContext(ctx aws.Context, in *sfn.StartSyncExecutionInput, _ ...request.Option) (*sfn.StartSyncExecutionOutput, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	m.StartSyncExecutionInput = in
+	if m.StartSyncExecutionResp == nil {
+		return &sfn.StartSyncExecutionOutput{Status: to.Strp(sfn.SyncExecutionStatusSucceeded)}, nil
+	}
+	return m.StartSyncExecutionResp, nil
+}
+
+func (m *MockSFNClient) StopExecution(in *sfn.StopExecutionInput) (*sfn.StopExecutionOutput, error) {
+	m.init()
+	m.StopExecutionInput = in
+	m.StopExecutionInputs = append(m.StopExecutionInputs, in)
+	if err := m.StopExecutionErrors[to.Strs(in.ExecutionArn)]; err != nil {
+		return nil, err
+	}
+	return &sfn.StopExecutionOutput{}, nil
+}
+
 func (m *MockSFNClient) DescribeExecution(in *sfn.DescribeExecutionInput) (*sfn.DescribeExecutionOutput, error) {
 	m.init()
 	return m.DescribeExecutionResp, nil
@@ -62,10 +128,33 @@ func (m *MockSFNClient) GetExecutionHistory(in *sfn.GetExecutionHistoryInput) (*
 
 func (m *MockSFNClient) DescribeStateMachine(in *sfn.DescribeStateMachineInput) (*sfn.DescribeStateMachineOutput, error) {
 	m.init()
-	return m.DescribeStateMachineResp, nil
+	m.DescribeStateMachineInputs = append(m.DescribeStateMachineInputs, in)
+	return m.DescribeStateMachineResp, m.DescribeStateMachineError
+}
+
+func (m *MockSFNClient) DescribeStateMachineWithContext(ctx aws.Context, in *sfn.DescribeStateMachineInput, _ ...request.Option) (*sfn.DescribeStateMachineOutput, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return m.DescribeStateMachine(in)
 }
 
 func (m *MockSFNClient) ListExecutions(in *sfn.ListExecutionsInput) (*sfn.ListExecutionsOutput, error) {
 	m.init()
 	return m.ListExecutionsResp, nil
 }
+
+func (m *MockSFNClient) ListExecutionsPages(in *sfn.ListExecutionsInput, fn func(*sfn.ListExecutionsOutput, bool) bool) error {
+	m.init()
+	pages := m.ListExecutionsPagesResp
+	if len(pages) == 0 {
+		pages = []*sfn.ListExecutionsOutput{m.ListExecutionsResp}
+	}
+
+	for i, page := range pages {
+		if !fn(page, i == len(pages)-1) {
+			break
+		}
+	}
+	return nil
+}
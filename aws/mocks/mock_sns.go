@@ -0,0 +1,22 @@
+package mocks
+
+import (
+	"github.com/aws/aws-sdk-go/service/sns"
+	"github.com/aws/aws-sdk-go/service/sns/snsiface"
+	"github.com/coinbase/step/utils/to"
+)
+
+type MockSNSClient struct {
+	snsiface.SNSAPI
+	Published    []*sns.PublishInput
+	PublishError error
+}
+
+func (m *MockSNSClient) Publish(in *sns.PublishInput) (*sns.PublishOutput, error) {
+	if m.PublishError != nil {
+		return nil, m.PublishError
+	}
+
+	m.Published = append(m.Published, in)
+	return &sns.PublishOutput{MessageId: to.Strp("message-id")}, nil
+}
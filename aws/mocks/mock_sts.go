@@ -0,0 +1,29 @@
+package mocks
+
+import (
+	"github.com/aws/aws-sdk-go/service/sts"
+	"github.com/aws/aws-sdk-go/service/sts/stsiface"
+	"github.com/coinbase/step/utils/to"
+)
+
+// MockSTSClient returns GetCallerIdentityResp, defaulting to an identity in account 000000000000
+type MockSTSClient struct {
+	stsiface.STSAPI
+	GetCallerIdentityResp  *sts.GetCallerIdentityOutput
+	GetCallerIdentityError error
+}
+
+func (m *MockSTSClient) GetCallerIdentity(in *sts.GetCallerIdentityInput) (*sts.GetCallerIdentityOutput, error) {
+	if m.GetCallerIdentityError != nil {
+		return nil, m.GetCallerIdentityError
+	}
+
+	if m.GetCallerIdentityResp != nil {
+		return m.GetCallerIdentityResp, nil
+	}
+
+	return &sts.GetCallerIdentityOutput{
+		Account: to.Strp("000000000000"),
+		Arn:     to.Strp("arn:aws:sts::000000000000:assumed-role/coinbase-step-deployer-assumed/session"),
+	}, nil
+}
@@ -1,11 +1,20 @@
+// Package mocks contains in-memory fakes of the AWS clients for testing deploys without AWS.
+// Each mock returns the stubbed *Resp and *Error fields and records the inputs it was called with
 package mocks
 
-import "github.com/coinbase/step/aws"
+import (
+	"github.com/aws/aws-sdk-go/service/sts"
+	"github.com/coinbase/step/aws"
+)
 
+// MockClients implements aws.AwsClients returning the same mock for every region, account and role
 type MockClients struct {
 	S3     *MockS3Client
 	Lambda *MockLambdaClient
 	SFN    *MockSFNClient
+	SNS    *MockSNSClient
+	KMS    *MockKMSClient
+	STS    *MockSTSClient
 }
 
 func (awsc *MockClients) S3Client(*string, *string, *string) aws.S3API {
@@ -20,10 +29,32 @@ func (awsc *MockClients) SFNClient(*string, *string, *string) aws.SFNAPI {
 	return awsc.SFN
 }
 
+func (awsc *MockClients) SNSClient(*string, *string, *string) aws.SNSAPI {
+	return awsc.SNS
+}
+
+func (awsc *MockClients) KMSClient(*string, *string, *string) aws.KMSAPI {
+	return awsc.KMS
+}
+
+// STSClient returns a copy of STS whose default caller identity is in account_id,
+// as assuming a role in account_id would be
+func (awsc *MockClients) STSClient(_ *string, account_id *string, _ *string) aws.STSAPI {
+	stsc := *awsc.STS
+	if stsc.GetCallerIdentityResp == nil && account_id != nil {
+		stsc.GetCallerIdentityResp = &sts.GetCallerIdentityOutput{Account: account_id}
+	}
+	return &stsc
+}
+
+// MockAwsClients returns MockClients with empty mocks
 func MockAwsClients() *MockClients {
 	return &MockClients{
 		&MockS3Client{},
 		&MockLambdaClient{},
 		&MockSFNClient{},
+		&MockSNSClient{},
+		&MockKMSClient{},
+		&MockSTSClient{},
 	}
 }
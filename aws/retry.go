@@ -0,0 +1,53 @@
+package aws
+
+import (
+	"context"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
+)
+
+// RetryPolicy retries transient AWS errors with exponential backoff
+type RetryPolicy struct {
+	MaxAttempts int           // Total attempts, less than 1 is a single attempt
+	BaseDelay   time.Duration // Delay before the second attempt, doubled each attempt after
+}
+
+// Do calls fn until it succeeds, returns a non retryable error, or MaxAttempts is reached
+func (p RetryPolicy) Do(fn func() error) error {
+	return p.DoWithContext(context.Background(), fn)
+}
+
+// DoWithContext is Do but stops waiting to retry and returns the contexts error when ctx is done
+func (p RetryPolicy) DoWithContext(ctx context.Context, fn func() error) error {
+	delay := p.BaseDelay
+
+	for attempt := 1; ; attempt++ {
+		err := fn()
+		if err == nil || attempt >= p.MaxAttempts || !IsRetryableError(err) {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(delay):
+		}
+		delay *= 2
+	}
+}
+
+// IsRetryableError returns true for throttling and 5xx errors,
+// validation and permission errors are never retried
+func IsRetryableError(err error) bool {
+	if request.IsErrorThrottle(err) {
+		return true
+	}
+
+	if reqErr, ok := err.(awserr.RequestFailure); ok {
+		return reqErr.StatusCode() >= 500
+	}
+
+	return false
+}
@@ -0,0 +1,63 @@
+package aws
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_IsRetryableError(t *testing.T) {
+	assert.True(t, IsRetryableError(awserr.New("TooManyRequestsException", "slow down", nil)))
+	assert.True(t, IsRetryableError(awserr.NewRequestFailure(awserr.New("ServiceException", "", nil), 503, "id")))
+	assert.False(t, IsRetryableError(awserr.NewRequestFailure(awserr.New("AccessDeniedException", "", nil), 403, "id")))
+	assert.False(t, IsRetryableError(awserr.New("InvalidParameterValueException", "", nil)))
+	assert.False(t, IsRetryableError(fmt.Errorf("error")))
+}
+
+func Test_RetryPolicy_Do(t *testing.T) {
+	throttle := awserr.New("ThrottlingException", "", nil)
+	policy := RetryPolicy{MaxAttempts: 3}
+
+	calls := 0
+	err := policy.Do(func() error {
+		calls++
+		return throttle
+	})
+	assert.Equal(t, throttle, err)
+	assert.Equal(t, 3, calls)
+
+	calls = 0
+	err = policy.Do(func() error {
+		calls++
+		return awserr.New("ValidationException", "", nil)
+	})
+	assert.Error(t, err)
+	assert.Equal(t, 1, calls)
+
+	calls = 0
+	assert.NoError(t, RetryPolicy{}.Do(func() error {
+		calls++
+		return nil
+	}))
+	assert.Equal(t, 1, calls)
+}
+
+func Test_RetryPolicy_DoWithContext(t *testing.T) {
+	throttle := awserr.New("ThrottlingException", "", nil)
+	policy := RetryPolicy{MaxAttempts: 3, BaseDelay: time.Hour}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	calls := 0
+	err := policy.DoWithContext(ctx, func() error {
+		calls++
+		cancel()
+		return throttle
+	})
+
+	assert.Equal(t, context.Canceled, err)
+	assert.Equal(t, 1, calls)
+}
@@ -1,13 +1,33 @@
 package s3
 
 import (
+	"bytes"
+	"encoding/json"
 	"fmt"
+	"time"
 
+	awssdk "github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/s3"
 	"github.com/coinbase/step/aws"
+	"github.com/coinbase/step/utils/to"
 )
 
 type Lock struct {
-	UUID string `json:"uuid,omitempty"`
+	UUID      string     `json:"uuid,omitempty"`
+	CreatedAt *time.Time `json:"created_at,omitempty"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"` // Set by the holder from its own TTL
+}
+
+// Expired returns true if the lock has passed the ExpiresAt its holder wrote
+// Locks without an ExpiresAt never expire
+func (l *Lock) Expired() bool {
+	if l.ExpiresAt == nil {
+		return false
+	}
+
+	return time.Now().After(*l.ExpiresAt)
 }
 
 // GrabLock creates a lock file in S3 with a UUID
@@ -15,33 +35,98 @@ type Lock struct {
 // if the Lock already exists and UUID is equal to the existing lock it will returns true, otherwise false
 // if the Lock doesn't exist it will create the file and return true
 func GrabLock(s3c aws.S3API, bucket *string, lock_path *string, uuid string) (bool, error) {
-	lock := &Lock{uuid}
-	var s3_lock Lock
+	return GrabLockWithTTL(s3c, bucket, lock_path, uuid, 0)
+}
+
+// GrabLockWithTTL is GrabLock, but the lock is written with an ExpiresAt ttl from now, 0 never expires.
+// A lock past the ExpiresAt written by its holder is treated as released and overwritten.
+// Writes are conditional, the lock is created with If-None-Match and an expired lock is replaced
+// with If-Match on its ETag, so only one of many racing processes wins
+func GrabLockWithTTL(s3c aws.S3API, bucket *string, lock_path *string, uuid string, ttl time.Duration) (bool, error) {
+	now := time.Now()
+	lock := &Lock{UUID: uuid, CreatedAt: &now}
+	if ttl > 0 {
+		lock.ExpiresAt = to.Timep(now.Add(ttl))
+	}
 
-	err := GetStruct(s3c, bucket, lock_path, &s3_lock)
+	s3_lock, etag, err := getLock(s3c, bucket, lock_path)
 	if err != nil {
-		switch err.(type) {
-		case *NotFoundError:
-			// good we want this
-		default:
-			return false, err // All other errors return
-		}
+		return false, err // All other errors return
 	}
 
 	// If s3_lock unmarshalled and the UUID
-	if s3_lock.UUID != "" {
+	if s3_lock != nil && s3_lock.UUID != "" {
 		// if UUID is the same
 		if s3_lock.UUID == lock.UUID {
 			// Already have the lock (caused by a retry ... maybe)
 			return true, nil
-		} else {
+		}
+
+		if !s3_lock.Expired() {
 			return false, nil
 		}
 	}
 
+	if s3_lock == nil {
+		etag = nil
+	}
+
 	// After this point we might have created the lock so return true
 	// Create the Lock
-	err = PutStruct(s3c, bucket, lock_path, lock)
+	return putLock(s3c, bucket, lock_path, lock, etag)
+}
+
+// GetLock returns the lock at lock_path, or nil if there is no lock
+func GetLock(s3c aws.S3API, bucket *string, lock_path *string) (*Lock, error) {
+	s3_lock, _, err := getLock(s3c, bucket, lock_path)
+	return s3_lock, err
+}
+
+// getLock returns the lock at lock_path and its ETag, or nil if there is no lock
+func getLock(s3c aws.S3API, bucket *string, lock_path *string) (*Lock, *string, error) {
+	var s3_lock Lock
+
+	out, raw, err := GetObject(s3c, bucket, lock_path)
+	if err != nil {
+		switch err.(type) {
+		case *NotFoundError:
+			// No lock
+			return nil, nil, nil
+		default:
+			return nil, nil, err // All other errors return
+		}
+	}
+
+	if err := json.Unmarshal(*raw, &s3_lock); err != nil {
+		return nil, nil, err
+	}
+
+	return &s3_lock, out.ETag, nil
+}
+
+// putLock uploads lock with If-Match etag, or If-None-Match * if etag is nil
+// If another process wrote the lock first S3 fails the precondition and it returns false
+func putLock(s3c aws.S3API, bucket *string, lock_path *string, lock *Lock, etag *string) (bool, error) {
+	raw, err := to.CanonicalJSON(lock)
+	if err != nil {
+		return false, err
+	}
+
+	header := map[string]string{"If-None-Match": "*"}
+	if etag != nil {
+		header = map[string]string{"If-Match": *etag}
+	}
+
+	_, err = s3c.PutObjectWithContext(awssdk.BackgroundContext(), &s3.PutObjectInput{
+		Bucket: bucket,
+		Key:    lock_path,
+		Body:   bytes.NewReader(raw),
+		ACL:    to.Strp("private"),
+	}, request.WithSetRequestHeaders(header))
+
+	if isPreconditionFailed(err) {
+		return false, nil
+	}
 
 	if err != nil {
 		return true, err
@@ -50,20 +135,29 @@ func GrabLock(s3c aws.S3API, bucket *string, lock_path *string, uuid string) (bo
 	return true, nil
 }
 
+// isPreconditionFailed is true if a conditional write lost to another writer
+func isPreconditionFailed(err error) bool {
+	if aerr, ok := err.(awserr.Error); ok {
+		switch aerr.Code() {
+		case "PreconditionFailed", "ConditionalRequestConflict":
+			return true
+		}
+	}
+
+	return false
+}
+
 // ReleaseLock removes the lock file for UUID
 // If the lock file exists and is not the same UUID it returns an error
 func ReleaseLock(s3c aws.S3API, bucket *string, lock_path *string, uuid string) error {
-	var s3_lock Lock
-
-	err := GetStruct(s3c, bucket, lock_path, &s3_lock)
+	s3_lock, err := GetLock(s3c, bucket, lock_path)
 	if err != nil {
-		switch err.(type) {
-		case *NotFoundError:
-			// No lock to release
-			return nil
-		default:
-			return err // All other errors return
-		}
+		return err
+	}
+
+	if s3_lock == nil {
+		// No lock to release
+		return nil
 	}
 
 	// if s3_lock unmarshalled and the UUID is different then error
@@ -3,6 +3,7 @@ package s3
 import (
 	"fmt"
 	"testing"
+	"time"
 
 	"github.com/coinbase/step/aws/mocks"
 	"github.com/coinbase/step/utils/to"
@@ -99,3 +100,69 @@ func Test_ReleaseLock_Failure_AnotherReleasesLock(t *testing.T) {
 
 	assert.Error(t, err)
 }
+
+func Test_GrabLockWithTTL_Success_Expired_Lock(t *testing.T) {
+	s3c := &mocks.MockS3Client{}
+	bucket := to.Strp("bucket")
+	path := to.Strp("path")
+
+	s3c.AddGetObject(*path, `{"uuid": "NOT_UUID", "expires_at": "2000-01-01T00:00:00Z"}`, nil)
+	grabbed, err := GrabLockWithTTL(s3c, bucket, path, "UUID", time.Hour)
+
+	assert.NoError(t, err)
+	assert.True(t, grabbed)
+
+	lock, err := GetLock(s3c, bucket, path)
+	assert.NoError(t, err)
+	assert.Equal(t, "UUID", lock.UUID)
+	assert.NotNil(t, lock.CreatedAt)
+	assert.WithinDuration(t, time.Now().Add(time.Hour), *lock.ExpiresAt, time.Minute)
+}
+
+func Test_GrabLockWithTTL_Failure_Not_Expired(t *testing.T) {
+	s3c := &mocks.MockS3Client{}
+	bucket := to.Strp("bucket")
+	path := to.Strp("path")
+
+	grabbed, err := GrabLockWithTTL(s3c, bucket, path, "NOT_UUID", time.Hour)
+	assert.NoError(t, err)
+	assert.True(t, grabbed)
+
+	grabbed, err = GrabLockWithTTL(s3c, bucket, path, "UUID", time.Nanosecond)
+	assert.NoError(t, err)
+	assert.False(t, grabbed)
+}
+
+func Test_GrabLockWithTTL_Failure_No_ExpiresAt_Never_Expires(t *testing.T) {
+	s3c := &mocks.MockS3Client{}
+	bucket := to.Strp("bucket")
+	path := to.Strp("path")
+
+	// The TTL of the grabber does not expire a lock written without one
+	s3c.AddGetObject(*path, `{"uuid": "NOT_UUID", "created_at": "2000-01-01T00:00:00Z"}`, nil)
+	grabbed, err := GrabLockWithTTL(s3c, bucket, path, "UUID", time.Nanosecond)
+
+	assert.NoError(t, err)
+	assert.False(t, grabbed)
+}
+
+func Test_GrabLock_Conditional_Put(t *testing.T) {
+	s3c := &mocks.MockS3Client{}
+	bucket := to.Strp("bucket")
+	path := to.Strp("path")
+
+	// Another process created the lock after it was read as missing
+	s3c.AddGetObject(*path, `{"uuid": "NOT_UUID"}`, nil)
+	grabbed, err := putLock(s3c, bucket, path, &Lock{UUID: "UUID"}, nil)
+	assert.NoError(t, err)
+	assert.False(t, grabbed)
+
+	// Another process stole the expired lock after it was read
+	grabbed, err = putLock(s3c, bucket, path, &Lock{UUID: "UUID"}, to.Strp(`"stale"`))
+	assert.NoError(t, err)
+	assert.False(t, grabbed)
+
+	lock, err := GetLock(s3c, bucket, path)
+	assert.NoError(t, err)
+	assert.Equal(t, "NOT_UUID", lock.UUID)
+}
@@ -7,10 +7,16 @@ import (
 	"fmt"
 	"io"
 	"io/ioutil"
+	"net/url"
+	"os"
+	"reflect"
 	"strings"
+	"sync"
 
+	awssdk "github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/aws/awserr"
 	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/aws/aws-sdk-go/service/s3/s3manager"
 	"github.com/coinbase/step/aws"
 	"github.com/coinbase/step/utils/to"
 )
@@ -35,7 +41,7 @@ func s3Error(bucket *string, path *string, err error) error {
 
 	if aerr, ok := err.(awserr.Error); ok {
 		switch aerr.Code() {
-		case s3.ErrCodeNoSuchKey:
+		case s3.ErrCodeNoSuchKey, "NotFound": // HeadObject has no body so no NoSuchKey
 			return &NotFoundError{bucket, path}
 		}
 	}
@@ -62,9 +68,15 @@ func GetStr(s3c aws.S3API, bucket *string, path *string) (*string, error) {
 }
 
 func GetObject(s3c aws.S3API, bucket *string, path *string) (*s3.GetObjectOutput, *[]byte, error) {
+	return GetObjectVersion(s3c, bucket, path, nil)
+}
+
+// GetObjectVersion downloads versionId of the object, a nil versionId is the latest version
+func GetObjectVersion(s3c aws.S3API, bucket *string, path *string, versionId *string) (*s3.GetObjectOutput, *[]byte, error) {
 	return get(s3c, &s3.GetObjectInput{
-		Bucket: bucket,
-		Key:    path,
+		Bucket:    bucket,
+		Key:       path,
+		VersionId: versionId,
 	})
 }
 
@@ -86,6 +98,192 @@ func get(s3c aws.S3API, input *s3.GetObjectInput) (*s3.GetObjectOutput, *[]byte,
 	return output, &b, nil
 }
 
+// ProgressFunc is called as a download is read, total is -1 if the size is unknown
+type ProgressFunc func(bytesRead int64, total int64)
+
+// StreamToFileThreshold is the object size above which GetWithProgress downloads to a temp file,
+// so the body is read into one exactly sized buffer instead of a growing one
+var StreamToFileThreshold int64 = 64 * 1024 * 1024
+
+type progressReader struct {
+	r        io.Reader
+	read     int64
+	total    int64
+	progress ProgressFunc
+}
+
+func (p *progressReader) Read(b []byte) (int, error) {
+	n, err := p.r.Read(b)
+	p.read += int64(n)
+	if n > 0 {
+		p.progress(p.read, p.total)
+	}
+	return n, err
+}
+
+// GetWithProgress downloads content from S3 calling progress as it is read, progress can be nil
+func GetWithProgress(s3c aws.S3API, bucket *string, path *string, progress ProgressFunc) (*[]byte, error) {
+	return GetVersionWithProgress(s3c, bucket, path, nil, progress)
+}
+
+// GetVersionWithProgress is GetWithProgress of versionId, a nil versionId is the latest version
+func GetVersionWithProgress(s3c aws.S3API, bucket *string, path *string, versionId *string, progress ProgressFunc) (*[]byte, error) {
+	output, err := s3c.GetObject(&s3.GetObjectInput{
+		Bucket:    bucket,
+		Key:       path,
+		VersionId: versionId,
+	})
+
+	if err != nil {
+		return nil, s3Error(bucket, path, err)
+	}
+
+	defer output.Body.Close()
+
+	total := int64(-1)
+	if output.ContentLength != nil {
+		total = *output.ContentLength
+	}
+
+	var body io.Reader = output.Body
+	if progress != nil {
+		body = &progressReader{r: output.Body, total: total, progress: progress}
+	}
+
+	if total > StreamToFileThreshold {
+		return getViaTempFile(body)
+	}
+
+	buf := bytes.NewBuffer(nil)
+	if total > 0 {
+		buf.Grow(int(total))
+	}
+
+	if _, err := io.Copy(buf, body); err != nil {
+		return nil, err
+	}
+
+	b := buf.Bytes()
+	return &b, nil
+}
+
+// GetLargeThreshold is the object size above which a Lambda Zip is downloaded with GetLarge
+var GetLargeThreshold int64 = 64 * 1024 * 1024
+
+// GetLargePartSize and GetLargeConcurrency configure the ranged GETs of GetLarge
+var (
+	GetLargePartSize    int64 = 16 * 1024 * 1024
+	GetLargeConcurrency       = 8
+)
+
+// Size returns the ContentLength of the object
+func Size(s3c aws.S3API, bucket *string, path *string) (int64, error) {
+	return SizeVersion(s3c, bucket, path, nil)
+}
+
+// SizeVersion returns the ContentLength of versionId of the object, a nil versionId is the latest version
+func SizeVersion(s3c aws.S3API, bucket *string, path *string, versionId *string) (int64, error) {
+	output, err := s3c.HeadObject(&s3.HeadObjectInput{
+		Bucket:    bucket,
+		Key:       path,
+		VersionId: versionId,
+	})
+
+	if err != nil {
+		return 0, s3Error(bucket, path, err)
+	}
+
+	if output.ContentLength == nil {
+		return 0, fmt.Errorf("Unknown S3 HeadObject Error: no ContentLength")
+	}
+
+	return *output.ContentLength, nil
+}
+
+// GetLarge downloads content from S3 with concurrent ranged GETs of GetLargePartSize,
+// a part whose body fails to read is downloaded again up to the client's MaxRetries times.
+// progress is called as parts are written, it can be nil
+func GetLarge(s3c aws.S3API, bucket *string, path *string, progress ProgressFunc) (*[]byte, error) {
+	return GetLargeVersion(s3c, bucket, path, nil, progress)
+}
+
+// GetLargeVersion is GetLarge of versionId, a nil versionId is the latest version
+func GetLargeVersion(s3c aws.S3API, bucket *string, path *string, versionId *string, progress ProgressFunc) (*[]byte, error) {
+	size, err := SizeVersion(s3c, bucket, path, versionId)
+	if err != nil {
+		return nil, err
+	}
+
+	downloader := s3manager.NewDownloaderWithClient(s3c, func(d *s3manager.Downloader) {
+		d.PartSize = GetLargePartSize
+		d.Concurrency = GetLargeConcurrency
+	})
+
+	buf := &progressWriterAt{w: awssdk.NewWriteAtBuffer(make([]byte, 0, size)), total: size, progress: progress}
+
+	n, err := downloader.Download(buf, &s3.GetObjectInput{
+		Bucket:    bucket,
+		Key:       path,
+		VersionId: versionId,
+	})
+
+	if err != nil {
+		return nil, s3Error(bucket, path, err)
+	}
+
+	if n != size {
+		return nil, fmt.Errorf("S3 Download Error: got %v of %v bytes for %v", n, size, *path)
+	}
+
+	b := buf.w.Bytes()
+	return &b, nil
+}
+
+type progressWriterAt struct {
+	w        *awssdk.WriteAtBuffer
+	total    int64
+	progress ProgressFunc
+
+	mu      sync.Mutex
+	written int64
+}
+
+func (p *progressWriterAt) WriteAt(b []byte, off int64) (int, error) {
+	n, err := p.w.WriteAt(b, off)
+	if p.progress == nil || n == 0 {
+		return n, err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.written += int64(n)
+	p.progress(p.written, p.total)
+
+	return n, err
+}
+
+func getViaTempFile(body io.Reader) (*[]byte, error) {
+	f, err := ioutil.TempFile("", "step-s3-")
+	if err != nil {
+		return nil, err
+	}
+
+	defer os.Remove(f.Name())
+	defer f.Close()
+
+	if _, err := io.Copy(f, body); err != nil {
+		return nil, err
+	}
+
+	b, err := ioutil.ReadFile(f.Name())
+	if err != nil {
+		return nil, err
+	}
+
+	return &b, nil
+}
+
 // GetBucketTags returns the tags on a bucket
 func GetBucketTags(s3c aws.S3API, bucket *string) (map[string]string, error) {
 	output, err := s3c.GetBucketTagging(&s3.GetBucketTaggingInput{
@@ -195,13 +393,23 @@ func PutSecure(s3c aws.S3API, bucket *string, path *string, content *string, kms
 }
 
 func put(s3c aws.S3API, input *s3.PutObjectInput) error {
-	_, err := s3c.PutObject(input)
+	_, err := putVersion(s3c, input)
+	return err
+}
+
+// putVersion returns the VersionId of the new object, nil if the bucket is not versioned
+func putVersion(s3c aws.S3API, input *s3.PutObjectInput) (*string, error) {
+	out, err := s3c.PutObject(input)
 
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	return nil
+	if out == nil {
+		return nil, nil
+	}
+
+	return out.VersionId, nil
 }
 
 // Delete deletes contents from S3
@@ -218,6 +426,64 @@ func Delete(s3c aws.S3API, bucket *string, path *string) error {
 	return nil
 }
 
+// Copy copies the object at srcPath in srcBucket to path in bucket without downloading it,
+// the caller must be able to read the source and write the destination
+func Copy(s3c aws.S3API, srcBucket *string, srcPath *string, bucket *string, path *string) error {
+	return CopyVersion(s3c, srcBucket, srcPath, nil, bucket, path)
+}
+
+// CopyVersion is Copy of srcVersion of the source object, a nil srcVersion is the latest version
+func CopyVersion(s3c aws.S3API, srcBucket *string, srcPath *string, srcVersion *string, bucket *string, path *string) error {
+	source := (&url.URL{Path: fmt.Sprintf("%v/%v", *srcBucket, *srcPath)}).EscapedPath()
+	if srcVersion != nil {
+		source = fmt.Sprintf("%v?versionId=%v", source, url.QueryEscape(*srcVersion))
+	}
+
+	_, err := s3c.CopyObject(&s3.CopyObjectInput{
+		CopySource: &source,
+		Bucket:     bucket,
+		Key:        path,
+	})
+
+	return s3Error(srcBucket, srcPath, err)
+}
+
+// List returns all the keys in a bucket under a prefix
+func List(s3c aws.S3API, bucket *string, prefix *string) ([]string, error) {
+	keys := []string{}
+	input := &s3.ListObjectsInput{
+		Bucket: bucket,
+		Prefix: prefix,
+	}
+
+	for {
+		output, err := s3c.ListObjects(input)
+		if err != nil {
+			return nil, err
+		}
+
+		if output == nil {
+			return keys, nil
+		}
+
+		for _, obj := range output.Contents {
+			if obj.Key != nil {
+				keys = append(keys, *obj.Key)
+			}
+		}
+
+		if output.IsTruncated == nil || !*output.IsTruncated || len(keys) == 0 {
+			return keys, nil
+		}
+
+		// NextMarker is only returned when a Delimiter is set
+		input.Marker = output.NextMarker
+		if input.Marker == nil {
+			input.Marker = &keys[len(keys)-1]
+		}
+	}
+}
+
 /////////
 // Struct Helpers
 /////////
@@ -242,9 +508,24 @@ func GetStruct(s3c aws.S3API, bucket *string, path *string, str interface{}) err
 	return nil
 }
 
-// PutStruct Uploads a Struct to S3
+// GetStructVersion reads versionId of a Struct from S3, nil is the latest version,
+// and returns the VersionId that was read, nil if the bucket is not versioned
+func GetStructVersion(s3c aws.S3API, bucket *string, path *string, versionId *string, str interface{}) (*string, error) {
+	out, raw, err := GetObjectVersion(s3c, bucket, path, versionId)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(*raw, str); err != nil {
+		return nil, err
+	}
+
+	return out.VersionId, nil
+}
+
+// PutStruct Uploads a Struct to S3 as to.CanonicalJSON
 func PutStruct(s3c aws.S3API, bucket *string, path *string, str interface{}) error {
-	outputJSON, err := json.Marshal(str)
+	outputJSON, err := to.CanonicalJSON(str)
 
 	if err != nil {
 		return err
@@ -253,6 +534,56 @@ func PutStruct(s3c aws.S3API, bucket *string, path *string, str interface{}) err
 	return Put(s3c, bucket, path, &outputJSON)
 }
 
+// PutStructWithSHA uploads a Struct to S3 and returns the SHA256 of it, see PutStructWithHash
+func PutStructWithSHA(s3c aws.S3API, bucket *string, path *string, str interface{}) (string, error) {
+	return PutStructWithHash(s3c, bucket, path, str, to.SHA256)
+}
+
+// PutStructWithHash uploads a Struct to S3 and returns its hash with algo.
+// The uploaded JSON is the to.CanonicalJSON that is hashed, it is unmarshalled into a new struct
+// of the same type and must hash the same, so GetStruct followed by to.HashStruct will match the returned hash
+func PutStructWithHash(s3c aws.S3API, bucket *string, path *string, str interface{}, algo string) (string, error) {
+	hash, _, err := PutStructWithHashVersion(s3c, bucket, path, str, algo)
+	return hash, err
+}
+
+// PutStructWithHashVersion is PutStructWithHash also returning the VersionId written, nil if the bucket is not versioned
+func PutStructWithHashVersion(s3c aws.S3API, bucket *string, path *string, str interface{}, algo string) (string, *string, error) {
+	if !to.ValidHashAlgo(algo) {
+		return "", nil, to.UnknownHashAlgoError(algo)
+	}
+
+	outputJSON, err := to.CanonicalJSON(str)
+	if err != nil {
+		return "", nil, err
+	}
+
+	hash := to.HashAByte(algo, &outputJSON)
+
+	// Round trip to catch fields that do not survive marshalling e.g. time precision or custom marshallers
+	roundTrip := reflect.New(reflect.Indirect(reflect.ValueOf(str)).Type()).Interface()
+	if err := json.Unmarshal(outputJSON, roundTrip); err != nil {
+		return "", nil, err
+	}
+
+	if rtHash := to.HashStruct(algo, roundTrip); rtHash != hash {
+		return "", nil, fmt.Errorf("Struct changes when read back from JSON, hash %v became %v", hash, rtHash)
+	}
+
+	versionId, err := putVersion(s3c, &s3.PutObjectInput{
+		Bucket: bucket,
+		Key:    path,
+		Body:   bytes.NewReader(outputJSON),
+		ACL:    to.Strp("private"),
+	})
+
+	if err != nil {
+		return "", nil, err
+	}
+
+	return hash, versionId, nil
+}
+
 /////////
 // File Helpers
 /////////
@@ -293,14 +624,23 @@ func putFile(s3c aws.S3API, file_path *string, input *s3.PutObjectInput) error {
 }
 
 /////////
-// SHA256 Helpers
+// Hash Helpers
 /////////
 
 // GetSHA256 returns a hex string of the SHA256 of the value of a key in S3
 func GetSHA256(s3c aws.S3API, bucket *string, path *string) (string, error) {
+	return GetHash(s3c, bucket, path, to.SHA256)
+}
+
+// GetHash returns a hex string of the algo hash of the value of a key in S3
+func GetHash(s3c aws.S3API, bucket *string, path *string, algo string) (string, error) {
+	if !to.ValidHashAlgo(algo) {
+		return "", to.UnknownHashAlgoError(algo)
+	}
+
 	bytes, err := Get(s3c, bucket, path)
 	if err != nil {
 		return "", err
 	}
-	return to.SHA256AByte(bytes), nil
+	return to.HashAByte(algo, bytes), nil
 }
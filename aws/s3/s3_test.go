@@ -20,6 +20,87 @@ func Test_Get_Success(t *testing.T) {
 	assert.Equal(t, "asd", string(*out))
 }
 
+func Test_GetWithProgress_Success(t *testing.T) {
+	s3c := &mocks.MockS3Client{}
+	_, err := GetWithProgress(s3c, to.Strp("bucket"), to.Strp("/path"), nil)
+	assert.IsType(t, &NotFoundError{}, err)
+
+	s3c.AddGetObject("/path", "asd", nil)
+	out, err := GetWithProgress(s3c, to.Strp("bucket"), to.Strp("/path"), nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "asd", string(*out))
+
+	calls := 0
+	s3c.AddGetObject("/path", "asdfgh", nil)
+	out, err = GetWithProgress(s3c, to.Strp("bucket"), to.Strp("/path"), func(read int64, total int64) {
+		calls++
+		assert.Equal(t, int64(6), read)
+		assert.Equal(t, int64(6), total)
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "asdfgh", string(*out))
+	assert.Equal(t, 1, calls)
+}
+
+func Test_GetWithProgress_TempFile(t *testing.T) {
+	defer func(threshold int64) { StreamToFileThreshold = threshold }(StreamToFileThreshold)
+	StreamToFileThreshold = 2
+
+	s3c := &mocks.MockS3Client{}
+	s3c.AddGetObject("/path", "large", nil)
+
+	var read int64
+	out, err := GetWithProgress(s3c, to.Strp("bucket"), to.Strp("/path"), func(r int64, total int64) { read = r })
+	assert.NoError(t, err)
+	assert.Equal(t, "large", string(*out))
+	assert.Equal(t, int64(5), read)
+}
+
+func Test_GetLarge_Success(t *testing.T) {
+	defer func(size int64) { GetLargePartSize = size }(GetLargePartSize)
+	GetLargePartSize = 4
+
+	s3c := &mocks.MockS3Client{}
+	_, err := GetLarge(s3c, to.Strp("bucket"), to.Strp("/path"), nil)
+	assert.IsType(t, &NotFoundError{}, err)
+
+	s3c.AddGetObject("/path", "0123456789", nil)
+
+	var read, total int64
+	out, err := GetLarge(s3c, to.Strp("bucket"), to.Strp("/path"), func(r int64, t int64) { read, total = r, t })
+	assert.NoError(t, err)
+	assert.Equal(t, "0123456789", string(*out))
+	assert.Equal(t, int64(10), read)
+	assert.Equal(t, int64(10), total)
+
+	// Ranged GETs of 4 bytes
+	assert.Equal(t, 3, len(s3c.GetObjectInputs))
+}
+
+func Test_GetLarge_RetriesParts(t *testing.T) {
+	defer func(size int64) { GetLargePartSize = size }(GetLargePartSize)
+	GetLargePartSize = 4
+
+	s3c := &mocks.MockS3Client{RangedBodyErrors: 2}
+	s3c.AddGetObject("/path", "0123456789", nil)
+
+	out, err := GetLarge(s3c, to.Strp("bucket"), to.Strp("/path"), nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "0123456789", string(*out))
+	assert.Equal(t, 5, len(s3c.GetObjectInputs))
+}
+
+func Test_Size(t *testing.T) {
+	s3c := &mocks.MockS3Client{}
+	_, err := Size(s3c, to.Strp("bucket"), to.Strp("/path"))
+	assert.IsType(t, &NotFoundError{}, err)
+
+	s3c.AddGetObject("/path", "asd", nil)
+	size, err := Size(s3c, to.Strp("bucket"), to.Strp("/path"))
+	assert.NoError(t, err)
+	assert.Equal(t, int64(3), size)
+}
+
 func Test_Put_Success(t *testing.T) {
 	s3c := &mocks.MockS3Client{}
 	bucket := to.Strp("bucket")
@@ -98,6 +179,40 @@ func Test_Delete_Success(t *testing.T) {
 	assert.IsType(t, &NotFoundError{}, err)
 }
 
+func Test_Copy_Success(t *testing.T) {
+	s3c := &mocks.MockS3Client{}
+	assert.NoError(t, PutStr(s3c, to.Strp("bucket"), to.Strp("src path/a+b.zip"), to.Strp("zip")))
+
+	err := Copy(s3c, to.Strp("bucket"), to.Strp("src path/a+b.zip"), to.Strp("other"), to.Strp("dst/a.zip"))
+	assert.NoError(t, err)
+	assert.Equal(t, "bucket/src%20path/a+b.zip", *s3c.CopyObjectInputs[0].CopySource)
+	assert.Equal(t, "other", *s3c.CopyObjectInputs[0].Bucket)
+
+	out, err := Get(s3c, to.Strp("other"), to.Strp("dst/a.zip"))
+	assert.NoError(t, err)
+	assert.Equal(t, "zip", string(*out))
+
+	err = Copy(s3c, to.Strp("bucket"), to.Strp("missing"), to.Strp("other"), to.Strp("dst/b.zip"))
+	assert.IsType(t, &NotFoundError{}, err)
+}
+
+func Test_CopyVersion_Success(t *testing.T) {
+	s3c := &mocks.MockS3Client{Versioned: true}
+	assert.NoError(t, PutStr(s3c, to.Strp("bucket"), to.Strp("src/a.zip"), to.Strp("v1zip")))
+	assert.NoError(t, PutStr(s3c, to.Strp("bucket"), to.Strp("src/a.zip"), to.Strp("v2zip")))
+
+	err := CopyVersion(s3c, to.Strp("bucket"), to.Strp("src/a.zip"), to.Strp("v1"), to.Strp("other"), to.Strp("dst/a.zip"))
+	assert.NoError(t, err)
+	assert.Equal(t, "bucket/src/a.zip?versionId=v1", *s3c.CopyObjectInputs[0].CopySource)
+
+	out, err := Get(s3c, to.Strp("other"), to.Strp("dst/a.zip"))
+	assert.NoError(t, err)
+	assert.Equal(t, "v1zip", string(*out))
+
+	err = CopyVersion(s3c, to.Strp("bucket"), to.Strp("src/a.zip"), to.Strp("v3"), to.Strp("other"), to.Strp("dst/a.zip"))
+	assert.IsType(t, &NotFoundError{}, err)
+}
+
 func Test_GetStruct_Success(t *testing.T) {
 	s3c := &mocks.MockS3Client{}
 	s3c.AddGetObject("/path", `{"name": "asd"}`, nil)
@@ -127,3 +242,78 @@ func Test_PutStruct_Success(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Equal(t, "asd", str.Name)
 }
+
+func Test_PutStructWithSHA_Success(t *testing.T) {
+	s3c := &mocks.MockS3Client{}
+	bucket := to.Strp("bucket")
+	key := to.Strp("/path")
+	type named struct {
+		Name string
+	}
+
+	sha, err := PutStructWithSHA(s3c, bucket, key, &named{"asd"})
+	assert.NoError(t, err)
+
+	str := named{}
+	err = GetStruct(s3c, bucket, key, &str)
+	assert.NoError(t, err)
+	assert.Equal(t, "asd", str.Name)
+	assert.Equal(t, to.SHA256Struct(&str), sha)
+}
+
+func Test_GetStructVersion_Reads_Version(t *testing.T) {
+	s3c := &mocks.MockS3Client{Versioned: true}
+	bucket := to.Strp("bucket")
+	key := to.Strp("/path")
+	type named struct {
+		Name string
+	}
+
+	sha, v1, err := PutStructWithHashVersion(s3c, bucket, key, &named{"first"}, "sha256")
+	assert.NoError(t, err)
+	assert.Equal(t, "v1", *v1)
+
+	_, v2, err := PutStructWithHashVersion(s3c, bucket, key, &named{"second"}, "sha256")
+	assert.NoError(t, err)
+	assert.Equal(t, "v2", *v2)
+
+	str := named{}
+	read, err := GetStructVersion(s3c, bucket, key, v1, &str)
+	assert.NoError(t, err)
+	assert.Equal(t, "v1", *read)
+	assert.Equal(t, "first", str.Name)
+	assert.Equal(t, to.SHA256Struct(&str), sha)
+
+	read, err = GetStructVersion(s3c, bucket, key, nil, &str)
+	assert.NoError(t, err)
+	assert.Equal(t, "v2", *read)
+	assert.Equal(t, "second", str.Name)
+
+	_, err = GetStructVersion(s3c, bucket, key, to.Strp("v3"), &str)
+	assert.Error(t, err)
+}
+
+type lossy struct {
+	Name string `json:"name"`
+}
+
+func (l *lossy) UnmarshalJSON(b []byte) error {
+	l.Name = "changed"
+	return nil
+}
+
+func Test_PutStructWithSHA_RoundTrip_Error(t *testing.T) {
+	s3c := &mocks.MockS3Client{}
+	bucket := to.Strp("bucket")
+	key := to.Strp("/path")
+
+	_, err := PutStructWithSHA(s3c, bucket, key, &lossy{"asd"})
+	assert.Error(t, err)
+	assert.Regexp(t, "changes when read back", err.Error())
+
+	_, err = Get(s3c, bucket, key)
+	assert.IsType(t, &NotFoundError{}, err)
+
+	_, err = PutStructWithHash(s3c, bucket, key, &lossy{"asd"}, "md4")
+	assert.Error(t, err)
+}
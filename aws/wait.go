@@ -0,0 +1,69 @@
+package aws
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go/service/sfn"
+	"github.com/coinbase/step/utils/to"
+)
+
+// WaitForExecutionMaxInterval caps the doubling of the WaitForExecution poll interval
+var WaitForExecutionMaxInterval = time.Minute
+
+// ExecutionTimeoutError is returned by WaitForExecution with the last observed status
+type ExecutionTimeoutError struct {
+	ExecutionArn string
+	Status       string
+	Timeout      time.Duration
+}
+
+func (e *ExecutionTimeoutError) Error() string {
+	return fmt.Sprintf("execution %v timed out after %v with status %v", e.ExecutionArn, e.Timeout, e.Status)
+}
+
+// WaitForExecution polls DescribeExecution until the execution is no longer RUNNING and returns it.
+// The wait between polls starts at pollInterval and doubles up to WaitForExecutionMaxInterval.
+// After timeout the last output is returned with an ExecutionTimeoutError, the execution is not stopped
+func WaitForExecution(sfnc SFNAPI, executionArn *string, pollInterval time.Duration, timeout time.Duration) (*sfn.DescribeExecutionOutput, error) {
+	deadline := time.Now().Add(timeout)
+	delay := pollInterval
+
+	for {
+		out, err := sfnc.DescribeExecution(&sfn.DescribeExecutionInput{
+			ExecutionArn: executionArn,
+		})
+
+		if err != nil {
+			return nil, err
+		}
+
+		if out == nil {
+			return nil, fmt.Errorf("Unknown DescribeExecution Error")
+		}
+
+		if to.Strs(out.Status) != sfn.ExecutionStatusRunning {
+			return out, nil
+		}
+
+		remaining := time.Until(deadline)
+		if remaining <= 0 {
+			return out, &ExecutionTimeoutError{
+				ExecutionArn: to.Strs(executionArn),
+				Status:       to.Strs(out.Status),
+				Timeout:      timeout,
+			}
+		}
+
+		sleep := delay
+		if sleep > remaining {
+			sleep = remaining
+		}
+
+		time.Sleep(sleep)
+
+		if delay *= 2; delay > WaitForExecutionMaxInterval {
+			delay = WaitForExecutionMaxInterval
+		}
+	}
+}
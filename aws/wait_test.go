@@ -0,0 +1,70 @@
+package aws
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/service/sfn"
+	"github.com/aws/aws-sdk-go/service/sfn/sfniface"
+	"github.com/coinbase/step/utils/to"
+	"github.com/stretchr/testify/assert"
+)
+
+// statusSFN returns each of statuses from DescribeExecution, repeating the last
+type statusSFN struct {
+	sfniface.SFNAPI
+	statuses []string
+	calls    int
+	err      error
+}
+
+func (s *statusSFN) DescribeExecution(in *sfn.DescribeExecutionInput) (*sfn.DescribeExecutionOutput, error) {
+	if s.err != nil {
+		return nil, s.err
+	}
+
+	status := s.statuses[len(s.statuses)-1]
+	if s.calls < len(s.statuses) {
+		status = s.statuses[s.calls]
+	}
+	s.calls++
+
+	return &sfn.DescribeExecutionOutput{ExecutionArn: in.ExecutionArn, Status: to.Strp(status), Output: to.Strp(`{"done":true}`)}, nil
+}
+
+func Test_WaitForExecution(t *testing.T) {
+	sfnc := &statusSFN{statuses: []string{"RUNNING", "RUNNING", "SUCCEEDED"}}
+
+	out, err := WaitForExecution(sfnc, to.Strp("arn"), time.Millisecond, time.Second)
+	assert.NoError(t, err)
+	assert.Equal(t, "SUCCEEDED", *out.Status)
+	assert.Equal(t, `{"done":true}`, *out.Output)
+	assert.Equal(t, 3, sfnc.calls)
+
+	sfnc = &statusSFN{statuses: []string{"FAILED"}}
+	out, err = WaitForExecution(sfnc, to.Strp("arn"), time.Millisecond, time.Second)
+	assert.NoError(t, err)
+	assert.Equal(t, "FAILED", *out.Status)
+	assert.Equal(t, 1, sfnc.calls)
+}
+
+func Test_WaitForExecution_Timeout(t *testing.T) {
+	sfnc := &statusSFN{statuses: []string{"RUNNING"}}
+
+	out, err := WaitForExecution(sfnc, to.Strp("arn"), time.Millisecond, 10*time.Millisecond)
+	assert.Equal(t, "RUNNING", *out.Status)
+	assert.IsType(t, &ExecutionTimeoutError{}, err)
+	assert.Equal(t, "RUNNING", err.(*ExecutionTimeoutError).Status)
+	assert.Regexp(t, "execution arn timed out after 10ms with status RUNNING", err.Error())
+
+	// Backoff doubles so there are far fewer polls than timeout/pollInterval
+	assert.True(t, sfnc.calls < 10)
+}
+
+func Test_WaitForExecution_Error(t *testing.T) {
+	sfnc := &statusSFN{err: fmt.Errorf("AccessDenied")}
+
+	_, err := WaitForExecution(sfnc, to.Strp("arn"), time.Millisecond, time.Second)
+	assert.Regexp(t, "AccessDenied", err.Error())
+}
@@ -0,0 +1,110 @@
+package bifrost
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/coinbase/step/aws"
+	"github.com/coinbase/step/aws/s3"
+)
+
+// LockBackend stores the locks that stop releases deploying at the same time
+// GrabLock returns grabbed true if the lock is held by uuid, or might be held when there is an error
+type LockBackend interface {
+	GrabLock(lockPath string, uuid string) (bool, error)
+	ReleaseLock(lockPath string, uuid string) error
+}
+
+// S3LockBackend is the default LockBackend that stores lock files in a bucket
+type S3LockBackend struct {
+	S3     aws.S3API
+	Bucket *string
+	MaxAge time.Duration // TTL written into the locks this backend grabs, 0 never expires
+}
+
+func (b *S3LockBackend) GrabLock(lockPath string, uuid string) (bool, error) {
+	return s3.GrabLockWithTTL(b.S3, b.Bucket, &lockPath, uuid, b.MaxAge)
+}
+
+func (b *S3LockBackend) ReleaseLock(lockPath string, uuid string) error {
+	return s3.ReleaseLock(b.S3, b.Bucket, &lockPath, uuid)
+}
+
+// MemoryLockBackend is a LockBackend that keeps locks in memory, safe for concurrent use.
+// It is the reference for the LockBackend contract, and a deterministic backend for tests:
+//   - GrabLock takes a lock that is not held, or is past the ExpiresAt its holder wrote
+//   - GrabLock of a lock held by uuid returns true without rewriting it, so its ExpiresAt is not extended
+//   - ReleaseLock removes a lock held by uuid, releasing a lock that is not held is not an error
+//   - ReleaseLock of a lock held by another uuid is an error, even if it has expired
+type MemoryLockBackend struct {
+	MaxAge time.Duration    // TTL written into the locks this backend grabs, 0 never expires
+	Now    func() time.Time // Defaults to time.Now
+
+	mu    sync.Mutex
+	locks map[string]s3.Lock
+}
+
+func (b *MemoryLockBackend) now() time.Time {
+	if b.Now == nil {
+		return time.Now()
+	}
+	return b.Now()
+}
+
+func (b *MemoryLockBackend) GrabLock(lockPath string, uuid string) (bool, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.locks == nil {
+		b.locks = map[string]s3.Lock{}
+	}
+
+	now := b.now()
+
+	if lock, ok := b.locks[lockPath]; ok {
+		if lock.UUID == uuid {
+			// Already have the lock
+			return true, nil
+		}
+
+		if lock.ExpiresAt == nil || !now.After(*lock.ExpiresAt) {
+			return false, nil
+		}
+	}
+
+	lock := s3.Lock{UUID: uuid, CreatedAt: &now}
+	if b.MaxAge > 0 {
+		expiresAt := now.Add(b.MaxAge)
+		lock.ExpiresAt = &expiresAt
+	}
+
+	b.locks[lockPath] = lock
+	return true, nil
+}
+
+func (b *MemoryLockBackend) ReleaseLock(lockPath string, uuid string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	lock, ok := b.locks[lockPath]
+	if !ok {
+		// No lock to release
+		return nil
+	}
+
+	if lock.UUID != uuid {
+		return fmt.Errorf("Release with UUID(%v) is trying to unlock UUID(%v)", uuid, lock.UUID)
+	}
+
+	delete(b.locks, lockPath)
+	return nil
+}
+
+// Holder returns the uuid holding the lock at lockPath, or "" if it is not held
+func (b *MemoryLockBackend) Holder(lockPath string) string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.locks[lockPath].UUID
+}
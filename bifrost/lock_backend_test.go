@@ -0,0 +1,111 @@
+package bifrost
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/coinbase/step/utils/to"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_MemoryLockBackend_GrabRelease(t *testing.T) {
+	backend := &MemoryLockBackend{}
+
+	grabbed, err := backend.GrabLock("lock", "a")
+	assert.NoError(t, err)
+	assert.True(t, grabbed)
+	assert.Equal(t, "a", backend.Holder("lock"))
+
+	// Grabbing again with the same uuid is a retry
+	grabbed, err = backend.GrabLock("lock", "a")
+	assert.NoError(t, err)
+	assert.True(t, grabbed)
+
+	grabbed, err = backend.GrabLock("lock", "b")
+	assert.NoError(t, err)
+	assert.False(t, grabbed)
+
+	assert.Error(t, backend.ReleaseLock("lock", "b"))
+	assert.NoError(t, backend.ReleaseLock("lock", "a"))
+	assert.Equal(t, "", backend.Holder("lock"))
+
+	// Releasing a lock that is not held is not an error
+	assert.NoError(t, backend.ReleaseLock("lock", "b"))
+
+	grabbed, err = backend.GrabLock("lock", "b")
+	assert.NoError(t, err)
+	assert.True(t, grabbed)
+}
+
+func Test_MemoryLockBackend_MaxAge(t *testing.T) {
+	now := time.Now()
+	backend := &MemoryLockBackend{MaxAge: time.Minute, Now: func() time.Time { return now }}
+
+	grabbed, _ := backend.GrabLock("lock", "a")
+	assert.True(t, grabbed)
+
+	// Grabbing again does not extend the TTL, like s3.GrabLockWithTTL
+	now = now.Add(30 * time.Second)
+	grabbed, _ = backend.GrabLock("lock", "a")
+	assert.True(t, grabbed)
+
+	now = now.Add(30 * time.Second)
+	grabbed, _ = backend.GrabLock("lock", "b")
+	assert.False(t, grabbed)
+
+	now = now.Add(time.Second)
+	grabbed, _ = backend.GrabLock("lock", "b")
+	assert.True(t, grabbed)
+	assert.Equal(t, "b", backend.Holder("lock"))
+
+	// The expired holder can no longer release it
+	assert.Error(t, backend.ReleaseLock("lock", "a"))
+}
+
+func Test_MemoryLockBackend_Race(t *testing.T) {
+	backend := &MemoryLockBackend{}
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	winners := []string{}
+
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func(uuid string) {
+			defer wg.Done()
+			grabbed, err := backend.GrabLock("lock", uuid)
+			assert.NoError(t, err)
+
+			if grabbed {
+				mu.Lock()
+				winners = append(winners, uuid)
+				mu.Unlock()
+			}
+		}(fmt.Sprintf("uuid-%v", i))
+	}
+
+	wg.Wait()
+
+	assert.Equal(t, 1, len(winners))
+	assert.Equal(t, winners[0], backend.Holder("lock"))
+}
+
+func Test_Lock_GrabLocksWith_MemoryLockBackend(t *testing.T) {
+	r := MockRelease()
+	r.SetDefaults(r.AwsRegion, r.AwsAccountID, "")
+
+	r2 := MockRelease()
+	r2.SetDefaults(r2.AwsRegion, r2.AwsAccountID, "")
+	r2.UUID = to.Strp("NOTUUID")
+
+	backend := &MemoryLockBackend{}
+
+	assert.NoError(t, r.GrabLocksWith(backend))
+	assert.Error(t, r2.GrabLocksWith(backend))
+	assert.Equal(t, *r.UUID, backend.Holder(*r.RootLockPath()))
+
+	assert.NoError(t, r.UnlockRootWith(backend))
+	assert.NoError(t, r2.GrabLocksWith(backend))
+}
@@ -0,0 +1,83 @@
+package bifrost
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/coinbase/step/aws"
+	"github.com/coinbase/step/aws/s3"
+	"github.com/coinbase/step/errors"
+	"github.com/coinbase/step/utils/to"
+)
+
+// NoncePath is where the nonce for the release is recorded
+// The nonce is keyed by the client generated ReleaseID because the deployer
+// replaces the UUID of every release it receives, so a replay always arrives with a new UUID
+func (r *Release) NoncePath() *string {
+	s := fmt.Sprintf("%v%v", *r.NonceDir(), to.Strs(r.ReleaseID))
+	return &s
+}
+
+// NonceDir is where the nonces of every release of the project config are recorded
+func (r *Release) NonceDir() *string {
+	s := fmt.Sprintf("%v/nonces/", *r.RootDir())
+	return &s
+}
+
+// CheckAndRecordNonce records that this release (UUID) has used its ReleaseID and
+// returns a ReplayError if another execution already used it.
+// Retries from the same execution succeed. The nonce is created with a conditional write, so of two
+// executions racing with the same ReleaseID only one records it.
+// A release with a CreatedAt maxSkew in the future is accepted for maxAge+maxSkew after it is recorded,
+// so the nonce expires after that, a release that old is already rejected by ValidateAttributes
+// Expired nonces are deleted by CleanupExpiredNonces
+func (r *Release) CheckAndRecordNonce(s3c aws.S3API) error {
+	maxAge, maxSkew := r.createdAtWindow()
+
+	recorded, err := s3.GrabLockWithTTL(s3c, r.Bucket, r.NoncePath(), to.Strs(r.UUID), maxAge+maxSkew)
+	if err != nil {
+		return err
+	}
+
+	if !recorded {
+		return &errors.ReplayError{Cause: fmt.Sprintf("Release %v has already been submitted", to.Strs(r.ReleaseID))}
+	}
+
+	return nil
+}
+
+// CleanupExpiredNonces deletes the nonces past their ExpiresAt, the releases they record are older than
+// the CreatedAt window so are already rejected by ValidateAttributes. Nonces recorded without an ExpiresAt
+// are deleted once their CreatedAt is older than the TTL CheckAndRecordNonce writes
+func (r *Release) CleanupExpiredNonces(s3c aws.S3API) error {
+	maxAge, maxSkew := r.createdAtWindow()
+
+	keys, err := s3.List(s3c, r.Bucket, r.NonceDir())
+	if err != nil {
+		return err
+	}
+
+	for _, key := range keys {
+		nonce, err := s3.GetLock(s3c, r.Bucket, &key)
+		if err != nil {
+			return err
+		}
+
+		if nonce == nil || !nonceExpired(nonce, maxAge+maxSkew) {
+			continue
+		}
+
+		if err := s3.Delete(s3c, r.Bucket, to.Strp(key)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func nonceExpired(nonce *s3.Lock, ttl time.Duration) bool {
+	if nonce.ExpiresAt == nil {
+		return nonce.CreatedAt != nil && time.Since(*nonce.CreatedAt) > ttl
+	}
+	return nonce.Expired()
+}
@@ -0,0 +1,85 @@
+package bifrost
+
+import (
+	"testing"
+	"time"
+
+	"github.com/coinbase/step/aws/s3"
+	"github.com/coinbase/step/errors"
+	"github.com/coinbase/step/utils/to"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Release_CheckAndRecordNonce(t *testing.T) {
+	release := MockRelease()
+	awsc := MockAwsClients(release)
+	s3c := awsc.S3Client(nil, nil, nil)
+
+	assert.NoError(t, release.CheckAndRecordNonce(s3c))
+
+	// Kept for the CreatedAt window including the skew
+	nonce, err := s3.GetLock(s3c, release.Bucket, release.NoncePath())
+	assert.NoError(t, err)
+	assert.WithinDuration(t, time.Now().Add(10*24*time.Hour+2*time.Minute), *nonce.ExpiresAt, time.Minute)
+
+	// Retry from the same execution
+	assert.NoError(t, release.CheckAndRecordNonce(s3c))
+
+	// Replay gets a new UUID from the deployer
+	release.UUID = to.Strp("replayed")
+	err = release.CheckAndRecordNonce(s3c)
+	assert.Error(t, err)
+	assert.IsType(t, &errors.ReplayError{}, err)
+
+	// Nonces older than the CreatedAt window can be garbage collected
+	old := to.Timep(time.Now().Add(-11 * 24 * time.Hour))
+	assert.NoError(t, s3.PutStruct(s3c, release.Bucket, release.NoncePath(), &s3.Lock{UUID: "old", CreatedAt: old, ExpiresAt: old}))
+	assert.NoError(t, release.CheckAndRecordNonce(s3c))
+}
+
+func Test_Release_CheckAndRecordNonce_Race(t *testing.T) {
+	release := MockRelease()
+	awsc := MockAwsClients(release)
+	s3c := awsc.S3Client(nil, nil, nil)
+
+	replay := *release
+	replay.UUID = to.Strp("replayed")
+
+	results := make(chan error, 2)
+	for _, r := range []*Release{release, &replay} {
+		go func(r *Release) { results <- r.CheckAndRecordNonce(s3c) }(r)
+	}
+
+	// Only one execution records the nonce
+	errs := []error{<-results, <-results}
+	assert.True(t, (errs[0] == nil) != (errs[1] == nil))
+}
+
+func Test_Release_CleanupExpiredNonces(t *testing.T) {
+	release := MockRelease()
+	awsc := MockAwsClients(release)
+	s3c := awsc.S3Client(nil, nil, nil)
+
+	assert.NoError(t, release.CleanupExpiredNonces(s3c))
+	assert.NoError(t, release.CheckAndRecordNonce(s3c))
+
+	old := to.Timep(time.Now().Add(-11 * 24 * time.Hour))
+	expired := *release
+	expired.ReleaseID = to.Strp("expired")
+	assert.NoError(t, s3.PutStruct(s3c, release.Bucket, expired.NoncePath(), &s3.Lock{UUID: "old", CreatedAt: old, ExpiresAt: old}))
+
+	// Recorded before nonces had an ExpiresAt
+	legacy := *release
+	legacy.ReleaseID = to.Strp("legacy")
+	assert.NoError(t, s3.PutStruct(s3c, release.Bucket, legacy.NoncePath(), &s3.Lock{UUID: "old", CreatedAt: old}))
+
+	recent := *release
+	recent.ReleaseID = to.Strp("recent")
+	assert.NoError(t, s3.PutStruct(s3c, release.Bucket, recent.NoncePath(), &s3.Lock{UUID: "new", CreatedAt: to.Timep(time.Now())}))
+
+	assert.NoError(t, release.CleanupExpiredNonces(s3c))
+
+	keys, err := s3.List(s3c, release.Bucket, release.NonceDir())
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []string{*release.NoncePath(), *recent.NoncePath()}, keys)
+}
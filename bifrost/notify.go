@@ -0,0 +1,57 @@
+package bifrost
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/aws/aws-sdk-go/service/sns"
+	"github.com/coinbase/step/aws"
+	"github.com/coinbase/step/utils/to"
+)
+
+// Deploy lifecycle events sent with Notify
+const (
+	NotifyStarted   = "started"
+	NotifySucceeded = "succeeded"
+	NotifyFailed    = "failed"
+)
+
+// NotifyMessage is the JSON published by Notify, subscribers depend on this shape
+type NotifyMessage struct {
+	Event       string        `json:"event"`
+	ProjectName *string       `json:"project_name,omitempty"`
+	ConfigName  *string       `json:"config_name,omitempty"`
+	ReleaseID   *string       `json:"release_id,omitempty"`
+	UUID        *string       `json:"uuid,omitempty"`
+	Error       *ReleaseError `json:"error,omitempty"`
+	SentAt      *time.Time    `json:"sent_at,omitempty"`
+}
+
+// NotifyMessage returns the message Notify publishes for event
+func (r *Release) NotifyMessage(event string) *NotifyMessage {
+	return &NotifyMessage{
+		Event:       event,
+		ProjectName: r.ProjectName,
+		ConfigName:  r.ConfigName,
+		ReleaseID:   r.ReleaseID,
+		UUID:        r.UUID,
+		Error:       r.Error,
+		SentAt:      to.Timep(time.Now()),
+	}
+}
+
+// Notify publishes the event for this release to the SNS topicArn
+func (r *Release) Notify(snsc aws.SNSAPI, topicArn string, event string) error {
+	raw, err := json.Marshal(r.NotifyMessage(event))
+	if err != nil {
+		return err
+	}
+
+	_, err = snsc.Publish(&sns.PublishInput{
+		TopicArn: &topicArn,
+		Subject:  to.Strp("step " + event),
+		Message:  to.Strp(string(raw)),
+	})
+
+	return err
+}
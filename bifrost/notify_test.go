@@ -0,0 +1,34 @@
+package bifrost
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/coinbase/step/aws/mocks"
+	"github.com/coinbase/step/utils/to"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Release_Notify(t *testing.T) {
+	snsc := &mocks.MockSNSClient{}
+	r := &Release{
+		ProjectName: to.Strp("project"),
+		ConfigName:  to.Strp("config"),
+		ReleaseID:   to.Strp("release-1"),
+		UUID:        to.Strp("uuid"),
+		Error:       &ReleaseError{Error: to.Strp("DeployLambdaError"), Cause: to.Strp("cause")},
+	}
+
+	assert.NoError(t, r.Notify(snsc, "arn:aws:sns:us-east-1:000000000000:topic", NotifyFailed))
+	assert.Equal(t, 1, len(snsc.Published))
+	assert.Equal(t, "arn:aws:sns:us-east-1:000000000000:topic", *snsc.Published[0].TopicArn)
+
+	var msg NotifyMessage
+	assert.NoError(t, json.Unmarshal([]byte(*snsc.Published[0].Message), &msg))
+	assert.Equal(t, "failed", msg.Event)
+	assert.Equal(t, "project", *msg.ProjectName)
+	assert.Equal(t, "config", *msg.ConfigName)
+	assert.Equal(t, "release-1", *msg.ReleaseID)
+	assert.Equal(t, "uuid", *msg.UUID)
+	assert.Equal(t, "DeployLambdaError", *msg.Error.Error)
+}
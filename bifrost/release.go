@@ -1,8 +1,10 @@
 package bifrost
 
 import (
+	"encoding/json"
 	"fmt"
 	"reflect"
+	"regexp"
 	"strings"
 	"time"
 
@@ -10,6 +12,7 @@ import (
 	"github.com/coinbase/step/aws/s3"
 	"github.com/coinbase/step/errors"
 	"github.com/coinbase/step/utils/is"
+	"github.com/coinbase/step/utils/schema"
 	"github.com/coinbase/step/utils/to"
 )
 
@@ -23,8 +26,14 @@ type ReleaseError struct {
 type Release struct {
 	AwsAccountID *string `json:"aws_account_id,omitempty"`
 	AwsRegion    *string `json:"aws_region,omitempty"`
+	Partition    *string `json:"partition,omitempty"` // Defaults from AwsRegion, aws, aws-us-gov or aws-cn
 
-	ReleaseSHA256 string `json:"-"` // Not Set By Client, Not Marshalled
+	ReleaseSHA256 string  `json:"-"`                   // Set By Client on upload, Not Marshalled
+	HashAlgo      *string `json:"hash_algo,omitempty"` // Algorithm for the release and lambda hashes, default sha256
+
+	// S3 Version of the uploaded release, set by the client after upload so it is not in the uploaded release.
+	// The deployer validates exactly this version, so overwriting the release after upload is detected
+	ReleaseVersionID *string `json:"release_version_id,omitempty"`
 
 	UUID      *string `json:"uuid,omitempty"`       // Generated By server
 	ReleaseID *string `json:"release_id,omitempty"` // Generated Client
@@ -36,7 +45,18 @@ type Release struct {
 	CreatedAt *time.Time `json:"created_at,omitempty"`
 	StartedAt *time.Time `json:"started_at,omitempty"`
 
-	Timeout *int `json:"timeout,omitempty"` // How long should we try and deploy in seconds
+	Timeout *int `json:"timeout,omitempty"`  // How long should we try and deploy in seconds
+	LockTTL *int `json:"lock_ttl,omitempty"` // Seconds after which this release's locks are stale and can be taken
+
+	// CreatedAt window in seconds, default 10 days in the past and 2 minutes in the future.
+	// Widening the window weakens replay protection, an old release can be redeployed for longer
+	CreatedAtMaxAge  *int `json:"created_at_max_age,omitempty"`
+	CreatedAtMaxSkew *int `json:"created_at_max_skew,omitempty"`
+
+	// Provenance of the release, part of ReleaseSHA256 so it cannot be changed after upload
+	GitSHA   *string `json:"git_sha,omitempty"`   // Commit the release was built from
+	BuildURL *string `json:"build_url,omitempty"` // CI build that created the release
+	Builder  *string `json:"builder,omitempty"`   // Who or what built the release
 
 	// Additional Metadata attached but should not be functional
 	Metadata map[string]string `json:"metadata,omitempty"`
@@ -60,6 +80,69 @@ func (r *Release) Validate(s3c aws.S3API, cRelease interface{}) error {
 		return fmt.Errorf("Release passed to Validate must be pointer e.g. &Release{}")
 	}
 
+	if err := r.ValidateAttributes(); err != nil {
+		return err
+	}
+
+	if err := r.ValidateReleaseSHA(s3c, cRelease, r.ReleaseVersionID); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+var gitSHARegex = regexp.MustCompile(`^[0-9a-f]{7,40}$`)
+
+// ValidateProvenance checks GitSHA looks like a commit, if requireGitSHA it must also be defined
+func (r *Release) ValidateProvenance(requireGitSHA bool) error {
+	if is.EmptyStr(r.GitSHA) {
+		if requireGitSHA {
+			return &errors.ValidationError{"GitSHA must be defined"}
+		}
+		return nil
+	}
+
+	if !gitSHARegex.MatchString(*r.GitSHA) {
+		return &errors.ValidationError{fmt.Sprintf("GitSHA must be a hex git commit SHA, got %q", *r.GitSHA)}
+	}
+
+	return nil
+}
+
+// ValidateAllowed checks ProjectName is in allowed and ConfigName is one of its configs,
+// so a deployer only deploys registered projects
+func (r *Release) ValidateAllowed(allowed map[string][]string) error {
+	project, config := to.Strs(r.ProjectName), to.Strs(r.ConfigName)
+
+	configs, ok := allowed[project]
+	if !ok {
+		return &errors.ValidationError{fmt.Sprintf("Project %q is not allowed by this deployer", project)}
+	}
+
+	for _, c := range configs {
+		if c == config {
+			return nil
+		}
+	}
+
+	return &errors.ValidationError{fmt.Sprintf("Config %q is not allowed for project %q by this deployer, allowed %v", config, project, configs)}
+}
+
+// ValidateAttributes checks 1. and 2. of Validate, it does not need AWS
+func (r *Release) ValidateAttributes() error {
+	return r.ValidateAttributesAt(time.Now())
+}
+
+// ValidateAttributesAt is ValidateAttributes with the CreatedAt window checked from now,
+// it returns a ValidationError
+func (r *Release) ValidateAttributesAt(now time.Time) error {
+	if err := r.validateAttributesAt(now); err != nil {
+		return &errors.ValidationError{err.Error()}
+	}
+	return nil
+}
+
+func (r *Release) validateAttributesAt(now time.Time) error {
 	if is.EmptyStr(r.AwsAccountID) {
 		return fmt.Errorf("AwsAccountID must be defined")
 	}
@@ -92,38 +175,112 @@ func (r *Release) Validate(s3c aws.S3API, cRelease interface{}) error {
 		return fmt.Errorf("Timeout must be defined")
 	}
 
+	if !to.ValidHashAlgo(r.HashAlgorithm()) {
+		return fmt.Errorf("HashAlgo %v is not supported", r.HashAlgorithm())
+	}
+
 	if r.CreatedAt == nil {
 		return fmt.Errorf("CreatedAt must be defined")
 	}
 
+	if err := r.ValidateProvenance(false); err != nil {
+		return err
+	}
+
 	if r.StartedAt == nil {
 		return fmt.Errorf("StartedAt must be defined")
 	}
 
-	// Created at date must be after 10 days ago, and before 2 mins from now (wiggle room)
-	// This allows roll backs but protects against redeploying something very old
-	if !is.WithinTimeFrame(r.CreatedAt, 10*24*time.Hour, 2*time.Minute) {
-		return fmt.Errorf("Created at older than 10 days (or in the future)")
+	if (r.CreatedAtMaxAge != nil && *r.CreatedAtMaxAge < 0) || (r.CreatedAtMaxSkew != nil && *r.CreatedAtMaxSkew < 0) {
+		return fmt.Errorf("CreatedAtMaxAge and CreatedAtMaxSkew must not be negative")
 	}
 
-	if err := r.validateReleaseSHA(s3c, cRelease); err != nil {
-		return err
+	// Created at date must be after 10 days ago, and before 2 mins from now (wiggle room)
+	// This allows roll backs but protects against redeploying something very old
+	maxAge, maxSkew := r.createdAtWindow()
+	if !is.WithinTimeFrameAt(r.CreatedAt, maxAge, maxSkew, now) {
+		return fmt.Errorf("Created at older than %v (or more than %v in the future)", maxAge, maxSkew)
 	}
 
 	return nil
 }
 
-func (r *Release) validateReleaseSHA(s3c aws.S3API, cRelease interface{}) error {
+// versionedRelease is implemented by Release and structs that embed it
+type versionedRelease interface {
+	setReleaseVersionID(versionId *string)
+}
 
-	err := s3.GetStruct(s3c, r.Bucket, r.ReleasePath(), cRelease)
+func (r *Release) setReleaseVersionID(versionId *string) {
+	r.ReleaseVersionID = versionId
+}
+
+// Sign sets ReleaseSHA256 to the hash ValidateReleaseSHA expects of the uploaded release.
+// Structs that embed Release must define their own Sign so all of their fields are hashed
+func (r *Release) Sign() {
+	unsigned := *r
+	unsigned.ReleaseSHA256 = ""
+	r.ReleaseSHA256 = to.HashStruct(r.HashAlgorithm(), &unsigned)
+}
+
+// FingerprintLength is the number of hex characters in a Fingerprint
+const FingerprintLength = 12
+
+// Fingerprint is a short prefix of ReleaseSHA256 to show next to the ReleaseID,
+// a release that is not signed uses the SHA256 of the release instead
+func (r *Release) Fingerprint() string {
+	if r.ReleaseSHA256 != "" {
+		return FingerprintSHA(r.ReleaseSHA256)
+	}
+	return FingerprintSHA(to.SHA256Struct(r))
+}
+
+// FingerprintSHA returns the Fingerprint of a release hashed to sha
+func FingerprintSHA(sha string) string {
+	if len(sha) <= FingerprintLength {
+		return sha
+	}
+	return sha[:FingerprintLength]
+}
+
+// ValidateReleaseSHA checks the uploaded release, unmarshalled into cRelease, hashes to ReleaseSHA256.
+// If versionId is not nil exactly that S3 Version of the release is read
+func (r *Release) ValidateReleaseSHA(s3c aws.S3API, cRelease interface{}, versionId *string) error {
+	_, raw, err := s3.GetObjectVersion(s3c, r.Bucket, r.ReleasePath(), versionId)
 	if err != nil {
 		return fmt.Errorf("Error Unmarshalling uploaded Release struct with %v", err.Error())
 	}
 
-	expected := to.SHA256Struct(cRelease)
+	return r.validateReleaseSHARaw(raw, cRelease)
+}
+
+// validateReleaseSHARaw unmarshals the uploaded release raw into cRelease and checks it hashes to ReleaseSHA256
+func (r *Release) validateReleaseSHARaw(raw *[]byte, cRelease interface{}) error {
+	if raw == nil {
+		return fmt.Errorf("Error Unmarshalling uploaded Release struct with empty release")
+	}
+
+	// Reject malformed releases with field errors before unmarshalling
+	if err := schema.Generate("Release", cRelease).Validate(*raw); err != nil {
+		return fmt.Errorf("Error Validating uploaded Release struct with %v", err.Error())
+	}
+
+	if err := json.Unmarshal(*raw, cRelease); err != nil {
+		return fmt.Errorf("Error Unmarshalling uploaded Release struct with %v", err.Error())
+	}
+
+	// ReleaseVersionID was set after upload so is copied to hash the same
+	if versioned, ok := cRelease.(versionedRelease); ok {
+		versioned.setReleaseVersionID(r.ReleaseVersionID)
+	}
+
+	expected := to.HashStruct(r.HashAlgorithm(), cRelease)
 
 	if expected != r.ReleaseSHA256 {
-		return fmt.Errorf("Release SHA incorrect expected %v, got %v", expected, r.ReleaseSHA256)
+		return &errors.SHAMismatchError{
+			Cause:    fmt.Sprintf("Release SHA incorrect expected %v, got %v", expected, r.ReleaseSHA256),
+			Expected: expected,
+			Actual:   r.ReleaseSHA256,
+		}
 	}
 
 	return nil
@@ -160,6 +317,10 @@ func (r *Release) SetDefaults(region *string, account *string, bucket_prefix str
 		r.AwsAccountID = account
 	}
 
+	if is.EmptyStr(r.Partition) {
+		r.Partition = to.Strp(to.PartitionForRegion(r.AwsRegion))
+	}
+
 	if is.EmptyStr(r.Bucket) && account != nil {
 		// default bucket is the default account_id not the release id (which could be in a different account)
 		r.Bucket = to.Strp(fmt.Sprintf("%v%v", bucket_prefix, *account))
@@ -174,34 +335,55 @@ func (r *Release) SetDefaults(region *string, account *string, bucket_prefix str
 // Paths
 ///////
 
+// ProjectDir, RootDir, ReleaseDir, ReleasePath, LogPath and SharedProjectDir compute the S3 keys
+// of a release from its fields, e.g. to find the keys of objects in a bucket listing without a Release
+
+func ProjectDir(account, project string) string {
+	return fmt.Sprintf("%v/%v", account, project)
+}
+
+func RootDir(account, project, config string) string {
+	return fmt.Sprintf("%v/%v", ProjectDir(account, project), config)
+}
+
+func ReleaseDir(account, project, config, releaseId string) string {
+	return fmt.Sprintf("%v/%v", RootDir(account, project, config), releaseId)
+}
+
+func ReleasePath(account, project, config, releaseId string) string {
+	return fmt.Sprintf("%v/release", ReleaseDir(account, project, config, releaseId))
+}
+
+func LogPath(account, project, config, releaseId string) string {
+	return fmt.Sprintf("%v/log", ReleaseDir(account, project, config, releaseId))
+}
+
+func SharedProjectDir(account, project string) string {
+	return fmt.Sprintf("%v/_shared", ProjectDir(account, project))
+}
+
 func (r *Release) ProjectDir() *string {
-	s := fmt.Sprintf("%v/%v", *r.AwsAccountID, *r.ProjectName)
-	return &s
+	return to.Strp(ProjectDir(*r.AwsAccountID, *r.ProjectName))
 }
 
 func (r *Release) RootDir() *string {
-	s := fmt.Sprintf("%v/%v", *r.ProjectDir(), *r.ConfigName)
-	return &s
+	return to.Strp(RootDir(*r.AwsAccountID, *r.ProjectName, *r.ConfigName))
 }
 
 func (r *Release) ReleaseDir() *string {
-	s := fmt.Sprintf("%v/%v", *r.RootDir(), *r.ReleaseID)
-	return &s
+	return to.Strp(ReleaseDir(*r.AwsAccountID, *r.ProjectName, *r.ConfigName, *r.ReleaseID))
 }
 
 func (r *Release) ReleasePath() *string {
-	s := fmt.Sprintf("%v/release", *r.ReleaseDir())
-	return &s
+	return to.Strp(ReleasePath(*r.AwsAccountID, *r.ProjectName, *r.ConfigName, *r.ReleaseID))
 }
 
 func (release *Release) LogPath() *string {
-	s := fmt.Sprintf("%v/log", *release.ReleaseDir())
-	return &s
+	return to.Strp(LogPath(*release.AwsAccountID, *release.ProjectName, *release.ConfigName, *release.ReleaseID))
 }
 
 func (r *Release) SharedProjectDir() *string {
-	s := fmt.Sprintf("%v/_shared", *r.ProjectDir())
-	return &s
+	return to.Strp(SharedProjectDir(*r.AwsAccountID, *r.ProjectName))
 }
 
 ///////
@@ -237,16 +419,26 @@ func (r *Release) TimedOut() error {
 
 // UnlockRootLock deletes the Lock File for the release
 func (r *Release) UnlockRoot(s3c aws.S3API) error {
-	return s3.ReleaseLock(s3c, r.Bucket, r.RootLockPath(), *r.UUID)
+	return r.UnlockRootWith(r.S3LockBackend(s3c))
+}
+
+// UnlockRootWith releases the root lock stored in backend
+func (r *Release) UnlockRootWith(backend LockBackend) error {
+	return backend.ReleaseLock(*r.RootLockPath(), *r.UUID)
 }
 
 // GrabLock retrieves the Lock returns LockExistsError, or LockError
 func (r *Release) GrabLocks(s3c aws.S3API) error {
-	if err := r.GrabReleaseLock(s3c); err != nil {
+	return r.GrabLocksWith(r.S3LockBackend(s3c))
+}
+
+// GrabLocksWith is GrabLocks with the locks stored in backend
+func (r *Release) GrabLocksWith(backend LockBackend) error {
+	if err := r.grabLock(backend, *r.ReleaseLockPath()); err != nil {
 		return err
 	}
 
-	if err := r.GrabRootLock(s3c); err != nil {
+	if err := r.grabLock(backend, *r.RootLockPath()); err != nil {
 		return err
 	}
 
@@ -254,15 +446,15 @@ func (r *Release) GrabLocks(s3c aws.S3API) error {
 }
 
 func (r *Release) GrabRootLock(s3c aws.S3API) error {
-	return r.grabLock(s3c, *r.RootLockPath())
+	return r.grabLock(r.S3LockBackend(s3c), *r.RootLockPath())
 }
 
 func (r *Release) GrabReleaseLock(s3c aws.S3API) error {
-	return r.grabLock(s3c, *r.ReleaseLockPath())
+	return r.grabLock(r.S3LockBackend(s3c), *r.ReleaseLockPath())
 }
 
-func (r *Release) grabLock(s3c aws.S3API, lockPath string) error {
-	grabbed, err := s3.GrabLock(s3c, r.Bucket, &lockPath, *r.UUID)
+func (r *Release) grabLock(backend LockBackend, lockPath string) error {
+	grabbed, err := backend.GrabLock(lockPath, *r.UUID)
 
 	// Check grabbed first because there are errors that can be thrown before anything is created
 	if !grabbed {
@@ -270,7 +462,7 @@ func (r *Release) grabLock(s3c aws.S3API, lockPath string) error {
 			return &errors.LockExistsError{err.Error()}
 		}
 
-		return &errors.LockExistsError{fmt.Sprintf("Lock Already Exists at %v:%v", *r.Bucket, lockPath)}
+		return &errors.LockExistsError{fmt.Sprintf("Lock Already Exists at %v:%v", to.Strs(r.Bucket), lockPath)}
 	}
 
 	// Error if MAYBE grabbed the lock and we should try to unlock
@@ -281,23 +473,154 @@ func (r *Release) grabLock(s3c aws.S3API, lockPath string) error {
 	return nil
 }
 
+// WithLock grabs the locks, runs deploy, then releases the root lock even if deploy panics.
+// If deploy runs longer than maxHold the root lock is released and a LockTimeoutError returned,
+// deploy is left running in its goroutine. A maxHold of 0 never times out
+func (r *Release) WithLock(s3c aws.S3API, maxHold time.Duration, deploy func() error) error {
+	return r.WithLockWith(r.S3LockBackend(s3c), maxHold, deploy)
+}
+
+// WithLockWith is WithLock with the locks stored in backend
+func (r *Release) WithLockWith(backend LockBackend, maxHold time.Duration, deploy func() error) (err error) {
+	if err := r.GrabLocksWith(backend); err != nil {
+		if _, ok := err.(*errors.LockExistsError); ok {
+			return err
+		}
+		// LockError might have grabbed the lock
+		r.UnlockRootWith(backend)
+		return err
+	}
+
+	defer func() {
+		if unlockErr := r.UnlockRootWith(backend); unlockErr != nil && err == nil {
+			err = &errors.LockError{unlockErr.Error()}
+		}
+	}()
+
+	done := make(chan error, 1)
+	go func() {
+		defer func() {
+			if rec := recover(); rec != nil {
+				done <- &errors.PanicError{fmt.Sprintf("%v", rec)}
+			}
+		}()
+		done <- deploy()
+	}()
+
+	if maxHold <= 0 {
+		return <-done
+	}
+
+	select {
+	case err := <-done:
+		return err
+	case <-time.After(maxHold):
+		return &errors.LockTimeoutError{fmt.Sprintf("Lock at %v:%v held longer than %v", to.Strs(r.Bucket), *r.RootLockPath(), maxHold)}
+	}
+}
+
+// S3LockBackend returns the default backend storing locks in the release Bucket
+func (r *Release) S3LockBackend(s3c aws.S3API) LockBackend {
+	return &S3LockBackend{S3: s3c, Bucket: r.Bucket, MaxAge: r.lockMaxAge()}
+}
+
+// LockHolder returns the UUID of the release holding the root lock and when it was grabbed
+// Both are nil if the root is not locked
+func (r *Release) LockHolder(s3c aws.S3API) (*string, *time.Time, error) {
+	lock, err := s3.GetLock(s3c, r.Bucket, r.RootLockPath())
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if lock == nil || lock.UUID == "" {
+		return nil, nil, nil
+	}
+
+	return to.Strp(lock.UUID), lock.CreatedAt, nil
+}
+
+// ForceReleaseLock deletes the root lock if it is held by expectedHolder.
+// If expectedHolder is nil the lock is deleted whoever holds it
+func (r *Release) ForceReleaseLock(s3c aws.S3API, expectedHolder *string) error {
+	if expectedHolder != nil {
+		holder, _, err := r.LockHolder(s3c)
+		if err != nil {
+			return err
+		}
+
+		if holder == nil {
+			// No lock to release
+			return nil
+		}
+
+		if *holder != *expectedHolder {
+			return &errors.LockHolderError{Cause: fmt.Sprintf("Lock at %v:%v held by %v not %v", *r.Bucket, *r.RootLockPath(), *holder, *expectedHolder)}
+		}
+	}
+
+	return s3.Delete(s3c, r.Bucket, r.RootLockPath())
+}
+
+// createdAtWindow returns how far in the past and future CreatedAt may be
+func (r *Release) createdAtWindow() (time.Duration, time.Duration) {
+	maxAge, maxSkew := 10*24*time.Hour, 2*time.Minute
+
+	if r.CreatedAtMaxAge != nil {
+		maxAge = time.Duration(*r.CreatedAtMaxAge) * time.Second
+	}
+
+	if r.CreatedAtMaxSkew != nil {
+		maxSkew = time.Duration(*r.CreatedAtMaxSkew) * time.Second
+	}
+
+	return maxAge, maxSkew
+}
+
+// HashAlgorithm returns the selected HashAlgo, or the default sha256
+func (r *Release) HashAlgorithm() string {
+	if is.EmptyStr(r.HashAlgo) {
+		return to.DefaultHashAlgo
+	}
+	return *r.HashAlgo
+}
+
+// lockMaxAge is the TTL written into the locks this release grabs, 0 never expires
+func (r *Release) lockMaxAge() time.Duration {
+	if r.LockTTL == nil || *r.LockTTL <= 0 {
+		return 0
+	}
+	return time.Duration(*r.LockTTL) * time.Second
+}
+
+// ReleaseLockPath is the lock of a single release
+func ReleaseLockPath(account, project, config, releaseId string) string {
+	return fmt.Sprintf("%v/lock", ReleaseDir(account, project, config, releaseId))
+}
+
+// RootLockPath is the lock shared by every release of a project config
+func RootLockPath(account, project, config string) string {
+	return fmt.Sprintf("%v/lock", RootDir(account, project, config))
+}
+
 func (r *Release) ReleaseLockPath() *string {
-	s := fmt.Sprintf("%v/lock", *r.ReleaseDir())
-	return &s
+	return to.Strp(ReleaseLockPath(*r.AwsAccountID, *r.ProjectName, *r.ConfigName, *r.ReleaseID))
 }
 
 func (r *Release) RootLockPath() *string {
-	s := fmt.Sprintf("%v/lock", *r.RootDir())
-	return &s
+	return to.Strp(RootLockPath(*r.AwsAccountID, *r.ProjectName, *r.ConfigName))
 }
 
 /////////
 // Halt
 /////////
 
+// HaltPath is the halt flag of a project config
+func HaltPath(account, project, config string) string {
+	return fmt.Sprintf("%v/halt", RootDir(account, project, config))
+}
+
 func (r *Release) HaltPath() *string {
-	s := fmt.Sprintf("%v/halt", *r.RootDir())
-	return &s
+	return to.Strp(HaltPath(*r.AwsAccountID, *r.ProjectName, *r.ConfigName))
 }
 
 // IsHalt will error with if halt flag found
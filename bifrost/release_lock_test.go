@@ -1,8 +1,14 @@
 package bifrost
 
 import (
+	"fmt"
 	"testing"
+	"time"
 
+	"github.com/coinbase/step/aws/dynamodb"
+	"github.com/coinbase/step/aws/mocks"
+	"github.com/coinbase/step/aws/s3"
+	"github.com/coinbase/step/errors"
 	"github.com/coinbase/step/utils/to"
 	"github.com/stretchr/testify/assert"
 )
@@ -24,3 +30,238 @@ func Test_Lock_GrabRootLock(t *testing.T) {
 	assert.NoError(t, r.UnlockRoot(s3c))
 	assert.NoError(t, r2.GrabRootLock(s3c))
 }
+
+func Test_Lock_GrabRootLock_LockTTL(t *testing.T) {
+	r := MockRelease()
+
+	r2 := MockRelease()
+	r2.UUID = to.Strp("NOTUUID")
+
+	awsc := MockAwsClients(r)
+	s3c := awsc.S3Client(nil, nil, nil)
+
+	assert.NoError(t, r.GrabRootLock(s3c))
+	assert.Error(t, r2.GrabRootLock(s3c))
+
+	// The TTL of the release trying to take the lock does not matter
+	awsc.S3.AddGetObject(*r.RootLockPath(), fmt.Sprintf(`{"uuid": %q, "created_at": %q}`, *r.UUID, time.Now().Add(-1*time.Hour).Format(time.RFC3339)), nil)
+	r2.LockTTL = to.Intp(60)
+	assert.Error(t, r2.GrabRootLock(s3c))
+
+	// The holder writes its own TTL into the lock
+	r.LockTTL = to.Intp(60)
+	assert.NoError(t, r.UnlockRoot(s3c))
+	assert.NoError(t, r.GrabRootLock(s3c))
+
+	lock, err := s3.GetLock(s3c, r.Bucket, r.RootLockPath())
+	assert.NoError(t, err)
+	assert.WithinDuration(t, time.Now().Add(time.Minute), *lock.ExpiresAt, 5*time.Second)
+	assert.Error(t, r2.GrabRootLock(s3c))
+
+	// Past the ExpiresAt the holder wrote the lock is stale
+	awsc.S3.AddGetObject(*r.RootLockPath(), fmt.Sprintf(`{"uuid": %q, "expires_at": %q}`, *r.UUID, time.Now().Add(-1*time.Second).Format(time.RFC3339)), nil)
+	r2.LockTTL = nil
+	assert.NoError(t, r2.GrabRootLock(s3c))
+	assert.Error(t, r.GrabRootLock(s3c))
+}
+
+func Test_Lock_LockHolder(t *testing.T) {
+	r := MockRelease()
+	awsc := MockAwsClients(r)
+	s3c := awsc.S3Client(nil, nil, nil)
+
+	holder, since, err := r.LockHolder(s3c)
+	assert.NoError(t, err)
+	assert.Nil(t, holder)
+	assert.Nil(t, since)
+
+	assert.NoError(t, r.GrabRootLock(s3c))
+
+	holder, since, err = r.LockHolder(s3c)
+	assert.NoError(t, err)
+	assert.Equal(t, *r.UUID, *holder)
+	assert.WithinDuration(t, time.Now(), *since, time.Minute)
+}
+
+func Test_Lock_ForceReleaseLock(t *testing.T) {
+	r := MockRelease()
+	awsc := MockAwsClients(r)
+	s3c := awsc.S3Client(nil, nil, nil)
+
+	assert.NoError(t, r.ForceReleaseLock(s3c, to.Strp("NOTUUID")))
+	assert.NoError(t, r.GrabRootLock(s3c))
+
+	err := r.ForceReleaseLock(s3c, to.Strp("NOTUUID"))
+	assert.Error(t, err)
+	assert.IsType(t, &errors.LockHolderError{}, err)
+
+	assert.NoError(t, r.ForceReleaseLock(s3c, r.UUID))
+	holder, _, _ := r.LockHolder(s3c)
+	assert.Nil(t, holder)
+
+	assert.NoError(t, r.GrabRootLock(s3c))
+	assert.NoError(t, r.ForceReleaseLock(s3c, nil))
+	holder, _, _ = r.LockHolder(s3c)
+	assert.Nil(t, holder)
+}
+
+func Test_Lock_WithLock(t *testing.T) {
+	r := MockRelease()
+	awsc := MockAwsClients(r)
+	s3c := awsc.S3Client(nil, nil, nil)
+
+	err := r.WithLock(s3c, 0, func() error {
+		holder, _, err := r.LockHolder(s3c)
+		assert.NoError(t, err)
+		assert.Equal(t, *r.UUID, *holder)
+		return fmt.Errorf("deploy failed")
+	})
+	assert.Regexp(t, "deploy failed", err.Error())
+
+	holder, _, _ := r.LockHolder(s3c)
+	assert.Nil(t, holder)
+
+	// Locked by another release
+	r2 := MockRelease()
+	r2.UUID = to.Strp("NOTUUID")
+	assert.NoError(t, r2.GrabRootLock(s3c))
+	err = r.WithLock(s3c, 0, func() error { return nil })
+	assert.IsType(t, &errors.LockExistsError{}, err)
+}
+
+func Test_Lock_WithLock_Panic(t *testing.T) {
+	r := MockRelease()
+	awsc := MockAwsClients(r)
+	s3c := awsc.S3Client(nil, nil, nil)
+
+	err := r.WithLock(s3c, time.Minute, func() error { panic("crashed") })
+	assert.IsType(t, &errors.PanicError{}, err)
+	assert.Regexp(t, "crashed", err.Error())
+
+	holder, _, _ := r.LockHolder(s3c)
+	assert.Nil(t, holder)
+}
+
+func Test_Lock_WithLock_Timeout(t *testing.T) {
+	r := MockRelease()
+	awsc := MockAwsClients(r)
+	s3c := awsc.S3Client(nil, nil, nil)
+
+	stuck := make(chan struct{})
+	defer close(stuck)
+
+	err := r.WithLock(s3c, 10*time.Millisecond, func() error {
+		<-stuck
+		return nil
+	})
+	assert.IsType(t, &errors.LockTimeoutError{}, err)
+
+	holder, _, _ := r.LockHolder(s3c)
+	assert.Nil(t, holder)
+}
+
+func Test_Lock_WithLockWith_MockLockBackend(t *testing.T) {
+	r := MockRelease()
+	r.SetDefaults(r.AwsRegion, r.AwsAccountID, "")
+	backend := &mocks.MockLockBackend{}
+
+	assert.NoError(t, r.WithLockWith(backend, 0, func() error {
+		assert.Equal(t, *r.UUID, backend.Locks[*r.RootLockPath()])
+		return nil
+	}))
+
+	assert.Equal(t, []string{*r.ReleaseLockPath(), *r.RootLockPath()}, backend.GrabLockCalls)
+	assert.Equal(t, []string{*r.RootLockPath()}, backend.ReleaseLockCalls)
+	_, locked := backend.Locks[*r.RootLockPath()]
+	assert.False(t, locked)
+
+	backend.GrabLockError = fmt.Errorf("unavailable")
+	err := r.WithLockWith(backend, 0, func() error { return nil })
+	assert.IsType(t, &errors.LockExistsError{}, err)
+}
+
+func Test_Lock_GrabLocksWith_DynamoDB(t *testing.T) {
+	r := MockRelease()
+	MockAwsClients(r)
+
+	r2 := MockRelease()
+	r2.UUID = to.Strp("NOTUUID")
+
+	backend := &dynamodb.LockBackend{DynamoDB: &mocks.MockDynamoDBClient{}, Table: to.Strp("locks")}
+
+	assert.NoError(t, r.GrabLocksWith(backend))
+	assert.Error(t, r2.GrabLocksWith(backend))
+
+	assert.NoError(t, r.UnlockRootWith(backend))
+	assert.NoError(t, r2.UnlockRootWith(backend))
+}
+
+// maybeGrabbedLockBackend errors as if the lock might have been grabbed
+type maybeGrabbedLockBackend struct {
+	MemoryLockBackend
+}
+
+func (b *maybeGrabbedLockBackend) GrabLock(lockPath string, uuid string) (bool, error) {
+	return true, fmt.Errorf("unknown")
+}
+
+func Test_Lock_WaitForLock(t *testing.T) {
+	defer func(base, max time.Duration) {
+		WaitForLockBaseDelay, WaitForLockMaxDelay = base, max
+	}(WaitForLockBaseDelay, WaitForLockMaxDelay)
+	WaitForLockBaseDelay, WaitForLockMaxDelay = 2*time.Millisecond, 10*time.Millisecond
+
+	r := MockRelease()
+	r.SetDefaults(r.AwsRegion, r.AwsAccountID, "")
+	r2 := MockRelease()
+	r2.UUID = to.Strp("NOTUUID")
+	backend := &MemoryLockBackend{}
+
+	// Not held
+	grabbed, err := r.WaitForLockWith(backend, time.Second)
+	assert.NoError(t, err)
+	assert.True(t, grabbed)
+	assert.NoError(t, r.UnlockRootWith(backend))
+
+	// Held until timeout
+	_, err = backend.GrabLock(*r2.RootLockPath(), *r2.UUID)
+	assert.NoError(t, err)
+	start := time.Now()
+	grabbed, err = r.WaitForLockWith(backend, 30*time.Millisecond)
+	assert.NoError(t, err)
+	assert.False(t, grabbed)
+	assert.True(t, time.Since(start) >= 30*time.Millisecond)
+	assert.Equal(t, "NOTUUID", backend.Holder(*r.RootLockPath()))
+
+	// Released while waiting
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		r2.UnlockRootWith(backend)
+	}()
+
+	grabbed, err = r.WaitForLockWith(backend, 5*time.Second)
+	assert.NoError(t, err)
+	assert.True(t, grabbed)
+	assert.Equal(t, *r.UUID, backend.Holder(*r.RootLockPath()))
+
+	// Errors that might have grabbed the lock are returned without waiting
+	grabbed, err = r.WaitForLockWith(&maybeGrabbedLockBackend{}, 5*time.Second)
+	assert.IsType(t, &errors.LockError{}, err)
+	assert.True(t, grabbed)
+}
+
+func Test_Lock_WaitForLock_S3(t *testing.T) {
+	r := MockRelease()
+	awsc := MockAwsClients(r)
+	s3c := awsc.S3Client(nil, nil, nil)
+
+	grabbed, err := r.WaitForLock(s3c, 0)
+	assert.NoError(t, err)
+	assert.True(t, grabbed)
+
+	r2 := MockRelease()
+	r2.UUID = to.Strp("NOTUUID")
+	grabbed, err = r2.WaitForLock(s3c, 0)
+	assert.NoError(t, err)
+	assert.False(t, grabbed)
+}
@@ -1,12 +1,15 @@
 package bifrost
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
 	"testing"
 	"time"
 
+	"github.com/aws/aws-sdk-go/service/s3"
 	"github.com/coinbase/step/aws/mocks"
+	"github.com/coinbase/step/errors"
 	"github.com/coinbase/step/utils/to"
 	"github.com/stretchr/testify/assert"
 )
@@ -48,6 +51,15 @@ func TestReleasePaths(t *testing.T) {
 	assert.Equal(t, "account/project/config/lock", *release.RootLockPath())
 	assert.Equal(t, "account/project/config/id/lock", *release.ReleaseLockPath())
 	assert.Equal(t, "account/project/_shared", *release.SharedProjectDir())
+	assert.Equal(t, "account/project/config/halt", *release.HaltPath())
+
+	// Methods match the standalone paths
+	assert.Equal(t, *release.ReleasePath(), ReleasePath("account", "project", "config", "id"))
+	assert.Equal(t, *release.LogPath(), LogPath("account", "project", "config", "id"))
+	assert.Equal(t, *release.RootLockPath(), RootLockPath("account", "project", "config"))
+	assert.Equal(t, *release.ReleaseLockPath(), ReleaseLockPath("account", "project", "config", "id"))
+	assert.Equal(t, *release.HaltPath(), HaltPath("account", "project", "config"))
+	assert.Equal(t, *release.SharedProjectDir(), SharedProjectDir("account", "project"))
 }
 
 func Test_Bifrost_Release_Is_Valid(t *testing.T) {
@@ -56,3 +68,163 @@ func Test_Bifrost_Release_Is_Valid(t *testing.T) {
 
 	assert.NoError(t, release.Validate(awsc.S3Client(nil, nil, nil), &Release{}))
 }
+
+func Test_Bifrost_Release_Sign(t *testing.T) {
+	release := MockRelease()
+	release.SetDefaults(release.AwsRegion, release.AwsAccountID, "")
+	awsc := MockAwsClients(release)
+
+	release.ReleaseSHA256 = "wrong"
+	release.Sign()
+	assert.Equal(t, to.SHA256Struct(release), release.ReleaseSHA256)
+	assert.NoError(t, release.ValidateReleaseSHA(awsc.S3Client(nil, nil, nil), &Release{}, nil))
+}
+
+func Test_Bifrost_Release_ValidateReleaseSHA_Version(t *testing.T) {
+	release := MockRelease()
+	awsc := mocks.MockAwsClients()
+	awsc.S3.Versioned = true
+	s3c := awsc.S3Client(nil, nil, nil)
+
+	raw, _ := json.Marshal(release)
+	_, err := s3c.PutObject(&s3.PutObjectInput{Bucket: release.Bucket, Key: release.ReleasePath(), Body: bytes.NewReader(raw)})
+	assert.NoError(t, err)
+
+	release.ReleaseVersionID = to.Strp("v1")
+	release.ReleaseSHA256 = to.SHA256Struct(release)
+	release.SetDefaults(release.AwsRegion, release.AwsAccountID, "")
+
+	assert.NoError(t, release.Validate(s3c, &Release{}))
+
+	// Overwriting the release after upload does not change the validated version
+	overwritten := *release
+	overwritten.ProjectName = to.Strp("other")
+	raw, _ = json.Marshal(overwritten)
+	_, err = s3c.PutObject(&s3.PutObjectInput{Bucket: release.Bucket, Key: release.ReleasePath(), Body: bytes.NewReader(raw)})
+	assert.NoError(t, err)
+
+	assert.NoError(t, release.ValidateReleaseSHA(s3c, &Release{}, release.ReleaseVersionID))
+	assert.Error(t, release.ValidateReleaseSHA(s3c, &Release{}, nil))
+	assert.Error(t, release.ValidateReleaseSHA(s3c, &Release{}, to.Strp("v3")))
+}
+
+func Test_Bifrost_Release_ValidateAllowed(t *testing.T) {
+	release := MockRelease()
+	assert.NoError(t, release.ValidateAllowed(map[string][]string{"project": {"other", "config"}}))
+
+	err := release.ValidateAllowed(map[string][]string{"other": {"config"}})
+	assert.Regexp(t, `Project "project" is not allowed`, err.Error())
+
+	err = release.ValidateAllowed(map[string][]string{"project": {"other"}})
+	assert.Regexp(t, `Config "config" is not allowed for project "project"`, err.Error())
+
+	assert.Error(t, release.ValidateAllowed(nil))
+}
+
+func Test_Bifrost_Release_ValidateProvenance(t *testing.T) {
+	release := MockRelease()
+	assert.NoError(t, release.ValidateProvenance(false))
+	assert.Regexp(t, "GitSHA must be defined", release.ValidateProvenance(true).Error())
+
+	release.GitSHA = to.Strp("0123456789abcdef0123456789abcdef01234567")
+	assert.NoError(t, release.ValidateProvenance(true))
+
+	release.GitSHA = to.Strp("not-a-sha")
+	assert.Regexp(t, "GitSHA must be a hex git commit SHA", release.ValidateProvenance(false).Error())
+
+	// Provenance changes the Release SHA
+	release.GitSHA = to.Strp("abcdef0")
+	sha := to.SHA256Struct(release)
+	release.BuildURL = to.Strp("https://ci/build/1")
+	assert.NotEqual(t, sha, to.SHA256Struct(release))
+}
+
+func Test_Bifrost_Release_ErrorTypes(t *testing.T) {
+	release := MockRelease()
+
+	err := release.ValidateProvenance(true)
+	assert.IsType(t, &errors.ValidationError{}, err)
+	assert.Equal(t, "GitSHA must be defined", err.Error())
+
+	assert.IsType(t, &errors.ValidationError{}, release.ValidateAllowed(nil))
+
+	release.AwsAccountID = nil
+	err = release.ValidateAttributes()
+	assert.IsType(t, &errors.ValidationError{}, err)
+	assert.Equal(t, "AwsAccountID must be defined", err.Error())
+
+	release = MockRelease()
+	s3c := &mocks.MockS3Client{}
+	release.SetDefaults(release.AwsRegion, release.AwsAccountID, "")
+	raw, _ := json.Marshal(release)
+	s3c.AddGetObject(*release.ReleasePath(), string(raw), nil)
+	release.ReleaseSHA256 = "wrong"
+
+	err = release.ValidateReleaseSHA(s3c, &Release{}, nil)
+	shaErr, ok := err.(*errors.SHAMismatchError)
+	assert.True(t, ok)
+	assert.Equal(t, "wrong", shaErr.Actual)
+	assert.Equal(t, fmt.Sprintf("Release SHA incorrect expected %v, got wrong", shaErr.Expected), err.Error())
+}
+
+func Test_Bifrost_Release_Fingerprint(t *testing.T) {
+	release := MockRelease()
+
+	// Unsigned releases are fingerprinted by their content
+	fingerprint := release.Fingerprint()
+	assert.Equal(t, FingerprintLength, len(fingerprint))
+	assert.Equal(t, fingerprint, release.Fingerprint())
+	assert.Equal(t, to.SHA256Struct(release)[:FingerprintLength], fingerprint)
+
+	release.ProjectName = to.Strp("other")
+	assert.NotEqual(t, fingerprint, release.Fingerprint())
+
+	release.ReleaseSHA256 = "0123456789abcdef0123456789abcdef"
+	assert.Equal(t, "0123456789ab", release.Fingerprint())
+
+	assert.Equal(t, "abc", FingerprintSHA("abc"))
+}
+
+func Test_Bifrost_Release_CreatedAt_Window(t *testing.T) {
+	release := MockRelease()
+	release.SetDefaults(release.AwsRegion, release.AwsAccountID, "")
+
+	release.CreatedAt = to.Timep(time.Now().Add(-11 * 24 * time.Hour))
+	assert.Error(t, release.ValidateAttributes())
+
+	release.CreatedAtMaxAge = to.Intp(12 * 24 * 60 * 60)
+	assert.NoError(t, release.ValidateAttributes())
+
+	release.CreatedAt = to.Timep(time.Now().Add(-10 * time.Minute))
+	release.CreatedAtMaxAge = to.Intp(300)
+	assert.Regexp(t, "older than 5m0s", release.ValidateAttributes().Error())
+
+	release.CreatedAt = to.Timep(time.Now().Add(5 * time.Minute))
+	release.CreatedAtMaxAge = nil
+	assert.Error(t, release.ValidateAttributes())
+
+	release.CreatedAtMaxSkew = to.Intp(600)
+	assert.NoError(t, release.ValidateAttributes())
+
+	release.CreatedAtMaxSkew = to.Intp(-1)
+	assert.Regexp(t, "must not be negative", release.ValidateAttributes().Error())
+}
+
+func Test_Bifrost_Release_ValidateAttributesAt_Boundaries(t *testing.T) {
+	now := time.Date(2019, 1, 2, 3, 4, 5, 0, time.UTC)
+	release := MockRelease()
+	release.SetDefaults(release.AwsRegion, release.AwsAccountID, "")
+	release.CreatedAtMaxAge = to.Intp(300)
+
+	release.CreatedAt = to.Timep(now.Add(-299 * time.Second))
+	assert.NoError(t, release.ValidateAttributesAt(now))
+
+	release.CreatedAt = to.Timep(now.Add(-300 * time.Second))
+	assert.Regexp(t, "older than 5m0s", release.ValidateAttributesAt(now).Error())
+
+	release.CreatedAt = to.Timep(now.Add(119 * time.Second))
+	assert.NoError(t, release.ValidateAttributesAt(now))
+
+	release.CreatedAt = to.Timep(now.Add(120 * time.Second))
+	assert.Regexp(t, "more than 2m0s in the future", release.ValidateAttributesAt(now).Error())
+}
@@ -0,0 +1,49 @@
+package bifrost
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// ReleaseURLClient fetches releases for ValidateReleaseSHAFromURL
+var ReleaseURLClient = &http.Client{Timeout: 30 * time.Second}
+
+// ValidateReleaseSHAFromURL is ValidateReleaseSHA reading the uploaded release from releaseURL, e.g. a presigned S3 GET URL,
+// so clients without GetObject on the Bucket can check the release the deployer will read
+func (r *Release) ValidateReleaseSHAFromURL(releaseURL string, cRelease interface{}) error {
+	return r.ValidateReleaseSHAFromURLWithContext(context.Background(), releaseURL, cRelease)
+}
+
+// ValidateReleaseSHAFromURLWithContext is ValidateReleaseSHAFromURL with ctx passed to the request
+func (r *Release) ValidateReleaseSHAFromURLWithContext(ctx context.Context, releaseURL string, cRelease interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, releaseURL, nil)
+	if err != nil {
+		return fmt.Errorf("Error Fetching uploaded Release with %v", err.Error())
+	}
+
+	resp, err := ReleaseURLClient.Do(req)
+	if uerr, ok := err.(*url.Error); ok {
+		// The URL is not in the error as a presigned URL is a credential
+		err = uerr.Err
+	}
+
+	if err != nil {
+		return fmt.Errorf("Error Fetching uploaded Release with %v", err.Error())
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("Error Fetching uploaded Release with status %v", resp.Status)
+	}
+
+	raw, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("Error Fetching uploaded Release with %v", err.Error())
+	}
+
+	return r.validateReleaseSHARaw(&raw, cRelease)
+}
@@ -0,0 +1,46 @@
+package bifrost
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Bifrost_Release_ValidateReleaseSHAFromURL(t *testing.T) {
+	release := MockRelease()
+	release.SetDefaults(release.AwsRegion, release.AwsAccountID, "")
+	raw, _ := json.Marshal(release)
+	release.Sign()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.URL.Query().Get("X-Amz-Signature") != "secret" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		w.Write(raw)
+	}))
+	defer server.Close()
+
+	assert.NoError(t, release.ValidateReleaseSHAFromURL(server.URL+"/release?X-Amz-Signature=secret", &Release{}))
+
+	// Expired or wrong presigned URL
+	err := release.ValidateReleaseSHAFromURL(server.URL+"/release?X-Amz-Signature=wrong", &Release{})
+	assert.Regexp(t, "status 403", err.Error())
+
+	// Release changed after upload
+	release.ProjectName = nil
+	release.Sign()
+	err = release.ValidateReleaseSHAFromURL(server.URL+"/release?X-Amz-Signature=secret", &Release{})
+	assert.Regexp(t, "Release SHA incorrect", err.Error())
+}
+
+func Test_Bifrost_Release_ValidateReleaseSHAFromURL_HidesURL(t *testing.T) {
+	release := MockRelease()
+
+	err := release.ValidateReleaseSHAFromURL("http://127.0.0.1:1/release?X-Amz-Signature=secret", &Release{})
+	assert.Error(t, err)
+	assert.NotRegexp(t, "secret", err.Error())
+}
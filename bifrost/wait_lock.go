@@ -0,0 +1,55 @@
+package bifrost
+
+import (
+	"math/rand"
+	"time"
+
+	"github.com/coinbase/step/aws"
+	"github.com/coinbase/step/errors"
+)
+
+// WaitForLock backoff, the delay between attempts doubles from WaitForLockBaseDelay up to WaitForLockMaxDelay
+// and each wait is jittered between half and all of the delay so waiting deploys do not retry together
+var (
+	WaitForLockBaseDelay = 1 * time.Second
+	WaitForLockMaxDelay  = 30 * time.Second
+)
+
+// WaitForLock is GrabRootLock retried until the root lock is grabbed or timeout elapses.
+// It returns false and no error if another release still holds the lock at timeout.
+// Like LockBackend GrabLock, an error with grabbed true means the lock might be held and should be unlocked
+func (r *Release) WaitForLock(s3c aws.S3API, timeout time.Duration) (bool, error) {
+	return r.WaitForLockWith(r.S3LockBackend(s3c), timeout)
+}
+
+// WaitForLockWith is WaitForLock with the lock stored in backend
+func (r *Release) WaitForLockWith(backend LockBackend, timeout time.Duration) (bool, error) {
+	deadline := time.Now().Add(timeout)
+	delay := WaitForLockBaseDelay
+
+	for {
+		err := r.grabLock(backend, *r.RootLockPath())
+		if err == nil {
+			return true, nil
+		}
+
+		if _, ok := err.(*errors.LockExistsError); !ok {
+			return true, err
+		}
+
+		remaining := time.Until(deadline)
+		if remaining <= 0 {
+			return false, nil
+		}
+
+		wait := delay/2 + time.Duration(rand.Int63n(int64(delay/2)+1))
+		if wait > remaining {
+			wait = remaining
+		}
+		time.Sleep(wait)
+
+		if delay *= 2; delay > WaitForLockMaxDelay {
+			delay = WaitForLockMaxDelay
+		}
+	}
+}
@@ -0,0 +1,75 @@
+package bifrost
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// WebhookSignatureHeader is the hex HMAC-SHA256 of the webhook body with the shared secret
+const WebhookSignatureHeader = "X-Signature"
+
+// WebhookClient posts webhooks for PostWebhook
+var WebhookClient = &http.Client{Timeout: 10 * time.Second}
+
+// PostWebhook POSTs the NotifyMessage for a succeeded deploy to webhookURL, see PostWebhookEvent
+func (r *Release) PostWebhook(webhookURL string, secret []byte) error {
+	return r.PostWebhookEvent(context.Background(), webhookURL, secret, NotifySucceeded)
+}
+
+// PostWebhookEvent POSTs the NotifyMessage for event to webhookURL signed with secret in the WebhookSignatureHeader.
+// Any status other than 2xx is an error
+func (r *Release) PostWebhookEvent(ctx context.Context, webhookURL string, secret []byte, event string) error {
+	if len(secret) == 0 {
+		return fmt.Errorf("Webhook Error: secret must be defined")
+	}
+
+	body, err := json.Marshal(r.NotifyMessage(event))
+	if err != nil {
+		return fmt.Errorf("Webhook Error: %v", err.Error())
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, webhookURL, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("Webhook Error: %v", err.Error())
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(WebhookSignatureHeader, WebhookSignature(body, secret))
+
+	resp, err := WebhookClient.Do(req)
+	if uerr, ok := err.(*url.Error); ok {
+		// The URL is not in the error as it can contain a token
+		err = uerr.Err
+	}
+
+	if err != nil {
+		return fmt.Errorf("Webhook Error: %v", err.Error())
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("Webhook Error: status %v", resp.Status)
+	}
+
+	return nil
+}
+
+// WebhookSignature returns the hex HMAC-SHA256 of body with secret
+func WebhookSignature(body []byte, secret []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// ValidWebhookSignature is used by receivers to check the WebhookSignatureHeader signature of body
+func ValidWebhookSignature(body []byte, signature string, secret []byte) bool {
+	return hmac.Equal([]byte(signature), []byte(WebhookSignature(body, secret)))
+}
@@ -0,0 +1,70 @@
+package bifrost
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/coinbase/step/utils/to"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Release_PostWebhook(t *testing.T) {
+	secret := []byte("secret")
+	r := &Release{
+		ProjectName: to.Strp("project"),
+		ConfigName:  to.Strp("config"),
+		ReleaseID:   to.Strp("release-1"),
+	}
+
+	var msg NotifyMessage
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		body, _ := ioutil.ReadAll(req.Body)
+		if req.Method != http.MethodPost || !ValidWebhookSignature(body, req.Header.Get(WebhookSignatureHeader), secret) {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		json.Unmarshal(body, &msg)
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+
+	assert.NoError(t, r.PostWebhook(server.URL+"/hook", secret))
+	assert.Equal(t, NotifySucceeded, msg.Event)
+	assert.Equal(t, "project", *msg.ProjectName)
+	assert.Equal(t, "release-1", *msg.ReleaseID)
+
+	err := r.PostWebhook(server.URL+"/hook", []byte("wrong"))
+	assert.Regexp(t, "status 401", err.Error())
+
+	err = r.PostWebhook(server.URL+"/hook", nil)
+	assert.Regexp(t, "secret must be defined", err.Error())
+}
+
+func Test_Release_PostWebhook_Timeout(t *testing.T) {
+	defer func(c *http.Client) { WebhookClient = c }(WebhookClient)
+	WebhookClient = &http.Client{Timeout: 10 * time.Millisecond}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		time.Sleep(100 * time.Millisecond)
+	}))
+	defer server.Close()
+
+	err := (&Release{}).PostWebhook(server.URL+"/hook?token=hidden", []byte("secret"))
+	assert.Error(t, err)
+	assert.NotRegexp(t, "hidden", err.Error())
+}
+
+func Test_ValidWebhookSignature(t *testing.T) {
+	body := []byte(`{"event":"succeeded"}`)
+	signature := WebhookSignature(body, []byte("secret"))
+
+	assert.True(t, ValidWebhookSignature(body, signature, []byte("secret")))
+	assert.False(t, ValidWebhookSignature(body, signature, []byte("other")))
+	assert.False(t, ValidWebhookSignature([]byte(`{}`), signature, []byte("secret")))
+	assert.False(t, ValidWebhookSignature(body, "not hex", []byte("secret")))
+}
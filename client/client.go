@@ -1,6 +1,7 @@
 package client
 
 import (
+	"fmt"
 	"time"
 
 	"github.com/coinbase/step/aws"
@@ -9,24 +10,52 @@ import (
 	"github.com/coinbase/step/utils/to"
 )
 
-// PrepareRelease returns a release with additional information filled in
+// PrepareRelease returns a release with additional information filled in,
+// zip_file_path is ignored for image, StepOnly and Lambdas releases
 func PrepareRelease(release *deployer.Release, zip_file_path *string) error {
 	region, account_id := to.RegionAccount()
-	release.SetDefaults(region, account_id, "coinbase-step-deployer-")
+	release.SetDefaults(region, account_id, deployer.DefaultBucketPrefix)
 
-	lambda_sha, err := to.SHA256File(*zip_file_path)
-	if err != nil {
-		return err
+	if release.DeploysLambda() && !release.IsImage() && len(release.Lambdas) == 0 {
+		lambda_sha, err := to.HashFile(release.HashAlgorithm(), *zip_file_path)
+		if err != nil {
+			return err
+		}
+		release.LambdaSHA256 = &lambda_sha
 	}
-	release.LambdaSHA256 = &lambda_sha
 
 	// Interpolate variables for resource strings
-	release.StateMachineJSON = to.InterpolateArnVariables(
-		release.StateMachineJSON,
-		release.AwsRegion,
-		release.AwsAccountID,
-		release.LambdaName,
-	)
+	if release.StateMachineJSON != nil {
+		release.StateMachineJSON = to.InterpolateArnVariables(
+			release.StateMachineJSON,
+			release.AwsRegion,
+			release.AwsAccountID,
+			release.LambdaName,
+		)
+	}
+
+	return nil
+}
+
+// PrepareReleaseLambdas is PrepareRelease for a release with Lambdas,
+// zip_file_paths maps each Lambda name to its zip file
+func PrepareReleaseLambdas(release *deployer.Release, zip_file_paths map[string]*string) error {
+	if err := PrepareRelease(release, nil); err != nil {
+		return err
+	}
+
+	for i, spec := range release.Lambdas {
+		zip_file_path, ok := zip_file_paths[to.Strs(spec.Name)]
+		if !ok || zip_file_path == nil {
+			return fmt.Errorf("No zip file for Lambda %v", to.Strs(spec.Name))
+		}
+
+		lambda_sha, err := to.HashFile(release.HashAlgorithm(), *zip_file_path)
+		if err != nil {
+			return err
+		}
+		release.Lambdas[i].SHA256 = &lambda_sha
+	}
 
 	return nil
 }
@@ -37,24 +66,74 @@ func PrepareReleaseBundle(awsc aws.AwsClients, release *deployer.Release, zip_fi
 		return err
 	}
 
-	err := s3.PutFile(
-		awsc.S3Client(nil, nil, nil),
-		zip_file_path,
-		release.Bucket,
-		release.LambdaZipPath(),
-	)
+	// Fail before uploading anything
+	if err := release.ValidateOffline(); err != nil {
+		return err
+	}
 
-	if err != nil {
+	// Images are deployed from ECR and StepOnly releases have no code so there is no zip to upload
+	if release.DeploysLambda() && !release.IsImage() {
+		err := s3.PutFile(
+			awsc.S3Client(nil, nil, nil),
+			zip_file_path,
+			release.LambdaZipBucket(),
+			release.LambdaZipPath(),
+		)
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return uploadRelease(awsc, release)
+}
+
+// PrepareReleaseBundleLambdas is PrepareReleaseBundle for a release with Lambdas,
+// zip_file_paths maps each Lambda name to its zip file
+func PrepareReleaseBundleLambdas(awsc aws.AwsClients, release *deployer.Release, zip_file_paths map[string]*string) error {
+	if len(release.Lambdas) == 0 {
+		return fmt.Errorf("Lambdas must be defined")
+	}
+
+	if err := PrepareReleaseLambdas(release, zip_file_paths); err != nil {
+		return err
+	}
+
+	// Fail before uploading anything
+	if err := release.ValidateOffline(); err != nil {
 		return err
 	}
 
+	for _, spec := range release.Lambdas {
+		err := s3.PutFile(
+			awsc.S3Client(nil, nil, nil),
+			zip_file_paths[*spec.Name],
+			release.LambdaZipBucket(),
+			release.ForLambda(spec).LambdaZipPath(),
+		)
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return uploadRelease(awsc, release)
+}
+
+// uploadRelease uploads the release so the deployer can match its SHA
+func uploadRelease(awsc aws.AwsClients, release *deployer.Release) error {
 	// reset CreateAt because it can take a while to upload the lambda
 	release.CreatedAt = to.Timep(time.Now())
 
 	// Uploading the Release to S3 to match SHAs
-	if err := s3.PutStruct(awsc.S3Client(nil, nil, nil), release.Bucket, release.ReleasePath(), release); err != nil {
+	_, versionId, err := s3.PutStructWithHashVersion(awsc.S3Client(nil, nil, nil), release.Bucket, release.ReleasePath(), release, release.HashAlgorithm())
+	if err != nil {
 		return err
 	}
 
+	// Pin the deploy to the uploaded version, the deployer hashes the release with it
+	release.ReleaseVersionID = versionId
+	release.Sign()
+
 	return nil
 }
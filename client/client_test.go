@@ -1,6 +1,7 @@
 package client
 
 import (
+	"strings"
 	"testing"
 	"time"
 
@@ -37,3 +38,106 @@ func Test_Client_PrepareReleaseBundle(t *testing.T) {
 
 	assert.NoError(t, err)
 }
+
+func Test_Client_PrepareRelease_DefaultBucketPrefix(t *testing.T) {
+	t.Setenv("AWS_REGION", "us-east-1")
+	t.Setenv("AWS_ACCOUNT_ID", "000000000000")
+
+	release := &deployer.Release{StepOnly: true}
+	assert.NoError(t, PrepareRelease(release, nil))
+	assert.Equal(t, "coinbase-step-deployer-000000000000", *release.Bucket)
+
+	defer func(prefix string) { deployer.DefaultBucketPrefix = prefix }(deployer.DefaultBucketPrefix)
+	deployer.DefaultBucketPrefix = "acme-deployer-"
+
+	release = &deployer.Release{StepOnly: true}
+	assert.NoError(t, PrepareRelease(release, nil))
+	assert.Equal(t, "acme-deployer-000000000000", *release.Bucket)
+}
+
+func Test_Client_PrepareReleaseBundle_ValidatesOffline(t *testing.T) {
+	awsc := mocks.MockAwsClients()
+	release := &deployer.Release{
+		Release: bifrost.Release{
+			AwsRegion:    to.Strp("project"),
+			AwsAccountID: to.Strp("project"),
+			ReleaseID:    to.TimeUUID("release-"),
+			CreatedAt:    to.Timep(time.Now()),
+			ProjectName:  to.Strp("project"),
+			ConfigName:   to.Strp("project"),
+			Bucket:       to.Strp("project"),
+		},
+		LambdaName:       to.Strp("project"),
+		StepFnName:       to.Strp("project"),
+		StateMachineJSON: to.Strp(`{"StartAt": "Missing", "States": {}}`),
+	}
+
+	err := PrepareReleaseBundle(
+		awsc,
+		release,
+		to.Strp("../resources/empty_lambda.zip"),
+	)
+
+	assert.Error(t, err)
+	assert.Regexp(t, "StateMachineJSON invalid", err.Error())
+
+	// Nothing uploaded
+	assert.Equal(t, 0, len(awsc.S3.GetObjectResp))
+}
+
+func Test_Client_PrepareReleaseBundle_Image(t *testing.T) {
+	awsc := mocks.MockAwsClients()
+	release := &deployer.Release{
+		Release: bifrost.Release{
+			AwsRegion:    to.Strp("us-east-1"),
+			AwsAccountID: to.Strp("000000000000"),
+			ReleaseID:    to.TimeUUID("release-"),
+			CreatedAt:    to.Timep(time.Now()),
+			ProjectName:  to.Strp("project"),
+			ConfigName:   to.Strp("project"),
+			Bucket:       to.Strp("project"),
+		},
+		LambdaName:       to.Strp("project"),
+		StepFnName:       to.Strp("project"),
+		StateMachineJSON: to.Strp(machine.EmptyStateMachine),
+		ImageUri:         to.Strp("000000000000.dkr.ecr.us-east-1.amazonaws.com/project@sha256:" + strings.Repeat("0", 64)),
+	}
+
+	assert.NoError(t, PrepareReleaseBundle(awsc, release, nil))
+	assert.Nil(t, release.LambdaSHA256)
+
+	// Only the release is uploaded
+	assert.Equal(t, 1, len(awsc.S3.GetObjectResp))
+	assert.NotNil(t, awsc.S3.GetObjectResp[*release.ReleasePath()])
+}
+
+func Test_Client_PrepareReleaseBundleLambdas(t *testing.T) {
+	awsc := mocks.MockAwsClients()
+	release := &deployer.Release{
+		Release: bifrost.Release{
+			AwsRegion:    to.Strp("project"),
+			AwsAccountID: to.Strp("project"),
+			ReleaseID:    to.TimeUUID("release-"),
+			CreatedAt:    to.Timep(time.Now()),
+			ProjectName:  to.Strp("project"),
+			ConfigName:   to.Strp("project"),
+			Bucket:       to.Strp("project"),
+		},
+		Lambdas:          []deployer.LambdaSpec{{Name: to.Strp("api")}, {Name: to.Strp("worker")}},
+		StepFnName:       to.Strp("project"),
+		StateMachineJSON: to.Strp(machine.EmptyStateMachine),
+	}
+
+	zip := to.Strp("../resources/empty_lambda.zip")
+
+	err := PrepareReleaseBundleLambdas(awsc, release, map[string]*string{"api": zip})
+	assert.Regexp(t, "No zip file for Lambda worker", err.Error())
+
+	assert.NoError(t, PrepareReleaseBundleLambdas(awsc, release, map[string]*string{"api": zip, "worker": zip}))
+	assert.NotNil(t, release.Lambdas[0].SHA256)
+	assert.NotNil(t, release.Lambdas[1].SHA256)
+
+	// Both zips and the release are uploaded
+	assert.Equal(t, 3, len(awsc.S3.GetObjectResp))
+	assert.NotNil(t, awsc.S3.GetObjectResp[*release.ForLambda(release.Lambdas[1]).LambdaZipPath()])
+}
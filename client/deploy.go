@@ -21,6 +21,7 @@ func Deploy(release *deployer.Release, zip_file_path *string, deployer_arn *stri
 		return err
 	}
 
+	fmt.Printf("Release %v (%v)\n", to.Strs(release.ReleaseID), release.Fingerprint())
 	fmt.Println("Preparing Deploy")
 	fmt.Println(to.PrettyJSONStr(release))
 	err = sendDeployToDeployer(awsc.SFNClient(nil, nil, nil), release.ReleaseID, release, deployer_arn)
@@ -0,0 +1,33 @@
+package deployer
+
+import (
+	"fmt"
+
+	"github.com/aws/aws-sdk-go/service/sts"
+	"github.com/coinbase/step/aws"
+	"github.com/coinbase/step/utils/is"
+	"github.com/coinbase/step/utils/to"
+)
+
+// ValidateDeployerAccount checks the credentials of stsc are in AwsAccountID,
+// so a deployer running in the wrong account fails before it calls Lambda or Step Functions
+func (release *Release) ValidateDeployerAccount(stsc aws.STSAPI) error {
+	if is.EmptyStr(release.AwsAccountID) {
+		return fmt.Errorf("AwsAccountID must be defined")
+	}
+
+	out, err := stsc.GetCallerIdentity(&sts.GetCallerIdentityInput{})
+	if err != nil {
+		return err
+	}
+
+	if out == nil || out.Account == nil {
+		return fmt.Errorf("Unknown STS GetCallerIdentity Error")
+	}
+
+	if *out.Account != *release.AwsAccountID {
+		return fmt.Errorf("Deployer is running in account %v as %v but release targets %v", *out.Account, to.Strs(out.Arn), *release.AwsAccountID)
+	}
+
+	return nil
+}
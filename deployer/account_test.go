@@ -0,0 +1,56 @@
+package deployer
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/service/sts"
+	"github.com/coinbase/step/aws/mocks"
+	"github.com/coinbase/step/utils/to"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Release_ValidateDeployerAccount(t *testing.T) {
+	release := MockRelease()
+	stsc := &mocks.MockSTSClient{}
+
+	stsc.GetCallerIdentityResp = &sts.GetCallerIdentityOutput{Account: release.AwsAccountID}
+	assert.NoError(t, release.ValidateDeployerAccount(stsc))
+
+	stsc.GetCallerIdentityResp = &sts.GetCallerIdentityOutput{
+		Account: to.Strp("111111111111"),
+		Arn:     to.Strp("arn:aws:sts::111111111111:assumed-role/other/session"),
+	}
+	assert.Equal(t,
+		"Deployer is running in account 111111111111 as arn:aws:sts::111111111111:assumed-role/other/session but release targets 00000000",
+		release.ValidateDeployerAccount(stsc).Error(),
+	)
+
+	stsc.GetCallerIdentityError = fmt.Errorf("ExpiredToken")
+	assert.Regexp(t, "ExpiredToken", release.ValidateDeployerAccount(stsc).Error())
+
+	release.AwsAccountID = nil
+	assert.Regexp(t, "AwsAccountID must be defined", release.ValidateDeployerAccount(stsc).Error())
+}
+
+func Test_DeployHandler_Execution_Errors_WrongDeployerAccount(t *testing.T) {
+	release := MockRelease()
+	awsc := MockAwsClients(release)
+	awsc.STS.GetCallerIdentityResp = &sts.GetCallerIdentityOutput{Account: to.Strp("111111111111")}
+
+	state_machine := createTestStateMachine(t, awsc)
+
+	exec, err := state_machine.Execute(release)
+	assert.Error(t, err)
+	assert.Regexp(t, "BadReleaseError", exec.LastOutputJSON)
+	assert.Regexp(t, "running in account 111111111111", exec.LastOutputJSON)
+	assertNoRootLockWithReleseLock(t, awsc, release)
+
+	assert.Equal(t, []string{
+		"Validate",
+		"Lock",
+		"ValidateResources",
+		"ReleaseLockFailure",
+		"FailureClean",
+	}, exec.Path())
+}
@@ -0,0 +1,67 @@
+package deployer
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/coinbase/step/aws"
+	"github.com/coinbase/step/utils/is"
+)
+
+var lambdaAliasRegexp = regexp.MustCompile(`^[a-zA-Z0-9_-]{1,128}$`)
+var lambdaVersionRegexp = regexp.MustCompile(`^[0-9]+$`)
+
+// LambdaQualifiedArn is LambdaArn with the LambdaQualifier alias appended, as the State Machine invokes it.
+// LambdaArn stays the unqualified function as code, configuration and tags belong to the function
+func (release *Release) LambdaQualifiedArn() *string {
+	if is.EmptyStr(release.LambdaQualifier) {
+		return release.LambdaArn()
+	}
+
+	s := fmt.Sprintf("%v:%v", *release.LambdaArn(), *release.LambdaQualifier)
+	return &s
+}
+
+// validateLambdaQualifier checks LambdaQualifier is an alias name, versions cannot be moved
+func (release *Release) validateLambdaQualifier() error {
+	if release.LambdaQualifier == nil {
+		return nil
+	}
+
+	q := *release.LambdaQualifier
+	if !lambdaAliasRegexp.MatchString(q) || lambdaVersionRegexp.MatchString(q) || q == "$LATEST" {
+		return fmt.Errorf("LambdaQualifier must be an alias name, got %q", q)
+	}
+
+	return nil
+}
+
+// DeployLambdaAlias publishes a version of the deployed Lambda and moves the LambdaQualifier alias to it,
+// it does nothing without a LambdaQualifier
+func (release *Release) DeployLambdaAlias(ctx context.Context, lambdaClient aws.LambdaAPI) error {
+	if is.EmptyStr(release.LambdaQualifier) {
+		return nil
+	}
+
+	return release.deployPhase(ctx, DeployPhaseLambdaAlias, func() error {
+		// A version cannot be published while the function is updating
+		if err := release.WaitForFunctionUpdated(ctx, lambdaClient); err != nil {
+			return err
+		}
+
+		_, err := release.PublishVersionAndAlias(lambdaClient, *release.LambdaQualifier)
+		return err
+	})
+}
+
+// lambdaFunctionArn returns a Lambda ARN without its version or alias qualifier
+func lambdaFunctionArn(arn string) string {
+	// arn:partition:lambda:region:account:function:name[:qualifier]
+	parts := strings.SplitN(arn, ":", 8)
+	if len(parts) == 8 {
+		return strings.Join(parts[:7], ":")
+	}
+	return arn
+}
@@ -0,0 +1,102 @@
+package deployer
+
+import (
+	"testing"
+
+	"github.com/coinbase/step/aws/mocks"
+	"github.com/coinbase/step/utils/to"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Release_LambdaQualifiedArn(t *testing.T) {
+	release := MockRelease()
+	release.AwsRegion = to.Strp("us-east-1")
+	assert.Equal(t, "arn:aws:lambda:us-east-1:00000000:function:lambdaname", *release.LambdaQualifiedArn())
+
+	release.LambdaQualifier = to.Strp("PROD")
+	assert.Equal(t, "arn:aws:lambda:us-east-1:00000000:function:lambdaname:PROD", *release.LambdaQualifiedArn())
+
+	// The function itself is deployed and tagged
+	assert.Equal(t, "arn:aws:lambda:us-east-1:00000000:function:lambdaname", *release.LambdaArn())
+
+	assert.Equal(t, *release.LambdaArn(), lambdaFunctionArn(*release.LambdaQualifiedArn()))
+	assert.Equal(t, *release.LambdaArn(), lambdaFunctionArn(*release.LambdaArn()))
+}
+
+func Test_Release_LambdaQualifier_Validate(t *testing.T) {
+	release := MockRelease()
+	MockAwsClients(release)
+	release.SetDefaults(to.Strp("region"), to.Strp("account"), "")
+
+	for _, q := range []string{"PROD", "live-1", "blue_green"} {
+		release.LambdaQualifier = to.Strp(q)
+		assert.NoError(t, release.validateAttributes(), q)
+	}
+
+	for _, q := range []string{"", "1", "$LATEST", "has:colon"} {
+		release.LambdaQualifier = to.Strp(q)
+		assert.Regexp(t, "LambdaQualifier must be an alias name", release.validateAttributes().Error(), q)
+	}
+
+	release.LambdaQualifier = to.Strp("PROD")
+	release.StepOnly = true
+	release.LambdaSHA256 = nil
+	assert.Regexp(t, "StepOnly releases cannot define Lambda settings", release.validateAttributes().Error())
+}
+
+func Test_Release_DeployLambda_Qualifier(t *testing.T) {
+	release := MockRelease()
+	awsc := MockAwsClients(release)
+	release.Bucket = to.Strp("bucket")
+
+	assert.NoError(t, release.DeployLambda(awsc.Lambda, awsc.S3, nil, nil))
+	assert.Equal(t, 0, len(awsc.Lambda.Aliases))
+
+	release.LambdaQualifier = to.Strp("PROD")
+	assert.NoError(t, release.DeployLambda(awsc.Lambda, awsc.S3, nil, nil))
+	assert.Equal(t, "1", *awsc.Lambda.Aliases["PROD"])
+
+	// Dry runs do not publish
+	awsc.Lambda.Aliases = nil
+	release.DryRun = true
+	assert.NoError(t, release.DeployLambda(awsc.Lambda, awsc.S3, nil, nil))
+	assert.Equal(t, 0, len(awsc.Lambda.Aliases))
+}
+
+func Test_Release_ValidateTaskResources_Qualified(t *testing.T) {
+	release := MockRelease()
+	release.AwsRegion = to.Strp("us-east-1")
+	release.LambdaQualifier = to.Strp("PROD")
+	release.StateMachineJSON = to.Strp(`{
+		"StartAt": "A",
+		"States": {
+			"A": {"Type": "Task", "Resource": "arn:aws:lambda:us-east-1:00000000:function:lambdaname:PROD", "Next": "B"},
+			"B": {"Type": "Task", "Resource": "arn:aws:lambda:us-east-1:00000000:function:other:PROD", "End": true}
+		}
+	}`)
+
+	// The alias of the release's Lambda is created by the deploy so the function is checked
+	lambdac := &mocks.MockLambdaClient{MissingFunctions: map[string]bool{
+		"arn:aws:lambda:us-east-1:00000000:function:lambdaname:PROD": true,
+	}}
+	assert.NoError(t, release.ValidateTaskResources(lambdac))
+
+	lambdac.MissingFunctions["arn:aws:lambda:us-east-1:00000000:function:lambdaname"] = true
+	assert.Regexp(t, "function:lambdaname:PROD", release.ValidateTaskResources(lambdac).Error())
+
+	// Other functions must have the alias
+	lambdac.MissingFunctions = map[string]bool{"arn:aws:lambda:us-east-1:00000000:function:other:PROD": true}
+	assert.Regexp(t, "function:other:PROD", release.ValidateTaskResources(lambdac).Error())
+}
+
+func Test_DeployHandler_Execution_LambdaQualifier(t *testing.T) {
+	release := MockRelease()
+	release.LambdaQualifier = to.Strp("PROD")
+	awsc := MockAwsClients(release)
+
+	state_machine := createTestStateMachine(t, awsc)
+
+	_, err := state_machine.Execute(release)
+	assert.NoError(t, err)
+	assert.Equal(t, "1", *awsc.Lambda.Aliases["PROD"])
+}
@@ -0,0 +1,27 @@
+package deployer
+
+import (
+	"fmt"
+
+	"github.com/coinbase/step/utils/is"
+)
+
+// ValidateApproval checks a release with ApprovalRequired is Approved and says by whom.
+// The approval is part of ReleaseSHA256 so it is given before the release is uploaded
+func (release *Release) ValidateApproval() error {
+	approved := release.Approved != nil && *release.Approved
+
+	if approved && is.EmptyStr(release.ApprovedBy) {
+		return fmt.Errorf("ApprovedBy must be defined when Approved")
+	}
+
+	if !release.ApprovalRequired {
+		return nil
+	}
+
+	if !approved {
+		return fmt.Errorf("Release requires approval and is pending approval")
+	}
+
+	return nil
+}
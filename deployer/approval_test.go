@@ -0,0 +1,69 @@
+package deployer
+
+import (
+	"testing"
+	"time"
+
+	"github.com/coinbase/step/utils/to"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Release_ValidateApproval(t *testing.T) {
+	release := MockRelease()
+	assert.NoError(t, release.ValidateApproval())
+
+	release.ApprovalRequired = true
+	assert.Regexp(t, "pending approval", release.ValidateApproval().Error())
+
+	release.Approved = to.Boolp(false)
+	assert.Regexp(t, "pending approval", release.ValidateApproval().Error())
+
+	release.Approved = to.Boolp(true)
+	assert.Regexp(t, "ApprovedBy must be defined", release.ValidateApproval().Error())
+
+	release.ApprovedBy = to.Strp("alice")
+	release.ApprovedAt = to.Timep(time.Now())
+	assert.NoError(t, release.ValidateApproval())
+}
+
+func Test_Release_Approval_Deploy(t *testing.T) {
+	release := MockRelease()
+	release.ApprovalRequired = true
+	awsc := MockAwsClients(release)
+
+	assert.Regexp(t, "pending approval", release.DeployStepFunction(awsc.SFN).Error())
+	assert.Regexp(t, "pending approval", release.DeployLambda(awsc.Lambda, awsc.S3, nil, nil).Error())
+
+	// Dry runs do not change AWS so are allowed
+	release.DryRun = true
+	assert.NoError(t, release.DeployStepFunction(awsc.SFN))
+}
+
+func Test_Release_Approval_SHA(t *testing.T) {
+	release := MockRelease()
+	release.ApprovalRequired = true
+	sha := to.SHA256Struct(release)
+
+	// Approving after upload changes the SHA so cannot be forged
+	release.Approved = to.Boolp(true)
+	release.ApprovedBy = to.Strp("alice")
+	assert.NotEqual(t, sha, to.SHA256Struct(release))
+}
+
+func Test_DeployHandler_Execution_Errors_PendingApproval(t *testing.T) {
+	release := MockRelease()
+	release.ApprovalRequired = true
+	awsc := MockAwsClients(release)
+
+	state_machine := createTestStateMachine(t, awsc)
+
+	exec, err := state_machine.Execute(release)
+	assert.Error(t, err)
+	assert.Regexp(t, "BadReleaseError", exec.LastOutputJSON)
+	assert.Regexp(t, "pending approval", exec.LastOutputJSON)
+
+	assert.Equal(t, []string{
+		"Validate",
+		"FailureClean",
+	}, exec.Path())
+}
@@ -0,0 +1,99 @@
+package deployer
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/coinbase/step/aws"
+	"github.com/coinbase/step/aws/s3"
+	"github.com/coinbase/step/bifrost"
+	"github.com/coinbase/step/utils/to"
+)
+
+// AuditRecord is the immutable record written for every deploy
+type AuditRecord struct {
+	ReleaseID     *string      `json:"release_id,omitempty"`
+	UUID          *string      `json:"uuid,omitempty"`
+	CreatedAt     *time.Time   `json:"created_at,omitempty"`
+	LambdaSHA256  *string      `json:"lambda_sha256,omitempty"`
+	Lambdas       []LambdaSpec `json:"lambdas,omitempty"`
+	ImageUri      *string      `json:"image_uri,omitempty"`
+	GitSHA        *string      `json:"git_sha,omitempty"`
+	BuildURL      *string      `json:"build_url,omitempty"`
+	Builder       *string      `json:"builder,omitempty"`
+	ReleaseSHA256 string       `json:"release_sha256"`
+	Fingerprint   string       `json:"fingerprint,omitempty"` // Short ReleaseSHA256 shown in logs
+	HashAlgo      string       `json:"hash_algo"`
+	DeployedBy    *string      `json:"deployed_by,omitempty"` // Role the deployer assumed to deploy
+	DryRun        bool         `json:"dry_run,omitempty"`
+	Success       bool         `json:"success"`
+	RecordedAt    *time.Time   `json:"recorded_at"`
+
+	ValidationMillis   *int64 `json:"validation_millis,omitempty"`
+	StepDeployMillis   *int64 `json:"step_deploy_millis,omitempty"`
+	LambdaDeployMillis *int64 `json:"lambda_deploy_millis,omitempty"`
+}
+
+// AuditDir is the prefix all audit records for the project config are written under
+func (release *Release) AuditDir() *string {
+	s := fmt.Sprintf("%v/audit", *release.RootDir())
+	return &s
+}
+
+// AuditRecordPath is a new key per call so records are never overwritten,
+// the timestamp prefix keeps the records ordered
+func (release *Release) AuditRecordPath(at time.Time) *string {
+	s := fmt.Sprintf("%v/%v-%v.json", *release.AuditDir(), at.UTC().Format("20060102T150405.000000000Z"), to.Strs(release.UUID))
+	return &s
+}
+
+// AuditRecord returns the audit record for the release in its current state
+func (release *Release) AuditRecord() *AuditRecord {
+	return &AuditRecord{
+		ReleaseID:     release.ReleaseID,
+		UUID:          release.UUID,
+		CreatedAt:     release.CreatedAt,
+		LambdaSHA256:  release.LambdaSHA256,
+		Lambdas:       release.Lambdas,
+		ImageUri:      release.ImageUri,
+		GitSHA:        release.GitSHA,
+		BuildURL:      release.BuildURL,
+		Builder:       release.Builder,
+		ReleaseSHA256: release.ReleaseSHA256,
+		Fingerprint:   fingerprint(release.ReleaseSHA256),
+		HashAlgo:      release.HashAlgorithm(),
+		DeployedBy:    to.RoleArn(release.Partition, release.AwsAccountID, assumed_role),
+		DryRun:        release.DryRun,
+		Success:       release.Success != nil && *release.Success,
+		RecordedAt:    to.Timep(time.Now()),
+
+		ValidationMillis:   release.ValidationMillis,
+		StepDeployMillis:   release.StepDeployMillis,
+		LambdaDeployMillis: release.LambdaDeployMillis,
+	}
+}
+
+// WriteAuditRecord writes the audit record as a new object under AuditDir.
+// S3 has no append, retention is expected to be enforced with S3 object-lock on the bucket
+func (release *Release) WriteAuditRecord(s3c aws.S3API) error {
+	record := release.AuditRecord()
+
+	// ReleaseSHA256 is not passed between states, so hash the uploaded release like Validate does
+	if record.ReleaseSHA256 == "" {
+		var uploaded Release
+		if err := s3.GetStruct(s3c, release.Bucket, release.ReleasePath(), &uploaded); err == nil {
+			record.ReleaseSHA256 = to.HashStruct(uploaded.HashAlgorithm(), &uploaded)
+			record.Fingerprint = fingerprint(record.ReleaseSHA256)
+		}
+	}
+
+	return s3.PutStruct(s3c, release.Bucket, release.AuditRecordPath(*record.RecordedAt), record)
+}
+
+// fingerprint is the Fingerprint of sha, empty if the SHA is unknown
+func fingerprint(sha string) string {
+	if sha == "" {
+		return ""
+	}
+	return bifrost.FingerprintSHA(sha)
+}
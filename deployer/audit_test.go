@@ -0,0 +1,84 @@
+package deployer
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/coinbase/step/aws/mocks"
+	"github.com/coinbase/step/aws/s3"
+	"github.com/coinbase/step/utils/to"
+	"github.com/stretchr/testify/assert"
+)
+
+func auditRecords(t *testing.T, awsc *mocks.MockClients, release *Release) []*AuditRecord {
+	keys, err := s3.List(awsc.S3, release.Bucket, release.AuditDir())
+	assert.NoError(t, err)
+
+	records := []*AuditRecord{}
+	for _, key := range keys {
+		var record AuditRecord
+		raw, _ := s3.Get(awsc.S3, release.Bucket, &key)
+		assert.NoError(t, json.Unmarshal(*raw, &record))
+		records = append(records, &record)
+	}
+	return records
+}
+
+func Test_Release_WriteAuditRecord(t *testing.T) {
+	release := MockRelease()
+	awsc := MockAwsClients(release)
+	release.Bucket = to.Strp("bucket")
+	release.Success = to.Boolp(true)
+	release.UUID = to.Strp("uuid")
+
+	assert.NoError(t, release.WriteAuditRecord(awsc.S3))
+	assert.NoError(t, release.WriteAuditRecord(awsc.S3))
+
+	// Never overwritten
+	records := auditRecords(t, awsc, release)
+	assert.Equal(t, 2, len(records))
+	assert.Equal(t, *release.UUID, *records[0].UUID)
+	assert.Equal(t, *release.LambdaSHA256, *records[0].LambdaSHA256)
+	assert.Equal(t, "arn:aws:iam::00000000:role/coinbase-step-deployer-assumed", *records[0].DeployedBy)
+	assert.True(t, records[0].Success)
+
+	assert.Regexp(t, "/audit/20170102T150405.000000000Z-", *release.AuditRecordPath(time.Date(2017, 1, 2, 15, 4, 5, 0, time.UTC)))
+}
+
+func Test_DeployHandler_Execution_WritesAuditRecord(t *testing.T) {
+	release := MockRelease()
+	awsc := MockAwsClients(release)
+
+	// The client shows the same Fingerprint for the release it uploaded
+	uploaded := *release
+	uploaded.Sign()
+
+	state_machine := createTestStateMachine(t, awsc)
+
+	_, err := state_machine.Execute(release)
+	assert.NoError(t, err)
+
+	records := auditRecords(t, awsc, release)
+	assert.Equal(t, 1, len(records))
+	assert.True(t, records[0].Success)
+	assert.NotEqual(t, "", records[0].ReleaseSHA256)
+	assert.Equal(t, records[0].ReleaseSHA256[:12], records[0].Fingerprint)
+	assert.Equal(t, uploaded.Fingerprint(), records[0].Fingerprint)
+	assert.NotNil(t, records[0].ValidationMillis)
+	assert.NotNil(t, records[0].StepDeployMillis)
+	assert.NotNil(t, records[0].LambdaDeployMillis)
+
+	release = MockRelease()
+	awsc = MockAwsClients(release)
+	awsc.SFN.UpdateStateMachineError = fmt.Errorf("AWSSFNError")
+	state_machine = createTestStateMachine(t, awsc)
+
+	_, err = state_machine.Execute(release)
+	assert.Error(t, err)
+
+	records = auditRecords(t, awsc, release)
+	assert.Equal(t, 1, len(records))
+	assert.False(t, records[0].Success)
+}
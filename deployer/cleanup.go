@@ -0,0 +1,167 @@
+package deployer
+
+import (
+	"fmt"
+
+	"github.com/coinbase/step/aws"
+	"github.com/coinbase/step/aws/s3"
+	"github.com/coinbase/step/utils/is"
+	"github.com/coinbase/step/utils/to"
+)
+
+// CleanupOldReleases deletes the releases of the project and config except the newest keep by CreatedAt,
+// the release live on the Lambda, the release it would roll back to and the release holding the root lock.
+// Releases that cannot be read are never deleted. Expired nonces are deleted with CleanupExpiredNonces.
+// The release JSON, lambda.zip and everything else in a release directory is deleted
+func (release *Release) CleanupOldReleases(s3c aws.S3API, lambdac aws.LambdaAPI, keep int) error {
+	if keep < 0 {
+		return fmt.Errorf("keep must not be negative")
+	}
+
+	live, err := release.liveReleaseIDs(s3c, lambdac)
+	if err != nil {
+		return err
+	}
+
+	holder, _, err := release.LockHolder(s3c)
+	if err != nil {
+		return err
+	}
+
+	releases, _, err := release.ListReleases(s3c, -1)
+	if err != nil {
+		return err
+	}
+
+	failures := []string{}
+
+	for i, r := range releases {
+		if i < keep || is.EmptyStr(r.ReleaseID) || live[*r.ReleaseID] {
+			continue
+		}
+
+		deploying, err := release.deploying(s3c, r, holder)
+		if err != nil {
+			failures = append(failures, fmt.Sprintf("%v: %v", *r.ReleaseID, err.Error()))
+			continue
+		}
+
+		if deploying {
+			continue
+		}
+
+		if err := release.deleteRelease(s3c, r); err != nil {
+			failures = append(failures, fmt.Sprintf("%v: %v", *r.ReleaseID, err.Error()))
+		}
+	}
+
+	if err := release.CleanupExpiredNonces(s3c); err != nil {
+		failures = append(failures, fmt.Sprintf("nonces: %v", err.Error()))
+	}
+
+	if len(failures) != 0 {
+		return fmt.Errorf("Cleanup failed for releases %q", failures)
+	}
+
+	return nil
+}
+
+// liveReleaseIDs returns the ReleaseID tagged on the Lambda and its previous release, a rollback deploys its lambda.zip.
+// The deployed release record is only used for a Lambda deployed before tagging was added
+func (release *Release) liveReleaseIDs(s3c aws.S3API, lambdac aws.LambdaAPI) (map[string]bool, error) {
+	live := map[string]bool{}
+
+	deployedID, err := release.CurrentDeployedReleaseId(lambdac)
+	if err != nil {
+		return nil, err
+	}
+
+	if deployedID == nil {
+		var deployed Release
+		err := s3.GetStruct(s3c, release.Bucket, release.DeployedReleasePath(), &deployed)
+		if err != nil {
+			switch err.(type) {
+			case *s3.NotFoundError:
+				// Nothing deployed yet
+				return live, nil
+			default:
+				return nil, err
+			}
+		}
+
+		if is.EmptyStr(deployed.ReleaseID) {
+			return nil, fmt.Errorf("Cleanup Error: deployed release has no ReleaseID")
+		}
+		deployedID = deployed.ReleaseID
+	}
+	live[*deployedID] = true
+
+	var previous Release
+	err = s3.GetStruct(s3c, release.Bucket, release.previousReleasePathOf(*deployedID), &previous)
+	if err != nil {
+		switch err.(type) {
+		case *s3.NotFoundError:
+			// Nothing to roll back to
+			return live, nil
+		default:
+			return nil, err
+		}
+	}
+
+	if !is.EmptyStr(previous.ReleaseID) {
+		live[*previous.ReleaseID] = true
+	}
+
+	return live, nil
+}
+
+// deploying returns true if r might be the release holding the root lock with UUID holder.
+// The holder grabs its release lock after the root lock, so a release without one might be the holder
+func (release *Release) deploying(s3c aws.S3API, r *Release, holder *string) (bool, error) {
+	if holder == nil {
+		return false, nil
+	}
+
+	lock, err := s3.GetLock(s3c, release.Bucket, r.ReleaseLockPath())
+	if err != nil {
+		return false, err
+	}
+
+	return lock == nil || lock.UUID == *holder, nil
+}
+
+// previousReleasePathOf is the PreviousReleasePath of the release releaseID in this project and config
+func (release *Release) previousReleasePathOf(releaseID string) *string {
+	return to.Strp(PreviousReleasePath(*release.AwsAccountID, *release.ProjectName, *release.ConfigName, releaseID))
+}
+
+// deleteRelease deletes every object in the release directory of r, and its zips in a separate ArtifactBucket
+func (release *Release) deleteRelease(s3c aws.S3API, r *Release) error {
+	dir := fmt.Sprintf("%v/%v/", *release.RootDir(), *r.ReleaseID)
+
+	keys, err := s3.List(s3c, release.Bucket, &dir)
+	if err != nil {
+		return err
+	}
+
+	for _, key := range keys {
+		if err := s3.Delete(s3c, release.Bucket, to.Strp(key)); err != nil {
+			return err
+		}
+	}
+
+	if is.EmptyStr(r.ArtifactBucket) || *r.ArtifactBucket == to.Strs(release.Bucket) {
+		return nil
+	}
+
+	for _, spec := range r.LambdaSpecs() {
+		zip := r.ForLambda(spec)
+		for _, key := range []*string{zip.LambdaZipPath(), zip.LambdaSignaturePath()} {
+			if err := s3.Delete(s3c, r.ArtifactBucket, key); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
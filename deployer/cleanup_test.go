@@ -0,0 +1,152 @@
+package deployer
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/service/lambda"
+	"github.com/coinbase/step/aws/mocks"
+	"github.com/coinbase/step/aws/s3"
+	"github.com/coinbase/step/utils/to"
+	"github.com/stretchr/testify/assert"
+)
+
+func mockReleaseHistory(s3c *mocks.MockS3Client, count int) []*Release {
+	releases := []*Release{}
+	for i := 1; i <= count; i++ {
+		r := MockRelease()
+		r.ReleaseID = to.Strp(fmt.Sprintf("release-%v", i))
+		r.CreatedAt = to.Timep(time.Now().Add(time.Duration(i) * time.Minute))
+		raw, _ := json.Marshal(r)
+		s3c.AddGetObject(*r.ReleasePath(), string(raw), nil)
+		s3c.AddGetObject(*r.LambdaZipPath(), "zip", nil)
+		releases = append(releases, r)
+	}
+	return releases
+}
+
+// mockLiveLambda returns a Lambda tagged as running releaseID
+func mockLiveLambda(releaseID string) *mocks.MockLambdaClient {
+	return &mocks.MockLambdaClient{
+		ListTagsResp: &lambda.ListTagsOutput{Tags: map[string]*string{ReleaseIdTag: to.Strp(releaseID)}},
+	}
+}
+
+func Test_Release_CleanupOldReleases(t *testing.T) {
+	s3c := &mocks.MockS3Client{}
+	release := MockRelease()
+	releases := mockReleaseHistory(s3c, 5)
+
+	// release-2 is live on the Lambda and would roll back to release-1,
+	// the deployed release record of release-3 is stale
+	lambdac := mockLiveLambda("release-2")
+	raw, _ := json.Marshal(releases[2])
+	s3c.AddGetObject(*release.DeployedReleasePath(), string(raw), nil)
+	raw, _ = json.Marshal(releases[0])
+	s3c.AddGetObject(*releases[1].PreviousReleasePath(), string(raw), nil)
+
+	s3c.AddGetObject("00000000/project/development/release-bad/release", "not_json", nil)
+	s3c.AddGetObject(*release.AuditRecordPath(time.Now()), "{}", nil)
+
+	// Only expired nonces are deleted
+	assert.NoError(t, releases[1].CheckAndRecordNonce(s3c))
+	old := to.Timep(time.Now().Add(-11 * 24 * time.Hour))
+	assert.NoError(t, s3.PutStruct(s3c, release.Bucket, releases[2].NoncePath(), &s3.Lock{UUID: "old", CreatedAt: old, ExpiresAt: old}))
+
+	assert.NoError(t, release.CleanupOldReleases(s3c, lambdac, 2))
+
+	nonces, err := s3.List(s3c, release.Bucket, release.NonceDir())
+	assert.NoError(t, err)
+	assert.Equal(t, []string{*releases[1].NoncePath()}, nonces)
+
+	for _, r := range releases {
+		_, kept := s3c.GetObjectResp[*r.ReleasePath()]
+		_, keptZip := s3c.GetObjectResp[*r.LambdaZipPath()]
+		assert.Equal(t, kept, keptZip)
+		assert.Equal(t, *r.ReleaseID != "release-3", kept, *r.ReleaseID)
+	}
+
+	// Unreadable releases, the deployed record and audit records are never deleted
+	assert.NotNil(t, s3c.GetObjectResp["00000000/project/development/release-bad/release"])
+	assert.NotNil(t, s3c.GetObjectResp[*release.DeployedReleasePath()])
+	audit, err := s3.List(s3c, release.Bucket, release.AuditDir())
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(audit))
+
+	// Only the live releases are kept
+	assert.NoError(t, release.CleanupOldReleases(s3c, lambdac, 0))
+	releasesLeft, _, err := release.ListReleases(s3c, -1)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(releasesLeft))
+
+	assert.Error(t, release.CleanupOldReleases(s3c, lambdac, -1))
+}
+
+func Test_Release_CleanupOldReleases_NothingDeployed(t *testing.T) {
+	s3c := &mocks.MockS3Client{}
+	release := MockRelease()
+	mockReleaseHistory(s3c, 3)
+	lambdac := &mocks.MockLambdaClient{ListTagsResp: &lambda.ListTagsOutput{}}
+
+	assert.NoError(t, release.CleanupOldReleases(s3c, lambdac, 1))
+
+	releases, _, err := release.ListReleases(s3c, -1)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(releases))
+	assert.Equal(t, "release-3", *releases[0].ReleaseID)
+}
+
+func Test_Release_CleanupOldReleases_DeployedRecord(t *testing.T) {
+	s3c := &mocks.MockS3Client{}
+	release := MockRelease()
+	releases := mockReleaseHistory(s3c, 3)
+
+	// A Lambda deployed before tagging uses the deployed release record
+	lambdac := &mocks.MockLambdaClient{ListTagsResp: &lambda.ListTagsOutput{}}
+	raw, _ := json.Marshal(releases[0])
+	s3c.AddGetObject(*release.DeployedReleasePath(), string(raw), nil)
+
+	assert.NoError(t, release.CleanupOldReleases(s3c, lambdac, 1))
+
+	releasesLeft, _, err := release.ListReleases(s3c, -1)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(releasesLeft))
+	assert.Equal(t, "release-1", *releasesLeft[1].ReleaseID)
+
+	lambdac.ListTagsError = fmt.Errorf("AccessDenied")
+	assert.Error(t, release.CleanupOldReleases(s3c, lambdac, 1))
+}
+
+func Test_Release_CleanupOldReleases_LockHolder(t *testing.T) {
+	s3c := &mocks.MockS3Client{}
+	release := MockRelease()
+	releases := mockReleaseHistory(s3c, 4)
+	lambdac := mockLiveLambda("release-4")
+
+	// release-2 holds the root lock, release-1 was deployed before by another UUID
+	// and release-3 has no release lock so might be the holder
+	_, err := s3.GrabLock(s3c, release.Bucket, release.RootLockPath(), "deploying")
+	assert.NoError(t, err)
+	_, err = s3.GrabLock(s3c, release.Bucket, releases[1].ReleaseLockPath(), "deploying")
+	assert.NoError(t, err)
+	_, err = s3.GrabLock(s3c, release.Bucket, releases[0].ReleaseLockPath(), "deployed")
+	assert.NoError(t, err)
+
+	assert.NoError(t, release.CleanupOldReleases(s3c, lambdac, 0))
+
+	for _, r := range releases {
+		_, kept := s3c.GetObjectResp[*r.ReleasePath()]
+		assert.Equal(t, *r.ReleaseID != "release-1", kept, *r.ReleaseID)
+	}
+
+	// Once unlocked the releases that are not live are deleted
+	assert.NoError(t, release.ForceReleaseLock(s3c, nil))
+	assert.NoError(t, release.CleanupOldReleases(s3c, lambdac, 0))
+
+	releasesLeft, _, err := release.ListReleases(s3c, -1)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(releasesLeft))
+	assert.Equal(t, "release-4", *releasesLeft[0].ReleaseID)
+}
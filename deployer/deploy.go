@@ -0,0 +1,106 @@
+package deployer
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go/service/lambda"
+	"github.com/coinbase/step/aws"
+	"github.com/coinbase/step/utils/to"
+)
+
+// DeployResult describes what Deploy changed, or would change for a DryRun
+type DeployResult struct {
+	DryRun bool `json:"dry_run,omitempty"`
+
+	StateMachineUpdated bool `json:"state_machine_updated"` // Definition changed
+
+	LambdaUpdated bool    `json:"lambda_updated"`         // CodeSha256 of any Lambda changed
+	PreviousSHA   *string `json:"previous_sha,omitempty"` // Base64 CodeSha256 before deploy, not set for Lambdas
+	NewSHA        *string `json:"new_sha,omitempty"`      // Base64 CodeSha256 after deploy, not set for Lambdas
+}
+
+// Deploy updates the Step Function then the Lambda in AwsRegion, like the deployer does,
+// and returns what changed. See DeployStepFunction and DeployLambda
+func (release *Release) Deploy(sfnClient aws.SFNAPI, lambdaClient aws.LambdaAPI, s3c aws.S3API, bucketRegion *string, bucketAccount *string) (*DeployResult, error) {
+	return release.DeployWithContext(context.Background(), sfnClient, lambdaClient, s3c, bucketRegion, bucketAccount)
+}
+
+// DeployWithContext is Deploy with ctx passed to the AWS calls, use WithDeployEvents to follow its progress
+func (release *Release) DeployWithContext(ctx context.Context, sfnClient aws.SFNAPI, lambdaClient aws.LambdaAPI, s3c aws.S3API, bucketRegion *string, bucketAccount *string) (*DeployResult, error) {
+	result := &DeployResult{DryRun: release.DryRun}
+
+	if release.DeploysStepFunction() {
+		stateMachineDiff, err := release.StateMachineDiff(sfnClient)
+		if err != nil {
+			return result, err
+		}
+		result.StateMachineUpdated = stateMachineDiff != ""
+
+		if err := release.deployStepFunction(ctx, sfnClient); err != nil {
+			return result, err
+		}
+	}
+
+	if !release.DeploysLambda() {
+		return result, nil
+	}
+
+	err := release.eachLambda(func(l *Release) error {
+		previous, newSHA, err := l.deployLambdaResult(ctx, lambdaClient, s3c, bucketRegion, bucketAccount)
+
+		// The SHAs are only reported for a single Lambda
+		if len(release.Lambdas) == 0 {
+			result.PreviousSHA, result.NewSHA = previous, newSHA
+		}
+
+		if newSHA != nil && to.Strs(newSHA) != to.Strs(previous) {
+			result.LambdaUpdated = true
+		}
+
+		return err
+	})
+
+	return result, err
+}
+
+// deployLambdaResult deploys the Lambda and returns its CodeSha256 before and after
+func (release *Release) deployLambdaResult(ctx context.Context, lambdaClient aws.LambdaAPI, s3c aws.S3API, bucketRegion *string, bucketAccount *string) (*string, *string, error) {
+	previous, err := release.liveCodeSHA(lambdaClient)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if err := release.DeployLambdaWithContext(ctx, lambdaClient, s3c, bucketRegion, bucketAccount); err != nil {
+		return previous, nil, err
+	}
+
+	if release.DryRun {
+		// Lambda only reports SHA256 so the new code is unknown for other HashAlgo or images
+		if release.HashAlgorithm() == to.SHA256 && !release.IsImage() {
+			if sha, err := to.NormalizeSHA256(to.Strs(release.LambdaSHA256)); err == nil {
+				return previous, &sha, nil
+			}
+		}
+		return previous, nil, nil
+	}
+
+	newSHA, err := release.liveCodeSHA(lambdaClient)
+	return previous, newSHA, err
+}
+
+func (release *Release) liveCodeSHA(lambdaClient aws.LambdaAPI) (*string, error) {
+	out, err := lambdaClient.GetFunctionConfiguration(&lambda.GetFunctionConfigurationInput{
+		FunctionName: release.LambdaArn(),
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	if out == nil {
+		return nil, fmt.Errorf("Unknown Lambda GetFunctionConfiguration Error")
+	}
+
+	return out.CodeSha256, nil
+}
@@ -0,0 +1,67 @@
+package deployer
+
+import (
+	"testing"
+
+	"github.com/coinbase/step/utils/to"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Release_Deploy_Result(t *testing.T) {
+	release := MockRelease()
+	awsc := MockAwsClients(release)
+	release.Bucket = to.Strp("bucket")
+	awsc.SFN.DescribeStateMachineResp.Definition = to.Strp(`{"StartAt": "LOSE", "States": {"LOSE": {"Type": "Fail"}}}`)
+	newSHA, _ := to.HexToBase64(*release.LambdaSHA256)
+
+	result, err := release.Deploy(awsc.SFN, awsc.Lambda, awsc.S3, nil, nil)
+	assert.NoError(t, err)
+	assert.True(t, result.StateMachineUpdated)
+	assert.True(t, result.LambdaUpdated)
+	assert.Nil(t, result.PreviousSHA)
+	assert.Equal(t, newSHA, *result.NewSHA)
+	assert.Equal(t, 1, awsc.SFN.UpdateStateMachineCalls)
+
+	// Deploying the same release again changes nothing
+	awsc.SFN.DescribeStateMachineResp.Definition = release.StateMachineJSON
+	result, err = release.Deploy(awsc.SFN, awsc.Lambda, awsc.S3, nil, nil)
+	assert.NoError(t, err)
+	assert.False(t, result.StateMachineUpdated)
+	assert.False(t, result.LambdaUpdated)
+	assert.Equal(t, newSHA, *result.PreviousSHA)
+}
+
+func Test_Release_Deploy_Configuration_And_Tags(t *testing.T) {
+	release := MockRelease()
+	awsc := MockAwsClients(release)
+	release.Bucket = to.Strp("bucket")
+	release.LambdaEnvironment = map[string]*string{"LOG_LEVEL": to.Strp("debug")}
+	release.LambdaMemorySize = to.Int64p(512)
+	release.LambdaReservedConcurrentExecutions = to.Int64p(5)
+	awsc.SFN.DescribeStateMachineResp.Definition = release.StateMachineJSON
+
+	// Deploy runs the same Lambda sequence as the deployer
+	_, err := release.Deploy(awsc.SFN, awsc.Lambda, awsc.S3, nil, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, awsc.Lambda.UpdateFunctionConfigurationCalls)
+	assert.Equal(t, "debug", *awsc.Lambda.UpdateFunctionConfigurationInput.Environment.Variables["LOG_LEVEL"])
+	assert.Equal(t, int64(512), *awsc.Lambda.UpdateFunctionConfigurationInput.MemorySize)
+	assert.Equal(t, int64(5), *awsc.Lambda.PutFunctionConcurrencyInput.ReservedConcurrentExecutions)
+	assert.Equal(t, *release.ReleaseID, to.Strs(awsc.Lambda.ListTagsResp.Tags[ReleaseIdTag]))
+}
+
+func Test_Release_Deploy_Result_DryRun(t *testing.T) {
+	release := MockRelease()
+	awsc := MockAwsClients(release)
+	release.Bucket = to.Strp("bucket")
+	release.DryRun = true
+	awsc.SFN.DescribeStateMachineResp.Definition = to.Strp(`{"StartAt": "LOSE", "States": {"LOSE": {"Type": "Fail"}}}`)
+
+	result, err := release.Deploy(awsc.SFN, awsc.Lambda, awsc.S3, nil, nil)
+	assert.NoError(t, err)
+	assert.True(t, result.DryRun)
+	assert.True(t, result.StateMachineUpdated)
+	assert.True(t, result.LambdaUpdated)
+	assert.Equal(t, 0, awsc.SFN.UpdateStateMachineCalls)
+	assert.Equal(t, 0, awsc.Lambda.UpdateFunctionCodeCalls)
+}
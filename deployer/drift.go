@@ -0,0 +1,100 @@
+package deployer
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/lambda"
+	"github.com/coinbase/step/aws"
+	"github.com/coinbase/step/utils/to"
+)
+
+// DetectDrift compares the live State Machine and Lambda code to this release,
+// returning true and a summary of the differences if they were changed outside of it.
+// StepOnly and LambdaOnly releases only compare what they deploy.
+// Lambda only reports SHA256 so zip code is not compared for other HashAlgo
+func (release *Release) DetectDrift(sfnc aws.SFNAPI, lambdac aws.LambdaAPI) (bool, string, error) {
+	summary := []string{}
+
+	if release.DeploysStepFunction() {
+		stateMachineDiff, err := release.StateMachineDiff(sfnc)
+		if err != nil {
+			return false, "", err
+		}
+
+		switch stateMachineDiff {
+		case "":
+		case NewStateMachineDiff:
+			summary = append(summary, fmt.Sprintf("State Machine %v does not exist", to.Strs(release.StepArn())))
+		default:
+			summary = append(summary, fmt.Sprintf("State Machine %v does not match release %v\n%v", to.Strs(release.StepArn()), to.Strs(release.ReleaseID), stateMachineDiff))
+		}
+	}
+
+	if release.DeploysLambda() {
+		err := release.eachLambda(func(l *Release) error {
+			lambdaDrift, err := l.lambdaCodeDrift(lambdac)
+			if err != nil {
+				return err
+			}
+
+			if lambdaDrift != "" {
+				summary = append(summary, lambdaDrift)
+			}
+
+			return nil
+		})
+
+		if err != nil {
+			return false, "", err
+		}
+	}
+
+	return len(summary) != 0, strings.Join(summary, "\n"), nil
+}
+
+// lambdaCodeDrift returns a description of how the live Lambda code differs from the release, empty if it does not
+func (release *Release) lambdaCodeDrift(lambdac aws.LambdaAPI) (string, error) {
+	out, err := lambdac.GetFunction(&lambda.GetFunctionInput{FunctionName: release.LambdaArn()})
+
+	if aerr, ok := err.(awserr.Error); ok && aerr.Code() == lambda.ErrCodeResourceNotFoundException {
+		return fmt.Sprintf("Lambda %v does not exist", to.Strs(release.LambdaArn())), nil
+	}
+
+	if err != nil {
+		return "", err
+	}
+
+	if out == nil || out.Configuration == nil {
+		return "", fmt.Errorf("Unknown Lambda GetFunction Error")
+	}
+
+	if release.IsImage() {
+		var live *string
+		if out.Code != nil {
+			live = out.Code.ImageUri
+		}
+
+		if to.Strs(live) != to.Strs(release.ImageUri) {
+			return fmt.Sprintf("Lambda %v ImageUri is %v expecting %v", to.Strs(release.LambdaArn()), to.Strs(live), to.Strs(release.ImageUri)), nil
+		}
+
+		return "", nil
+	}
+
+	if release.HashAlgorithm() != to.SHA256 {
+		return "", nil
+	}
+
+	expected, err := to.NormalizeSHA256(to.Strs(release.LambdaSHA256))
+	if err != nil {
+		return "", fmt.Errorf("LambdaSHA256 invalid: %v", err.Error())
+	}
+
+	if live := to.Strs(out.Configuration.CodeSha256); live != expected {
+		return fmt.Sprintf("Lambda %v CodeSha256 is %v expecting %v", to.Strs(release.LambdaArn()), live, expected), nil
+	}
+
+	return "", nil
+}
@@ -0,0 +1,78 @@
+package deployer
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/lambda"
+	"github.com/aws/aws-sdk-go/service/sfn"
+	"github.com/coinbase/step/utils/to"
+	"github.com/stretchr/testify/assert"
+)
+
+func mockDriftRelease() (*Release, *lambda.GetFunctionOutput) {
+	r := MockRelease()
+	r.LambdaSHA256 = to.Strp(to.SHA256Str(to.Strp("lambda_zip")))
+	codeSHA, _ := to.HexToBase64(*r.LambdaSHA256)
+	return r, &lambda.GetFunctionOutput{Configuration: &lambda.FunctionConfiguration{CodeSha256: &codeSHA}}
+}
+
+func Test_Release_DetectDrift(t *testing.T) {
+	r, live := mockDriftRelease()
+	awsc := MockAwsClients(r)
+	awsc.SFN.DescribeStateMachineResp.Definition = r.StateMachineJSON
+	awsc.Lambda.GetFunctionResp = live
+
+	drift, summary, err := r.DetectDrift(awsc.SFN, awsc.Lambda)
+	assert.NoError(t, err)
+	assert.False(t, drift)
+	assert.Equal(t, "", summary)
+
+	// Hand edited State Machine and Lambda
+	awsc.SFN.DescribeStateMachineResp.Definition = to.Strp(`{"StartAt": "LOSE", "States": {"LOSE": {"Type": "Fail"}}}`)
+	live.Configuration.CodeSha256 = to.Strp("other")
+
+	drift, summary, err = r.DetectDrift(awsc.SFN, awsc.Lambda)
+	assert.NoError(t, err)
+	assert.True(t, drift)
+	assert.Regexp(t, "State Machine .*stepfnname does not match release release-1", summary)
+	assert.Regexp(t, `(?m)^- "StartAt": "LOSE",$`, summary)
+	assert.Regexp(t, "Lambda .*lambdaname CodeSha256 is other expecting", summary)
+}
+
+func Test_Release_DetectDrift_Missing(t *testing.T) {
+	r, _ := mockDriftRelease()
+	awsc := MockAwsClients(r)
+	awsc.SFN.DescribeStateMachineError = awserr.New(sfn.ErrCodeStateMachineDoesNotExist, "does not exist", nil)
+	awsc.Lambda.MissingFunctions = map[string]bool{*r.LambdaArn(): true}
+
+	drift, summary, err := r.DetectDrift(awsc.SFN, awsc.Lambda)
+	assert.NoError(t, err)
+	assert.True(t, drift)
+	assert.Regexp(t, "State Machine .* does not exist", summary)
+	assert.Regexp(t, "Lambda .* does not exist", summary)
+
+	awsc.SFN.DescribeStateMachineError = awserr.New("AccessDeniedException", "denied", nil)
+	_, _, err = r.DetectDrift(awsc.SFN, awsc.Lambda)
+	assert.Error(t, err)
+}
+
+func Test_Release_DetectDrift_Image(t *testing.T) {
+	r := mockImageRelease()
+	awsc := MockAwsClients(r)
+	awsc.SFN.DescribeStateMachineResp.Definition = r.StateMachineJSON
+	awsc.Lambda.GetFunctionResp = &lambda.GetFunctionOutput{
+		Configuration: &lambda.FunctionConfiguration{},
+		Code:          &lambda.FunctionCodeLocation{ImageUri: r.ImageUri},
+	}
+
+	drift, _, err := r.DetectDrift(awsc.SFN, awsc.Lambda)
+	assert.NoError(t, err)
+	assert.False(t, drift)
+
+	awsc.Lambda.GetFunctionResp.Code.ImageUri = to.Strp("other")
+	drift, summary, err := r.DetectDrift(awsc.SFN, awsc.Lambda)
+	assert.NoError(t, err)
+	assert.True(t, drift)
+	assert.Regexp(t, "ImageUri is other expecting", summary)
+}
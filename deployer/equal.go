@@ -0,0 +1,114 @@
+package deployer
+
+import (
+	"bytes"
+
+	"github.com/coinbase/step/utils/to"
+)
+
+// deployedFields are the attributes of a Release that change what is deployed
+type deployedFields struct {
+	AwsAccountID *string
+	AwsRegion    *string
+	AwsRegions   []*string
+	ProjectName  *string
+	ConfigName   *string
+	HashAlgo     string
+
+	LambdaName       *string
+	LambdaSHA256     *string
+	Lambdas          []LambdaSpec
+	ImageUri         *string
+	StepFnName       *string
+	LambdaQualifier  *string
+	ArtifactBucket   *string
+	LambdaZipVersion *string
+
+	StateMachineJSON     *string
+	InputSchema          *string
+	WorkflowType         string
+	LoggingConfiguration *LoggingConfiguration
+	TracingConfiguration *TracingConfiguration
+
+	LambdaEnvironment                  map[string]*string
+	LambdaMemorySize                   *int64
+	LambdaTimeout                      *int64
+	LambdaReservedConcurrentExecutions *int64
+	Layers                             []*string
+}
+
+func (release *Release) deployedFields() *deployedFields {
+	lambdaSHA := release.LambdaSHA256
+	if release.HashAlgorithm() == to.SHA256 && lambdaSHA != nil {
+		if sha, err := to.NormalizeSHA256(*lambdaSHA); err == nil {
+			lambdaSHA = &sha
+		}
+	}
+
+	return &deployedFields{
+		AwsAccountID: release.AwsAccountID,
+		AwsRegion:    release.AwsRegion,
+		AwsRegions:   release.AwsRegions,
+		ProjectName:  release.ProjectName,
+		ConfigName:   release.ConfigName,
+		HashAlgo:     release.HashAlgorithm(),
+
+		LambdaName:       release.LambdaName,
+		LambdaSHA256:     lambdaSHA,
+		Lambdas:          release.deployedLambdas(),
+		ImageUri:         release.ImageUri,
+		StepFnName:       release.StepFnName,
+		LambdaQualifier:  release.LambdaQualifier,
+		ArtifactBucket:   release.LambdaZipBucket(),
+		LambdaZipVersion: release.LambdaZipVersion,
+
+		StateMachineJSON: canonicalStateMachineJSON(release.StateMachineJSON),
+		InputSchema:      canonicalJSONStr(release.InputSchema),
+		WorkflowType:     release.StateMachineType(),
+
+		LoggingConfiguration: release.LoggingConfiguration,
+		TracingConfiguration: release.TracingConfiguration,
+
+		LambdaEnvironment:                  release.LambdaEnvironment,
+		LambdaMemorySize:                   release.LambdaMemorySize,
+		LambdaTimeout:                      release.LambdaTimeout,
+		LambdaReservedConcurrentExecutions: release.LambdaReservedConcurrentExecutions,
+		Layers:                             release.deployedLayers(),
+	}
+}
+
+// deployedLambdas returns Lambdas with normalized SHA256s
+func (release *Release) deployedLambdas() []LambdaSpec {
+	if release.Lambdas == nil {
+		return nil
+	}
+
+	lambdas := []LambdaSpec{}
+	for _, spec := range release.Lambdas {
+		if release.HashAlgorithm() == to.SHA256 && spec.SHA256 != nil {
+			if sha, err := to.NormalizeSHA256(*spec.SHA256); err == nil {
+				spec.SHA256 = &sha
+			}
+		}
+		lambdas = append(lambdas, spec)
+	}
+
+	return lambdas
+}
+
+// Equal returns true if other deploys the same code, from the same S3 Version and bucket, State Machine,
+// Lambda settings and alias to the same place. Attributes that do not change the deploy,
+// like UUID, ReleaseID, CreatedAt and Metadata, are ignored
+func (release *Release) Equal(other *Release) bool {
+	if release == nil || other == nil {
+		return release == other
+	}
+
+	a, errA := to.CanonicalJSON(release.deployedFields())
+	b, errB := to.CanonicalJSON(other.deployedFields())
+	if errA != nil || errB != nil {
+		return false
+	}
+
+	return bytes.Equal(a, b)
+}
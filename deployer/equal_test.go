@@ -0,0 +1,67 @@
+package deployer
+
+import (
+	"testing"
+
+	"github.com/coinbase/step/utils/to"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Release_Equal(t *testing.T) {
+	a := MockRelease()
+	a.LambdaSHA256 = to.Strp(to.SHA256Str(to.Strp("zip")))
+	a.StateMachineJSON = to.Strp(`{"StartAt": "WIN", "States": {"WIN": {"Type": "Succeed"}}}`)
+
+	// Server set and non functional attributes are ignored
+	b := MockRelease()
+	b.ReleaseID = to.Strp("release-2")
+	b.UUID = to.Strp("uuid")
+	b.Metadata = nil
+	b.StateMachineJSON = to.Strp("{\n \"States\": {\"WIN\": {\"Type\": \"Succeed\"}},\n \"StartAt\": \"WIN\"\n}")
+	sha, _ := to.HexToBase64(*a.LambdaSHA256)
+	b.LambdaSHA256 = &sha
+
+	assert.True(t, a.Equal(b))
+	assert.True(t, b.Equal(a))
+
+	b.LambdaSHA256 = to.Strp(to.SHA256Str(to.Strp("other")))
+	assert.False(t, a.Equal(b))
+
+	b.LambdaSHA256 = a.LambdaSHA256
+	b.StateMachineJSON = to.Strp(`{"StartAt": "WIN", "States": {"WIN": {"Type": "Pass", "End": true}}}`)
+	assert.False(t, a.Equal(b))
+
+	b.StateMachineJSON = a.StateMachineJSON
+	b.LambdaMemorySize = to.Int64p(256)
+	assert.False(t, a.Equal(b))
+
+	b.LambdaMemorySize = nil
+	b.ConfigName = to.Strp("production")
+	assert.False(t, a.Equal(b))
+
+	// Moving the alias or deploying another zip version or bucket is a different deploy
+	b.ConfigName = a.ConfigName
+	b.LambdaQualifier = to.Strp("PROD")
+	assert.False(t, a.Equal(b))
+
+	b.LambdaQualifier = nil
+	b.ArtifactBucket = to.Strp("artifacts")
+	assert.False(t, a.Equal(b))
+
+	b.ArtifactBucket = a.Bucket
+	assert.True(t, a.Equal(b))
+
+	b.LambdaZipVersion = to.Strp("v2")
+	assert.False(t, a.Equal(b))
+
+	b.LambdaZipVersion = nil
+	b.Lambdas = []LambdaSpec{{Name: to.Strp("a"), ZipVersion: to.Strp("v1")}}
+	a.Lambdas = []LambdaSpec{{Name: to.Strp("a"), ZipVersion: to.Strp("v2")}}
+	assert.False(t, a.Equal(b))
+
+	b.Lambdas = a.Lambdas
+	assert.True(t, a.Equal(b))
+
+	assert.False(t, a.Equal(nil))
+	assert.True(t, (*Release)(nil).Equal(nil))
+}
@@ -0,0 +1,76 @@
+package deployer
+
+import (
+	"context"
+	"time"
+
+	"github.com/coinbase/step/utils/to"
+)
+
+// DeployPhase is a step of a deploy reported to a DeployEventFunc
+type DeployPhase string
+
+const (
+	DeployPhaseStepFunction DeployPhase = "step_function" // UpdateStateMachine
+	DeployPhaseDownload     DeployPhase = "download"      // Download the Lambda Zip from S3
+	DeployPhaseLambdaCode   DeployPhase = "lambda_code"   // UpdateFunctionCode
+	DeployPhaseLambdaWait   DeployPhase = "lambda_wait"   // Wait for the Lambda update to finish
+	DeployPhaseLambdaAlias  DeployPhase = "lambda_alias"  // Publish a version and move the LambdaQualifier alias
+)
+
+// DeployEvent is sent when a DeployPhase starts and again when it ends
+type DeployEvent struct {
+	Phase  DeployPhase `json:"phase"`
+	Region string      `json:"region,omitempty"`
+	Lambda string      `json:"lambda,omitempty"` // Lambda phases only
+
+	Time     time.Time     `json:"time"`
+	Ended    bool          `json:"ended"`
+	Duration time.Duration `json:"duration,omitempty"` // Ended only
+	Error    string        `json:"error,omitempty"`    // Ended only, the phase failed
+}
+
+// DeployEventFunc is called synchronously by the deploying goroutine, it should return quickly
+type DeployEventFunc func(DeployEvent)
+
+type deployEventsKey struct{}
+
+// WithDeployEvents returns a ctx that makes the WithContext deploy functions, e.g. DeployWithContext
+// and DeployLambdaRegionsWithContext, call events as each phase starts and ends
+func WithDeployEvents(ctx context.Context, events DeployEventFunc) context.Context {
+	return context.WithValue(ctx, deployEventsKey{}, events)
+}
+
+// deployPhase calls fn, reporting it as phase to the DeployEventFunc in ctx if there is one
+func (release *Release) deployPhase(ctx context.Context, phase DeployPhase, fn func() error) error {
+	events, _ := ctx.Value(deployEventsKey{}).(DeployEventFunc)
+	if events == nil {
+		return fn()
+	}
+
+	event := DeployEvent{
+		Phase:  phase,
+		Region: to.Strs(release.AwsRegion),
+		Time:   time.Now(),
+	}
+
+	if phase != DeployPhaseStepFunction {
+		event.Lambda = to.Strs(release.LambdaName)
+	}
+
+	events(event)
+
+	err := fn()
+
+	start := event.Time
+	event.Time = time.Now()
+	event.Ended = true
+	event.Duration = event.Time.Sub(start)
+	if err != nil {
+		event.Error = err.Error()
+	}
+
+	events(event)
+
+	return err
+}
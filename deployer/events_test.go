@@ -0,0 +1,87 @@
+package deployer
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/coinbase/step/utils/to"
+	"github.com/stretchr/testify/assert"
+)
+
+func recordDeployEvents() (context.Context, *[]DeployEvent) {
+	events := []DeployEvent{}
+	ctx := WithDeployEvents(context.Background(), func(e DeployEvent) {
+		events = append(events, e)
+	})
+	return ctx, &events
+}
+
+func Test_Release_DeployWithContext_Events(t *testing.T) {
+	release := MockRelease()
+	awsc := MockAwsClients(release)
+	release.Bucket = to.Strp("bucket")
+	awsc.SFN.DescribeStateMachineResp.Definition = release.StateMachineJSON
+	release.LambdaQualifier = to.Strp("live")
+
+	ctx, events := recordDeployEvents()
+	_, err := release.DeployWithContext(ctx, awsc.SFN, awsc.Lambda, awsc.S3, nil, nil)
+	assert.NoError(t, err)
+
+	phases := []string{}
+	for _, e := range *events {
+		phases = append(phases, fmt.Sprintf("%v %v", e.Phase, e.Ended))
+		assert.Equal(t, to.Strs(release.AwsRegion), e.Region)
+		assert.Equal(t, "", e.Error)
+
+		if e.Phase == DeployPhaseStepFunction {
+			assert.Equal(t, "", e.Lambda)
+		} else {
+			assert.Equal(t, "lambdaname", e.Lambda)
+		}
+	}
+
+	assert.Equal(t, []string{
+		"step_function false", "step_function true",
+		"download false", "download true",
+		"lambda_code false", "lambda_code true",
+		"lambda_wait false", "lambda_wait true",
+		"lambda_alias false", "lambda_alias true",
+	}, phases)
+
+	for _, e := range *events {
+		if e.Ended {
+			assert.True(t, e.Duration >= 0)
+		} else {
+			assert.Equal(t, int64(0), int64(e.Duration))
+		}
+	}
+}
+
+func Test_Release_DeployWithContext_EventsError(t *testing.T) {
+	release := MockRelease()
+	awsc := MockAwsClients(release)
+	release.Bucket = to.Strp("bucket")
+	awsc.SFN.DescribeStateMachineResp.Definition = release.StateMachineJSON
+	awsc.SFN.UpdateStateMachineError = fmt.Errorf("AWSSFNError")
+
+	ctx, events := recordDeployEvents()
+	_, err := release.DeployWithContext(ctx, awsc.SFN, awsc.Lambda, awsc.S3, nil, nil)
+	assert.Error(t, err)
+
+	assert.Equal(t, 2, len(*events))
+	last := (*events)[1]
+	assert.Equal(t, DeployPhaseStepFunction, last.Phase)
+	assert.True(t, last.Ended)
+	assert.Equal(t, "AWSSFNError", last.Error)
+}
+
+func Test_Release_DeployWithContext_NoEvents(t *testing.T) {
+	release := MockRelease()
+	awsc := MockAwsClients(release)
+	release.Bucket = to.Strp("bucket")
+	awsc.SFN.DescribeStateMachineResp.Definition = release.StateMachineJSON
+
+	_, err := release.DeployWithContext(context.Background(), awsc.SFN, awsc.Lambda, awsc.S3, nil, nil)
+	assert.NoError(t, err)
+}
@@ -0,0 +1,53 @@
+package deployer
+
+import (
+	"fmt"
+
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/sfn"
+	"github.com/coinbase/step/aws"
+	"github.com/coinbase/step/utils/to"
+)
+
+// StopRunningExecutions stops every RUNNING execution of the Step Function with cause,
+// e.g. after deploying a fix for executions stuck on the old definition. It returns how many were stopped
+func (release *Release) StopRunningExecutions(sfnc aws.SFNAPI, cause string) (int, error) {
+	running := []*string{}
+
+	err := sfnc.ListExecutionsPages(&sfn.ListExecutionsInput{
+		MaxResults:      to.Int64p(100),
+		StateMachineArn: release.StepArn(),
+		StatusFilter:    to.Strp(sfn.ExecutionStatusRunning),
+	}, func(page *sfn.ListExecutionsOutput, lastPage bool) bool {
+		for _, exec := range page.Executions {
+			running = append(running, exec.ExecutionArn)
+		}
+		return !lastPage
+	})
+
+	if err != nil {
+		return 0, err
+	}
+
+	stopped := 0
+	for _, arn := range running {
+		_, err := sfnc.StopExecution(&sfn.StopExecutionInput{
+			ExecutionArn: arn,
+			Error:        to.Strp("StoppedByDeploy"),
+			Cause:        &cause,
+		})
+
+		if aerr, ok := err.(awserr.Error); ok && aerr.Code() == sfn.ErrCodeExecutionDoesNotExist {
+			// Finished since it was listed
+			continue
+		}
+
+		if err != nil {
+			return stopped, fmt.Errorf("Stopped %v of %v executions: %v", stopped, len(running), err.Error())
+		}
+
+		stopped++
+	}
+
+	return stopped, nil
+}
@@ -0,0 +1,46 @@
+package deployer
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/sfn"
+	"github.com/coinbase/step/utils/to"
+	"github.com/stretchr/testify/assert"
+)
+
+func runningPage(arns ...string) *sfn.ListExecutionsOutput {
+	page := &sfn.ListExecutionsOutput{}
+	for _, arn := range arns {
+		page.Executions = append(page.Executions, &sfn.ExecutionListItem{ExecutionArn: to.Strp(arn), Status: to.Strp("RUNNING")})
+	}
+	return page
+}
+
+func Test_Release_StopRunningExecutions(t *testing.T) {
+	release := MockRelease()
+	awsc := MockAwsClients(release)
+
+	count, err := release.StopRunningExecutions(awsc.SFN, "fix")
+	assert.NoError(t, err)
+	assert.Equal(t, 0, count)
+
+	awsc.SFN.ListExecutionsPagesResp = []*sfn.ListExecutionsOutput{runningPage("a", "b"), runningPage("c")}
+	awsc.SFN.StopExecutionErrors = map[string]error{
+		"b": awserr.New(sfn.ErrCodeExecutionDoesNotExist, "finished", nil),
+	}
+
+	count, err = release.StopRunningExecutions(awsc.SFN, "fix")
+	assert.NoError(t, err)
+	assert.Equal(t, 2, count)
+	assert.Len(t, awsc.SFN.StopExecutionInputs, 3)
+	assert.Equal(t, "c", *awsc.SFN.StopExecutionInputs[2].ExecutionArn)
+	assert.Equal(t, "fix", *awsc.SFN.StopExecutionInputs[2].Cause)
+
+	awsc.SFN.StopExecutionErrors["c"] = fmt.Errorf("AccessDenied")
+	count, err = release.StopRunningExecutions(awsc.SFN, "fix")
+	assert.Error(t, err)
+	assert.Equal(t, 1, count)
+	assert.Regexp(t, "Stopped 1 of 3 executions: AccessDenied", err.Error())
+}
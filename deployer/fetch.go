@@ -0,0 +1,117 @@
+package deployer
+
+import (
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/coinbase/step/aws"
+	"github.com/coinbase/step/aws/s3"
+	"github.com/coinbase/step/utils/to"
+)
+
+// FetchLambdaZip downloads the lambda.zip and checks it matches LambdaSHA256,
+// if LambdaZipVersion was recorded that exact S3 version is downloaded
+func (release *Release) FetchLambdaZip(s3c aws.S3API) ([]byte, error) {
+	if release.IsImage() {
+		return nil, fmt.Errorf("Release is deployed from ImageUri %v, there is no lambda.zip", to.Strs(release.ImageUri))
+	}
+
+	_, zip, err := s3.GetObjectVersion(s3c, release.LambdaZipBucket(), release.LambdaZipPath(), release.LambdaZipVersion)
+	if err != nil {
+		return nil, err
+	}
+
+	if sha := to.HashAByte(release.HashAlgorithm(), zip); !release.lambdaSHAMatches(sha) {
+		return nil, release.lambdaSHAMismatch(sha)
+	}
+
+	return *zip, nil
+}
+
+// downloadLambdaZip downloads the LambdaZipVersion of the Zip to deploy, the latest version if none was recorded.
+// Over s3.GetLargeThreshold it is downloaded in parts with s3.GetLarge. Either way the bytes are checked against LambdaSHA256
+func (release *Release) downloadLambdaZip(s3c aws.S3API, progress s3.ProgressFunc) (*[]byte, error) {
+	size, err := s3.SizeVersion(s3c, release.LambdaZipBucket(), release.LambdaZipPath(), release.LambdaZipVersion)
+	if err != nil {
+		return nil, err
+	}
+
+	var zip *[]byte
+	if size <= s3.GetLargeThreshold {
+		zip, err = s3.GetVersionWithProgress(s3c, release.LambdaZipBucket(), release.LambdaZipPath(), release.LambdaZipVersion, progress)
+	} else {
+		zip, err = s3.GetLargeVersion(s3c, release.LambdaZipBucket(), release.LambdaZipPath(), release.LambdaZipVersion, progress)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	if sha := to.HashAByte(release.HashAlgorithm(), zip); !release.lambdaSHAMatches(sha) {
+		return nil, release.lambdaSHAMismatch(sha)
+	}
+
+	return zip, nil
+}
+
+// ExtractLambdaZip writes the files in zipBytes to dir, rejecting entries that would be written outside dir
+func ExtractLambdaZip(zipBytes []byte, dir string) error {
+	reader, err := zip.NewReader(bytes.NewReader(zipBytes), int64(len(zipBytes)))
+	if err != nil {
+		return err
+	}
+
+	root, err := filepath.Abs(dir)
+	if err != nil {
+		return err
+	}
+
+	for _, file := range reader.File {
+		path := filepath.Join(root, file.Name)
+		if path != root && !strings.HasPrefix(path, root+string(os.PathSeparator)) {
+			return fmt.Errorf("zip entry %q is outside %v", file.Name, dir)
+		}
+
+		if file.FileInfo().IsDir() {
+			if err := os.MkdirAll(path, 0755); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if err := extractZipFile(file, path); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func extractZipFile(file *zip.File, path string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+
+	src, err := file.Open()
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	dst, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, file.Mode().Perm())
+	if err != nil {
+		return err
+	}
+
+	if _, err := io.Copy(dst, src); err != nil {
+		dst.Close()
+		return err
+	}
+
+	return dst.Close()
+}
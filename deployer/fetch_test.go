@@ -0,0 +1,124 @@
+package deployer
+
+import (
+	"archive/zip"
+	"bytes"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/coinbase/step/aws/s3"
+	"github.com/coinbase/step/utils/to"
+	"github.com/stretchr/testify/assert"
+)
+
+func mockZip(t *testing.T, files map[string]string) []byte {
+	buf := new(bytes.Buffer)
+	w := zip.NewWriter(buf)
+	for name, content := range files {
+		f, err := w.Create(name)
+		assert.NoError(t, err)
+		_, err = f.Write([]byte(content))
+		assert.NoError(t, err)
+	}
+	assert.NoError(t, w.Close())
+	return buf.Bytes()
+}
+
+func Test_Release_FetchLambdaZip(t *testing.T) {
+	release := MockRelease()
+	awsc := MockAwsClients(release)
+	release.Bucket = to.Strp("bucket")
+
+	zip, err := release.FetchLambdaZip(awsc.S3)
+	assert.NoError(t, err)
+	assert.Equal(t, "lambda_zip", string(zip))
+
+	// The zip in the bucket was swapped after the release was validated
+	awsc.S3.AddGetObject(*release.LambdaZipPath(), "swapped", nil)
+	_, err = release.FetchLambdaZip(awsc.S3)
+	assert.Regexp(t, "Lambda SHA mismatch", err.Error())
+
+	release.ImageUri = to.Strp("000000000000.dkr.ecr.us-east-1.amazonaws.com/repo@sha256:abc")
+	_, err = release.FetchLambdaZip(awsc.S3)
+	assert.Error(t, err)
+}
+
+func Test_Release_FetchLambdaZip_Version(t *testing.T) {
+	release := MockRelease()
+	awsc := MockAwsClients(release)
+	release.Bucket = to.Strp("bucket")
+	awsc.S3.Versioned = true
+
+	assert.NoError(t, s3.PutStr(awsc.S3, release.Bucket, release.LambdaZipPath(), to.Strp("lambda_zip")))
+	assert.NoError(t, s3.PutStr(awsc.S3, release.Bucket, release.LambdaZipPath(), to.Strp("swapped")))
+
+	// The validated version is fetched, not the latest
+	release.LambdaZipVersion = to.Strp("v1")
+	zip, err := release.FetchLambdaZip(awsc.S3)
+	assert.NoError(t, err)
+	assert.Equal(t, "lambda_zip", string(zip))
+}
+
+func Test_ExtractLambdaZip(t *testing.T) {
+	dir := t.TempDir()
+	raw := mockZip(t, map[string]string{"main": "binary", "config/app.json": "{}"})
+
+	assert.NoError(t, ExtractLambdaZip(raw, dir))
+
+	main, err := ioutil.ReadFile(filepath.Join(dir, "main"))
+	assert.NoError(t, err)
+	assert.Equal(t, "binary", string(main))
+
+	config, err := ioutil.ReadFile(filepath.Join(dir, "config", "app.json"))
+	assert.NoError(t, err)
+	assert.Equal(t, "{}", string(config))
+
+	// Entries cannot escape dir
+	evil := mockZip(t, map[string]string{"../evil": "x"})
+	assert.Regexp(t, "outside", ExtractLambdaZip(evil, dir).Error())
+
+	assert.Error(t, ExtractLambdaZip([]byte("not a zip"), dir))
+}
+
+func Test_Release_DeployLambda_LargeZip(t *testing.T) {
+	defer func(threshold int64, size int64) {
+		s3.GetLargeThreshold, s3.GetLargePartSize = threshold, size
+	}(s3.GetLargeThreshold, s3.GetLargePartSize)
+	s3.GetLargeThreshold, s3.GetLargePartSize = 4, 4
+
+	release := MockRelease()
+	awsc := MockAwsClients(release)
+	release.Bucket = to.Strp("bucket")
+
+	assert.NoError(t, release.DeployLambda(awsc.Lambda, awsc.S3, nil, nil))
+	assert.Equal(t, "lambda_zip", string(awsc.Lambda.UpdateFunctionCodeInputs[0].ZipFile))
+	assert.Equal(t, 3, len(awsc.S3.GetObjectInputs))
+
+	// The assembled zip must match LambdaSHA256
+	awsc.S3.AddGetObject(*release.LambdaZipPath(), "swapped_zip", nil)
+	err := release.DeployLambda(awsc.Lambda, awsc.S3, nil, nil)
+	assert.Regexp(t, "Lambda SHA mismatch", err.Error())
+	assert.Equal(t, 1, awsc.Lambda.UpdateFunctionCodeCalls)
+}
+
+func Test_Release_DeployLambda_Version(t *testing.T) {
+	release := MockRelease()
+	awsc := MockAwsClients(release)
+	release.Bucket = to.Strp("bucket")
+	awsc.S3.Versioned = true
+
+	assert.NoError(t, s3.PutStr(awsc.S3, release.Bucket, release.LambdaZipPath(), to.Strp("lambda_zip")))
+	assert.NoError(t, release.ValidateLambdaSHA(awsc.S3))
+	assert.NoError(t, s3.PutStr(awsc.S3, release.Bucket, release.LambdaZipPath(), to.Strp("swapped")))
+
+	// The validated version is deployed, not the latest
+	assert.NoError(t, release.DeployLambda(awsc.Lambda, awsc.S3, nil, nil))
+	assert.Equal(t, "lambda_zip", string(awsc.Lambda.UpdateFunctionCodeInputs[0].ZipFile))
+
+	// A small zip is still checked against LambdaSHA256
+	release.LambdaZipVersion = to.Strp("v2")
+	err := release.DeployLambda(awsc.Lambda, awsc.S3, nil, nil)
+	assert.Regexp(t, "Lambda SHA mismatch", err.Error())
+	assert.Equal(t, 1, awsc.Lambda.UpdateFunctionCodeCalls)
+}
@@ -8,9 +8,13 @@ package deployer
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"os"
+	"time"
 
 	"github.com/coinbase/step/aws"
+	"github.com/coinbase/step/bifrost"
 	"github.com/coinbase/step/errors"
 	"github.com/coinbase/step/utils/to"
 )
@@ -27,6 +31,10 @@ type DeployLambdaError struct {
 	err error
 }
 
+type SmokeTestError struct {
+	err error
+}
+
 func (e DeploySFNError) Error() string {
 	return fmt.Sprintf("DeploySFNError: %v", e.err.Error())
 }
@@ -35,44 +43,133 @@ func (e DeployLambdaError) Error() string {
 	return fmt.Sprintf("DeployLambdaError: %v", e.err.Error())
 }
 
+func (e SmokeTestError) Error() string {
+	return fmt.Sprintf("SmokeTestError: %v", e.err.Error())
+}
+
 ////////////
 // HANDLERS
 ////////////
 
 var assumed_role = to.Strp("coinbase-step-deployer-assumed")
 
+// RequireGitSHAEnv when "true" rejects releases without a GitSHA, so every deploy can be traced to a build
+const RequireGitSHAEnv = "STEP_REQUIRE_GIT_SHA"
+
+// AllowedProjectsEnv is JSON mapping each ProjectName to the ConfigNames this deployer accepts,
+// e.g. {"project": ["development", "production"]}, unset accepts all
+const AllowedProjectsEnv = "STEP_ALLOWED_PROJECTS"
+
+// ArtifactBucketEnv is the separate ArtifactBucket the deployer Lambda policy allows lambda.zips to be read from,
+// unset only allows the lambda.zip in the release Bucket
+const ArtifactBucketEnv = "STEP_ARTIFACT_BUCKET"
+
+// allowedProjects returns the parsed AllowedProjectsEnv, nil if it is not set
+func allowedProjects() (map[string][]string, error) {
+	raw := os.Getenv(AllowedProjectsEnv)
+	if raw == "" {
+		return nil, nil
+	}
+
+	var allowed map[string][]string
+	if err := json.Unmarshal([]byte(raw), &allowed); err != nil {
+		return nil, fmt.Errorf("%v is not valid JSON: %v", AllowedProjectsEnv, err.Error())
+	}
+
+	return allowed, nil
+}
+
 func ValidateHandler(awsc aws.AwsClients) interface{} {
 	return func(ctx context.Context, release *Release) (*Release, error) {
+		start := time.Now()
+
 		// Override any attributes set by the client
-		release.ReleaseSHA256 = to.SHA256Struct(release)
+		release.Sign()
 		release.WipeControlledValues()
 
 		region, account := to.AwsRegionAccountFromContext(ctx)
-		release.SetDefaults(region, account, "coinbase-step-deployer-")
+		release.SetDefaults(region, account, DefaultBucketPrefix)
+
+		// Before Validate reads the lambda.zip from the ArtifactBucket
+		if err := release.ValidateArtifactBucket(os.Getenv(ArtifactBucketEnv)); err != nil {
+			return nil, errors.BadReleaseError{err.Error()}
+		}
 
 		// Validate the attributes for the release
 		if err := release.Validate(awsc.S3Client(nil, nil, nil)); err != nil {
 			return nil, errors.BadReleaseError{err.Error()}
 		}
 
+		if err := release.ValidateProvenance(os.Getenv(RequireGitSHAEnv) == "true"); err != nil {
+			return nil, errors.BadReleaseError{err.Error()}
+		}
+
+		allowed, err := allowedProjects()
+		if err != nil {
+			return nil, errors.BadReleaseError{err.Error()}
+		}
+
+		if allowed != nil {
+			if err := release.ValidateAllowed(allowed); err != nil {
+				return nil, errors.BadReleaseError{err.Error()}
+			}
+		}
+
+		release.ValidationMillis = elapsedMillis(nil, start)
+
 		return release, nil
 	}
 }
 
 func LockHandler(awsc aws.AwsClients) interface{} {
 	return func(ctx context.Context, release *Release) (*Release, error) {
+		notify(awsc, release, bifrost.NotifyStarted, nil)
+
 		// returns LockExistsError, LockError
-		return release, release.GrabLocks(awsc.S3Client(nil, nil, nil))
+		err := release.GrabLocks(awsc.S3Client(nil, nil, nil))
+
+		if _, ok := err.(*errors.LockExistsError); ok {
+			// Goes straight to FailureClean so ReleaseLockFailure will not notify
+			notify(awsc, release, bifrost.NotifyFailed, err)
+		}
+
+		if err != nil {
+			return release, err
+		}
+
+		// returns ReplayError, the locks are released by ReleaseLockFailure
+		return release, release.CheckAndRecordNonce(awsc.S3Client(nil, nil, nil))
 	}
 }
 
 func ValidateResourcesHandler(awsc aws.AwsClients) interface{} {
 	return func(ctx context.Context, release *Release) (*Release, error) {
-		// Validate the Resources for the release
-		if err := release.ValidateResources(awsc.LambdaClient(release.AwsRegion, release.AwsAccountID, assumed_role), awsc.SFNClient(release.AwsRegion, release.AwsAccountID, assumed_role)); err != nil {
+		start := time.Now()
+
+		// Fail fast if the deployer credentials are not in the release account
+		if stsClients, ok := awsc.(aws.STSClients); ok {
+			stsc := stsClients.STSClient(release.AwsRegion, release.AwsAccountID, assumed_role)
+			if err := release.ValidateDeployerAccount(stsc); err != nil {
+				return nil, errors.BadReleaseError{err.Error()}
+			}
+		}
+
+		signingKeyId := os.Getenv(SigningKeyEnv)
+
+		var kmsc aws.KMSAPI
+		if kmsClients, ok := awsc.(aws.KMSClients); ok {
+			kmsc = kmsClients.KMSClient(nil, nil, nil)
+		} else if signingKeyId != "" {
+			return nil, errors.BadReleaseError{fmt.Sprintf("%v is set but KMS is not available", SigningKeyEnv)}
+		}
+
+		// Validate the Resources for the release in every region
+		if err := release.ValidateResourcesRegionsWithContext(ctx, awsc, kmsc, awsc.S3Client(nil, nil, nil), signingKeyId); err != nil {
 			return nil, errors.BadReleaseError{err.Error()}
 		}
 
+		release.ValidationMillis = elapsedMillis(release.ValidationMillis, start)
+
 		return release, nil
 	}
 }
@@ -80,24 +177,66 @@ func ValidateResourcesHandler(awsc aws.AwsClients) interface{} {
 func DeployHandler(awsc aws.AwsClients) interface{} {
 	return func(ctx context.Context, release *Release) (*Release, error) {
 
+		// Remember what was live so this release can be rolled back
+		if err := release.RecordPreviousRelease(awsc.S3Client(nil, nil, nil)); err != nil {
+			// ignore errors
+			fmt.Printf("Warning(RecordPreviousRelease) error ignored: %v\n", err.Error())
+		}
+
 		// Update Step Function first because State Machine if it fails we can recover
-		if err := release.DeployStepFunction(awsc.SFNClient(release.AwsRegion, release.AwsAccountID, assumed_role)); err != nil {
+		start := time.Now()
+		err := release.DeployStepFunctionRegionsWithContext(ctx, awsc)
+		release.StepDeployMillis = elapsedMillis(nil, start)
+		if err != nil {
 			return nil, DeploySFNError{err}
 		}
 
-		if err := release.DeployLambda(awsc.LambdaClient(release.AwsRegion, release.AwsAccountID, assumed_role), awsc.S3Client(nil, nil, nil)); err != nil {
+		// The Bucket is in the deployers region and account
+		bucketRegion, bucketAccount := to.AwsRegionAccountFromContext(ctx)
+		start = time.Now()
+		err = release.DeployLambdaRegionsWithContext(ctx, awsc, awsc.S3Client(nil, nil, nil), bucketRegion, bucketAccount)
+		release.LambdaDeployMillis = elapsedMillis(nil, start)
+		if err != nil {
+			// Goes straight to FailureDirty so ReleaseLockFailure will not notify or audit
+			writeAuditRecord(awsc, release)
+			notify(awsc, release, bifrost.NotifyFailed, DeployLambdaError{err})
 			return nil, DeployLambdaError{err}
 		}
 
+		if err := release.SmokeTestRegions(awsc); err != nil {
+			// Deployed but broken, goes straight to FailureDirty
+			writeAuditRecord(awsc, release)
+			notify(awsc, release, bifrost.NotifyFailed, SmokeTestError{err})
+			return nil, SmokeTestError{err}
+		}
+
 		release.Success = to.Boolp(true)
+
+		writeAuditRecord(awsc, release)
+
+		if release.DryRun {
+			// Nothing was deployed
+			release.UnlockRoot(awsc.S3Client(nil, nil, nil))
+			return release, nil
+		}
+
+		if err := release.RecordDeployedRelease(awsc.S3Client(nil, nil, nil)); err != nil {
+			// ignore errors
+			fmt.Printf("Warning(RecordDeployedRelease) error ignored: %v\n", err.Error())
+		}
+
 		release.UnlockRoot(awsc.S3Client(nil, nil, nil))
 
+		notify(awsc, release, bifrost.NotifySucceeded, nil)
+
 		return release, nil
 	}
 }
 
 func ReleaseLockFailureHandler(awsc aws.AwsClients) interface{} {
 	return func(ctx context.Context, release *Release) (*Release, error) {
+		writeAuditRecord(awsc, release)
+		notify(awsc, release, bifrost.NotifyFailed, nil)
 
 		if err := release.UnlockRoot(awsc.S3Client(nil, nil, nil)); err != nil {
 			return nil, errors.LockError{err.Error()}
@@ -106,3 +245,10 @@ func ReleaseLockFailureHandler(awsc aws.AwsClients) interface{} {
 		return release, nil
 	}
 }
+
+func writeAuditRecord(awsc aws.AwsClients, release *Release) {
+	if err := release.WriteAuditRecord(awsc.S3Client(nil, nil, nil)); err != nil {
+		// ignore errors
+		fmt.Printf("Warning(WriteAuditRecord) error ignored: %v\n", err.Error())
+	}
+}
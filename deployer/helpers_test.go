@@ -51,10 +51,19 @@ func MockAwsClients(r *Release) *mocks.MockClients {
 	lambda_zip_file_contents := "lambda_zip"
 	awsc.S3.AddGetObject(*r.LambdaZipPath(), lambda_zip_file_contents, nil)
 
-	if r.LambdaSHA256 == nil {
+	if r.LambdaSHA256 == nil && r.DeploysLambda() && len(r.Lambdas) == 0 {
 		r.LambdaSHA256 = to.Strp(to.SHA256Str(&lambda_zip_file_contents))
 	}
 
+	for i, spec := range r.Lambdas {
+		zip := fmt.Sprintf("%v_zip", to.Strs(spec.Name))
+		awsc.S3.AddGetObject(*r.ForLambda(spec).LambdaZipPath(), zip, nil)
+
+		if spec.SHA256 == nil {
+			r.Lambdas[i].SHA256 = to.Strp(to.SHA256Str(&zip))
+		}
+	}
+
 	raw, _ := json.Marshal(r)
 
 	account_id := r.AwsAccountID
@@ -0,0 +1,66 @@
+package deployer
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/coinbase/step/aws"
+	"github.com/coinbase/step/aws/s3"
+)
+
+// SkippedRelease is a release ListReleases could not read
+type SkippedRelease struct {
+	Key string
+	Err error
+}
+
+func (s SkippedRelease) String() string {
+	return fmt.Sprintf("%v: %v", s.Key, s.Err.Error())
+}
+
+// ListReleases returns up to limit releases for the project and config, newest first.
+// Releases that cannot be read are skipped and returned so callers can warn about them
+func (release *Release) ListReleases(s3c aws.S3API, limit int) ([]*Release, []SkippedRelease, error) {
+	root := fmt.Sprintf("%v/", *release.RootDir())
+
+	keys, err := s3.List(s3c, release.Bucket, &root)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	releases := []*Release{}
+	skipped := []SkippedRelease{}
+
+	for _, key := range keys {
+		// Only root/<release_id>/release
+		parts := strings.Split(strings.TrimPrefix(key, root), "/")
+		if len(parts) != 2 || parts[1] != "release" {
+			continue
+		}
+
+		var r Release
+		if err := s3.GetStruct(s3c, release.Bucket, &key, &r); err != nil {
+			skipped = append(skipped, SkippedRelease{Key: key, Err: err})
+			continue
+		}
+
+		releases = append(releases, &r)
+	}
+
+	sort.SliceStable(releases, func(i, j int) bool {
+		if releases[i].CreatedAt == nil {
+			return false
+		}
+		if releases[j].CreatedAt == nil {
+			return true
+		}
+		return releases[i].CreatedAt.After(*releases[j].CreatedAt)
+	})
+
+	if limit >= 0 && len(releases) > limit {
+		releases = releases[:limit]
+	}
+
+	return releases, skipped, nil
+}
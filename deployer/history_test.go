@@ -0,0 +1,47 @@
+package deployer
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/coinbase/step/aws/mocks"
+	"github.com/coinbase/step/utils/to"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Release_ListReleases(t *testing.T) {
+	s3c := &mocks.MockS3Client{}
+	release := MockRelease()
+
+	for i := 1; i <= 3; i++ {
+		r := MockRelease()
+		r.ReleaseID = to.Strp(fmt.Sprintf("release-%v", i))
+		r.CreatedAt = to.Timep(time.Now().Add(time.Duration(i) * time.Minute))
+		raw, _ := json.Marshal(r)
+		s3c.AddGetObject(*r.ReleasePath(), string(raw), nil)
+		s3c.AddGetObject(*r.LambdaZipPath(), "zip", nil)
+	}
+
+	// Bad release should be skipped
+	s3c.AddGetObject("00000000/project/development/release-bad/release", "not_json", nil)
+	// Other configs are not listed
+	s3c.AddGetObject("00000000/project/production/release-4/release", "{}", nil)
+
+	releases, skipped, err := release.ListReleases(s3c, 10)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, len(releases))
+	assert.Equal(t, "release-3", *releases[0].ReleaseID)
+	assert.Equal(t, "release-1", *releases[2].ReleaseID)
+
+	assert.Equal(t, 1, len(skipped))
+	assert.Equal(t, "00000000/project/development/release-bad/release", skipped[0].Key)
+	assert.Error(t, skipped[0].Err)
+	assert.Regexp(t, "^00000000/project/development/release-bad/release: ", skipped[0].String())
+
+	releases, _, err = release.ListReleases(s3c, 2)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(releases))
+	assert.Equal(t, "release-2", *releases[1].ReleaseID)
+}
@@ -0,0 +1,64 @@
+package deployer
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+
+	"github.com/aws/aws-sdk-go/service/lambda"
+	"github.com/coinbase/step/aws"
+	"github.com/coinbase/step/utils/is"
+	"github.com/coinbase/step/utils/to"
+)
+
+// ECR image URIs pinned by digest, e.g. 000000000000.dkr.ecr.us-east-1.amazonaws.com/repo@sha256:<hex>
+var imageUriRegexp = regexp.MustCompile(`^[0-9]{12}\.dkr\.ecr\.([a-z0-9-]+)\.amazonaws\.com(\.cn)?/[a-z0-9._/-]+@sha256:([0-9a-f]{64})$`)
+
+// IsImage returns true if the Lambda is deployed from a container image instead of a lambda.zip
+func (release *Release) IsImage() bool {
+	return !is.EmptyStr(release.ImageUri)
+}
+
+// ImageDigest returns the hex sha256 digest the ImageUri is pinned to
+func (release *Release) ImageDigest() (string, error) {
+	match := imageUriRegexp.FindStringSubmatch(to.Strs(release.ImageUri))
+	if match == nil {
+		return "", fmt.Errorf("ImageUri must be an ECR image pinned by digest e.g. <account>.dkr.ecr.<region>.amazonaws.com/<repo>@sha256:<digest>")
+	}
+
+	return match[3], nil
+}
+
+// ValidateImageDigest checks the ImageUri is pinned to a digest, so the deployed image cannot change,
+// and that it is in every region deployed to because Lambda only pulls images from its own region
+func (release *Release) ValidateImageDigest() error {
+	if _, err := release.ImageDigest(); err != nil {
+		return err
+	}
+
+	imageRegion := imageUriRegexp.FindStringSubmatch(*release.ImageUri)[1]
+	for _, region := range release.Regions() {
+		if to.Strs(region) != imageRegion {
+			return fmt.Errorf("ImageUri is in %v but Lambda is deployed to %v", imageRegion, to.Strs(region))
+		}
+	}
+
+	return nil
+}
+
+func (release *Release) deployLambdaImageInput() *lambda.UpdateFunctionCodeInput {
+	return &lambda.UpdateFunctionCodeInput{
+		FunctionName: release.LambdaArn(),
+		ImageUri:     release.ImageUri,
+	}
+}
+
+// DeployLambdaCodeFromImage points the Lambda at the ImageUri
+func (release *Release) DeployLambdaCodeFromImage(lambdaClient aws.LambdaAPI) error {
+	return release.DeployLambdaCodeFromImageWithContext(context.Background(), lambdaClient)
+}
+
+// DeployLambdaCodeFromImageWithContext is DeployLambdaCodeFromImage with ctx passed to the AWS calls
+func (release *Release) DeployLambdaCodeFromImageWithContext(ctx context.Context, lambdaClient aws.LambdaAPI) error {
+	return release.updateFunctionCode(ctx, lambdaClient, release.deployLambdaImageInput())
+}
@@ -0,0 +1,69 @@
+package deployer
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/coinbase/step/utils/to"
+	"github.com/stretchr/testify/assert"
+)
+
+var digest = strings.Repeat("a", 64)
+
+func mockImageRelease() *Release {
+	release := MockRelease()
+	release.AwsRegion = to.Strp("us-east-1")
+	release.LambdaSHA256 = nil
+	release.ImageUri = to.Strp("000000000000.dkr.ecr.us-east-1.amazonaws.com/project/lambda@sha256:" + digest)
+	return release
+}
+
+func Test_Release_Image_ValidateAttributes(t *testing.T) {
+	release := mockImageRelease()
+	assert.NoError(t, release.validateAttributes())
+
+	d, err := release.ImageDigest()
+	assert.NoError(t, err)
+	assert.Equal(t, digest, d)
+
+	// Exactly one of zip or image
+	release.LambdaSHA256 = to.Strp("sha")
+	assert.Regexp(t, "Exactly one of LambdaSHA256", release.validateAttributes().Error())
+
+	release.LambdaSHA256 = nil
+	release.ImageUri = nil
+	assert.Regexp(t, "Exactly one of LambdaSHA256", release.validateAttributes().Error())
+
+	// Tags can change so must be pinned by digest
+	release.ImageUri = to.Strp("000000000000.dkr.ecr.us-east-1.amazonaws.com/project/lambda:latest")
+	assert.Regexp(t, "pinned by digest", release.validateAttributes().Error())
+
+	// Lambda only pulls images from its own region
+	release = mockImageRelease()
+	release.AwsRegions = []*string{to.Strp("us-east-1"), to.Strp("us-west-2")}
+	assert.Regexp(t, "ImageUri is in us-east-1 but Lambda is deployed to us-west-2", release.validateAttributes().Error())
+}
+
+func Test_Release_Image_Deploy(t *testing.T) {
+	release := mockImageRelease()
+	awsc := MockAwsClients(release)
+	release.Bucket = to.Strp("bucket")
+
+	// No zip is needed
+	delete(awsc.S3.GetObjectResp, *release.LambdaZipPath())
+	assert.NoError(t, release.validateLambdaCode(awsc.S3))
+
+	assert.NoError(t, release.DeployLambdaRegions(awsc, awsc.S3, release.AwsRegion, release.AwsAccountID))
+	assert.Equal(t, 1, len(awsc.Lambda.UpdateFunctionCodeInputs))
+	assert.Equal(t, *release.ImageUri, to.Strs(awsc.Lambda.UpdateFunctionCodeInputs[0].ImageUri))
+	assert.Nil(t, awsc.Lambda.UpdateFunctionCodeInputs[0].ZipFile)
+
+	input, err := release.DeployLambdaDryRun(awsc.S3)
+	assert.NoError(t, err)
+	assert.Equal(t, *release.ImageUri, to.Strs(input.ImageUri))
+
+	// Signatures are for zips
+	err = release.ValidateResources(awsc.Lambda, awsc.SFN, awsc.KMS, awsc.S3, "key")
+	assert.Error(t, err)
+	assert.Regexp(t, "only supported for lambda.zip", err.Error())
+}
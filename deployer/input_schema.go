@@ -0,0 +1,45 @@
+package deployer
+
+import (
+	"fmt"
+
+	"github.com/coinbase/step/utils/schema"
+)
+
+// ValidateInputSchema checks sample is a valid input for the State Machine by InputSchema,
+// without an InputSchema every input is valid
+func (release *Release) ValidateInputSchema(sample []byte) error {
+	if release.InputSchema == nil {
+		return nil
+	}
+
+	s, err := schema.Parse([]byte(*release.InputSchema))
+	if err != nil {
+		return fmt.Errorf("InputSchema invalid with '%v'", err.Error())
+	}
+
+	if err := s.Validate(sample); err != nil {
+		return fmt.Errorf("Input does not match InputSchema: %v", err.Error())
+	}
+
+	return nil
+}
+
+// validateInputSchema checks InputSchema parses and the SmokeTestInput matches it
+func (release *Release) validateInputSchema() error {
+	if release.InputSchema == nil {
+		return nil
+	}
+
+	if _, err := schema.Parse([]byte(*release.InputSchema)); err != nil {
+		return fmt.Errorf("InputSchema invalid with '%v'", err.Error())
+	}
+
+	if release.SmokeTestInput != nil {
+		if err := release.ValidateInputSchema([]byte(*release.SmokeTestInput)); err != nil {
+			return fmt.Errorf("SmokeTestInput invalid: %v", err.Error())
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,54 @@
+package deployer
+
+import (
+	"testing"
+	"time"
+
+	"github.com/coinbase/step/utils/to"
+	"github.com/stretchr/testify/assert"
+)
+
+var inputSchema = `{
+	"type": "object",
+	"required": ["env"],
+	"properties": {"env": {"type": "string"}, "count": {"type": "integer"}}
+}`
+
+func Test_Release_ValidateInputSchema(t *testing.T) {
+	release := MockRelease()
+	assert.NoError(t, release.ValidateInputSchema([]byte(`"anything"`)))
+
+	release.InputSchema = to.Strp(inputSchema)
+	assert.NoError(t, release.ValidateInputSchema([]byte(`{"env": "prod", "count": 1}`)))
+
+	err := release.ValidateInputSchema([]byte(`{"count": "1"}`))
+	assert.Error(t, err)
+	assert.Regexp(t, `Input does not match InputSchema`, err.Error())
+	assert.Regexp(t, `\$.env is required`, err.Error())
+	assert.Regexp(t, `\$.count expected integer got string`, err.Error())
+}
+
+func Test_Release_InputSchema_ValidateAttributes(t *testing.T) {
+	release := MockRelease()
+	release.LambdaSHA256 = to.Strp(to.SHA256Str(to.Strp("zip")))
+	release.InputSchema = to.Strp(inputSchema)
+	release.SmokeTestInput = to.Strp(`{"env": "prod"}`)
+	assert.NoError(t, release.validateAttributes())
+
+	release.SmokeTestInput = to.Strp(`{}`)
+	assert.Regexp(t, "SmokeTestInput invalid", release.validateAttributes().Error())
+
+	release.InputSchema = to.Strp(`{"type": 1}`)
+	assert.Regexp(t, "InputSchema invalid", release.validateAttributes().Error())
+}
+
+func Test_Release_InputSchema_SmokeTest(t *testing.T) {
+	release := MockRelease()
+	release.InputSchema = to.Strp(inputSchema)
+	awsc := MockAwsClients(release)
+
+	err := release.SmokeTest(awsc.SFN, `{}`, time.Second)
+	assert.Error(t, err)
+	assert.Regexp(t, "Smoke Test Error: Input does not match InputSchema", err.Error())
+	assert.Nil(t, awsc.SFN.StartExecutionInput)
+}
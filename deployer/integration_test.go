@@ -1,12 +1,16 @@
 package deployer
 
 import (
+	"encoding/json"
 	"fmt"
+	"os"
 	"testing"
 	"time"
 
 	"github.com/aws/aws-sdk-go/service/lambda"
 	"github.com/aws/aws-sdk-go/service/sfn"
+	"github.com/coinbase/step/aws/mocks"
+	"github.com/coinbase/step/bifrost"
 	"github.com/coinbase/step/utils/to"
 	"github.com/stretchr/testify/assert"
 )
@@ -53,6 +57,26 @@ func Test_DeployHandler_Execution_NoUUIDorSHA_Override(t *testing.T) {
 	assert.NotEqual(t, output["release_sha256"], "badString")
 }
 
+func Test_DeployHandler_Execution_Timings(t *testing.T) {
+	release := MockRelease()
+	release.LambdaDeployMillis = to.Int64p(999999)
+
+	awsc := MockAwsClients(release)
+	state_machine := createTestStateMachine(t, awsc)
+
+	exec, err := state_machine.Execute(release)
+	output := exec.Output
+	assert.NoError(t, err)
+	assert.Equal(t, output["success"], true)
+
+	// Set by the deployer not the client
+	for _, key := range []string{"validation_millis", "step_deploy_millis", "lambda_deploy_millis"} {
+		millis, ok := output[key].(float64)
+		assert.True(t, ok, key)
+		assert.True(t, millis >= 0 && millis < 999999, key)
+	}
+}
+
 /////////
 // UNHAPPY PATH :(
 /////////
@@ -276,7 +300,7 @@ func Test_DeployHandler_Execution_Errors_WrongSFNPath(t *testing.T) {
 
 func Test_DeployHandler_Execution_Errors_BadLambdaSHA(t *testing.T) {
 	release := MockRelease()
-	release.LambdaSHA256 = to.Strp("wrongsha")
+	release.LambdaSHA256 = to.Strp(to.SHA256Str(to.Strp("wrongzip")))
 
 	awsc := MockAwsClients(release)
 
@@ -354,6 +378,90 @@ func Test_DeployHandler_Execution_Errors_DifferentReleaseSHA(t *testing.T) {
 	}, exec.Path())
 }
 
+func Test_DeployHandler_Execution_Errors_ChangedGitSHA(t *testing.T) {
+	release := MockRelease()
+	release.GitSHA = to.Strp("abcdef0")
+	awsc := MockAwsClients(release)
+
+	// Provenance is part of the Release SHA
+	release.GitSHA = to.Strp("abcdef1")
+	state_machine := createTestStateMachine(t, awsc)
+
+	exec, err := state_machine.Execute(release)
+	assert.Error(t, err)
+	assert.Regexp(t, "Release SHA", exec.LastOutputJSON)
+}
+
+func Test_DeployHandler_Execution_Errors_NotAllowed(t *testing.T) {
+	os.Setenv(AllowedProjectsEnv, `{"project": ["production"]}`)
+	defer os.Unsetenv(AllowedProjectsEnv)
+
+	release := MockRelease()
+	awsc := MockAwsClients(release)
+	state_machine := createTestStateMachine(t, awsc)
+
+	exec, err := state_machine.Execute(release)
+	assert.Error(t, err)
+	assert.Regexp(t, "BadReleaseError", exec.LastOutputJSON)
+	assert.Regexp(t, "is not allowed for project", exec.LastOutputJSON)
+	assertNoRootLock(t, awsc, release)
+
+	os.Setenv(AllowedProjectsEnv, fmt.Sprintf(`{"project": [%q]}`, *release.ConfigName))
+	release = MockRelease()
+	awsc = MockAwsClients(release)
+	state_machine = createTestStateMachine(t, awsc)
+
+	_, err = state_machine.Execute(release)
+	assert.NoError(t, err)
+}
+
+func Test_DeployHandler_Execution_Errors_RequireGitSHA(t *testing.T) {
+	os.Setenv(RequireGitSHAEnv, "true")
+	defer os.Unsetenv(RequireGitSHAEnv)
+
+	release := MockRelease()
+	awsc := MockAwsClients(release)
+	state_machine := createTestStateMachine(t, awsc)
+
+	exec, err := state_machine.Execute(release)
+	assert.Error(t, err)
+	assert.Regexp(t, "BadReleaseError", exec.LastOutputJSON)
+	assert.Regexp(t, "GitSHA must be defined", exec.LastOutputJSON)
+	assertNoRootLock(t, awsc, release)
+
+	release = MockRelease()
+	release.GitSHA = to.Strp("abcdef0")
+	awsc = MockAwsClients(release)
+	state_machine = createTestStateMachine(t, awsc)
+
+	_, err = state_machine.Execute(release)
+	assert.NoError(t, err)
+}
+
+func Test_DeployHandler_Execution_Errors_ArtifactBucket(t *testing.T) {
+	release := MockRelease()
+	release.ArtifactBucket = to.Strp("artifacts")
+	awsc := MockAwsClients(release)
+	state_machine := createTestStateMachine(t, awsc)
+
+	exec, err := state_machine.Execute(release)
+	assert.Error(t, err)
+	assert.Regexp(t, "BadReleaseError", exec.LastOutputJSON)
+	assert.Regexp(t, "ArtifactBucket artifacts is not readable", exec.LastOutputJSON)
+	assertNoRootLock(t, awsc, release)
+
+	os.Setenv(ArtifactBucketEnv, "artifacts")
+	defer os.Unsetenv(ArtifactBucketEnv)
+
+	release = MockRelease()
+	release.ArtifactBucket = to.Strp("artifacts")
+	awsc = MockAwsClients(release)
+	state_machine = createTestStateMachine(t, awsc)
+
+	_, err = state_machine.Execute(release)
+	assert.NoError(t, err)
+}
+
 // Upload Errors
 func Test_DeployHandler_Execution_Errors_DeploySFNError(t *testing.T) {
 	release := MockRelease()
@@ -400,3 +508,83 @@ func Test_DeployHandler_Execution_Errors_DeployLambdaError(t *testing.T) {
 		"FailureDirty",
 	}, exec.Path())
 }
+
+/////////
+// NOTIFICATIONS
+/////////
+
+func notifiedEvents(awsc *mocks.MockClients) []string {
+	events := []string{}
+	for _, in := range awsc.SNS.Published {
+		var msg bifrost.NotifyMessage
+		json.Unmarshal([]byte(*in.Message), &msg)
+		events = append(events, msg.Event)
+	}
+	return events
+}
+
+func Test_DeployHandler_Execution_Notify_Succeeded(t *testing.T) {
+	os.Setenv(NotifyTopicEnv, "arn:aws:sns:us-east-1:000000000000:topic")
+	defer os.Unsetenv(NotifyTopicEnv)
+
+	release := MockRelease()
+	awsc := MockAwsClients(release)
+	state_machine := createTestStateMachine(t, awsc)
+
+	_, err := state_machine.Execute(release)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"started", "succeeded"}, notifiedEvents(awsc))
+}
+
+func Test_DeployHandler_Execution_Notify_Failed(t *testing.T) {
+	os.Setenv(NotifyTopicEnv, "arn:aws:sns:us-east-1:000000000000:topic")
+	defer os.Unsetenv(NotifyTopicEnv)
+
+	release := MockRelease()
+	awsc := MockAwsClients(release)
+	awsc.Lambda.UpdateFunctionCodeError = fmt.Errorf("AWSLambdaError")
+	state_machine := createTestStateMachine(t, awsc)
+
+	_, err := state_machine.Execute(release)
+	assert.Error(t, err)
+	assert.Equal(t, []string{"started", "failed"}, notifiedEvents(awsc))
+	assert.Regexp(t, "AWSLambdaError", *awsc.SNS.Published[1].Message)
+
+	// Lock exists fails before any deploy
+	awsc = MockAwsClients(release)
+	awsc.S3.AddGetObject(*release.RootLockPath(), `{"uuid":"notuuid"}`, nil)
+	state_machine = createTestStateMachine(t, awsc)
+
+	_, err = state_machine.Execute(release)
+	assert.Error(t, err)
+	assert.Equal(t, []string{"started", "failed"}, notifiedEvents(awsc))
+}
+
+func Test_DeployHandler_Execution_Notify_Disabled(t *testing.T) {
+	release := MockRelease()
+	awsc := MockAwsClients(release)
+	state_machine := createTestStateMachine(t, awsc)
+
+	_, err := state_machine.Execute(release)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, len(awsc.SNS.Published))
+}
+
+func Test_DeployHandler_Execution_Errors_ReplayError(t *testing.T) {
+	release := MockRelease()
+	awsc := MockAwsClients(release)
+	awsc.S3.AddGetObject(*release.NoncePath(), `{"uuid":"previous-execution"}`, nil)
+	state_machine := createTestStateMachine(t, awsc)
+
+	exec, err := state_machine.Execute(release)
+
+	assert.Error(t, err)
+	assert.Regexp(t, "ReplayError", exec.LastOutputJSON)
+	assert.Equal(t, []string{
+		"Validate",
+		"Lock",
+		"ReleaseLockFailure",
+		"FailureClean",
+	}, exec.Path())
+	assertNoRootLockWithReleseLock(t, awsc, release)
+}
@@ -0,0 +1,191 @@
+package deployer
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go/service/lambda"
+	"github.com/coinbase/step/aws"
+	"github.com/coinbase/step/utils/to"
+)
+
+// reservedLambdaEnvironment are the keys Lambda sets and will not allow to be overridden
+var reservedLambdaEnvironment = map[string]bool{
+	"_HANDLER":                        true,
+	"_X_AMZN_TRACE_ID":                true,
+	"AWS_REGION":                      true,
+	"AWS_DEFAULT_REGION":              true,
+	"AWS_EXECUTION_ENV":               true,
+	"AWS_LAMBDA_FUNCTION_NAME":        true,
+	"AWS_LAMBDA_FUNCTION_MEMORY_SIZE": true,
+	"AWS_LAMBDA_FUNCTION_VERSION":     true,
+	"AWS_LAMBDA_LOG_GROUP_NAME":       true,
+	"AWS_LAMBDA_LOG_STREAM_NAME":      true,
+	"AWS_LAMBDA_RUNTIME_API":          true,
+	"AWS_ACCESS_KEY":                  true,
+	"AWS_ACCESS_KEY_ID":               true,
+	"AWS_SECRET_ACCESS_KEY":           true,
+	"AWS_SESSION_TOKEN":               true,
+	"LAMBDA_TASK_ROOT":                true,
+	"LAMBDA_RUNTIME_DIR":              true,
+	"TZ":                              true,
+}
+
+func (release *Release) validateLambdaEnvironment() error {
+	for key, value := range release.LambdaEnvironment {
+		if reservedLambdaEnvironment[key] {
+			return fmt.Errorf("LambdaEnvironment cannot set reserved key %v", key)
+		}
+
+		if value == nil {
+			return fmt.Errorf("LambdaEnvironment value for %v must be defined", key)
+		}
+	}
+
+	return nil
+}
+
+// DeployLambdaConfiguration updates the Lambda Environment Variables, MemorySize, Timeout and Layers that are
+// defined in one UpdateFunctionConfiguration, then waits for the update to finish. It does nothing if none are defined
+func (release *Release) DeployLambdaConfiguration(lambdaClient aws.LambdaAPI) error {
+	return release.DeployLambdaConfigurationWithContext(context.Background(), lambdaClient)
+}
+
+// DeployLambdaConfigurationWithContext is DeployLambdaConfiguration with ctx passed to the wait
+func (release *Release) DeployLambdaConfigurationWithContext(ctx context.Context, lambdaClient aws.LambdaAPI) error {
+	layers := release.deployedLayers()
+	if release.LambdaEnvironment == nil && release.LambdaMemorySize == nil && release.LambdaTimeout == nil && layers == nil {
+		return nil
+	}
+
+	input := &lambda.UpdateFunctionConfigurationInput{
+		FunctionName: release.LambdaArn(),
+		MemorySize:   release.LambdaMemorySize,
+		Timeout:      release.LambdaTimeout,
+		Layers:       layers,
+	}
+
+	if release.LambdaEnvironment != nil {
+		input.Environment = &lambda.Environment{Variables: release.LambdaEnvironment}
+	}
+
+	if _, err := lambdaClient.UpdateFunctionConfiguration(input); err != nil {
+		return err
+	}
+
+	// Publishing a version while the update is in progress fails with ResourceConflictException
+	return release.WaitForFunctionUpdated(ctx, lambdaClient)
+}
+
+func (release *Release) validateLambdaSettings() error {
+	if m := release.LambdaMemorySize; m != nil && (*m < 128 || *m > 10240) {
+		return fmt.Errorf("LambdaMemorySize must be between 128 and 10240, got %v", *m)
+	}
+
+	if t := release.LambdaTimeout; t != nil && (*t < 1 || *t > 900) {
+		return fmt.Errorf("LambdaTimeout must be between 1 and 900, got %v", *t)
+	}
+
+	if c := release.LambdaReservedConcurrentExecutions; c != nil && *c < 0 {
+		return fmt.Errorf("LambdaReservedConcurrentExecutions must not be negative, got %v", *c)
+	}
+
+	return release.validateLayers()
+}
+
+// maxLambdaLayers is the most layers a Lambda can use
+const maxLambdaLayers = 5
+
+// deployedLayers returns the layers sent to UpdateFunctionConfiguration,
+// an empty list if ClearLayers and nil if the layers are unchanged
+func (release *Release) deployedLayers() []*string {
+	if release.ClearLayers {
+		return []*string{}
+	}
+
+	if len(release.Layers) == 0 {
+		return nil
+	}
+
+	return release.Layers
+}
+
+func (release *Release) validateLayers() error {
+	if release.ClearLayers && len(release.Layers) != 0 {
+		return fmt.Errorf("ClearLayers cannot be used with Layers")
+	}
+
+	if release.deployedLayers() == nil {
+		return nil
+	}
+
+	if release.IsImage() {
+		return fmt.Errorf("Layers cannot be used with ImageUri")
+	}
+
+	if len(release.Layers) > maxLambdaLayers {
+		return fmt.Errorf("Layers must have at most %v layers, got %v", maxLambdaLayers, len(release.Layers))
+	}
+
+	for i, layer := range release.Layers {
+		if layer == nil {
+			return fmt.Errorf("Layers[%v] must be defined", i)
+		}
+
+		if _, err := to.ParseLayerVersionArn(*layer); err != nil {
+			return fmt.Errorf("Layers[%v] %v", i, err)
+		}
+	}
+
+	return nil
+}
+
+// DeployLambdaSettings updates the Lambda Reserved Concurrency if it is defined,
+// the other settings are updated with the configuration by DeployLambdaConfiguration
+func (release *Release) DeployLambdaSettings(lambdaClient aws.LambdaAPI) error {
+	if release.LambdaReservedConcurrentExecutions == nil {
+		return nil
+	}
+
+	_, err := lambdaClient.PutFunctionConcurrency(&lambda.PutFunctionConcurrencyInput{
+		FunctionName:                 release.LambdaArn(),
+		ReservedConcurrentExecutions: release.LambdaReservedConcurrentExecutions,
+	})
+
+	return err
+}
+
+// ValidateLambdaRuntime errors if the Runtime of any deployed Lambda is in deprecated, e.g. "nodejs12.x"
+func (release *Release) ValidateLambdaRuntime(lambdac aws.LambdaAPI, deprecated []string) error {
+	if !release.DeploysLambda() || len(deprecated) == 0 {
+		return nil
+	}
+
+	return release.eachLambda(func(l *Release) error {
+		out, err := lambdac.GetFunctionConfiguration(&lambda.GetFunctionConfigurationInput{
+			FunctionName: l.LambdaArn(),
+		})
+
+		if err != nil {
+			return err
+		}
+
+		if out == nil {
+			return fmt.Errorf("Unknown Lambda GetFunctionConfiguration Error")
+		}
+
+		runtime := to.Strs(out.Runtime)
+		if runtime == "" {
+			// Image Lambdas have no Runtime
+			return nil
+		}
+
+		for _, d := range deprecated {
+			if runtime == d {
+				return fmt.Errorf("Lambda runtime %v is deprecated", runtime)
+			}
+		}
+
+		return nil
+	})
+}
@@ -0,0 +1,212 @@
+package deployer
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/coinbase/step/aws/mocks"
+	"github.com/coinbase/step/utils/to"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Release_DeployLambdaConfiguration(t *testing.T) {
+	lambdaClient := &mocks.MockLambdaClient{}
+	r := MockRelease()
+
+	// No-op
+	assert.NoError(t, r.DeployLambdaConfiguration(lambdaClient))
+	assert.Nil(t, lambdaClient.UpdateFunctionConfigurationInput)
+
+	r.LambdaEnvironment = map[string]*string{"LOG_LEVEL": to.Strp("debug")}
+	assert.NoError(t, r.DeployLambdaConfiguration(lambdaClient))
+	assert.Equal(t, "debug", *lambdaClient.UpdateFunctionConfigurationInput.Environment.Variables["LOG_LEVEL"])
+	assert.Equal(t, *r.LambdaArn(), *lambdaClient.UpdateFunctionConfigurationInput.FunctionName)
+}
+
+func Test_Release_Validate_LambdaEnvironment(t *testing.T) {
+	r := MockRelease()
+	r.LambdaEnvironment = map[string]*string{"LOG_LEVEL": to.Strp("debug")}
+	assert.NoError(t, r.validateLambdaEnvironment())
+
+	r.LambdaEnvironment = map[string]*string{"AWS_REGION": to.Strp("us-east-1")}
+	err := r.validateLambdaEnvironment()
+	assert.Error(t, err)
+	assert.Regexp(t, "reserved key AWS_REGION", err.Error())
+}
+
+func Test_Release_DeployLambdaConfiguration_Settings(t *testing.T) {
+	defer func(d time.Duration) { LambdaUpdatePollInterval = d }(LambdaUpdatePollInterval)
+	LambdaUpdatePollInterval = time.Millisecond
+
+	lambdaClient := &mocks.MockLambdaClient{}
+	r := MockRelease()
+
+	r.LambdaEnvironment = map[string]*string{"LOG_LEVEL": to.Strp("debug")}
+	r.LambdaMemorySize = to.Int64p(512)
+	r.LambdaTimeout = to.Int64p(60)
+	lambdaClient.LastUpdateStatuses = []string{"InProgress"}
+
+	// Sent in one update that is waited on
+	assert.NoError(t, r.DeployLambdaConfiguration(lambdaClient))
+	assert.Equal(t, 1, lambdaClient.UpdateFunctionConfigurationCalls)
+	assert.Equal(t, "debug", *lambdaClient.UpdateFunctionConfigurationInput.Environment.Variables["LOG_LEVEL"])
+	assert.Equal(t, int64(512), *lambdaClient.UpdateFunctionConfigurationInput.MemorySize)
+	assert.Equal(t, int64(60), *lambdaClient.UpdateFunctionConfigurationInput.Timeout)
+	assert.Nil(t, lambdaClient.UpdateFunctionConfigurationInput.Layers)
+	assert.Empty(t, lambdaClient.LastUpdateStatuses)
+
+	// Settings without Environment leave it unchanged
+	r.LambdaEnvironment = nil
+	assert.NoError(t, r.DeployLambdaConfiguration(lambdaClient))
+	assert.Nil(t, lambdaClient.UpdateFunctionConfigurationInput.Environment)
+
+	lambdaClient.LastUpdateStatuses = []string{"Failed"}
+	assert.Error(t, r.DeployLambdaConfiguration(lambdaClient))
+}
+
+func Test_Release_DeployLambdaSettings(t *testing.T) {
+	lambdaClient := &mocks.MockLambdaClient{}
+	r := MockRelease()
+
+	// No-op
+	assert.NoError(t, r.DeployLambdaSettings(lambdaClient))
+	assert.Nil(t, lambdaClient.PutFunctionConcurrencyInput)
+
+	r.LambdaMemorySize = to.Int64p(512)
+	r.LambdaReservedConcurrentExecutions = to.Int64p(5)
+	assert.NoError(t, r.DeployLambdaSettings(lambdaClient))
+	assert.Equal(t, int64(5), *lambdaClient.PutFunctionConcurrencyInput.ReservedConcurrentExecutions)
+	assert.Nil(t, lambdaClient.UpdateFunctionConfigurationInput)
+}
+
+func Test_Release_DeployLambdaConfiguration_Layers(t *testing.T) {
+	lambdaClient := &mocks.MockLambdaClient{}
+	r := MockRelease()
+
+	r.Layers = []*string{to.Strp("arn:aws:lambda:us-east-1:000000000000:layer:shared:3")}
+	assert.NoError(t, r.DeployLambdaConfiguration(lambdaClient))
+	assert.Equal(t, r.Layers, lambdaClient.UpdateFunctionConfigurationInput.Layers)
+	assert.Nil(t, lambdaClient.UpdateFunctionConfigurationInput.MemorySize)
+
+	// An empty list leaves the layers unchanged
+	lambdaClient.UpdateFunctionConfigurationInput = nil
+	r.Layers = []*string{}
+	assert.NoError(t, r.DeployLambdaConfiguration(lambdaClient))
+	assert.Nil(t, lambdaClient.UpdateFunctionConfigurationInput)
+
+	// ClearLayers removes the layers
+	r.Layers = nil
+	r.ClearLayers = true
+	assert.NoError(t, r.DeployLambdaConfiguration(lambdaClient))
+	assert.Equal(t, []*string{}, lambdaClient.UpdateFunctionConfigurationInput.Layers)
+
+	lambdaClient.UpdateFunctionConfigurationError = fmt.Errorf("InvalidParameterValueException")
+	assert.Error(t, r.DeployLambdaConfiguration(lambdaClient))
+}
+
+func Test_Release_Validate_LambdaSettings(t *testing.T) {
+	r := MockRelease()
+	assert.NoError(t, r.validateLambdaSettings())
+
+	r.LambdaMemorySize = to.Int64p(64)
+	assert.Error(t, r.validateLambdaSettings())
+
+	r.LambdaMemorySize = to.Int64p(10240)
+	assert.NoError(t, r.validateLambdaSettings())
+
+	r.LambdaTimeout = to.Int64p(901)
+	assert.Error(t, r.validateLambdaSettings())
+
+	r.LambdaTimeout = to.Int64p(0)
+	assert.Error(t, r.validateLambdaSettings())
+}
+
+func Test_Release_Validate_Layers(t *testing.T) {
+	r := MockRelease()
+	layer := to.Strp("arn:aws:lambda:us-east-1:000000000000:layer:shared:3")
+
+	r.Layers = []*string{}
+	assert.NoError(t, r.validateLambdaSettings())
+
+	r.Layers = []*string{layer}
+	assert.NoError(t, r.validateLambdaSettings())
+
+	r.Layers = []*string{layer, to.Strp("arn:aws:lambda:us-east-1:000000000000:layer:shared")}
+	err := r.validateLambdaSettings()
+	assert.Error(t, err)
+	assert.Regexp(t, `Layers\[1\] .* is not a Lambda layer version ARN`, err.Error())
+
+	r.Layers = []*string{nil}
+	assert.Error(t, r.validateLambdaSettings())
+
+	r.Layers = []*string{layer, layer, layer, layer, layer, layer}
+	err = r.validateLambdaSettings()
+	assert.Error(t, err)
+	assert.Regexp(t, "Layers must have at most 5 layers, got 6", err.Error())
+
+	r.ClearLayers = true
+	assert.Regexp(t, "ClearLayers cannot be used with Layers", r.validateLambdaSettings().Error())
+
+	r.Layers = nil
+	assert.NoError(t, r.validateLambdaSettings())
+
+	r.ImageUri = to.Strp("000000000000.dkr.ecr.us-east-1.amazonaws.com/repo@sha256:abc")
+	assert.Error(t, r.validateLambdaSettings())
+
+	r.ClearLayers = false
+	r.Layers = []*string{layer}
+	assert.Error(t, r.validateLambdaSettings())
+}
+
+func Test_DeployHandler_Execution_ClearLayers(t *testing.T) {
+	release := MockRelease()
+	release.ClearLayers = true
+	awsc := MockAwsClients(release)
+
+	state_machine := createTestStateMachine(t, awsc)
+
+	// ClearLayers survives the JSON between the states to remove every layer
+	exec, err := state_machine.Execute(release)
+	assert.NoError(t, err)
+	assert.Equal(t, true, exec.Output["clear_layers"])
+	assert.Equal(t, []*string{}, awsc.Lambda.UpdateFunctionConfigurationInput.Layers)
+}
+
+func Test_Release_ValidateLambdaRuntime(t *testing.T) {
+	lambdaClient := &mocks.MockLambdaClient{Runtime: to.Strp("go1.x")}
+	r := MockRelease()
+
+	assert.NoError(t, r.ValidateLambdaRuntime(lambdaClient, nil))
+	assert.NoError(t, r.ValidateLambdaRuntime(lambdaClient, []string{"nodejs12.x"}))
+
+	err := r.ValidateLambdaRuntime(lambdaClient, []string{"nodejs12.x", "go1.x"})
+	assert.Error(t, err)
+	assert.Regexp(t, "Lambda runtime go1.x is deprecated", err.Error())
+
+	// Image Lambdas have no Runtime
+	lambdaClient.Runtime = nil
+	assert.NoError(t, r.ValidateLambdaRuntime(lambdaClient, []string{"go1.x"}))
+
+	// StepOnly releases deploy no Lambda
+	lambdaClient.Runtime = to.Strp("go1.x")
+	r.StepOnly = true
+	assert.NoError(t, r.ValidateLambdaRuntime(lambdaClient, []string{"go1.x"}))
+	r.StepOnly = false
+
+	lambdaClient.GetFunctionConfigurationError = fmt.Errorf("ResourceNotFoundException")
+	assert.Error(t, r.ValidateLambdaRuntime(lambdaClient, []string{"go1.x"}))
+}
+
+func Test_Release_ValidateLambdaRuntime_Lambdas(t *testing.T) {
+	lambdaClient := &mocks.MockLambdaClient{Runtime: to.Strp("python3.6")}
+	r := MockRelease()
+	r.Lambdas = []LambdaSpec{
+		{Name: to.Strp("first"), SHA256: r.LambdaSHA256},
+		{Name: to.Strp("second"), SHA256: r.LambdaSHA256},
+	}
+
+	err := r.ValidateLambdaRuntime(lambdaClient, []string{"python3.6"})
+	assert.Error(t, err)
+	assert.Regexp(t, "Lambda first: Lambda runtime python3.6 is deprecated", err.Error())
+}
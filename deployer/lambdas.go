@@ -0,0 +1,122 @@
+package deployer
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/coinbase/step/utils/is"
+	"github.com/coinbase/step/utils/to"
+)
+
+// LambdaSpec is one of the Lambdas a release deploys, all of them share the release's Lambda settings
+type LambdaSpec struct {
+	Name   *string `json:"name,omitempty"`   // Lambda Name
+	SHA256 *string `json:"sha256,omitempty"` // Lambda SHA256 Zip file
+
+	// Key of the zip relative to the release directory, defaults to <Name>.zip
+	ZipPath *string `json:"zip_path,omitempty"`
+
+	// S3 Version of the zip whose SHA was validated, set by the deployer not the client
+	ZipVersion *string `json:"zip_version,omitempty"`
+}
+
+// zipName returns ZipPath, defaulting to <Name>.zip
+func (spec *LambdaSpec) zipName() string {
+	if is.EmptyStr(spec.ZipPath) {
+		return fmt.Sprintf("%v.zip", to.Strs(spec.Name))
+	}
+	return *spec.ZipPath
+}
+
+// LambdaSpecs returns the Lambdas to deploy, Lambdas if defined otherwise
+// a single Lambda from LambdaName, LambdaSHA256 and the lambda.zip
+func (release *Release) LambdaSpecs() []LambdaSpec {
+	if len(release.Lambdas) != 0 {
+		return release.Lambdas
+	}
+
+	return []LambdaSpec{{
+		Name:       release.LambdaName,
+		SHA256:     release.LambdaSHA256,
+		ZipPath:    to.Strp("lambda.zip"),
+		ZipVersion: release.LambdaZipVersion,
+	}}
+}
+
+// ForLambda returns a copy of the release that deploys only the Lambda spec
+func (release *Release) ForLambda(spec LambdaSpec) *Release {
+	r := *release
+	r.Lambdas = nil
+	r.LambdaName = spec.Name
+	r.LambdaSHA256 = spec.SHA256
+	r.LambdaZipVersion = spec.ZipVersion
+	r.lambdaZipName = to.Strp(spec.zipName())
+
+	return &r
+}
+
+// eachLambda calls fn with the release for every Lambda, stopping at the first error.
+// The validated ZipVersion of every Lambda is recorded back on the release
+func (release *Release) eachLambda(fn func(*Release) error) error {
+	if len(release.Lambdas) == 0 {
+		return fn(release)
+	}
+
+	for i, spec := range release.Lambdas {
+		r := release.ForLambda(spec)
+		err := fn(r)
+		release.Lambdas[i].ZipVersion = r.LambdaZipVersion
+
+		if err != nil {
+			return fmt.Errorf("Lambda %v: %w", to.Strs(spec.Name), err)
+		}
+	}
+
+	return nil
+}
+
+// reservedReleaseFiles are written to the release directory by the client and deployer
+var reservedReleaseFiles = map[string]bool{"release": true, "lock": true, "log": true}
+
+// validateLambdas checks Lambdas are not mixed with the single Lambda fields,
+// and each has a unique name and a zip inside the release directory
+func (release *Release) validateLambdas() error {
+	if len(release.Lambdas) == 0 {
+		return nil
+	}
+
+	if !is.EmptyStr(release.LambdaName) || !is.EmptyStr(release.LambdaSHA256) || !is.EmptyStr(release.ImageUri) {
+		return fmt.Errorf("Lambdas cannot be combined with LambdaName, LambdaSHA256 or ImageUri")
+	}
+
+	if !is.EmptyStr(release.ExpectedLiveLambdaSHA) {
+		return fmt.Errorf("Lambdas cannot be combined with ExpectedLiveLambdaSHA")
+	}
+
+	names := map[string]bool{}
+	zips := map[string]bool{}
+
+	for _, spec := range release.Lambdas {
+		if is.EmptyStr(spec.Name) {
+			return fmt.Errorf("Lambdas name must be defined")
+		}
+
+		if names[*spec.Name] {
+			return fmt.Errorf("Lambdas name %v is not unique", *spec.Name)
+		}
+		names[*spec.Name] = true
+
+		zipName := spec.zipName()
+		if path.IsAbs(zipName) || path.Clean(zipName) != zipName || strings.HasPrefix(zipName, "..") || reservedReleaseFiles[zipName] {
+			return fmt.Errorf("Lambda %v ZipPath %q must be a file in the release directory", *spec.Name, zipName)
+		}
+
+		if zips[zipName] {
+			return fmt.Errorf("Lambda %v ZipPath %q is not unique", *spec.Name, zipName)
+		}
+		zips[zipName] = true
+	}
+
+	return nil
+}
@@ -0,0 +1,115 @@
+package deployer
+
+import (
+	"testing"
+
+	"github.com/coinbase/step/utils/to"
+	"github.com/stretchr/testify/assert"
+)
+
+func MockLambdasRelease() *Release {
+	release := MockRelease()
+	release.LambdaName = nil
+	release.Lambdas = []LambdaSpec{
+		{Name: to.Strp("api")},
+		{Name: to.Strp("worker"), ZipPath: to.Strp("worker/code.zip")},
+	}
+	return release
+}
+
+func Test_Release_LambdaSpecs(t *testing.T) {
+	release := MockRelease()
+	specs := release.LambdaSpecs()
+	assert.Equal(t, 1, len(specs))
+	assert.Equal(t, "lambdaname", to.Strs(specs[0].Name))
+	assert.Equal(t, *release.LambdaZipPath(), *release.ForLambda(specs[0]).LambdaZipPath())
+
+	release = MockLambdasRelease()
+	assert.Equal(t, 2, len(release.LambdaSpecs()))
+
+	api := release.ForLambda(release.Lambdas[0])
+	assert.Equal(t, "api", to.Strs(api.LambdaName))
+	assert.Equal(t, "00000000/project/development/release-1/api.zip", *api.LambdaZipPath())
+	assert.Equal(t, "00000000/project/development/release-1/worker/code.zip", *release.ForLambda(release.Lambdas[1]).LambdaZipPath())
+}
+
+func Test_Release_Lambdas_ValidateAttributes(t *testing.T) {
+	release := MockLambdasRelease()
+	MockAwsClients(release)
+	assert.NoError(t, release.validateAttributes())
+
+	release.LambdaName = to.Strp("lambdaname")
+	assert.Regexp(t, "Lambdas cannot be combined with LambdaName", release.validateAttributes().Error())
+	release.LambdaName = nil
+
+	release.Lambdas[1].Name = to.Strp("api")
+	assert.Regexp(t, "Lambdas name api is not unique", release.validateAttributes().Error())
+	release.Lambdas[1].Name = to.Strp("worker")
+
+	for _, zipPath := range []string{"../other/lambda.zip", "/lambda.zip", "a/../lambda.zip", "release"} {
+		release.Lambdas[1].ZipPath = to.Strp(zipPath)
+		assert.Regexp(t, "must be a file in the release directory", release.validateAttributes().Error())
+	}
+
+	release.Lambdas[1].ZipPath = to.Strp("api.zip")
+	assert.Regexp(t, "is not unique", release.validateAttributes().Error())
+	release.Lambdas[1].ZipPath = nil
+
+	release.Lambdas[1].SHA256 = nil
+	assert.Regexp(t, "Lambda worker: Exactly one of LambdaSHA256", release.validateAttributes().Error())
+}
+
+func Test_Release_Lambdas_ValidateLambdaSHA(t *testing.T) {
+	release := MockLambdasRelease()
+	awsc := MockAwsClients(release)
+	release.Bucket = to.Strp("bucket")
+	awsc.S3.GetObjectResp[*release.ForLambda(release.Lambdas[1]).LambdaZipPath()].Resp.VersionId = to.Strp("v1")
+
+	assert.NoError(t, release.validateLambdaCode(awsc.S3))
+	assert.Nil(t, release.Lambdas[0].ZipVersion)
+	assert.Equal(t, "v1", to.Strs(release.Lambdas[1].ZipVersion))
+
+	awsc.S3.AddGetObject(*release.ForLambda(release.Lambdas[1]).LambdaZipPath(), "swapped", nil)
+	assert.Regexp(t, "Lambda worker: Lambda SHA mismatch", release.validateLambdaCode(awsc.S3).Error())
+}
+
+func Test_DeployHandler_Execution_Lambdas(t *testing.T) {
+	release := MockLambdasRelease()
+	awsc := MockAwsClients(release)
+	state_machine := createTestStateMachine(t, awsc)
+
+	exec, err := state_machine.Execute(release)
+	assert.NoError(t, err)
+	assert.Equal(t, true, exec.Output["success"])
+
+	inputs := awsc.Lambda.UpdateFunctionCodeInputs
+	assert.Equal(t, 2, len(inputs))
+	assert.Regexp(t, "api$", to.Strs(inputs[0].FunctionName))
+	assert.Equal(t, "api_zip", string(inputs[0].ZipFile))
+	assert.Regexp(t, "worker$", to.Strs(inputs[1].FunctionName))
+	assert.Equal(t, "worker_zip", string(inputs[1].ZipFile))
+}
+
+func Test_DeployHandler_Execution_Lambdas_Tags(t *testing.T) {
+	release := MockLambdasRelease()
+	awsc := MockAwsClients(release)
+	awsc.Lambda.ListTagsResp.Tags["ConfigName"] = to.Strp("other")
+	state_machine := createTestStateMachine(t, awsc)
+
+	exec, err := state_machine.Execute(release)
+	assert.Error(t, err)
+	assert.Regexp(t, "Lambda api: Lambda ConfigName tag incorrect", exec.LastOutputJSON)
+	assert.Equal(t, 0, awsc.Lambda.UpdateFunctionCodeCalls)
+}
+
+func Test_Release_Lambdas_Deploy(t *testing.T) {
+	release := MockLambdasRelease()
+	awsc := MockAwsClients(release)
+	release.Bucket = to.Strp("bucket")
+	awsc.SFN.DescribeStateMachineResp.Definition = release.StateMachineJSON
+
+	result, err := release.Deploy(awsc.SFN, awsc.Lambda, awsc.S3, nil, nil)
+	assert.NoError(t, err)
+	assert.Nil(t, result.NewSHA)
+	assert.Equal(t, 2, awsc.Lambda.UpdateFunctionCodeCalls)
+}
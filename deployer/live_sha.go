@@ -0,0 +1,55 @@
+package deployer
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go/service/lambda"
+	"github.com/coinbase/step/aws"
+	"github.com/coinbase/step/utils/is"
+	"github.com/coinbase/step/utils/to"
+)
+
+func (release *Release) validateExpectedLiveLambdaSHA() error {
+	if is.EmptyStr(release.ExpectedLiveLambdaSHA) {
+		return nil
+	}
+
+	if _, err := to.NormalizeSHA256(*release.ExpectedLiveLambdaSHA); err != nil {
+		return fmt.Errorf("ExpectedLiveLambdaSHA %v", err.Error())
+	}
+
+	return nil
+}
+
+// expectLiveLambdaSHA errors unless the Lambda CodeSha256 is ExpectedLiveLambdaSHA, and returns its RevisionId.
+// Updating the code with the RevisionId fails if the Lambda changes after it was checked.
+// It returns nil without ExpectedLiveLambdaSHA
+func (release *Release) expectLiveLambdaSHA(ctx context.Context, lambdaClient aws.LambdaAPI) (*string, error) {
+	if is.EmptyStr(release.ExpectedLiveLambdaSHA) {
+		return nil, nil
+	}
+
+	expected, err := to.NormalizeSHA256(*release.ExpectedLiveLambdaSHA)
+	if err != nil {
+		return nil, fmt.Errorf("ExpectedLiveLambdaSHA %v", err.Error())
+	}
+
+	out, err := lambdaClient.GetFunctionConfigurationWithContext(ctx, &lambda.GetFunctionConfigurationInput{
+		FunctionName: release.LambdaArn(),
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	if out == nil {
+		return nil, fmt.Errorf("Unknown Lambda GetFunctionConfiguration Error")
+	}
+
+	if live := to.Strs(out.CodeSha256); live != expected {
+		return nil, fmt.Errorf("Lambda CodeSha256 is %q not ExpectedLiveLambdaSHA %q, it was changed by another deploy", live, expected)
+	}
+
+	return out.RevisionId, nil
+}
@@ -0,0 +1,67 @@
+package deployer
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/coinbase/step/utils/to"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Release_ExpectedLiveLambdaSHA_Validate(t *testing.T) {
+	release := MockRelease()
+	release.SetDefaults(to.Strp("region"), to.Strp("account"), "")
+	release.LambdaSHA256 = to.Strp(to.SHA256Str(to.Strp("code")))
+
+	release.ExpectedLiveLambdaSHA = release.LambdaSHA256
+	assert.NoError(t, release.ValidateOffline())
+
+	release.ExpectedLiveLambdaSHA = to.Strp("notasha")
+	assert.Regexp(t, "ExpectedLiveLambdaSHA", release.ValidateOffline().Error())
+
+	release.ExpectedLiveLambdaSHA = release.LambdaSHA256
+	release.Lambdas = []LambdaSpec{{Name: release.LambdaName, SHA256: release.LambdaSHA256}}
+	release.LambdaName, release.LambdaSHA256 = nil, nil
+	assert.Regexp(t, "Lambdas cannot be combined with ExpectedLiveLambdaSHA", release.ValidateOffline().Error())
+}
+
+func Test_Release_ExpectedLiveLambdaSHA_Deploy(t *testing.T) {
+	release := MockRelease()
+	awsc := MockAwsClients(release)
+	release.Bucket = to.Strp("bucket")
+
+	live := to.SHA256Str(to.Strp("live code"))
+	liveBase64, _ := to.HexToBase64(live)
+	awsc.Lambda.CodeSha256 = &liveBase64
+	awsc.Lambda.RevisionId = to.Strp("revision")
+
+	// Hex or base64 are compared with the Lambda's base64 CodeSha256
+	release.ExpectedLiveLambdaSHA = &live
+	assert.NoError(t, release.DeployLambda(awsc.Lambda, awsc.S3, nil, nil))
+	assert.Equal(t, 1, awsc.Lambda.UpdateFunctionCodeCalls)
+	assert.Equal(t, "revision", *awsc.Lambda.UpdateFunctionCodeInputs[0].RevisionId)
+
+	// The live code is now the release's, a deploy expecting the old code aborts
+	release.ExpectedLiveLambdaSHA = &liveBase64
+	err := release.DeployLambda(awsc.Lambda, awsc.S3, nil, nil)
+	assert.Error(t, err)
+	assert.Regexp(t, "changed by another deploy", err.Error())
+	assert.Equal(t, 1, awsc.Lambda.UpdateFunctionCodeCalls)
+
+	// Without ExpectedLiveLambdaSHA the code is always updated
+	release.ExpectedLiveLambdaSHA = nil
+	assert.NoError(t, release.DeployLambda(awsc.Lambda, awsc.S3, nil, nil))
+	assert.Equal(t, 2, awsc.Lambda.UpdateFunctionCodeCalls)
+	assert.Nil(t, awsc.Lambda.UpdateFunctionCodeInputs[1].RevisionId)
+}
+
+func Test_Release_ExpectedLiveLambdaSHA_GetFunctionConfigurationError(t *testing.T) {
+	release := MockRelease()
+	awsc := MockAwsClients(release)
+	release.Bucket = to.Strp("bucket")
+	release.ExpectedLiveLambdaSHA = release.LambdaSHA256
+	awsc.Lambda.GetFunctionConfigurationError = fmt.Errorf("ResourceNotFoundException")
+
+	assert.Error(t, release.DeployLambda(awsc.Lambda, awsc.S3, nil, nil))
+	assert.Equal(t, 0, awsc.Lambda.UpdateFunctionCodeCalls)
+}
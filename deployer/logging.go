@@ -0,0 +1,83 @@
+package deployer
+
+import (
+	"fmt"
+
+	"github.com/aws/aws-sdk-go/service/sfn"
+	"github.com/coinbase/step/utils/is"
+	"github.com/coinbase/step/utils/to"
+)
+
+// LoggingConfiguration sends the State Machine execution history to CloudWatch Logs
+type LoggingConfiguration struct {
+	Level                *string `json:"level,omitempty"`                  // ALL, ERROR, FATAL or OFF (default)
+	IncludeExecutionData bool    `json:"include_execution_data,omitempty"` // Log inputs and outputs
+	LogGroupArn          *string `json:"log_group_arn,omitempty"`          // Destination, required unless Level is OFF
+}
+
+// TracingConfiguration enables X-Ray tracing of the State Machine
+type TracingConfiguration struct {
+	Enabled bool `json:"enabled"`
+}
+
+func (release *Release) validateStepObservability() error {
+	logging := release.LoggingConfiguration
+	if logging == nil {
+		return nil
+	}
+
+	level := to.Strs(logging.Level)
+	switch level {
+	case "", sfn.LogLevelOff:
+		return nil
+	case sfn.LogLevelAll, sfn.LogLevelError, sfn.LogLevelFatal:
+	default:
+		return fmt.Errorf("LoggingConfiguration Level must be ALL, ERROR, FATAL or OFF, got %q", level)
+	}
+
+	if is.EmptyStr(logging.LogGroupArn) {
+		return fmt.Errorf("LoggingConfiguration LogGroupArn must be defined when Level is %v", level)
+	}
+
+	arn, err := to.ParseArn(*logging.LogGroupArn)
+	if err != nil || arn.Service != "logs" {
+		return fmt.Errorf("LoggingConfiguration LogGroupArn must be a CloudWatch Logs ARN, got %q", *logging.LogGroupArn)
+	}
+
+	return nil
+}
+
+// sfnLoggingConfiguration is nil if LoggingConfiguration is not set, leaving the State Machine unchanged
+func (release *Release) sfnLoggingConfiguration() *sfn.LoggingConfiguration {
+	logging := release.LoggingConfiguration
+	if logging == nil {
+		return nil
+	}
+
+	level := sfn.LogLevelOff
+	if !is.EmptyStr(logging.Level) {
+		level = *logging.Level
+	}
+
+	config := &sfn.LoggingConfiguration{
+		Level:                &level,
+		IncludeExecutionData: to.Boolp(logging.IncludeExecutionData),
+	}
+
+	if !is.EmptyStr(logging.LogGroupArn) {
+		config.Destinations = []*sfn.LogDestination{
+			{CloudWatchLogsLogGroup: &sfn.CloudWatchLogsLogGroup{LogGroupArn: logging.LogGroupArn}},
+		}
+	}
+
+	return config
+}
+
+// sfnTracingConfiguration is nil if TracingConfiguration is not set, leaving the State Machine unchanged
+func (release *Release) sfnTracingConfiguration() *sfn.TracingConfiguration {
+	if release.TracingConfiguration == nil {
+		return nil
+	}
+
+	return &sfn.TracingConfiguration{Enabled: to.Boolp(release.TracingConfiguration.Enabled)}
+}
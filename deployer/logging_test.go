@@ -0,0 +1,50 @@
+package deployer
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go/service/sfn"
+	"github.com/coinbase/step/utils/to"
+	"github.com/stretchr/testify/assert"
+)
+
+var logGroupArn = "arn:aws:logs:us-east-1:000000000000:log-group:/step/project:*"
+
+func Test_Release_LoggingConfiguration_ValidateAttributes(t *testing.T) {
+	release := MockRelease()
+	release.LambdaSHA256 = to.Strp(to.SHA256Str(to.Strp("zip")))
+
+	release.LoggingConfiguration = &LoggingConfiguration{Level: to.Strp(sfn.LogLevelOff)}
+	assert.NoError(t, release.validateAttributes())
+
+	release.LoggingConfiguration.Level = to.Strp(sfn.LogLevelAll)
+	assert.Regexp(t, "LogGroupArn must be defined when Level is ALL", release.validateAttributes().Error())
+
+	release.LoggingConfiguration.Level = to.Strp(sfn.LogLevelError)
+	release.LoggingConfiguration.LogGroupArn = to.Strp("arn:aws:s3:::bucket")
+	assert.Regexp(t, "LogGroupArn must be a CloudWatch Logs ARN", release.validateAttributes().Error())
+
+	release.LoggingConfiguration.LogGroupArn = &logGroupArn
+	assert.NoError(t, release.validateAttributes())
+
+	release.LoggingConfiguration.Level = to.Strp("DEBUG")
+	assert.Regexp(t, "Level must be ALL, ERROR, FATAL or OFF", release.validateAttributes().Error())
+}
+
+func Test_Release_LoggingConfiguration_DeployStepFunctionInput(t *testing.T) {
+	release := MockRelease()
+
+	input := release.deployStepFunctionInput()
+	assert.Nil(t, input.LoggingConfiguration)
+	assert.Nil(t, input.TracingConfiguration)
+
+	release.LoggingConfiguration = &LoggingConfiguration{Level: to.Strp(sfn.LogLevelAll), IncludeExecutionData: true, LogGroupArn: &logGroupArn}
+	release.TracingConfiguration = &TracingConfiguration{Enabled: true}
+
+	input = release.deployStepFunctionInput()
+	assert.NoError(t, input.Validate())
+	assert.Equal(t, sfn.LogLevelAll, *input.LoggingConfiguration.Level)
+	assert.True(t, *input.LoggingConfiguration.IncludeExecutionData)
+	assert.Equal(t, logGroupArn, *input.LoggingConfiguration.Destinations[0].CloudWatchLogsLogGroup.LogGroupArn)
+	assert.True(t, *input.TracingConfiguration.Enabled)
+}
@@ -14,7 +14,7 @@ func StateMachine() (*machine.StateMachine, error) {
     "States": {
       "Validate": {
         "Type": "TaskFn",
-        "Resource": "arn:aws:lambda:{{aws_region}}:{{aws_account}}:function:{{lambda_name}}",
+        "Resource": "arn:{{aws_partition}}:lambda:{{aws_region}}:{{aws_account}}:function:{{lambda_name}}",
         "Comment": "Validate and Set Defaults",
         "Next": "Lock",
         "Catch": [
@@ -28,7 +28,7 @@ func StateMachine() (*machine.StateMachine, error) {
       },
       "Lock": {
         "Type": "TaskFn",
-        "Resource": "arn:aws:lambda:{{aws_region}}:{{aws_account}}:function:{{lambda_name}}",
+        "Resource": "arn:{{aws_partition}}:lambda:{{aws_region}}:{{aws_account}}:function:{{lambda_name}}",
         "Comment": "Grab Lock",
         "Next": "ValidateResources",
         "Catch": [
@@ -48,7 +48,7 @@ func StateMachine() (*machine.StateMachine, error) {
       },
       "ValidateResources": {
         "Type": "TaskFn",
-        "Resource": "arn:aws:lambda:{{aws_region}}:{{aws_account}}:function:{{lambda_name}}",
+        "Resource": "arn:{{aws_partition}}:lambda:{{aws_region}}:{{aws_account}}:function:{{lambda_name}}",
         "Comment": "ValidateResources",
         "Next": "Deploy",
         "Catch": [
@@ -62,7 +62,7 @@ func StateMachine() (*machine.StateMachine, error) {
       },
       "Deploy": {
         "Type": "TaskFn",
-        "Resource": "arn:aws:lambda:{{aws_region}}:{{aws_account}}:function:{{lambda_name}}",
+        "Resource": "arn:{{aws_partition}}:lambda:{{aws_region}}:{{aws_account}}:function:{{lambda_name}}",
         "Comment": "Upload Step-Function and Lambda",
         "Next": "Success",
         "Catch": [
@@ -82,7 +82,7 @@ func StateMachine() (*machine.StateMachine, error) {
       },
       "ReleaseLockFailure": {
         "Type": "TaskFn",
-        "Resource": "arn:aws:lambda:{{aws_region}}:{{aws_account}}:function:{{lambda_name}}",
+        "Resource": "arn:{{aws_partition}}:lambda:{{aws_region}}:{{aws_account}}:function:{{lambda_name}}",
         "Comment": "Release the Lock and Fail",
         "Next": "FailureClean",
         "Retry": [ {
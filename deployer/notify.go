@@ -0,0 +1,41 @@
+package deployer
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/coinbase/step/aws"
+	"github.com/coinbase/step/bifrost"
+	"github.com/coinbase/step/utils/to"
+)
+
+// NotifyTopicEnv is the environment variable holding the SNS topic ARN for deploy events,
+// if it is not set no events are sent
+const NotifyTopicEnv = "STEP_NOTIFY_TOPIC_ARN"
+
+// notify sends the lifecycle event if a topic is configured and awsc supports SNS
+// cause is attached as the release Error. Errors are ignored so notifications never break a deploy
+func notify(awsc aws.AwsClients, release *Release, event string, cause error) {
+	topicArn := os.Getenv(NotifyTopicEnv)
+	if topicArn == "" {
+		return
+	}
+
+	snsClients, ok := awsc.(aws.SNSClients)
+	if !ok {
+		return
+	}
+
+	r := release.Release
+	if cause != nil {
+		r.Error = &bifrost.ReleaseError{
+			Error: to.Strp(to.ErrorType(cause)),
+			Cause: to.Strp(cause.Error()),
+		}
+	}
+
+	if err := r.Notify(snsClients.SNSClient(nil, nil, nil), topicArn, event); err != nil {
+		// ignore errors
+		fmt.Printf("Warning(Notify) error ignored: %v\n", err.Error())
+	}
+}
@@ -0,0 +1,46 @@
+package deployer
+
+import (
+	"fmt"
+
+	"github.com/coinbase/step/utils/is"
+)
+
+// DeploysLambda returns false for StepOnly releases
+func (release *Release) DeploysLambda() bool {
+	return !release.StepOnly
+}
+
+// DeploysStepFunction returns false for LambdaOnly releases
+func (release *Release) DeploysStepFunction() bool {
+	return !release.LambdaOnly
+}
+
+// validatePartial checks a StepOnly or LambdaOnly release does not define what it will not deploy
+func (release *Release) validatePartial() error {
+	if release.StepOnly && release.LambdaOnly {
+		return fmt.Errorf("At most one of StepOnly or LambdaOnly can be set")
+	}
+
+	if release.StepOnly {
+		if !is.EmptyStr(release.LambdaSHA256) || !is.EmptyStr(release.ImageUri) || len(release.Lambdas) != 0 {
+			return fmt.Errorf("StepOnly releases cannot define LambdaSHA256, ImageUri or Lambdas")
+		}
+
+		if release.LambdaEnvironment != nil || release.LambdaMemorySize != nil || release.LambdaTimeout != nil || release.LambdaReservedConcurrentExecutions != nil || release.deployedLayers() != nil || release.LambdaQualifier != nil {
+			return fmt.Errorf("StepOnly releases cannot define Lambda settings")
+		}
+	}
+
+	if release.LambdaOnly {
+		if !is.EmptyStr(release.StateMachineJSON) || release.InputSchema != nil {
+			return fmt.Errorf("LambdaOnly releases cannot define StateMachineJSON or InputSchema")
+		}
+
+		if release.SmokeTestInput != nil && is.EmptyStr(release.StepFnName) {
+			return fmt.Errorf("SmokeTestInput requires StepFnName")
+		}
+	}
+
+	return nil
+}
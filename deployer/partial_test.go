@@ -0,0 +1,112 @@
+package deployer
+
+import (
+	"testing"
+
+	"github.com/coinbase/step/utils/to"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Release_StepOnly_ValidateAttributes(t *testing.T) {
+	release := MockRelease()
+	release.StepOnly = true
+	release.LambdaName = nil
+	assert.NoError(t, release.validateAttributes())
+
+	release.LambdaSHA256 = to.Strp(to.SHA256Str(to.Strp("zip")))
+	assert.Regexp(t, "StepOnly releases cannot define LambdaSHA256", release.validateAttributes().Error())
+
+	release.LambdaSHA256 = nil
+	release.LambdaMemorySize = to.Int64p(256)
+	assert.Regexp(t, "StepOnly releases cannot define Lambda settings", release.validateAttributes().Error())
+
+	release.LambdaMemorySize = nil
+	release.Layers = []*string{to.Strp("arn:aws:lambda:us-east-1:000000000000:layer:shared:3")}
+	assert.Regexp(t, "StepOnly releases cannot define Lambda settings", release.validateAttributes().Error())
+
+	release.Layers = nil
+	release.ClearLayers = true
+	assert.Regexp(t, "StepOnly releases cannot define Lambda settings", release.validateAttributes().Error())
+
+	release.ClearLayers = false
+	release.StateMachineJSON = nil
+	assert.Regexp(t, "StateMachineJSON must be defined", release.validateAttributes().Error())
+
+	release.LambdaOnly = true
+	assert.Regexp(t, "At most one of StepOnly or LambdaOnly", release.validateAttributes().Error())
+}
+
+func Test_Release_LambdaOnly_ValidateAttributes(t *testing.T) {
+	release := MockRelease()
+	release.LambdaOnly = true
+	release.LambdaSHA256 = to.Strp(to.SHA256Str(to.Strp("zip")))
+	release.StateMachineJSON = nil
+	release.StepFnName = nil
+	assert.NoError(t, release.validateAttributes())
+
+	release.StateMachineJSON = to.Strp(`{}`)
+	assert.Regexp(t, "LambdaOnly releases cannot define StateMachineJSON", release.validateAttributes().Error())
+
+	release.StateMachineJSON = nil
+	release.SmokeTestInput = to.Strp(`{}`)
+	assert.Regexp(t, "SmokeTestInput requires StepFnName", release.validateAttributes().Error())
+
+	release.SmokeTestInput = nil
+	release.LambdaSHA256 = nil
+	assert.Regexp(t, "Exactly one of LambdaSHA256", release.validateAttributes().Error())
+}
+
+func Test_DeployHandler_Execution_StepOnly(t *testing.T) {
+	release := MockRelease()
+	release.StepOnly = true
+	awsc := MockAwsClients(release)
+	awsc.Lambda.ListTagsResp.Tags = nil // Lambda is not validated
+	state_machine := createTestStateMachine(t, awsc)
+
+	exec, err := state_machine.Execute(release)
+	assert.NoError(t, err)
+	assert.Equal(t, true, exec.Output["success"])
+
+	assert.Equal(t, 1, awsc.SFN.UpdateStateMachineCalls)
+	assert.Equal(t, 0, awsc.Lambda.UpdateFunctionCodeCalls)
+}
+
+func Test_DeployHandler_Execution_LambdaOnly(t *testing.T) {
+	release := MockRelease()
+	release.LambdaOnly = true
+	release.StateMachineJSON = nil
+	release.StepFnName = nil
+	awsc := MockAwsClients(release)
+	state_machine := createTestStateMachine(t, awsc)
+
+	exec, err := state_machine.Execute(release)
+	assert.NoError(t, err)
+	assert.Equal(t, true, exec.Output["success"])
+
+	assert.Equal(t, 0, awsc.SFN.UpdateStateMachineCalls)
+	assert.Equal(t, 1, awsc.Lambda.UpdateFunctionCodeCalls)
+	assert.Equal(t, 0, len(awsc.SFN.DescribeStateMachineInputs))
+}
+
+func Test_Release_Deploy_Partial(t *testing.T) {
+	release := MockRelease()
+	release.StepOnly = true
+	awsc := MockAwsClients(release)
+	awsc.SFN.DescribeStateMachineResp.Definition = release.StateMachineJSON
+
+	result, err := release.Deploy(awsc.SFN, awsc.Lambda, awsc.S3, nil, nil)
+	assert.NoError(t, err)
+	assert.False(t, result.LambdaUpdated)
+	assert.Equal(t, 0, awsc.Lambda.UpdateFunctionCodeCalls)
+
+	release = MockRelease()
+	release.LambdaOnly = true
+	release.StateMachineJSON = nil
+	awsc = MockAwsClients(release)
+
+	result, err = release.Deploy(awsc.SFN, awsc.Lambda, awsc.S3, nil, nil)
+	assert.NoError(t, err)
+	assert.False(t, result.StateMachineUpdated)
+	assert.Equal(t, 0, awsc.SFN.UpdateStateMachineCalls)
+	assert.Equal(t, 1, awsc.Lambda.UpdateFunctionCodeCalls)
+}
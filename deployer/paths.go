@@ -0,0 +1,20 @@
+package deployer
+
+import "github.com/coinbase/step/bifrost"
+
+// Paths of the bifrost files in a deployer bucket, for tools that list or clean the bucket
+
+// ReleasePath is the key of the uploaded release
+func ReleasePath(account, project, config, releaseId string) string {
+	return bifrost.ReleasePath(account, project, config, releaseId)
+}
+
+// LockPath is the root lock held while any release of the project config is deploying
+func LockPath(account, project, config string) string {
+	return bifrost.RootLockPath(account, project, config)
+}
+
+// ReleaseLockPath is the lock that stops a release being deployed twice
+func ReleaseLockPath(account, project, config, releaseId string) string {
+	return bifrost.ReleaseLockPath(account, project, config, releaseId)
+}
@@ -0,0 +1,25 @@
+package deployer
+
+import (
+	"testing"
+
+	"github.com/coinbase/step/utils/to"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Paths_Match_Release_Methods(t *testing.T) {
+	release := MockRelease()
+
+	assert.Equal(t, "00000000/project/development/release-1/release", ReleasePath("00000000", "project", "development", "release-1"))
+	assert.Equal(t, *release.ReleasePath(), ReleasePath("00000000", "project", "development", "release-1"))
+	assert.Equal(t, *release.RootLockPath(), LockPath("00000000", "project", "development"))
+	assert.Equal(t, *release.ReleaseLockPath(), ReleaseLockPath("00000000", "project", "development", "release-1"))
+	assert.Equal(t, *release.DeployedReleasePath(), DeployedReleasePath("00000000", "project", "development"))
+	assert.Equal(t, *release.PreviousReleasePath(), PreviousReleasePath("00000000", "project", "development", "release-1"))
+
+	assert.Equal(t, "00000000/project/development/release-1/lambda.zip", *release.LambdaZipPath())
+	assert.Equal(t, *release.LambdaZipPath(), LambdaZipPath("00000000", "project", "development", "release-1", "lambda.zip"))
+
+	spec := LambdaSpec{Name: to.Strp("worker")}
+	assert.Equal(t, *release.ForLambda(spec).LambdaZipPath(), LambdaZipPath("00000000", "project", "development", "release-1", "worker.zip"))
+}
@@ -0,0 +1,159 @@
+package deployer
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/iam"
+	"github.com/aws/aws-sdk-go/service/lambda"
+	"github.com/aws/aws-sdk-go/service/sfn"
+	"github.com/coinbase/step/aws"
+	"github.com/coinbase/step/machine"
+	"github.com/coinbase/step/utils/to"
+)
+
+// LambdaResources returns the unique Lambda ARNs used as Task Resources in StateMachineJSON
+func (release *Release) LambdaResources() ([]string, error) {
+	sm, err := machine.FromJSON([]byte(*release.StateMachineJSON))
+	if err != nil {
+		return nil, err
+	}
+
+	seen := map[string]bool{}
+	arns := []string{}
+
+	for _, task := range sm.AllTasks() {
+		if task.Resource == nil {
+			continue
+		}
+
+		// Lambda ARNs in any partition
+		if a, err := to.ParseArn(*task.Resource); err != nil || a.Service != "lambda" {
+			continue
+		}
+
+		if !seen[*task.Resource] {
+			seen[*task.Resource] = true
+			arns = append(arns, *task.Resource)
+		}
+	}
+
+	sort.Strings(arns)
+	return arns, nil
+}
+
+// ValidateRolePermissions simulates the State Machine role invoking every Lambda Resource
+// and errors with the functions it cannot invoke
+func (release *Release) ValidateRolePermissions(iamc aws.IAMAPI, sfnc aws.SFNAPI) error {
+	out, err := sfnc.DescribeStateMachine(&sfn.DescribeStateMachineInput{StateMachineArn: release.StepArn()})
+	if err != nil {
+		return err
+	}
+
+	if out == nil || out.RoleArn == nil {
+		return fmt.Errorf("Unknown Step Function Error")
+	}
+
+	arns, err := release.LambdaResources()
+	if err != nil {
+		return err
+	}
+
+	if len(arns) == 0 {
+		return nil
+	}
+
+	input := &iam.SimulatePrincipalPolicyInput{
+		PolicySourceArn: out.RoleArn,
+		ActionNames:     []*string{to.Strp("lambda:InvokeFunction")},
+		ResourceArns:    []*string{},
+	}
+
+	for i := range arns {
+		input.ResourceArns = append(input.ResourceArns, &arns[i])
+	}
+
+	denied := []string{}
+	for {
+		resp, err := iamc.SimulatePrincipalPolicy(input)
+		if err != nil {
+			return err
+		}
+
+		for _, result := range resp.EvaluationResults {
+			if result.EvalDecision == nil || *result.EvalDecision != iam.PolicyEvaluationDecisionTypeAllowed {
+				denied = append(denied, *result.EvalResourceName)
+			}
+		}
+
+		if resp.IsTruncated == nil || !*resp.IsTruncated {
+			break
+		}
+
+		input.Marker = resp.Marker
+	}
+
+	if len(denied) != 0 {
+		return fmt.Errorf("Role %v cannot invoke %q", *out.RoleArn, denied)
+	}
+
+	return nil
+}
+
+// ValidateTaskResources checks every Lambda Resource in StateMachineJSON exists.
+// Qualified ARNs of the release's Lambdas are checked on the function, as the deploy creates the alias.
+// Resources in another account or region cannot be checked and print a warning,
+// or error if StrictTaskResources is set
+func (release *Release) ValidateTaskResources(lambdac aws.LambdaAPI) error {
+	arns, err := release.LambdaResources()
+	if err != nil {
+		return err
+	}
+
+	deployed := map[string]bool{}
+	if release.DeploysLambda() {
+		for _, spec := range release.LambdaSpecs() {
+			deployed[*release.ForLambda(spec).LambdaArn()] = true
+		}
+	}
+
+	missing := []string{}
+	foreign := []string{}
+
+	for _, arn := range arns {
+		region, account, _ := to.ArnRegionAccountResource(arn)
+		if region != to.Strs(release.AwsRegion) || account != to.Strs(release.AwsAccountID) {
+			foreign = append(foreign, arn)
+			continue
+		}
+
+		function := arn
+		if deployed[lambdaFunctionArn(arn)] {
+			function = lambdaFunctionArn(arn)
+		}
+
+		_, err := lambdac.GetFunction(&lambda.GetFunctionInput{FunctionName: to.Strp(function)})
+		if aerr, ok := err.(awserr.Error); ok && aerr.Code() == lambda.ErrCodeResourceNotFoundException {
+			missing = append(missing, arn)
+			continue
+		}
+
+		if err != nil {
+			return err
+		}
+	}
+
+	if len(missing) != 0 {
+		return fmt.Errorf("Task Resources do not exist %q", missing)
+	}
+
+	if len(foreign) != 0 {
+		if release.StrictTaskResources {
+			return fmt.Errorf("Task Resources not in account %v region %v %q", to.Strs(release.AwsAccountID), to.Strs(release.AwsRegion), foreign)
+		}
+		fmt.Printf("Warning(ValidateTaskResources) Task Resources in another account or region not checked: %q\n", foreign)
+	}
+
+	return nil
+}
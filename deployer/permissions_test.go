@@ -0,0 +1,80 @@
+package deployer
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go/service/sfn"
+	"github.com/coinbase/step/aws/mocks"
+	"github.com/coinbase/step/utils/to"
+	"github.com/stretchr/testify/assert"
+)
+
+var lambdaResourcesStateMachine = `{
+  "StartAt": "A",
+  "States": {
+    "A": {"Type": "Task", "Resource": "arn:aws:lambda:us-east-1:00000000:function:a", "Next": "B"},
+    "B": {"Type": "Task", "Resource": "arn:aws:lambda:us-east-1:00000000:function:b", "Next": "C"},
+    "C": {"Type": "Task", "Resource": "arn:aws:lambda:us-east-1:00000000:function:a", "Next": "D"},
+    "D": {"Type": "Task", "Resource": "arn:aws:states:::sns:publish", "End": true}
+  }
+}`
+
+func Test_Release_LambdaResources(t *testing.T) {
+	r := MockRelease()
+	r.StateMachineJSON = to.Strp(lambdaResourcesStateMachine)
+
+	arns, err := r.LambdaResources()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{
+		"arn:aws:lambda:us-east-1:00000000:function:a",
+		"arn:aws:lambda:us-east-1:00000000:function:b",
+	}, arns)
+}
+
+func Test_Release_ValidateRolePermissions(t *testing.T) {
+	r := MockRelease()
+	r.StateMachineJSON = to.Strp(lambdaResourcesStateMachine)
+
+	sfnc := &mocks.MockSFNClient{DescribeStateMachineResp: &sfn.DescribeStateMachineOutput{RoleArn: to.Strp("arn:aws:iam::00000000:role/step/project/development/role")}}
+	iamc := &mocks.MockIAMClient{}
+
+	assert.NoError(t, r.ValidateRolePermissions(iamc, sfnc))
+
+	iamc.DeniedResources = map[string]bool{"arn:aws:lambda:us-east-1:00000000:function:b": true}
+	err := r.ValidateRolePermissions(iamc, sfnc)
+	assert.Error(t, err)
+	assert.Regexp(t, "cannot invoke", err.Error())
+	assert.Regexp(t, "function:b", err.Error())
+	assert.NotRegexp(t, "function:a", err.Error())
+}
+
+func Test_Release_ValidateTaskResources(t *testing.T) {
+	r := MockRelease()
+	r.AwsRegion = to.Strp("us-east-1")
+	r.StateMachineJSON = to.Strp(lambdaResourcesStateMachine)
+	lambdac := &mocks.MockLambdaClient{}
+
+	assert.NoError(t, r.ValidateTaskResources(lambdac))
+
+	lambdac.MissingFunctions = map[string]bool{"arn:aws:lambda:us-east-1:00000000:function:b": true}
+	err := r.ValidateTaskResources(lambdac)
+	assert.Error(t, err)
+	assert.Regexp(t, "do not exist", err.Error())
+	assert.Regexp(t, "function:b", err.Error())
+}
+
+func Test_Release_ValidateTaskResources_Other_Account(t *testing.T) {
+	r := MockRelease()
+	r.AwsRegion = to.Strp("us-east-1")
+	r.AwsAccountID = to.Strp("11111111")
+	r.StateMachineJSON = to.Strp(lambdaResourcesStateMachine)
+	lambdac := &mocks.MockLambdaClient{}
+
+	// Warning only
+	assert.NoError(t, r.ValidateTaskResources(lambdac))
+
+	r.StrictTaskResources = true
+	err := r.ValidateTaskResources(lambdac)
+	assert.Error(t, err)
+	assert.Regexp(t, "not in account 11111111", err.Error())
+}
@@ -0,0 +1,144 @@
+package deployer
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/coinbase/step/aws"
+	"github.com/coinbase/step/aws/s3"
+	"github.com/coinbase/step/utils/is"
+	"github.com/coinbase/step/utils/to"
+)
+
+// Promote copies the release to targetConfig in targetAccount so the target deploys the byte-identical
+// Lambda zips this release was validated with. The zips and their signatures are copied to the target
+// LambdaZipPath and the promoted release is uploaded and signed with the same LambdaSHA256s.
+// A target in another account uses its default Bucket, and ARNs of this account in StateMachineJSON,
+// LambdaName, StepFnName and Lambdas are changed to targetAccount
+func (release *Release) Promote(s3c aws.S3API, targetAccount, targetConfig string) (*Release, error) {
+	if targetAccount == "" || targetConfig == "" {
+		return nil, fmt.Errorf("Promote target account and config must be defined")
+	}
+
+	if is.EmptyStr(release.AwsAccountID) || is.EmptyStr(release.ProjectName) || is.EmptyStr(release.ConfigName) || is.EmptyStr(release.ReleaseID) || is.EmptyStr(release.Bucket) {
+		return nil, fmt.Errorf("Promote requires AwsAccountID, ProjectName, ConfigName, ReleaseID and Bucket")
+	}
+
+	if targetAccount == *release.AwsAccountID && targetConfig == *release.ConfigName {
+		return nil, fmt.Errorf("Promote target must be a different account or config")
+	}
+
+	promoted, err := release.promoted(targetAccount, targetConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	if release.DeploysLambda() && !release.IsImage() {
+		err := release.eachLambda(func(r *Release) error {
+			target := *promoted
+			target.lambdaZipName = r.lambdaZipName
+			return r.copyLambdaZip(s3c, &target)
+		})
+
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	promoted.CreatedAt = to.Timep(time.Now())
+
+	_, versionId, err := s3.PutStructWithHashVersion(s3c, promoted.Bucket, promoted.ReleasePath(), promoted, promoted.HashAlgorithm())
+	if err != nil {
+		return nil, err
+	}
+
+	promoted.ReleaseVersionID = versionId
+	promoted.Sign()
+
+	return promoted, nil
+}
+
+// promoted returns a copy of the release for targetConfig in targetAccount without the values
+// set by the deployer or a previous upload
+func (release *Release) promoted(targetAccount, targetConfig string) (*Release, error) {
+	raw, err := release.ToJSON()
+	if err != nil {
+		return nil, err
+	}
+
+	promoted, err := ReleaseFromJSON(raw)
+	if err != nil {
+		return nil, err
+	}
+
+	promoted.WipeControlledValues()
+	promoted.Error = nil
+	promoted.ReleaseVersionID = nil
+	promoted.ReleaseSHA256 = ""
+	promoted.LambdaZipVersion = nil
+	promoted.ExpectedLiveLambdaSHA = nil // The live Lambda of this config, not the target's
+
+	// The target must be approved on its own
+	promoted.Approved = nil
+	promoted.ApprovedBy = nil
+	promoted.ApprovedAt = nil
+
+	sourceAccount := *release.AwsAccountID
+	promoted.AwsAccountID = to.Strp(targetAccount)
+	promoted.ConfigName = to.Strp(targetConfig)
+
+	if targetAccount != sourceAccount {
+		promoted.Bucket = to.Strp(fmt.Sprintf("%v%v", DefaultBucketPrefix, targetAccount))
+		promoted.ArtifactBucket = nil
+
+		promoted.StateMachineJSON = promoteArnAccount(promoted.StateMachineJSON, sourceAccount, targetAccount)
+		promoted.LambdaName = promoteArnAccount(promoted.LambdaName, sourceAccount, targetAccount)
+		promoted.StepFnName = promoteArnAccount(promoted.StepFnName, sourceAccount, targetAccount)
+	}
+
+	for i := range promoted.Lambdas {
+		promoted.Lambdas[i].ZipVersion = nil
+		if targetAccount != sourceAccount {
+			promoted.Lambdas[i].Name = promoteArnAccount(promoted.Lambdas[i].Name, sourceAccount, targetAccount)
+		}
+	}
+
+	return promoted, nil
+}
+
+// copyLambdaZip copies the LambdaZipVersion of the Lambda zip, and its signature if there is one, to the zip path of target.
+// The version is checked against LambdaSHA256 first, without a LambdaZipVersion the latest version is checked and pinned
+func (release *Release) copyLambdaZip(s3c aws.S3API, target *Release) error {
+	source := *release
+	if is.EmptyStr(source.LambdaZipVersion) {
+		if err := source.ValidateLambdaSHA(s3c); err != nil {
+			return err
+		}
+	} else if _, err := source.FetchLambdaZip(s3c); err != nil {
+		return err
+	}
+
+	err := s3.CopyVersion(s3c, source.LambdaZipBucket(), source.LambdaZipPath(), source.LambdaZipVersion, target.LambdaZipBucket(), target.LambdaZipPath())
+	if err != nil {
+		return err
+	}
+
+	err = s3.Copy(s3c, release.LambdaZipBucket(), release.LambdaSignaturePath(), target.LambdaZipBucket(), target.LambdaSignaturePath())
+	if _, ok := err.(*s3.NotFoundError); ok {
+		// Unsigned zip
+		return nil
+	}
+
+	return err
+}
+
+// promoteArnAccount replaces the account in ARNs, e.g. arn:aws:lambda:us-east-1:<from>:function:name
+func promoteArnAccount(str *string, from, to string) *string {
+	if str == nil {
+		return nil
+	}
+
+	s := strings.Replace(*str, fmt.Sprintf(":%v:", from), fmt.Sprintf(":%v:", to), -1)
+	return &s
+}
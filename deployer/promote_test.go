@@ -0,0 +1,113 @@
+package deployer
+
+import (
+	"testing"
+
+	"github.com/coinbase/step/aws/mocks"
+	"github.com/coinbase/step/aws/s3"
+	"github.com/coinbase/step/errors"
+	"github.com/coinbase/step/utils/to"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Release_Promote(t *testing.T) {
+	release := MockRelease()
+	release.StateMachineJSON = to.Strp(`{"StartAt": "A", "States": {"A": {"Type": "Task", "Resource": "arn:aws:lambda:us-east-1:00000000:function:lambdaname", "End": true}}}`)
+	awsc := MockAwsClients(release)
+	release.SetDefaults(to.Strp("us-east-1"), to.Strp("00000000"), "bucket-")
+	release.LambdaZipVersion = to.Strp("v1")
+	release.Approved = to.Boolp(true)
+
+	// The latest zip was replaced after the release was validated
+	awsc.S3.Versions = map[string][]string{*release.LambdaZipPath(): {"lambda_zip"}}
+	awsc.S3.AddGetObject(*release.LambdaZipPath(), "replaced_zip", nil)
+	release.Sign()
+
+	promoted, err := release.Promote(awsc.S3, "11111111", "production")
+	assert.NoError(t, err)
+
+	assert.Equal(t, "11111111", *promoted.AwsAccountID)
+	assert.Equal(t, "production", *promoted.ConfigName)
+	assert.Equal(t, *release.ReleaseID, *promoted.ReleaseID)
+	assert.Equal(t, *release.LambdaSHA256, *promoted.LambdaSHA256)
+	assert.Equal(t, DefaultBucketPrefix+"11111111", *promoted.Bucket)
+	assert.Regexp(t, `arn:aws:lambda:us-east-1:11111111:function:lambdaname`, *promoted.StateMachineJSON)
+	assert.Nil(t, promoted.LambdaZipVersion)
+	assert.Nil(t, promoted.Approved)
+	assert.Nil(t, promoted.UUID)
+	assert.NotEqual(t, release.ReleaseSHA256, promoted.ReleaseSHA256)
+
+	// The source is not changed
+	assert.Equal(t, "00000000", *release.AwsAccountID)
+	assert.Equal(t, "v1", *release.LambdaZipVersion)
+
+	// The pinned zip is copied byte for byte and the promoted release validates
+	zip, err := s3.Get(awsc.S3, promoted.LambdaZipBucket(), promoted.LambdaZipPath())
+	assert.NoError(t, err)
+	assert.Equal(t, "lambda_zip", string(*zip))
+	assert.Equal(t, 2, len(awsc.S3.CopyObjectInputs)) // the zip and its signature, which is not found
+
+	promoted.SetDefaults(to.Strp("us-east-1"), to.Strp("11111111"), DefaultBucketPrefix)
+	assert.NoError(t, promoted.Validate(awsc.S3))
+}
+
+func Test_Release_Promote_Lambdas_Signatures(t *testing.T) {
+	release := MockRelease()
+	release.LambdaName = nil
+	release.Lambdas = []LambdaSpec{{Name: to.Strp("a")}, {Name: to.Strp("b")}}
+	awsc := MockAwsClients(release)
+	release.SetDefaults(to.Strp("us-east-1"), to.Strp("00000000"), "bucket-")
+
+	signed := release.ForLambda(release.Lambdas[0])
+	awsc.S3.AddGetObject(*signed.LambdaSignaturePath(), "signature", nil)
+
+	promoted, err := release.Promote(awsc.S3, "00000000", "production")
+	assert.NoError(t, err)
+
+	// Same account keeps the Bucket
+	assert.Equal(t, *release.Bucket, *promoted.Bucket)
+
+	for i, spec := range promoted.Lambdas {
+		zip, err := s3.Get(awsc.S3, promoted.Bucket, promoted.ForLambda(spec).LambdaZipPath())
+		assert.NoError(t, err)
+		assert.Equal(t, *release.Lambdas[i].Name+"_zip", string(*zip))
+	}
+
+	sig, err := s3.Get(awsc.S3, promoted.Bucket, promoted.ForLambda(promoted.Lambdas[0]).LambdaSignaturePath())
+	assert.NoError(t, err)
+	assert.Equal(t, "signature", string(*sig))
+
+	promoted.SetDefaults(to.Strp("us-east-1"), to.Strp("00000000"), DefaultBucketPrefix)
+	assert.NoError(t, promoted.Validate(awsc.S3))
+}
+
+func Test_Release_Promote_Errors(t *testing.T) {
+	release := MockRelease()
+	awsc := MockAwsClients(release)
+	release.SetDefaults(to.Strp("us-east-1"), to.Strp("00000000"), "bucket-")
+
+	_, err := release.Promote(awsc.S3, "", "production")
+	assert.Error(t, err)
+
+	_, err = release.Promote(awsc.S3, "00000000", "development")
+	assert.Regexp(t, "Promote target must be a different account or config", err.Error())
+
+	// Missing zip
+	s3c := &mocks.MockS3Client{}
+	_, err = release.Promote(s3c, "11111111", "production")
+	assert.IsType(t, &s3.NotFoundError{}, err)
+	assert.Equal(t, 0, len(s3c.PutObjectInputs))
+
+	// A zip that does not match LambdaSHA256 is not copied
+	awsc.S3.AddGetObject(*release.LambdaZipPath(), "replaced_zip", nil)
+	awsc.S3.CopyObjectInputs = nil
+	_, err = release.Promote(awsc.S3, "11111111", "production")
+	assert.IsType(t, &errors.SHAMismatchError{}, err)
+	assert.Equal(t, 0, len(awsc.S3.CopyObjectInputs))
+
+	release.LambdaZipVersion = to.Strp("v1")
+	awsc.S3.Versions = map[string][]string{*release.LambdaZipPath(): {"replaced_zip"}}
+	_, err = release.Promote(awsc.S3, "11111111", "production")
+	assert.IsType(t, &errors.SHAMismatchError{}, err)
+	assert.Equal(t, 0, len(awsc.S3.CopyObjectInputs))
+}
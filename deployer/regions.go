@@ -0,0 +1,141 @@
+package deployer
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/coinbase/step/aws"
+	"github.com/coinbase/step/utils/is"
+	"github.com/coinbase/step/utils/to"
+)
+
+// Regions returns the regions to deploy to, AwsRegions if defined otherwise AwsRegion
+func (release *Release) Regions() []*string {
+	if len(release.AwsRegions) != 0 {
+		return release.AwsRegions
+	}
+	return []*string{release.AwsRegion}
+}
+
+func (release *Release) validateRegions() error {
+	if release.AwsRegions == nil {
+		return nil
+	}
+
+	if !is.UniqueStrp(release.AwsRegions) {
+		return fmt.Errorf("AwsRegions must be unique and not nil")
+	}
+
+	for _, region := range release.AwsRegions {
+		if is.EmptyStr(region) {
+			return fmt.Errorf("AwsRegions must not contain an empty region")
+		}
+	}
+
+	return nil
+}
+
+// ForRegion returns a copy of the release that deploys to region
+func (release *Release) ForRegion(region *string) *Release {
+	r := *release
+	r.AwsRegion = region
+	r.AwsRegions = nil
+	return &r
+}
+
+// DeployLambdaRegions uploads the Lambda code to every region.
+// Regions in the Buckets region and account are deployed from S3, see DeployLambda.
+// Each region is independent, failed regions are returned in a combined error
+func (release *Release) DeployLambdaRegions(awsc aws.AwsClients, s3c aws.S3API, bucketRegion *string, bucketAccount *string) error {
+	return release.DeployLambdaRegionsWithContext(context.Background(), awsc, s3c, bucketRegion, bucketAccount)
+}
+
+// DeployLambdaRegionsWithContext is DeployLambdaRegions with ctx passed to the Lambda code updates
+func (release *Release) DeployLambdaRegionsWithContext(ctx context.Context, awsc aws.AwsClients, s3c aws.S3API, bucketRegion *string, bucketAccount *string) error {
+	if !release.DeploysLambda() {
+		return nil
+	}
+
+	return release.eachLambda(func(l *Release) error {
+		return l.deployLambdaFunctionRegions(ctx, awsc, s3c, bucketRegion, bucketAccount)
+	})
+}
+
+func (release *Release) deployLambdaFunctionRegions(ctx context.Context, awsc aws.AwsClients, s3c aws.S3API, bucketRegion *string, bucketAccount *string) error {
+	if release.DryRun {
+		_, err := release.DeployLambdaDryRun(s3c)
+		return err
+	}
+
+	// Download at most once for all regions
+	var zip *[]byte
+
+	return release.eachRegion(func(r *Release) error {
+		lambdaClient := awsc.LambdaClient(r.AwsRegion, r.AwsAccountID, assumed_role)
+		return r.deployLambdaFunction(ctx, lambdaClient, s3c, bucketRegion, bucketAccount, nil, &zip)
+	})
+}
+
+// ValidateResourcesRegionsWithContext is ValidateResourcesWithContext in every region with that region's
+// Lambda and Step Function clients. The lambda.zip signature is the same in every region so is only checked once
+func (release *Release) ValidateResourcesRegionsWithContext(ctx context.Context, awsc aws.AwsClients, kmsc aws.KMSAPI, s3c aws.S3API, signingKeyId string) error {
+	return release.eachRegion(func(r *Release) error {
+		keyId := signingKeyId
+		signingKeyId = ""
+
+		lambdaClient := awsc.LambdaClient(r.AwsRegion, r.AwsAccountID, assumed_role)
+		sfnClient := awsc.SFNClient(r.AwsRegion, r.AwsAccountID, assumed_role)
+		return r.ValidateResourcesWithContext(ctx, lambdaClient, sfnClient, kmsc, s3c, keyId)
+	})
+}
+
+// DeployStepFunctionRegions updates the State Machine in every region.
+// Each region is independent, failed regions are returned in a combined error
+func (release *Release) DeployStepFunctionRegions(awsc aws.AwsClients) error {
+	return release.DeployStepFunctionRegionsWithContext(context.Background(), awsc)
+}
+
+// DeployStepFunctionRegionsWithContext is DeployStepFunctionRegions with ctx passed to the AWS calls
+func (release *Release) DeployStepFunctionRegionsWithContext(ctx context.Context, awsc aws.AwsClients) error {
+	if !release.DeploysStepFunction() {
+		return nil
+	}
+
+	return release.eachRegion(func(r *Release) error {
+		return r.deployStepFunction(ctx, awsc.SFNClient(r.AwsRegion, r.AwsAccountID, assumed_role))
+	})
+}
+
+// deployStepFunction updates and tags the State Machine, it is the sequence shared by Deploy and DeployStepFunctionRegions
+func (release *Release) deployStepFunction(ctx context.Context, sfnClient aws.SFNAPI) error {
+	if err := release.DeployStepFunctionWithContext(ctx, sfnClient); err != nil {
+		return err
+	}
+
+	if release.DryRun {
+		return nil
+	}
+
+	if err := release.TagStepFunction(sfnClient); err != nil {
+		// ignore errors, the State Machine is already deployed
+		fmt.Printf("Warning(TagStepFunction) error ignored: %v\n", err.Error())
+	}
+
+	return nil
+}
+
+func (release *Release) eachRegion(deploy func(*Release) error) error {
+	failures := []string{}
+
+	for _, region := range release.Regions() {
+		if err := deploy(release.ForRegion(region)); err != nil {
+			failures = append(failures, fmt.Sprintf("%v: %v", to.Strs(region), err.Error()))
+		}
+	}
+
+	if len(failures) != 0 {
+		return fmt.Errorf("Deploy failed in regions %q", failures)
+	}
+
+	return nil
+}
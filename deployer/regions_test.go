@@ -0,0 +1,124 @@
+package deployer
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/coinbase/step/aws"
+	"github.com/coinbase/step/aws/mocks"
+	"github.com/coinbase/step/utils/to"
+	"github.com/stretchr/testify/assert"
+)
+
+// regionClients returns a failing SFN client for the bad region
+type regionClients struct {
+	*mocks.MockClients
+	badRegion string
+}
+
+func (awsc *regionClients) SFNClient(region *string, _ *string, _ *string) aws.SFNAPI {
+	if to.Strs(region) == awsc.badRegion {
+		return &mocks.MockSFNClient{UpdateStateMachineError: fmt.Errorf("RegionDown"), DescribeStateMachineError: fmt.Errorf("RegionDown")}
+	}
+	return awsc.SFN
+}
+
+func Test_Release_Regions(t *testing.T) {
+	r := MockRelease()
+	r.AwsRegion = to.Strp("us-east-1")
+	assert.Equal(t, []string{"us-east-1"}, to.StrSlice(r.Regions()))
+
+	r.AwsRegions = []*string{to.Strp("us-west-2"), to.Strp("eu-west-1")}
+	assert.Equal(t, []string{"us-west-2", "eu-west-1"}, to.StrSlice(r.Regions()))
+
+	assert.Equal(t, "arn:aws:lambda:eu-west-1:00000000:function:lambdaname", *r.ForRegion(to.Strp("eu-west-1")).LambdaArn())
+	assert.Equal(t, "arn:aws:states:us-west-2:00000000:stateMachine:stepfnname", *r.ForRegion(to.Strp("us-west-2")).StepArn())
+
+	r.AwsRegions = []*string{to.Strp("us-west-2"), to.Strp("us-west-2")}
+	assert.Error(t, r.validateRegions())
+}
+
+func Test_Release_DeployRegions(t *testing.T) {
+	r := MockRelease()
+	awsc := MockAwsClients(r)
+	r.Bucket = to.Strp("bucket")
+	r.AwsRegions = []*string{to.Strp("us-east-1"), to.Strp("us-west-2"), to.Strp("eu-west-1")}
+
+	assert.NoError(t, r.DeployLambdaRegions(awsc, awsc.S3, nil, nil))
+	assert.NoError(t, r.DeployStepFunctionRegions(awsc))
+
+	err := r.DeployStepFunctionRegions(&regionClients{awsc, "us-west-2"})
+	assert.Error(t, err)
+	assert.Regexp(t, "us-west-2: RegionDown", err.Error())
+	assert.NotRegexp(t, "us-east-1", err.Error())
+	assert.NotRegexp(t, "eu-west-1", err.Error())
+}
+
+func Test_Release_ValidateResourcesRegions(t *testing.T) {
+	r := MockRelease()
+	awsc := MockAwsClients(r)
+	r.Bucket = to.Strp("bucket")
+	r.AwsRegions = []*string{to.Strp("us-east-1"), to.Strp("us-west-2"), to.Strp("eu-west-1")}
+
+	assert.NoError(t, r.ValidateResourcesRegionsWithContext(context.Background(), awsc, nil, awsc.S3, ""))
+
+	err := r.ValidateResourcesRegionsWithContext(context.Background(), &regionClients{awsc, "us-west-2"}, nil, awsc.S3, "")
+	assert.Error(t, err)
+	assert.Regexp(t, "us-west-2: .*RegionDown", err.Error())
+	assert.NotRegexp(t, "us-east-1", err.Error())
+	assert.NotRegexp(t, "eu-west-1", err.Error())
+
+	// The handler validates every region
+	handler := ValidateResourcesHandler(&regionClients{awsc, "us-west-2"}).(func(context.Context, *Release) (*Release, error))
+	_, err = handler(context.Background(), r)
+	assert.Regexp(t, "us-west-2: .*RegionDown", err.Error())
+}
+
+func Test_Release_DeployRegions_S3Pointer(t *testing.T) {
+	r := MockRelease()
+	awsc := MockAwsClients(r)
+	r.Bucket = to.Strp("bucket")
+	r.AwsRegion = to.Strp("us-east-1")
+	r.AwsRegions = []*string{to.Strp("us-east-1"), to.Strp("us-west-2")}
+
+	// Validating the SHA records the S3 Version
+	awsc.S3.Versions = map[string][]string{*r.LambdaZipPath(): {"lambda_zip"}}
+	awsc.S3.GetObjectResp[*r.LambdaZipPath()].Resp.VersionId = to.Strp("v1")
+	assert.NoError(t, r.ValidateLambdaSHA(awsc.S3))
+	assert.Equal(t, "v1", to.Strs(r.LambdaZipVersion))
+
+	assert.NoError(t, r.DeployLambdaRegions(awsc, awsc.S3, to.Strp("us-east-1"), r.AwsAccountID))
+
+	inputs := awsc.Lambda.UpdateFunctionCodeInputs
+	assert.Equal(t, 2, len(inputs))
+
+	// Same region and account as the bucket
+	assert.Equal(t, "bucket", to.Strs(inputs[0].S3Bucket))
+	assert.Equal(t, *r.LambdaZipPath(), to.Strs(inputs[0].S3Key))
+	assert.Equal(t, "v1", to.Strs(inputs[0].S3ObjectVersion))
+	assert.Nil(t, inputs[0].ZipFile)
+
+	// Other region downloads the zip
+	assert.Nil(t, inputs[1].S3Bucket)
+	assert.Equal(t, "lambda_zip", string(inputs[1].ZipFile))
+}
+
+func Test_Release_UseLambdaS3Pointer(t *testing.T) {
+	r := MockRelease()
+	r.AwsRegion = to.Strp("us-east-1")
+	account := r.AwsAccountID
+
+	// No validated version
+	assert.False(t, r.UseLambdaS3Pointer(to.Strp("us-east-1"), account))
+
+	r.LambdaZipVersion = to.Strp("v1")
+	assert.True(t, r.UseLambdaS3Pointer(to.Strp("us-east-1"), account))
+	assert.False(t, r.UseLambdaS3Pointer(to.Strp("us-west-2"), account))
+	assert.False(t, r.UseLambdaS3Pointer(to.Strp("us-east-1"), to.Strp("11111111")))
+	assert.False(t, r.UseLambdaS3Pointer(nil, nil))
+
+	// Separate artifact bucket
+	r.ArtifactBucket = to.Strp("artifacts")
+	assert.False(t, r.UseLambdaS3Pointer(to.Strp("us-east-1"), account))
+}
@@ -1,15 +1,24 @@
 package deployer
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
+	"strings"
+	"sync"
+	"time"
 
+	"github.com/aws/aws-sdk-go/aws/awserr"
 	"github.com/aws/aws-sdk-go/service/lambda"
 	"github.com/aws/aws-sdk-go/service/sfn"
 	"github.com/coinbase/step/aws"
 	"github.com/coinbase/step/aws/s3"
 	"github.com/coinbase/step/bifrost"
+	"github.com/coinbase/step/errors"
 	"github.com/coinbase/step/machine"
+	"github.com/coinbase/step/utils/diff"
 	"github.com/coinbase/step/utils/is"
+	"github.com/coinbase/step/utils/schema"
 	"github.com/coinbase/step/utils/to"
 )
 
@@ -22,26 +31,276 @@ type Release struct {
 	LambdaSHA256 *string `json:"lambda_sha256,omitempty"` // Lambda SHA256 Zip file
 	StepFnName   *string `json:"step_fn_name,omitempty"`  // Step Function Name
 
+	// Base64 or hex CodeSha256 the Lambda must have before the deploy, otherwise it is aborted
+	// so a concurrent deploy is not overwritten. Not supported with Lambdas
+	ExpectedLiveLambdaSHA *string `json:"expected_live_lambda_sha,omitempty"`
+
+	// Alias the State Machine invokes, e.g. PROD. After each deploy a version is published and the alias moved to it
+	LambdaQualifier *string `json:"lambda_qualifier,omitempty"`
+
+	// Bucket with the lambda.zip, defaults to Bucket. The release and locks are always in Bucket
+	ArtifactBucket *string `json:"artifact_bucket,omitempty"`
+
+	// S3 Version of the lambda.zip whose SHA was validated, set by the deployer not the client
+	LambdaZipVersion *string `json:"lambda_zip_version,omitempty"`
+
+	// Deploy many Lambdas that share the State Machine, instead of LambdaName and LambdaSHA256
+	Lambdas []LambdaSpec `json:"lambdas,omitempty"`
+
+	// ECR container image pinned by digest, deployed instead of a lambda.zip with LambdaSHA256
+	ImageUri *string `json:"image_uri,omitempty"`
+
+	LambdaEnvironment map[string]*string `json:"lambda_environment,omitempty"` // Lambda Environment Variables, nil leaves them unchanged
+
+	// Lambda Settings, nil leaves them unchanged
+	LambdaMemorySize                   *int64 `json:"lambda_memory_size,omitempty"`                    // MB 128-10240
+	LambdaTimeout                      *int64 `json:"lambda_timeout,omitempty"`                        // Seconds 1-900
+	LambdaReservedConcurrentExecutions *int64 `json:"lambda_reserved_concurrent_executions,omitempty"` // Reserved Concurrency
+
+	// Lambda layer version ARNs, in order, replacing the Lambda's layers. Empty leaves them unchanged
+	// as an empty list does not survive the JSON between states, ClearLayers removes every layer
+	Layers      []*string `json:"layers,omitempty"`
+	ClearLayers bool      `json:"clear_layers,omitempty"`
+
+	AwsRegions []*string `json:"aws_regions,omitempty"` // Deploy to many regions, defaults to AwsRegion
+
 	StateMachineJSON *string `json:"state_machine_json,omitempty"`
+
+	// STANDARD (default) or EXPRESS, only set on create as a State Machine's type cannot be updated
+	WorkflowType *string `json:"workflow_type,omitempty"`
+
+	// State Machine CloudWatch Logs and X-Ray, nil leaves them unchanged
+	LoggingConfiguration *LoggingConfiguration `json:"logging_configuration,omitempty"`
+	TracingConfiguration *TracingConfiguration `json:"tracing_configuration,omitempty"`
+
+	// Milliseconds each phase of the deploy took, set by the deployer
+	ValidationMillis   *int64 `json:"validation_millis,omitempty"`    // Validate and ValidateResources
+	StepDeployMillis   *int64 `json:"step_deploy_millis,omitempty"`   // Step Function in every region
+	LambdaDeployMillis *int64 `json:"lambda_deploy_millis,omitempty"` // Lambdas in every region
+
+	// JSON Schema of the State Machine input, documents what callers must send
+	InputSchema *string `json:"input_schema,omitempty"`
+
+	DryRun bool `json:"dry_run,omitempty"` // Validate and build the deploy without updating AWS
+
+	// Deploy only part of the release, at most one can be set
+	StepOnly   bool `json:"step_only,omitempty"`   // Only update the State Machine, there is no Lambda code
+	LambdaOnly bool `json:"lambda_only,omitempty"` // Only update the Lambda, there is no StateMachineJSON
+
+	StrictTaskResources bool `json:"strict_task_resources,omitempty"` // Error on Task Resources in other accounts or regions
+
+	// Gated deploys, a release with ApprovalRequired is only deployed once Approved
+	ApprovalRequired bool       `json:"approval_required,omitempty"`
+	Approved         *bool      `json:"approved,omitempty"`
+	ApprovedBy       *string    `json:"approved_by,omitempty"`
+	ApprovedAt       *time.Time `json:"approved_at,omitempty"`
+
+	// Smoke Test the deployed Step Function, nil skips it
+	SmokeTestInput   *string `json:"smoke_test_input,omitempty"`   // Execution input JSON
+	SmokeTestTimeout *int    `json:"smoke_test_timeout,omitempty"` // Seconds per region, default 300, see MaxSmokeTestTime
+
+	lambdaZipName *string // set by ForLambda
+}
+
+// Fingerprint is a short prefix of ReleaseSHA256 to show next to the ReleaseID,
+// a release that is not signed uses the SHA256 of the whole release instead
+func (release *Release) Fingerprint() string {
+	if release.ReleaseSHA256 != "" {
+		return bifrost.FingerprintSHA(release.ReleaseSHA256)
+	}
+	return bifrost.FingerprintSHA(to.SHA256Struct(release))
+}
+
+// DefaultBucketPrefix is prefixed to the account ID to name the Bucket of releases that do not set one,
+// the deployer and client must agree on it
+var DefaultBucketPrefix = "coinbase-step-deployer-"
+
+// SetDefaults sets the bifrost defaults and canonicalizes StateMachineJSON,
+// so definitions that only differ in whitespace, key order or key casing hash the same
+func (release *Release) SetDefaults(region *string, account *string, bucket_prefix string) {
+	release.Release.SetDefaults(region, account, bucket_prefix)
+	release.StateMachineJSON = canonicalStateMachineJSON(release.StateMachineJSON)
+}
+
+// Sign sets ReleaseSHA256 to the hash of the whole release, as the deployer computes it in Validate.
+// Call it after SetDefaults and the upload, as any later change invalidates it
+func (release *Release) Sign() {
+	unsigned := *release
+	unsigned.ReleaseSHA256 = ""
+	release.ReleaseSHA256 = to.HashStruct(release.HashAlgorithm(), &unsigned)
+}
+
+// canonicalStateMachineJSON is canonicalJSONStr of the machine.Normalize definition
+func canonicalStateMachineJSON(str *string) *string {
+	if normalized, err := machine.Normalize(str); err == nil {
+		str = normalized
+	}
+
+	return canonicalJSONStr(str)
+}
+
+// canonicalJSONStr returns str with sorted keys and no whitespace, invalid JSON is returned unchanged
+func canonicalJSONStr(str *string) *string {
+	if str == nil {
+		return nil
+	}
+
+	raw, err := to.CanonicalJSON(json.RawMessage(*str))
+	if err != nil {
+		return str
+	}
+
+	return to.Strp(string(raw))
 }
 
 //////////
 // Validations
 //////////
 
+// Validate checks the release attributes and that the uploaded release and lambda.zip match their SHAs
 func (r *Release) Validate(s3c aws.S3API) error {
 	if err := r.Release.Validate(s3c, &Release{}); err != nil {
 		return err
 	}
 
+	if err := r.validateAttributes(); err != nil {
+		return &errors.ValidationError{err.Error()}
+	}
+
+	// Checked by the deployer not ValidateOffline, so a pending release can be uploaded
+	if err := r.ValidateApproval(); err != nil {
+		return &errors.ValidationError{err.Error()}
+	}
+
+	if err := r.validateLambdaCode(s3c); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// validateLambdaCode checks the image digest for image releases, otherwise the lambda.zip SHA
+func (r *Release) validateLambdaCode(s3c aws.S3API) error {
+	if !r.DeploysLambda() {
+		return nil
+	}
+
+	if r.IsImage() {
+		return r.ValidateImageDigest()
+	}
+
+	return r.eachLambda(func(l *Release) error {
+		return l.ValidateLambdaSHA(s3c)
+	})
+}
+
+// ValidateOffline runs the validations that do not need AWS,
+// so a client can reject a bad release before uploading it
+func (r *Release) ValidateOffline() error {
+	if err := r.Release.ValidateAttributes(); err != nil {
+		return err
+	}
+
+	if err := r.validateAttributes(); err != nil {
+		return &errors.ValidationError{err.Error()}
+	}
+
+	return nil
+}
+
+// SchemaJSON returns the JSON Schema of a Release, generated from its json tags,
+// as a contract for clients in other languages
+func (r *Release) SchemaJSON() string {
+	return releaseSchema().JSON()
+}
+
+// ValidateSchema checks raw release JSON against SchemaJSON before it is unmarshalled,
+// giving an error for every wrongly typed or unknown field
+func (r *Release) ValidateSchema(raw []byte) error {
+	return releaseSchema().Validate(raw)
+}
+
+func releaseSchema() *schema.Schema {
+	return schema.Generate("Release", Release{})
+}
+
+func (r *Release) validateAttributes() error {
+	if err := r.validatePartial(); err != nil {
+		return err
+	}
+
+	if err := r.validateRegions(); err != nil {
+		return err
+	}
+
+	if err := r.validateSmokeTest(); err != nil {
+		return err
+	}
+
+	if r.DeploysLambda() {
+		if err := r.validateLambdaAttributes(); err != nil {
+			return err
+		}
+	}
+
+	if r.DeploysStepFunction() {
+		if err := r.validateStepFunctionAttributes(); err != nil {
+			return err
+		}
+	}
+
+	return r.runValidators()
+}
+
+func (r *Release) validateLambdaAttributes() error {
+	if err := r.validateLambdas(); err != nil {
+		return err
+	}
+
+	return r.eachLambda((*Release).validateLambdaFunctionAttributes)
+}
+
+func (r *Release) validateLambdaFunctionAttributes() error {
 	if is.EmptyStr(r.LambdaName) {
 		return fmt.Errorf("LambdaName must be defined")
 	}
 
-	if is.EmptyStr(r.LambdaSHA256) {
-		return fmt.Errorf("LambdaSHA256 must be defined")
+	if is.EmptyStr(r.LambdaSHA256) == is.EmptyStr(r.ImageUri) {
+		return fmt.Errorf("Exactly one of LambdaSHA256 (zip) or ImageUri (image) must be defined")
 	}
 
+	if err := r.validateLambdaSHAFormat(); err != nil {
+		return err
+	}
+
+	if err := r.validateLambdaEnvironment(); err != nil {
+		return err
+	}
+
+	if err := r.validateLambdaSettings(); err != nil {
+		return err
+	}
+
+	if err := r.validateLambdaQualifier(); err != nil {
+		return err
+	}
+
+	if err := r.validateExpectedLiveLambdaSHA(); err != nil {
+		return err
+	}
+
+	if r.IsImage() {
+		if err := r.ValidateImageDigest(); err != nil {
+			return err
+		}
+
+		return r.deployLambdaImageInput().Validate()
+	}
+
+	return r.deployLambdaInput(to.ABytep([]byte{})).Validate()
+}
+
+func (r *Release) validateStepFunctionAttributes() error {
 	if is.EmptyStr(r.StepFnName) {
 		return fmt.Errorf("StepFnName must be defined")
 	}
@@ -50,21 +309,31 @@ func (r *Release) Validate(s3c aws.S3API) error {
 		return fmt.Errorf("StateMachineJSON must be defined")
 	}
 
+	if err := r.validateWorkflowType(); err != nil {
+		return err
+	}
+
+	if err := r.validateStepObservability(); err != nil {
+		return err
+	}
+
 	// Validate State machine
 	if err := machine.Validate(r.StateMachineJSON); err != nil {
 		return fmt.Errorf("StateMachineJSON invalid with '%v'", err.Error())
 	}
 
-	if err := r.deployLambdaInput(to.ABytep([]byte{})).Validate(); err != nil {
+	if err := r.validateInputSchema(); err != nil {
 		return err
 	}
 
-	if err := r.deployStepFunctionInput().Validate(); err != nil {
+	input := r.deployStepFunctionInput()
+	if err := input.Validate(); err != nil {
 		return err
 	}
 
-	if err := r.ValidateLambdaSHA(s3c); err != nil {
-		return err
+	// The pretty printed definition is what is sent to AWS
+	if err := machine.ValidateDefinitionSize(input.Definition); err != nil {
+		return fmt.Errorf("StateMachineJSON invalid with '%v'", err.Error())
 	}
 
 	return nil
@@ -72,45 +341,150 @@ func (r *Release) Validate(s3c aws.S3API) error {
 
 // Resource Validations
 
-func (r *Release) ValidateResources(lambdac aws.LambdaAPI, sfnc aws.SFNAPI) error {
-	if err := r.ValidateLambdaFunctionTags(lambdac); err != nil {
-		return err
+// ValidateResources checks the Lambda and Step Function can be deployed to,
+// if signingKeyId is not empty the lambda.zip signature is also validated
+func (r *Release) ValidateResources(lambdac aws.LambdaAPI, sfnc aws.SFNAPI, kmsc aws.KMSAPI, s3c aws.S3API, signingKeyId string) error {
+	return r.ValidateResourcesWithContext(context.Background(), lambdac, sfnc, kmsc, s3c, signingKeyId)
+}
+
+// ValidateResourcesWithContext is ValidateResources with ctx passed to the AWS calls
+func (r *Release) ValidateResourcesWithContext(ctx context.Context, lambdac aws.LambdaAPI, sfnc aws.SFNAPI, kmsc aws.KMSAPI, s3c aws.S3API, signingKeyId string) error {
+	checks := []func() error{}
+
+	if r.DeploysLambda() {
+		checks = append(checks, func() error {
+			return r.eachLambda(func(l *Release) error {
+				return l.ValidateLambdaFunctionTagsWithContext(ctx, lambdac)
+			})
+		})
 	}
 
-	if err := r.ValidateStepFunctionPath(sfnc); err != nil {
-		return err
+	if !is.EmptyStr(r.StepFnName) {
+		checks = append(checks, func() error {
+			return r.ValidateStepFunctionPathWithContext(ctx, sfnc)
+		})
 	}
 
-	return nil
+	if signingKeyId != "" && r.DeploysLambda() {
+		checks = append(checks, func() error {
+			if r.IsImage() {
+				return fmt.Errorf("Lambda Signature Error: signatures are only supported for lambda.zip releases")
+			}
+
+			return r.eachLambda(func(l *Release) error {
+				return l.ValidateLambdaSignatureWithContext(ctx, kmsc, s3c, signingKeyId)
+			})
+		})
+	}
+
+	err := validateConcurrently(checks...)
+	if err != nil && ctx.Err() != nil {
+		// The checks were cancelled, not failed
+		return ctx.Err()
+	}
+
+	return err
+}
+
+// ValidationErrors are the errors of every check that failed in validateConcurrently
+type ValidationErrors []error
+
+func (e ValidationErrors) Error() string {
+	failures := []string{}
+	for _, err := range e {
+		failures = append(failures, err.Error())
+	}
+
+	return fmt.Sprintf("%v validations failed: %v", len(failures), strings.Join(failures, "; "))
+}
+
+// Unwrap returns the errors so errors.Is and errors.As find each of them, e.g. a SHAMismatchError
+func (e ValidationErrors) Unwrap() []error {
+	return e
+}
+
+// validateConcurrently runs the checks at the same time as each is an AWS round trip,
+// and returns all of their errors instead of only the first
+func validateConcurrently(checks ...func() error) error {
+	errs := make([]error, len(checks))
+
+	var wg sync.WaitGroup
+	for i, check := range checks {
+		wg.Add(1)
+		go func(i int, check func() error) {
+			defer wg.Done()
+			defer func() {
+				// A panic in a goroutine cannot be recovered by the handler
+				if rec := recover(); rec != nil {
+					errs[i] = &errors.PanicError{fmt.Sprintf("%v", rec)}
+				}
+			}()
+			errs[i] = check()
+		}(i, check)
+	}
+	wg.Wait()
+
+	failed := ValidationErrors{}
+	for _, err := range errs {
+		if err != nil {
+			failed = append(failed, err)
+		}
+	}
+
+	switch len(failed) {
+	case 0:
+		return nil
+	case 1:
+		return failed[0]
+	}
+
+	return failed
 }
 
 func (r *Release) ValidateLambdaFunctionTags(lambdac aws.LambdaAPI) error {
-	project, config, deployer, err := r.LambdaProjectConfigDeployerTags(lambdac)
+	return r.ValidateLambdaFunctionTagsWithContext(context.Background(), lambdac)
+}
+
+func (r *Release) ValidateLambdaFunctionTagsWithContext(ctx context.Context, lambdac aws.LambdaAPI) error {
+	project, config, deployer, err := r.LambdaProjectConfigDeployerTagsWithContext(ctx, lambdac)
 	if err != nil {
 		return err
 	}
 
 	if project == nil || config == nil || deployer == nil {
-		return fmt.Errorf("ProjectName, ConfigName and or DeployWith tag on lambda is nil")
+		return &errors.TagMismatchError{Cause: "ProjectName, ConfigName and or DeployWith tag on lambda is nil"}
 	}
 
 	if *r.ProjectName != *project {
-		return fmt.Errorf("Lambda ProjectName tag incorrect, expecting %v has %v", *r.ProjectName, *project)
+		return lambdaTagMismatch("ProjectName", *r.ProjectName, *project)
 	}
 
 	if *r.ConfigName != *config {
-		return fmt.Errorf("Lambda ConfigName tag incorrect, expecting %v has %v", *r.ConfigName, *config)
+		return lambdaTagMismatch("ConfigName", *r.ConfigName, *config)
 	}
 
 	if "step-deployer" != *deployer {
-		return fmt.Errorf("Lambda DeployWith tag incorrect, expecting step-deployer has %v", *deployer)
+		return lambdaTagMismatch("DeployWith", "step-deployer", *deployer)
 	}
 
 	return nil
 }
 
+func lambdaTagMismatch(tag string, expected string, actual string) error {
+	return &errors.TagMismatchError{
+		Cause:    fmt.Sprintf("Lambda %v tag incorrect, expecting %v has %v", tag, expected, actual),
+		Tag:      tag,
+		Expected: expected,
+		Actual:   actual,
+	}
+}
+
 func (r *Release) ValidateStepFunctionPath(sfnc aws.SFNAPI) error {
-	out, err := sfnc.DescribeStateMachine(&sfn.DescribeStateMachineInput{StateMachineArn: r.StepArn()})
+	return r.ValidateStepFunctionPathWithContext(context.Background(), sfnc)
+}
+
+func (r *Release) ValidateStepFunctionPathWithContext(ctx context.Context, sfnc aws.SFNAPI) error {
+	out, err := sfnc.DescribeStateMachineWithContext(ctx, &sfn.DescribeStateMachineInput{StateMachineArn: r.StepArn()})
 
 	if err != nil {
 		return err
@@ -120,7 +494,21 @@ func (r *Release) ValidateStepFunctionPath(sfnc aws.SFNAPI) error {
 		return fmt.Errorf("Unknown Step Function Error")
 	}
 
-	path := to.ArnPath(*out.RoleArn)
+	if out.Type != nil && *out.Type != r.StateMachineType() {
+		return fmt.Errorf("Step Function type is %v expecting %v, the type cannot be updated", *out.Type, r.StateMachineType())
+	}
+
+	return r.validateStepFunctionRole(*out.RoleArn)
+}
+
+// validateStepFunctionRole checks the role is in the /step/<project>/<config>/ path
+func (r *Release) validateStepFunctionRole(roleArnStr string) error {
+	roleArn, err := to.ParseArn(roleArnStr)
+	if err != nil {
+		return fmt.Errorf("Step Function Role ARN invalid: %v", err.Error())
+	}
+
+	path := roleArn.Path()
 
 	expected := fmt.Sprintf("/step/%v/%v/", *r.ProjectName, *r.ConfigName)
 	if path != expected {
@@ -130,39 +518,92 @@ func (r *Release) ValidateStepFunctionPath(sfnc aws.SFNAPI) error {
 	return nil
 }
 
+// validateLambdaSHAFormat checks a SHA256 LambdaSHA256 is a hex or base64 32 byte digest,
+// other HashAlgo are not checked
+func (r *Release) validateLambdaSHAFormat() error {
+	if is.EmptyStr(r.LambdaSHA256) || r.HashAlgorithm() != to.SHA256 {
+		return nil
+	}
+
+	if _, err := to.NormalizeSHA256(*r.LambdaSHA256); err != nil {
+		return fmt.Errorf("LambdaSHA256 invalid: %v", err.Error())
+	}
+
+	return nil
+}
+
+// lambdaSHAMatches returns true if the hex sha of the lambda.zip is LambdaSHA256,
+// SHA256s are compared as base64 so either encoding of LambdaSHA256 matches
+func (r *Release) lambdaSHAMatches(sha string) bool {
+	if r.HashAlgorithm() != to.SHA256 {
+		return sha == to.Strs(r.LambdaSHA256)
+	}
+
+	expected, err := to.NormalizeSHA256(to.Strs(r.LambdaSHA256))
+	if err != nil {
+		return false
+	}
+
+	actual, err := to.NormalizeSHA256(sha)
+	return err == nil && actual == expected
+}
+
+// lambdaSHAMismatch is the SHAMismatchError for a lambda.zip that hashed to sha
+func (r *Release) lambdaSHAMismatch(sha string) error {
+	return &errors.SHAMismatchError{
+		Cause:    fmt.Sprintf("Lambda SHA mismatch, expecting %v, got %v", to.Strs(r.LambdaSHA256), sha),
+		Expected: to.Strs(r.LambdaSHA256),
+		Actual:   sha,
+	}
+}
+
+// ValidateLambdaSHA checks the uploaded lambda.zip matches LambdaSHA256,
+// and records its S3 Version so exactly that zip can be deployed from S3
 func (r *Release) ValidateLambdaSHA(s3c aws.S3API) error {
-	sha, err := s3.GetSHA256(s3c, r.Bucket, r.LambdaZipPath())
+	r.LambdaZipVersion = nil
+
+	out, zip, err := s3.GetObject(s3c, r.LambdaZipBucket(), r.LambdaZipPath())
 	if err != nil {
 		return err
 	}
 
-	if sha != *r.LambdaSHA256 {
-		return fmt.Errorf("Lambda SHA mismatch, expecting %v, got %v", *r.LambdaSHA256, sha)
+	if sha := to.HashAByte(r.HashAlgorithm(), zip); !r.lambdaSHAMatches(sha) {
+		return r.lambdaSHAMismatch(sha)
+	}
+
+	if out != nil {
+		r.LambdaZipVersion = out.VersionId
 	}
 
 	return nil
 }
 
 func (r *Release) LambdaProjectConfigDeployerTags(lambdac aws.LambdaAPI) (*string, *string, *string, error) {
-	out, err := lambdac.ListTags(&lambda.ListTagsInput{
-		Resource: r.LambdaArn(),
-	})
+	return r.LambdaProjectConfigDeployerTagsWithContext(context.Background(), lambdac)
+}
 
+func (r *Release) LambdaProjectConfigDeployerTagsWithContext(ctx context.Context, lambdac aws.LambdaAPI) (*string, *string, *string, error) {
+	tags, err := r.LambdaTagsWithContext(ctx, lambdac)
 	if err != nil {
 		return nil, nil, nil, err
 	}
 
-	if out == nil {
-		return nil, nil, nil, fmt.Errorf("Unknown Lambda Tags Error")
-	}
-
-	return out.Tags["ProjectName"], out.Tags["ConfigName"], out.Tags["DeployWith"], nil
+	return tags["ProjectName"], tags["ConfigName"], tags["DeployWith"], nil
 }
 
 //////////
 // AWS Methods
 //////////
 
+// DeployRetryPolicy retries throttled and 5xx errors when updating the Lambda and Step Function
+var DeployRetryPolicy = aws.RetryPolicy{MaxAttempts: 5, BaseDelay: 500 * time.Millisecond}
+
+// LambdaUpdatePollInterval is how long to wait between checks that a Lambda code update has finished
+var LambdaUpdatePollInterval = 2 * time.Second
+
+// LambdaUpdateTimeout is how long to wait for a Lambda code update to finish
+var LambdaUpdateTimeout = 5 * time.Minute
+
 func (release *Release) deployLambdaInput(zip *[]byte) *lambda.UpdateFunctionCodeInput {
 	return &lambda.UpdateFunctionCodeInput{
 		FunctionName: release.LambdaArn(),
@@ -170,57 +611,381 @@ func (release *Release) deployLambdaInput(zip *[]byte) *lambda.UpdateFunctionCod
 	}
 }
 
+// deployLambdaS3Input points the Lambda at the validated version of the lambda.zip,
+// avoiding the direct upload size limit
+func (release *Release) deployLambdaS3Input() *lambda.UpdateFunctionCodeInput {
+	return &lambda.UpdateFunctionCodeInput{
+		FunctionName:    release.LambdaArn(),
+		S3Bucket:        release.LambdaZipBucket(),
+		S3Key:           release.LambdaZipPath(),
+		S3ObjectVersion: release.LambdaZipVersion,
+	}
+}
+
+// UseLambdaS3Pointer returns true if the Lambda can be deployed from the Bucket directly.
+// Lambda can only read code from a Bucket in its own region and account,
+// and without a validated S3 Version the zip could have changed since its SHA was checked.
+// The region and account of a separate ArtifactBucket are unknown so it is always downloaded
+func (release *Release) UseLambdaS3Pointer(bucketRegion *string, bucketAccount *string) bool {
+	if is.EmptyStr(release.LambdaZipVersion) || is.EmptyStr(bucketRegion) || is.EmptyStr(bucketAccount) {
+		return false
+	}
+
+	if to.Strs(release.LambdaZipBucket()) != to.Strs(release.Bucket) {
+		return false
+	}
+
+	return *bucketRegion == to.Strs(release.AwsRegion) && *bucketAccount == to.Strs(release.AwsAccountID)
+}
+
 // DeployLambdaCode
 func (release *Release) DeployLambdaCode(lambdaClient aws.LambdaAPI, zip *[]byte) error {
-	_, err := lambdaClient.UpdateFunctionCode(release.deployLambdaInput(zip))
-	return err
+	return release.DeployLambdaCodeWithContext(context.Background(), lambdaClient, zip)
 }
 
-// DeployLambda uploads new Code to the Lambda
-func (release *Release) DeployLambda(lambdaClient aws.LambdaAPI, s3c aws.S3API) error {
-	// Download and pass Zip file because lambda might be in another region or account
-	zip, err := s3.Get(s3c, release.Bucket, release.LambdaZipPath())
+// DeployLambdaCodeWithContext is DeployLambdaCode with ctx passed to the AWS calls
+func (release *Release) DeployLambdaCodeWithContext(ctx context.Context, lambdaClient aws.LambdaAPI, zip *[]byte) error {
+	return release.updateFunctionCode(ctx, lambdaClient, release.deployLambdaInput(zip))
+}
+
+// DeployLambdaCodeFromS3 deploys the validated lambda.zip from the Bucket without downloading it
+func (release *Release) DeployLambdaCodeFromS3(lambdaClient aws.LambdaAPI) error {
+	return release.DeployLambdaCodeFromS3WithContext(context.Background(), lambdaClient)
+}
+
+// DeployLambdaCodeFromS3WithContext is DeployLambdaCodeFromS3 with ctx passed to the AWS calls
+func (release *Release) DeployLambdaCodeFromS3WithContext(ctx context.Context, lambdaClient aws.LambdaAPI) error {
+	return release.updateFunctionCode(ctx, lambdaClient, release.deployLambdaS3Input())
+}
+
+func (release *Release) updateFunctionCode(ctx context.Context, lambdaClient aws.LambdaAPI, input *lambda.UpdateFunctionCodeInput) error {
+	revisionID, err := release.expectLiveLambdaSHA(ctx, lambdaClient)
 	if err != nil {
 		return err
 	}
+	input.RevisionId = revisionID
+
+	err = release.deployPhase(ctx, DeployPhaseLambdaCode, func() error {
+		return DeployRetryPolicy.DoWithContext(ctx, func() error {
+			_, err := lambdaClient.UpdateFunctionCodeWithContext(ctx, input)
+			return err
+		})
+	})
 
-	err = release.DeployLambdaCode(lambdaClient, zip)
 	if err != nil {
 		return err
 	}
 
+	return release.deployPhase(ctx, DeployPhaseLambdaWait, func() error {
+		return release.WaitForFunctionUpdated(ctx, lambdaClient)
+	})
+}
+
+// WaitForFunctionUpdated polls the Lambda until its LastUpdateStatus is Successful,
+// otherwise updating its configuration can fail with ResourceConflictException
+func (release *Release) WaitForFunctionUpdated(ctx context.Context, lambdaClient aws.LambdaAPI) error {
+	deadline := time.Now().Add(LambdaUpdateTimeout)
+
+	for {
+		out, err := lambdaClient.GetFunctionConfigurationWithContext(ctx, &lambda.GetFunctionConfigurationInput{
+			FunctionName: release.LambdaArn(),
+		})
+
+		if err != nil {
+			return err
+		}
+
+		switch to.Strs(out.LastUpdateStatus) {
+		case "", lambda.LastUpdateStatusSuccessful:
+			// Empty for functions without update states
+			return nil
+		case lambda.LastUpdateStatusFailed:
+			return fmt.Errorf("Lambda Update Failed: %v %v", to.Strs(out.LastUpdateStatusReasonCode), to.Strs(out.LastUpdateStatusReason))
+		}
+
+		if time.Now().After(deadline) {
+			return fmt.Errorf("Lambda Update Error: timed out after %v with status %v", LambdaUpdateTimeout, to.Strs(out.LastUpdateStatus))
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(LambdaUpdatePollInterval):
+		}
+	}
+}
+
+// DeployLambda uploads new Code to the Lambda. bucketRegion and bucketAccount are where the Bucket is,
+// if they match the Lambda the code is deployed from S3, otherwise the Zip is downloaded and uploaded
+func (release *Release) DeployLambda(lambdaClient aws.LambdaAPI, s3c aws.S3API, bucketRegion *string, bucketAccount *string) error {
+	return release.DeployLambdaWithContext(context.Background(), lambdaClient, s3c, bucketRegion, bucketAccount)
+}
+
+// DeployLambdaWithContext is DeployLambda with ctx passed to the Lambda calls
+func (release *Release) DeployLambdaWithContext(ctx context.Context, lambdaClient aws.LambdaAPI, s3c aws.S3API, bucketRegion *string, bucketAccount *string) error {
+	return release.DeployLambdaWithProgress(ctx, lambdaClient, s3c, bucketRegion, bucketAccount, nil)
+}
+
+// DeployLambdaWithProgress is DeployLambdaWithContext calling progress while the Zip is downloaded, progress can be nil
+func (release *Release) DeployLambdaWithProgress(ctx context.Context, lambdaClient aws.LambdaAPI, s3c aws.S3API, bucketRegion *string, bucketAccount *string, progress s3.ProgressFunc) error {
+	if !release.DeploysLambda() {
+		return nil
+	}
+
+	if !release.DryRun {
+		if err := release.ValidateApproval(); err != nil {
+			return err
+		}
+	}
+
+	return release.eachLambda(func(l *Release) error {
+		var zip *[]byte
+		return l.deployLambdaFunction(ctx, lambdaClient, s3c, bucketRegion, bucketAccount, progress, &zip)
+	})
+}
+
+// deployLambdaFunction deploys the code, configuration, settings, alias and tags of one Lambda,
+// it is the sequence shared by DeployLambda and DeployLambdaRegions.
+// zip holds the downloaded Zip so deploying many regions downloads it at most once
+func (release *Release) deployLambdaFunction(ctx context.Context, lambdaClient aws.LambdaAPI, s3c aws.S3API, bucketRegion *string, bucketAccount *string, progress s3.ProgressFunc, zip **[]byte) error {
+	if release.DryRun {
+		_, err := release.DeployLambdaDryRun(s3c)
+		return err
+	}
+
+	if err := release.deployLambdaFunctionCode(ctx, lambdaClient, s3c, bucketRegion, bucketAccount, progress, zip); err != nil {
+		return err
+	}
+
+	if err := release.DeployLambdaConfigurationWithContext(ctx, lambdaClient); err != nil {
+		return err
+	}
+
+	if err := release.DeployLambdaSettings(lambdaClient); err != nil {
+		return err
+	}
+
+	// Published last so the version has the new code and configuration
+	if err := release.DeployLambdaAlias(ctx, lambdaClient); err != nil {
+		return err
+	}
+
+	if err := release.TagLambda(lambdaClient); err != nil {
+		// ignore errors, the code is already deployed
+		fmt.Printf("Warning(TagLambda) error ignored: %v\n", err.Error())
+	}
+
 	return nil
 }
 
+func (release *Release) deployLambdaFunctionCode(ctx context.Context, lambdaClient aws.LambdaAPI, s3c aws.S3API, bucketRegion *string, bucketAccount *string, progress s3.ProgressFunc, zip **[]byte) error {
+	if release.IsImage() {
+		return release.DeployLambdaCodeFromImageWithContext(ctx, lambdaClient)
+	}
+
+	if release.UseLambdaS3Pointer(bucketRegion, bucketAccount) {
+		return release.DeployLambdaCodeFromS3WithContext(ctx, lambdaClient)
+	}
+
+	// Download and pass Zip file because lambda might be in another region or account
+	if *zip == nil {
+		err := release.deployPhase(ctx, DeployPhaseDownload, func() error {
+			var err error
+			*zip, err = release.downloadLambdaZip(s3c, progress)
+			return err
+		})
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return release.DeployLambdaCodeWithContext(ctx, lambdaClient, *zip)
+}
+
+// DeployLambdaDryRun downloads and validates the Zip and returns the input that DeployLambda would send
+func (release *Release) DeployLambdaDryRun(s3c aws.S3API) (*lambda.UpdateFunctionCodeInput, error) {
+	if release.IsImage() {
+		if err := release.ValidateImageDigest(); err != nil {
+			return nil, err
+		}
+		return release.deployLambdaImageInput(), nil
+	}
+
+	zip, err := s3.Get(s3c, release.LambdaZipBucket(), release.LambdaZipPath())
+	if err != nil {
+		return nil, err
+	}
+
+	if sha := to.HashAByte(release.HashAlgorithm(), zip); !release.lambdaSHAMatches(sha) {
+		return nil, release.lambdaSHAMismatch(sha)
+	}
+
+	input := release.deployLambdaInput(zip)
+	if err := input.Validate(); err != nil {
+		return nil, err
+	}
+
+	return input, nil
+}
+
 func (release *Release) deployStepFunctionInput() *sfn.UpdateStateMachineInput {
 	return &sfn.UpdateStateMachineInput{
-		Definition:      to.Strp(to.PrettyJSONStr(release.StateMachineJSON)),
-		StateMachineArn: release.StepArn(),
+		Definition:           to.Strp(to.PrettyJSONStr(release.StateMachineJSON)),
+		StateMachineArn:      release.StepArn(),
+		LoggingConfiguration: release.sfnLoggingConfiguration(),
+		TracingConfiguration: release.sfnTracingConfiguration(),
 	}
 }
 
 // DeployStepFunction updates the step function State Machine
 func (release *Release) DeployStepFunction(sfnClient aws.SFNAPI) error {
-	_, err := sfnClient.UpdateStateMachine(release.deployStepFunctionInput())
+	return release.DeployStepFunctionWithContext(context.Background(), sfnClient)
+}
 
-	if err != nil {
+// DeployStepFunctionWithContext is DeployStepFunction with ctx passed to the AWS calls
+func (release *Release) DeployStepFunctionWithContext(ctx context.Context, sfnClient aws.SFNAPI) error {
+	if !release.DeploysStepFunction() {
+		return nil
+	}
+
+	if release.DryRun {
+		_, err := release.DeployStepFunctionDryRun()
 		return err
 	}
 
-	return nil
+	if err := release.ValidateApproval(); err != nil {
+		return err
+	}
+
+	return release.deployPhase(ctx, DeployPhaseStepFunction, func() error {
+		return DeployRetryPolicy.DoWithContext(ctx, func() error {
+			_, err := sfnClient.UpdateStateMachineWithContext(ctx, release.deployStepFunctionInput())
+			return err
+		})
+	})
+}
+
+// DeployStepFunctionDryRun validates and returns the input that DeployStepFunction would send
+func (release *Release) DeployStepFunctionDryRun() (*sfn.UpdateStateMachineInput, error) {
+	if is.EmptyStr(release.StateMachineJSON) {
+		return nil, fmt.Errorf("StateMachineJSON must be defined")
+	}
+
+	if err := machine.Validate(release.StateMachineJSON); err != nil {
+		return nil, fmt.Errorf("StateMachineJSON invalid with '%v'", err.Error())
+	}
+
+	input := release.deployStepFunctionInput()
+	if err := input.Validate(); err != nil {
+		return nil, err
+	}
+
+	if err := machine.ValidateDefinitionSize(input.Definition); err != nil {
+		return nil, fmt.Errorf("StateMachineJSON invalid with '%v'", err.Error())
+	}
+
+	return input, nil
 }
 
 ///////
 // Lambda
 ///////
 
+// LambdaZipPath is the key of the zip zipName in the release directory. zipName is lambda.zip
+// for a release with a single Lambda, otherwise the LambdaSpec ZipPath or <Name>.zip
+func LambdaZipPath(account, project, config, releaseId, zipName string) string {
+	return fmt.Sprintf("%v/%v", bifrost.ReleaseDir(account, project, config, releaseId), zipName)
+}
+
+// LambdaZipPath is the lambda.zip in the release directory, or the zip of the Lambda for a ForLambda release
 func (release *Release) LambdaZipPath() *string {
-	s := fmt.Sprintf("%v/lambda.zip", *release.ReleaseDir())
-	return &s
+	name := "lambda.zip"
+	if release.lambdaZipName != nil {
+		name = *release.lambdaZipName
+	}
+
+	return to.Strp(LambdaZipPath(*release.AwsAccountID, *release.ProjectName, *release.ConfigName, *release.ReleaseID, name))
+}
+
+// ValidateArtifactBucket checks a separate ArtifactBucket is allowedBucket, the only other bucket
+// the deployer Lambda policy can read from. An empty allowedBucket allows no ArtifactBucket
+func (release *Release) ValidateArtifactBucket(allowedBucket string) error {
+	if is.EmptyStr(release.ArtifactBucket) || *release.ArtifactBucket == to.Strs(release.Bucket) {
+		return nil
+	}
+
+	if *release.ArtifactBucket != allowedBucket {
+		return fmt.Errorf("ArtifactBucket %v is not readable by this deployer, only %q", *release.ArtifactBucket, allowedBucket)
+	}
+
+	return nil
+}
+
+// LambdaZipBucket is the ArtifactBucket if set, otherwise the Bucket
+func (release *Release) LambdaZipBucket() *string {
+	if is.EmptyStr(release.ArtifactBucket) {
+		return release.Bucket
+	}
+	return release.ArtifactBucket
 }
 
 func (release *Release) LambdaArn() *string {
-	return to.LambdaArn(release.AwsRegion, release.AwsAccountID, release.LambdaName)
+	return to.LambdaArn(release.Partition, release.AwsRegion, release.AwsAccountID, release.LambdaName)
+}
+
+// PublishVersionAndAlias publishes a version of the deployed Lambda code and points alias at it.
+// LambdaSHA256 guards that the published code is the code that was deployed,
+// Lambda only reports SHA256 so there is no guard for other HashAlgo or for images
+func (release *Release) PublishVersionAndAlias(lambdaClient aws.LambdaAPI, alias string) (*string, error) {
+	var codeSHA *string
+	if release.HashAlgorithm() == to.SHA256 && !release.IsImage() {
+		sha, err := to.NormalizeSHA256(to.Strs(release.LambdaSHA256))
+		if err != nil {
+			return nil, fmt.Errorf("LambdaSHA256 invalid: %v", err.Error())
+		}
+		codeSHA = &sha
+	}
+
+	version, err := lambdaClient.PublishVersion(&lambda.PublishVersionInput{
+		FunctionName: release.LambdaArn(),
+		CodeSha256:   codeSHA,
+	})
+
+	if err != nil {
+		if aerr, ok := err.(awserr.Error); ok && aerr.Code() == lambda.ErrCodeInvalidParameterValueException {
+			return nil, fmt.Errorf("Lambda CodeSha256 mismatch, expecting %v: %v", to.Strs(codeSHA), aerr.Message())
+		}
+		return nil, err
+	}
+
+	if version == nil || version.Version == nil {
+		return nil, fmt.Errorf("Unknown Lambda PublishVersion Error")
+	}
+
+	if codeSHA != nil && version.CodeSha256 != nil && *version.CodeSha256 != *codeSHA {
+		return nil, fmt.Errorf("Lambda CodeSha256 mismatch, expecting %v, got %v", *codeSHA, *version.CodeSha256)
+	}
+
+	_, err = lambdaClient.UpdateAlias(&lambda.UpdateAliasInput{
+		FunctionName:    release.LambdaArn(),
+		Name:            &alias,
+		FunctionVersion: version.Version,
+	})
+
+	if aerr, ok := err.(awserr.Error); ok && aerr.Code() == lambda.ErrCodeResourceNotFoundException {
+		_, err = lambdaClient.CreateAlias(&lambda.CreateAliasInput{
+			FunctionName:    release.LambdaArn(),
+			Name:            &alias,
+			FunctionVersion: version.Version,
+		})
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return version.Version, nil
 }
 
 ///////
@@ -228,5 +993,86 @@ func (release *Release) LambdaArn() *string {
 ///////
 
 func (release *Release) StepArn() *string {
-	return to.StepArn(release.AwsRegion, release.AwsAccountID, release.StepFnName)
+	return to.StepArn(release.Partition, release.AwsRegion, release.AwsAccountID, release.StepFnName)
+}
+
+// DescribeDeployed returns the live State Machine at StepArn, e.g. to show its status after a deploy
+func (release *Release) DescribeDeployed(sfnClient aws.SFNAPI) (*sfn.DescribeStateMachineOutput, error) {
+	out, err := sfnClient.DescribeStateMachine(&sfn.DescribeStateMachineInput{StateMachineArn: release.StepArn()})
+	if err != nil {
+		return nil, err
+	}
+
+	if out == nil {
+		return nil, fmt.Errorf("Unknown Step Function Error")
+	}
+
+	return out, nil
+}
+
+// NewStateMachineDiff is returned by StateMachineDiff when the State Machine does not exist yet
+const NewStateMachineDiff = "new state machine"
+
+// StateMachineDiff returns a unified diff from the live State Machine definition to StateMachineJSON
+func (release *Release) StateMachineDiff(sfnClient aws.SFNAPI) (string, error) {
+	out, err := sfnClient.DescribeStateMachine(&sfn.DescribeStateMachineInput{StateMachineArn: release.StepArn()})
+
+	if aerr, ok := err.(awserr.Error); ok && aerr.Code() == sfn.ErrCodeStateMachineDoesNotExist {
+		return NewStateMachineDiff, nil
+	}
+
+	if err != nil {
+		return "", err
+	}
+
+	if out == nil || out.Definition == nil {
+		return "", fmt.Errorf("Unknown Step Function Error")
+	}
+
+	return diff.Unified(
+		*release.StepArn(),
+		*release.ReleaseID,
+		to.PrettyJSONStr(out.Definition),
+		to.PrettyJSONStr(release.StateMachineJSON),
+	), nil
+}
+
+// EnsureStepFunction creates the State Machine with roleArn if it does not exist, otherwise updates it.
+// roleArn must be in the /step/<project>/<config>/ path so later deploys pass ValidateStepFunctionPath
+func (release *Release) EnsureStepFunction(sfnClient aws.SFNAPI, roleArn string) error {
+	_, err := sfnClient.DescribeStateMachine(&sfn.DescribeStateMachineInput{StateMachineArn: release.StepArn()})
+
+	if err == nil {
+		return release.DeployStepFunction(sfnClient)
+	}
+
+	if aerr, ok := err.(awserr.Error); !ok || aerr.Code() != sfn.ErrCodeStateMachineDoesNotExist {
+		return err
+	}
+
+	if err := release.validateStepFunctionRole(roleArn); err != nil {
+		return err
+	}
+
+	if release.DryRun {
+		_, err := release.DeployStepFunctionDryRun()
+		return err
+	}
+
+	if err := release.ValidateApproval(); err != nil {
+		return err
+	}
+
+	return DeployRetryPolicy.Do(func() error {
+		_, err := sfnClient.CreateStateMachine(&sfn.CreateStateMachineInput{
+			Name:       release.StepFnName,
+			Definition: to.Strp(to.PrettyJSONStr(release.StateMachineJSON)),
+			RoleArn:    &roleArn,
+			Type:       to.Strp(release.StateMachineType()),
+
+			LoggingConfiguration: release.sfnLoggingConfiguration(),
+			TracingConfiguration: release.sfnTracingConfiguration(),
+		})
+		return err
+	})
 }
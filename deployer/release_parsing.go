@@ -3,6 +3,8 @@ package deployer
 import (
 	"bytes"
 	"encoding/json"
+
+	"github.com/coinbase/step/utils/to"
 )
 
 // The goal here is to raise an error if a key is sent that is not supported.
@@ -28,3 +30,19 @@ func (release *Release) UnmarshalJSON(data []byte) error {
 	*release = Release(releaseWithExceptions.releaseAlias)
 	return nil
 }
+
+// ToJSON returns the release as to.CanonicalJSON, object keys sorted and HTML escaped.
+// These are the bytes that are uploaded to S3 and hashed for ReleaseSHA256
+func (release *Release) ToJSON() ([]byte, error) {
+	return to.CanonicalJSON(release)
+}
+
+// ReleaseFromJSON parses a release written by ToJSON, unknown keys are an error
+func ReleaseFromJSON(raw []byte) (*Release, error) {
+	var release Release
+	if err := json.Unmarshal(raw, &release); err != nil {
+		return nil, err
+	}
+
+	return &release, nil
+}
@@ -1,11 +1,24 @@
 package deployer
 
 import (
+	"context"
+	"encoding/json"
+	goerrors "errors"
+	"fmt"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/lambda"
+	"github.com/aws/aws-sdk-go/service/sfn"
 	"github.com/stretchr/testify/assert"
 
+	"github.com/coinbase/step/aws"
 	"github.com/coinbase/step/aws/mocks"
+	"github.com/coinbase/step/aws/s3"
+	"github.com/coinbase/step/errors"
+	"github.com/coinbase/step/machine"
 	"github.com/coinbase/step/utils/to"
 )
 
@@ -17,6 +30,69 @@ func Test_Release_DeployStepFunction(t *testing.T) {
 	assert.NoError(t, err)
 }
 
+func Test_Release_Sign(t *testing.T) {
+	r := MockRelease()
+	r.SetDefaults(to.Strp("region"), to.Strp("account"), "")
+	awsc := MockAwsClients(r)
+
+	r.Sign()
+	assert.NoError(t, r.ValidateReleaseSHA(awsc.S3, &Release{}, nil))
+
+	// The existing SHA is not part of the hash
+	sha := r.ReleaseSHA256
+	r.Sign()
+	assert.Equal(t, sha, r.ReleaseSHA256)
+
+	// Signing only the embedded bifrost.Release misses the deployer fields
+	r.Release.Sign()
+	assert.Regexp(t, "Release SHA incorrect", r.ValidateReleaseSHA(awsc.S3, &Release{}, nil).Error())
+}
+
+func Test_Release_ValidateResources_AllFailures(t *testing.T) {
+	r := MockRelease()
+	awsc := MockAwsClients(r)
+	assert.NoError(t, r.ValidateResources(awsc.Lambda, awsc.SFN, awsc.KMS, awsc.S3, ""))
+
+	awsc.Lambda.ListTagsResp.Tags["ConfigName"] = to.Strp("other")
+	err := r.ValidateResources(awsc.Lambda, awsc.SFN, awsc.KMS, awsc.S3, "")
+	assert.Equal(t, "Lambda ConfigName tag incorrect, expecting development has other", err.Error())
+
+	// Every failure is reported, not only the first
+	r.Bucket = to.Strp("bucket")
+	awsc.SFN.DescribeStateMachineResp.RoleArn = to.Strp("arn:aws:iam::000000000000:role/other/role-name")
+	err = r.ValidateResources(awsc.Lambda, awsc.SFN, awsc.KMS, awsc.S3, "key")
+	assert.Regexp(t, "^3 validations failed: ", err.Error())
+	assert.Regexp(t, "Lambda ConfigName tag incorrect", err.Error())
+	assert.Regexp(t, "Incorrect Step Function Role Path", err.Error())
+	assert.Regexp(t, "Lambda Signature Error", err.Error())
+
+	// The typed errors can still be found
+	var tagErr *errors.TagMismatchError
+	assert.True(t, goerrors.As(err, &tagErr))
+	assert.Regexp(t, "Lambda ConfigName tag incorrect", tagErr.Error())
+}
+
+func Test_validateConcurrently(t *testing.T) {
+	assert.NoError(t, validateConcurrently())
+	assert.NoError(t, validateConcurrently(func() error { return nil }))
+
+	err := validateConcurrently(func() error { panic("boom") }, func() error { return nil })
+	assert.Equal(t, "PanicError: boom", err.Error())
+
+	shaErr := &errors.SHAMismatchError{Cause: "sha"}
+	tagErr := &errors.TagMismatchError{Cause: "tag"}
+	err = validateConcurrently(func() error { return shaErr }, func() error { return tagErr })
+	assert.Equal(t, "2 validations failed: sha; tag", err.Error())
+
+	var foundSHA *errors.SHAMismatchError
+	assert.True(t, goerrors.As(err, &foundSHA))
+	assert.Equal(t, shaErr, foundSHA)
+
+	var foundTag *errors.TagMismatchError
+	assert.True(t, goerrors.As(err, &foundTag))
+	assert.Equal(t, tagErr, foundTag)
+}
+
 func Test_Release_DeployLambda(t *testing.T) {
 	lambdaClient := &mocks.MockLambdaClient{}
 	s3c := &mocks.MockS3Client{}
@@ -24,8 +100,481 @@ func Test_Release_DeployLambda(t *testing.T) {
 	r := MockRelease()
 	r.Bucket = to.Strp("bucket")
 	s3c.AddGetObject(*r.LambdaZipPath(), "", nil)
+	r.LambdaSHA256 = to.Strp(to.SHA256Str(to.Strp("")))
+
+	err := r.DeployLambda(lambdaClient, s3c, nil, nil)
+	assert.NoError(t, err)
+
+}
+
+func Test_Release_DeployLambdaWithProgress(t *testing.T) {
+	lambdaClient := &mocks.MockLambdaClient{}
+	s3c := &mocks.MockS3Client{}
+
+	r := MockRelease()
+	r.Bucket = to.Strp("bucket")
+	s3c.AddGetObject(*r.LambdaZipPath(), "zipcontent", nil)
+	r.LambdaSHA256 = to.Strp(to.SHA256Str(to.Strp("zipcontent")))
+
+	var read, total int64
+	err := r.DeployLambdaWithProgress(context.Background(), lambdaClient, s3c, nil, nil, func(bytesRead int64, size int64) {
+		read, total = bytesRead, size
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, int64(10), read)
+	assert.Equal(t, int64(10), total)
+}
+
+func Test_Release_DeployStepFunction_DryRun(t *testing.T) {
+	sfnClient := &mocks.MockSFNClient{}
+	sfnClient.UpdateStateMachineError = fmt.Errorf("should not be called")
+
+	r := MockRelease()
+	r.DryRun = true
+
+	assert.NoError(t, r.DeployStepFunction(sfnClient))
+
+	input, err := r.DeployStepFunctionDryRun()
+	assert.NoError(t, err)
+	assert.Equal(t, *r.StepArn(), *input.StateMachineArn)
+
+	r.StateMachineJSON = to.Strp("{}")
+	assert.Error(t, r.DeployStepFunction(sfnClient))
+}
+
+func Test_Release_StateMachineJSON_PrettySize(t *testing.T) {
+	// Under the limit as sent by the client, over it once pretty printed for deploy
+	zeros := strings.TrimSuffix(strings.Repeat("0,", 300000), ",")
+	r := MockRelease()
+	r.StateMachineJSON = to.Strp(fmt.Sprintf(`{"StartAt":"WIN","States":{"WIN":{"Type":"Pass","Result":[%v],"End":true}}}`, zeros))
+	assert.True(t, len(*r.StateMachineJSON) < machine.MaxDefinitionBytes)
+
+	_, err := r.DeployStepFunctionDryRun()
+	assert.Regexp(t, "byte limit", err.Error())
+
+	r.StepOnly = true
+	r.SetDefaults(to.Strp("region"), to.Strp("account"), "")
+	assert.Regexp(t, "byte limit", r.ValidateOffline().Error())
+}
+
+func Test_Release_DeployLambda_DryRun(t *testing.T) {
+	lambdaClient := &mocks.MockLambdaClient{}
+	lambdaClient.UpdateFunctionCodeError = fmt.Errorf("should not be called")
+	s3c := &mocks.MockS3Client{}
+
+	r := MockRelease()
+	r.DryRun = true
+	r.Bucket = to.Strp("bucket")
+	r.LambdaSHA256 = to.Strp(to.SHA256Str(to.Strp("zip")))
+	s3c.AddGetObject(*r.LambdaZipPath(), "zip", nil)
+
+	assert.NoError(t, r.DeployLambda(lambdaClient, s3c, nil, nil))
+
+	input, err := r.DeployLambdaDryRun(s3c)
+	assert.NoError(t, err)
+	assert.Equal(t, *r.LambdaArn(), *input.FunctionName)
+	assert.Equal(t, []byte("zip"), input.ZipFile)
+
+	r.LambdaSHA256 = to.Strp("wrongsha")
+	assert.Error(t, r.DeployLambda(lambdaClient, s3c, nil, nil))
+}
+
+func Test_Release_LambdaSHA256_Encoding(t *testing.T) {
+	s3c := &mocks.MockS3Client{}
+	r := MockRelease()
+	r.Bucket = to.Strp("bucket")
+	s3c.AddGetObject(*r.LambdaZipPath(), "zip", nil)
+
+	hexSHA := to.SHA256Str(to.Strp("zip"))
+	b64SHA, _ := to.HexToBase64(hexSHA)
+
+	for _, sha := range []string{hexSHA, b64SHA} {
+		r.LambdaSHA256 = to.Strp(sha)
+		assert.NoError(t, r.validateAttributes())
+		assert.NoError(t, r.ValidateLambdaSHA(s3c))
+	}
+
+	r.LambdaSHA256 = to.Strp("notasha")
+	assert.Regexp(t, "LambdaSHA256 invalid", r.validateAttributes().Error())
+	assert.Regexp(t, "Lambda SHA mismatch", r.ValidateLambdaSHA(s3c).Error())
+}
+
+func Test_Release_ErrorTypes(t *testing.T) {
+	r := MockRelease()
+	awsc := MockAwsClients(r)
+
+	awsc.Lambda.ListTagsResp.Tags["ConfigName"] = to.Strp("other")
+	err := r.ValidateLambdaFunctionTags(awsc.Lambda)
+	tagErr, ok := err.(*errors.TagMismatchError)
+	assert.True(t, ok)
+	assert.Equal(t, "ConfigName", tagErr.Tag)
+	assert.Equal(t, "other", tagErr.Actual)
+	assert.Equal(t, "Lambda ConfigName tag incorrect, expecting development has other", err.Error())
+
+	awsc.S3.AddGetObject(*r.LambdaZipPath(), "swapped", nil)
+	err = r.ValidateLambdaSHA(awsc.S3)
+	shaErr, ok := err.(*errors.SHAMismatchError)
+	assert.True(t, ok)
+	assert.Equal(t, *r.LambdaSHA256, shaErr.Expected)
+	assert.Regexp(t, "^Lambda SHA mismatch", err.Error())
+
+	r.SetDefaults(to.Strp("region"), to.Strp("account"), "")
+	r.StepOnly = true
+	r.LambdaOnly = true
+	err = r.ValidateOffline()
+	assert.IsType(t, &errors.ValidationError{}, err)
+	assert.Regexp(t, "^At most one of StepOnly or LambdaOnly", err.Error())
+}
+
+func Test_Release_ArtifactBucket(t *testing.T) {
+	lambdaClient := &mocks.MockLambdaClient{}
+	s3c := &mocks.MockS3Client{}
+	r := MockRelease()
+	r.Bucket = to.Strp("bucket")
+	assert.Equal(t, "bucket", *r.LambdaZipBucket())
+
+	r.ArtifactBucket = to.Strp("artifacts")
+	assert.Equal(t, "artifacts", *r.LambdaZipBucket())
+	assert.Equal(t, "bucket", *r.Bucket)
+
+	r.LambdaSHA256 = to.Strp(to.SHA256Str(to.Strp("zip")))
+	s3c.AddGetObject(*r.LambdaZipPath(), "zip", nil)
+	assert.NoError(t, r.ValidateLambdaSHA(s3c))
+	assert.NoError(t, r.DeployLambda(lambdaClient, s3c, nil, nil))
+
+	for _, in := range s3c.GetObjectInputs {
+		assert.Equal(t, "artifacts", *in.Bucket)
+	}
+
+	input := r.deployLambdaS3Input()
+	assert.Equal(t, "artifacts", *input.S3Bucket)
+
+	// Only the ArtifactBucket the deployer can read is allowed
+	assert.NoError(t, r.ValidateArtifactBucket("artifacts"))
+	assert.Regexp(t, "ArtifactBucket artifacts is not readable", r.ValidateArtifactBucket("").Error())
+	assert.Error(t, r.ValidateArtifactBucket("other"))
+
+	r.ArtifactBucket = r.Bucket
+	assert.NoError(t, r.ValidateArtifactBucket(""))
+}
+
+func Test_Release_SetDefaults_CanonicalStateMachineJSON(t *testing.T) {
+	compact := MockRelease()
+	compact.UUID = to.Strp("uuid")
+	compact.StartedAt = compact.CreatedAt
+	compact.StateMachineJSON = to.Strp(`{"StartAt":"WIN","States":{"WIN":{"Type":"Succeed"}}}`)
+
+	pretty := *compact
+	pretty.StateMachineJSON = to.Strp("{\n  \"States\": {\n    \"WIN\": { \"Type\": \"Succeed\" }\n  },\n  \"StartAt\": \"WIN\"\n}\n")
+
+	assert.NotEqual(t, to.SHA256Struct(compact), to.SHA256Struct(&pretty))
+
+	compact.SetDefaults(to.Strp("region"), to.Strp("account"), "prefix-")
+	pretty.SetDefaults(to.Strp("region"), to.Strp("account"), "prefix-")
+
+	assert.Equal(t, `{"StartAt":"WIN","States":{"WIN":{"Type":"Succeed"}}}`, *pretty.StateMachineJSON)
+	assert.Equal(t, to.SHA256Struct(compact), to.SHA256Struct(&pretty))
+	assert.Equal(t, *compact.deployStepFunctionInput().Definition, *pretty.deployStepFunctionInput().Definition)
+
+	// Legacy key casing is respelled so it hashes the same
+	cased := *compact
+	cased.StateMachineJSON = to.Strp(`{"startAt":"WIN","states":{"WIN":{"type":"succeed"}}}`)
+	cased.SetDefaults(to.Strp("region"), to.Strp("account"), "prefix-")
+	assert.Equal(t, to.SHA256Struct(compact), to.SHA256Struct(&cased))
+
+	// Invalid JSON is left for validation to reject
+	invalid := MockRelease()
+	invalid.StateMachineJSON = to.Strp("{not json")
+	invalid.SetDefaults(to.Strp("region"), to.Strp("account"), "prefix-")
+	assert.Equal(t, "{not json", *invalid.StateMachineJSON)
+}
+
+func Test_Release_DescribeDeployed(t *testing.T) {
+	sfnClient := &mocks.MockSFNClient{}
+	r := MockRelease()
+	r.AwsRegion = to.Strp("us-east-1")
+
+	sfnClient.DescribeStateMachineResp = &sfn.DescribeStateMachineOutput{
+		Status:       to.Strp(sfn.StateMachineStatusActive),
+		CreationDate: to.Timep(time.Now()),
+	}
+
+	out, err := r.DescribeDeployed(sfnClient)
+	assert.NoError(t, err)
+	assert.Equal(t, sfn.StateMachineStatusActive, *out.Status)
+	assert.Equal(t, "arn:aws:states:us-east-1:00000000:stateMachine:stepfnname", *sfnClient.DescribeStateMachineInputs[0].StateMachineArn)
+
+	sfnClient.DescribeStateMachineError = fmt.Errorf("AccessDenied")
+	_, err = r.DescribeDeployed(sfnClient)
+	assert.Error(t, err)
+}
+
+func Test_Release_StateMachineDiff_Formatting(t *testing.T) {
+	sfnClient := &mocks.MockSFNClient{}
+	r := MockRelease()
+	r.StateMachineJSON = to.Strp("{\"StartAt\": \"WIN\",\n\"States\": {\"WIN\": {\"Type\": \"Succeed\"}}}")
+	r.SetDefaults(to.Strp("region"), to.Strp("account"), "prefix-")
+
+	sfnClient.DescribeStateMachineResp = &sfn.DescribeStateMachineOutput{
+		Definition: to.Strp("{\n \"States\": {\"WIN\": {\"Type\": \"Succeed\"}},\n \"StartAt\": \"WIN\"\n}"),
+	}
+
+	diff, err := r.StateMachineDiff(sfnClient)
+	assert.NoError(t, err)
+	assert.Equal(t, "", diff)
+}
+
+func Test_Release_PublishVersionAndAlias(t *testing.T) {
+	lambdaClient := &mocks.MockLambdaClient{}
+	r := MockRelease()
+	r.LambdaSHA256 = to.Strp(to.SHA256Str(to.Strp("zip")))
+
+	version, err := r.PublishVersionAndAlias(lambdaClient, "live")
+	assert.NoError(t, err)
+	assert.Equal(t, "1", *version)
+	assert.Equal(t, "1", *lambdaClient.Aliases["live"])
+
+	// Updates an existing alias
+	lambdaClient.PublishVersionResp = &lambda.FunctionConfiguration{Version: to.Strp("2")}
+	version, err = r.PublishVersionAndAlias(lambdaClient, "live")
+	assert.NoError(t, err)
+	assert.Equal(t, "2", *version)
+	assert.Equal(t, "2", *lambdaClient.Aliases["live"])
+}
+
+func Test_Release_PublishVersionAndAlias_SHA_Mismatch(t *testing.T) {
+	lambdaClient := &mocks.MockLambdaClient{}
+	lambdaClient.PublishVersionError = awserr.New(lambda.ErrCodeInvalidParameterValueException, "CodeSHA256 does not match", nil)
+
+	r := MockRelease()
+	r.LambdaSHA256 = to.Strp(to.SHA256Str(to.Strp("zip")))
+
+	_, err := r.PublishVersionAndAlias(lambdaClient, "live")
+	assert.Error(t, err)
+	assert.Regexp(t, "CodeSha256 mismatch", err.Error())
+
+	r.LambdaSHA256 = to.Strp("notahexsha")
+	_, err = r.PublishVersionAndAlias(lambdaClient, "live")
+	assert.Error(t, err)
+}
+
+func Test_Release_StateMachineDiff(t *testing.T) {
+	sfnClient := &mocks.MockSFNClient{}
+	r := MockRelease()
+
+	sfnClient.DescribeStateMachineResp = &sfn.DescribeStateMachineOutput{Definition: r.StateMachineJSON}
+	d, err := r.StateMachineDiff(sfnClient)
+	assert.NoError(t, err)
+	assert.Equal(t, "", d)
+
+	sfnClient.DescribeStateMachineResp = &sfn.DescribeStateMachineOutput{Definition: to.Strp(`{"StartAt": "LOSE", "States": {"LOSE": {"Type": "Fail"}}}`)}
+	d, err = r.StateMachineDiff(sfnClient)
+	assert.NoError(t, err)
+	assert.Regexp(t, `(?m)^- "StartAt": "LOSE",$`, d)
+	assert.Regexp(t, `(?m)^\+ "StartAt": "WIN",$`, d)
+
+	sfnClient.DescribeStateMachineError = awserr.New(sfn.ErrCodeStateMachineDoesNotExist, "does not exist", nil)
+	d, err = r.StateMachineDiff(sfnClient)
+	assert.NoError(t, err)
+	assert.Equal(t, NewStateMachineDiff, d)
+
+	sfnClient.DescribeStateMachineError = fmt.Errorf("AccessDenied")
+	_, err = r.StateMachineDiff(sfnClient)
+	assert.Error(t, err)
+}
+
+func Test_Release_EnsureStepFunction(t *testing.T) {
+	sfnClient := &mocks.MockSFNClient{}
+	r := MockRelease()
+	roleArn := fmt.Sprintf("arn:aws:iam::000000000000:role/step/%v/%v/role", *r.ProjectName, *r.ConfigName)
+
+	// Exists so is updated
+	assert.NoError(t, r.EnsureStepFunction(sfnClient, roleArn))
+	assert.Equal(t, 1, sfnClient.UpdateStateMachineCalls)
+	assert.Nil(t, sfnClient.CreateStateMachineInput)
+
+	// Does not exist so is created
+	sfnClient.DescribeStateMachineError = awserr.New(sfn.ErrCodeStateMachineDoesNotExist, "does not exist", nil)
+	assert.NoError(t, r.EnsureStepFunction(sfnClient, roleArn))
+	assert.Equal(t, 1, sfnClient.UpdateStateMachineCalls)
+	assert.Equal(t, *r.StepFnName, *sfnClient.CreateStateMachineInput.Name)
+	assert.Equal(t, roleArn, *sfnClient.CreateStateMachineInput.RoleArn)
+
+	// Role must be in the step path
+	sfnClient.CreateStateMachineInput = nil
+	err := r.EnsureStepFunction(sfnClient, "arn:aws:iam::000000000000:role/other")
+	assert.Error(t, err)
+	assert.Regexp(t, "Incorrect Step Function Role Path", err.Error())
+	assert.Nil(t, sfnClient.CreateStateMachineInput)
+
+	// Other errors are returned
+	sfnClient.DescribeStateMachineError = fmt.Errorf("AccessDenied")
+	assert.Error(t, r.EnsureStepFunction(sfnClient, roleArn))
+	assert.Nil(t, sfnClient.CreateStateMachineInput)
+}
+
+func Test_Release_HashAlgo_SHA512(t *testing.T) {
+	release := MockRelease()
+	release.HashAlgo = to.Strp(to.SHA512)
+	lambdaZip := []byte("lambda_zip")
+	release.LambdaSHA256 = to.Strp(to.HashAByte(to.SHA512, &lambdaZip))
+
+	awsc := MockAwsClients(release)
+	state_machine := createTestStateMachine(t, awsc)
+
+	_, err := state_machine.Execute(release)
+	assert.NoError(t, err)
+
+	// A SHA256 LambdaSHA256 does not validate with SHA512 selected
+	release = MockRelease()
+	release.HashAlgo = to.Strp(to.SHA512)
+	awsc = MockAwsClients(release)
+	state_machine = createTestStateMachine(t, awsc)
+
+	exec, err := state_machine.Execute(release)
+	assert.Error(t, err)
+	assert.Regexp(t, "Lambda SHA mismatch", exec.LastOutputJSON)
+
+	release.HashAlgo = to.Strp("md5")
+	release.UUID = to.Strp("uuid")
+	release.SetDefaults(to.Strp("us-east-1"), to.Strp("00000000"), "")
+	assert.Regexp(t, "HashAlgo md5 is not supported", release.Validate(awsc.S3).Error())
+}
+
+func Test_Release_Deploy_RetriesThrottling(t *testing.T) {
+	defer func(p aws.RetryPolicy) { DeployRetryPolicy = p }(DeployRetryPolicy)
+	DeployRetryPolicy = aws.RetryPolicy{MaxAttempts: 3}
+
+	release := MockRelease()
+	awsc := MockAwsClients(release)
+	release.Bucket = to.Strp("bucket")
+
+	throttle := awserr.New("TooManyRequestsException", "Rate exceeded", nil)
+	awsc.Lambda.UpdateFunctionCodeFails = []error{throttle, throttle}
+	awsc.SFN.UpdateStateMachineFails = []error{throttle, throttle}
+
+	assert.NoError(t, release.DeployLambda(awsc.Lambda, awsc.S3, nil, nil))
+	assert.Equal(t, 3, awsc.Lambda.UpdateFunctionCodeCalls)
+
+	assert.NoError(t, release.DeployStepFunction(awsc.SFN))
+	assert.Equal(t, 3, awsc.SFN.UpdateStateMachineCalls)
+
+	// Permission errors are not retried
+	awsc.SFN.UpdateStateMachineCalls = 0
+	awsc.SFN.UpdateStateMachineError = awserr.New("AccessDeniedException", "denied", nil)
+	assert.Error(t, release.DeployStepFunction(awsc.SFN))
+	assert.Equal(t, 1, awsc.SFN.UpdateStateMachineCalls)
+}
+
+func Test_Release_DeployLambda_WaitForFunctionUpdated(t *testing.T) {
+	defer func(i, d time.Duration) { LambdaUpdatePollInterval, LambdaUpdateTimeout = i, d }(LambdaUpdatePollInterval, LambdaUpdateTimeout)
+	LambdaUpdatePollInterval = time.Millisecond
+
+	release := MockRelease()
+	awsc := MockAwsClients(release)
+	release.Bucket = to.Strp("bucket")
+
+	awsc.Lambda.LastUpdateStatuses = []string{lambda.LastUpdateStatusInProgress, lambda.LastUpdateStatusInProgress}
+	assert.NoError(t, release.DeployLambda(awsc.Lambda, awsc.S3, nil, nil))
+	assert.Len(t, awsc.Lambda.LastUpdateStatuses, 0)
+
+	awsc.Lambda.LastUpdateStatuses = []string{lambda.LastUpdateStatusInProgress, lambda.LastUpdateStatusFailed}
+	err := release.DeployLambda(awsc.Lambda, awsc.S3, nil, nil)
+	assert.Error(t, err)
+	assert.Regexp(t, "Lambda Update Failed", err.Error())
+
+	LambdaUpdateTimeout = 0
+	awsc.Lambda.LastUpdateStatuses = []string{lambda.LastUpdateStatusInProgress}
+	err = release.DeployLambda(awsc.Lambda, awsc.S3, nil, nil)
+	assert.Error(t, err)
+	assert.Regexp(t, "timed out after 0s with status InProgress", err.Error())
+}
+
+func Test_Release_WithContext_Cancelled(t *testing.T) {
+	release := MockRelease()
+	awsc := MockAwsClients(release)
+	release.Bucket = to.Strp("bucket")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	assert.Equal(t, context.Canceled, release.DeployStepFunctionWithContext(ctx, awsc.SFN))
+	assert.Equal(t, 0, awsc.SFN.UpdateStateMachineCalls)
+
+	assert.Equal(t, context.Canceled, release.DeployLambdaWithContext(ctx, awsc.Lambda, awsc.S3, nil, nil))
+	assert.Equal(t, 0, awsc.Lambda.UpdateFunctionCodeCalls)
+
+	assert.Equal(t, context.Canceled, release.ValidateResourcesWithContext(ctx, awsc.Lambda, awsc.SFN, nil, awsc.S3, ""))
+
+	assert.NoError(t, release.ValidateResourcesWithContext(context.Background(), awsc.Lambda, awsc.SFN, nil, awsc.S3, ""))
+}
+
+func Test_Release_Partition(t *testing.T) {
+	r := MockRelease()
+	r.AwsRegion = to.Strp("us-gov-west-1")
+	r.SetDefaults(nil, nil, "")
+
+	assert.Equal(t, "aws-us-gov", *r.Partition)
+	assert.Equal(t, "arn:aws-us-gov:lambda:us-gov-west-1:00000000:function:lambdaname", *r.LambdaArn())
+	assert.Equal(t, "arn:aws-us-gov:states:us-gov-west-1:00000000:stateMachine:stepfnname", *r.StepArn())
+
+	r = MockRelease()
+	r.Partition = to.Strp("aws-cn")
+	r.SetDefaults(nil, nil, "")
+	assert.Equal(t, "aws-cn", *r.Partition)
+}
+
+func Test_Release_ValidateSchema(t *testing.T) {
+	r := MockRelease()
+	r.LambdaEnvironment = map[string]*string{"KEY": to.Strp("value")}
+
+	raw, err := json.Marshal(r)
+	assert.NoError(t, err)
+	assert.NoError(t, r.ValidateSchema(raw))
+
+	err = r.ValidateSchema([]byte(`{
+		"lambda_nam": "typo",
+		"lambda_timeout": "30",
+		"aws_regions": ["us-east-1", 1],
+		"created_at": "yesterday",
+		"error": {"Error": "e", "Cause": 1}
+	}`))
+	assert.Error(t, err)
+	assert.Regexp(t, `\$.lambda_nam is not a known field`, err.Error())
+	assert.Regexp(t, `\$.lambda_timeout expected integer or null got string`, err.Error())
+	assert.Regexp(t, `\$.aws_regions\[1\] expected string or null got integer`, err.Error())
+	assert.Regexp(t, `\$.created_at expected date-time`, err.Error())
+	assert.Regexp(t, `\$.error.Cause expected string or null got integer`, err.Error())
+
+	assert.Regexp(t, `"state_machine_json"`, r.SchemaJSON())
+	assert.Regexp(t, `"release_id"`, r.SchemaJSON())
+	assert.NotRegexp(t, `ReleaseSHA256`, r.SchemaJSON())
+}
+
+func Test_Release_ToJSON_FromJSON(t *testing.T) {
+	release := MockRelease()
+	awsc := MockAwsClients(release)
+	release.StateMachineJSON = to.Strp(`{"StartAt": "A<B>&", "States": {"A<B>&": {"Type": "Succeed"}}}`)
+
+	raw, err := release.ToJSON()
+	assert.NoError(t, err)
+
+	// The bytes are what is hashed
+	assert.Equal(t, to.HashStruct(release.HashAlgorithm(), release), to.HashAByte(release.HashAlgorithm(), &raw))
+
+	// and what is uploaded
+	_, _, err = s3.PutStructWithHashVersion(awsc.S3, release.Bucket, release.ReleasePath(), release, release.HashAlgorithm())
+	assert.NoError(t, err)
+	uploaded, err := s3.Get(awsc.S3, release.Bucket, release.ReleasePath())
+	assert.NoError(t, err)
+	assert.Equal(t, string(raw), string(*uploaded))
 
-	err := r.DeployLambda(lambdaClient, s3c)
+	parsed, err := ReleaseFromJSON(raw)
 	assert.NoError(t, err)
+	assert.Equal(t, to.HashStruct(release.HashAlgorithm(), release), to.HashStruct(parsed.HashAlgorithm(), parsed))
+	assert.Equal(t, *release.StateMachineJSON, *parsed.StateMachineJSON)
 
+	_, err = ReleaseFromJSON([]byte(`{"lambda_nme": "typo"}`))
+	assert.Error(t, err)
 }
@@ -0,0 +1,130 @@
+package deployer
+
+import (
+	"fmt"
+
+	"github.com/coinbase/step/aws"
+	"github.com/coinbase/step/aws/s3"
+	"github.com/coinbase/step/bifrost"
+	"github.com/coinbase/step/errors"
+	"github.com/coinbase/step/utils/to"
+)
+
+///////
+// Paths
+///////
+
+// DeployedReleasePath is where the last successfully deployed release of the project config is recorded
+func DeployedReleasePath(account, project, config string) string {
+	return fmt.Sprintf("%v/deployed_release", bifrost.RootDir(account, project, config))
+}
+
+// PreviousReleasePath is where the release that was live before releaseId is recorded
+func PreviousReleasePath(account, project, config, releaseId string) string {
+	return fmt.Sprintf("%v/previous_release", bifrost.ReleaseDir(account, project, config, releaseId))
+}
+
+// DeployedReleasePath is where the last successfully deployed release is recorded
+func (release *Release) DeployedReleasePath() *string {
+	return to.Strp(DeployedReleasePath(*release.AwsAccountID, *release.ProjectName, *release.ConfigName))
+}
+
+// PreviousReleasePath is where the release that was live before this release is recorded
+func (release *Release) PreviousReleasePath() *string {
+	return to.Strp(PreviousReleasePath(*release.AwsAccountID, *release.ProjectName, *release.ConfigName, *release.ReleaseID))
+}
+
+///////
+// Record
+///////
+
+// RecordPreviousRelease copies the currently deployed release next to this release
+// so that it can be rolled back to. If nothing has been deployed, nothing is recorded
+func (release *Release) RecordPreviousRelease(s3c aws.S3API) error {
+	var previous Release
+	err := s3.GetStruct(s3c, release.Bucket, release.DeployedReleasePath(), &previous)
+	if err != nil {
+		switch err.(type) {
+		case *s3.NotFoundError:
+			// Nothing deployed yet
+			return nil
+		default:
+			return err
+		}
+	}
+
+	return s3.PutStruct(s3c, release.Bucket, release.PreviousReleasePath(), &previous)
+}
+
+// RecordDeployedRelease marks this release as the live release
+func (release *Release) RecordDeployedRelease(s3c aws.S3API) error {
+	return s3.PutStruct(s3c, release.Bucket, release.DeployedReleasePath(), release)
+}
+
+///////
+// Rollback
+///////
+
+// PreviousRelease returns the release that was deployed before this release
+func (release *Release) PreviousRelease(s3c aws.S3API) (*Release, error) {
+	var previous Release
+	err := s3.GetStruct(s3c, release.Bucket, release.PreviousReleasePath(), &previous)
+	if err != nil {
+		switch err.(type) {
+		case *s3.NotFoundError:
+			return nil, fmt.Errorf("Rollback Error: no previous release found at %v:%v", *release.Bucket, *release.PreviousReleasePath())
+		default:
+			return nil, err
+		}
+	}
+
+	return &previous, nil
+}
+
+// Rollback redeploys the Step Function and Lambda of the previous release.
+// The rollback is given its own UUID so its lock does not collide with this release
+func (release *Release) Rollback(lambdaClient aws.LambdaAPI, sfnClient aws.SFNAPI, s3c aws.S3API) (err error) {
+	rollback, err := release.PreviousRelease(s3c)
+	if err != nil {
+		return err
+	}
+
+	rollback.WipeControlledValues()
+	rollback.UUID = to.TimeUUID("rollback-")
+
+	// The live SHA expected when it was first deployed is stale
+	rollback.ExpectedLiveLambdaSHA = nil
+	rollback.SetDefaults(release.AwsRegion, release.AwsAccountID, DefaultBucketPrefix)
+
+	if err := rollback.validateLambdaCode(s3c); err != nil {
+		return err
+	}
+
+	if err := rollback.GrabRootLock(s3c); err != nil {
+		if _, ok := err.(*errors.LockExistsError); !ok {
+			// LockError might have grabbed the lock
+			rollback.UnlockRoot(s3c)
+		}
+		return err
+	}
+
+	// The root lock is released whether or not the rollback deploys
+	defer func() {
+		if unlockErr := rollback.UnlockRoot(s3c); unlockErr != nil && err == nil {
+			err = &errors.LockError{unlockErr.Error()}
+		}
+	}()
+
+	if err := rollback.DeployStepFunction(sfnClient); err != nil {
+		return DeploySFNError{err}
+	}
+
+	// Bucket location is unknown here so the Zip is uploaded
+	if err := rollback.DeployLambda(lambdaClient, s3c, nil, nil); err != nil {
+		return DeployLambdaError{err}
+	}
+
+	rollback.Success = to.Boolp(true)
+
+	return rollback.RecordDeployedRelease(s3c)
+}
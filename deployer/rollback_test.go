@@ -0,0 +1,91 @@
+package deployer
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/coinbase/step/aws/s3"
+	"github.com/coinbase/step/utils/to"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Release_Rollback_No_Previous(t *testing.T) {
+	release := MockRelease()
+	awsc := MockAwsClients(release)
+	release.SetDefaults(to.Strp("region"), to.Strp("account"), "")
+
+	err := release.Rollback(awsc.Lambda, awsc.SFN, awsc.S3)
+	assert.Error(t, err)
+	assert.Regexp(t, "no previous release", err.Error())
+}
+
+func Test_Release_Rollback_Works(t *testing.T) {
+	previous := MockRelease()
+	awsc := MockAwsClients(previous)
+	previous.SetDefaults(to.Strp("region"), to.Strp("account"), "")
+	s3c := awsc.S3Client(nil, nil, nil)
+
+	assert.NoError(t, previous.RecordDeployedRelease(s3c))
+
+	release := MockRelease()
+	release.ReleaseID = to.Strp("release-2")
+	release.SetDefaults(to.Strp("region"), to.Strp("account"), "")
+
+	assert.NoError(t, release.RecordPreviousRelease(s3c))
+	assert.NoError(t, release.RecordDeployedRelease(s3c))
+
+	assert.NoError(t, release.Rollback(awsc.Lambda, awsc.SFN, s3c))
+
+	var deployed Release
+	assert.NoError(t, s3.GetStruct(s3c, release.Bucket, release.DeployedReleasePath(), &deployed))
+	assert.Equal(t, "release-1", *deployed.ReleaseID)
+	assert.Regexp(t, "^rollback-", *deployed.UUID)
+	assert.NotEqual(t, *release.UUID, *deployed.UUID)
+
+	assertNoRootLock(t, awsc, release)
+}
+
+func Test_Release_Rollback_Locked(t *testing.T) {
+	previous := MockRelease()
+	awsc := MockAwsClients(previous)
+	previous.SetDefaults(to.Strp("region"), to.Strp("account"), "")
+	s3c := awsc.S3Client(nil, nil, nil)
+
+	assert.NoError(t, previous.RecordDeployedRelease(s3c))
+
+	release := MockRelease()
+	release.ReleaseID = to.Strp("release-2")
+	release.SetDefaults(to.Strp("region"), to.Strp("account"), "")
+
+	assert.NoError(t, release.RecordPreviousRelease(s3c))
+	assert.NoError(t, release.GrabRootLock(s3c))
+
+	err := release.Rollback(awsc.Lambda, awsc.SFN, s3c)
+	assert.Error(t, err)
+	assert.Regexp(t, "LockExistsError", err.Error())
+}
+
+func Test_Release_Rollback_Deploy_Error_Unlocks(t *testing.T) {
+	previous := MockRelease()
+	awsc := MockAwsClients(previous)
+	previous.SetDefaults(to.Strp("region"), to.Strp("account"), "")
+	s3c := awsc.S3Client(nil, nil, nil)
+
+	assert.NoError(t, previous.RecordDeployedRelease(s3c))
+
+	release := MockRelease()
+	release.ReleaseID = to.Strp("release-2")
+	release.SetDefaults(to.Strp("region"), to.Strp("account"), "")
+	assert.NoError(t, release.RecordPreviousRelease(s3c))
+
+	awsc.SFN.UpdateStateMachineError = fmt.Errorf("AccessDenied")
+	err := release.Rollback(awsc.Lambda, awsc.SFN, s3c)
+	assert.IsType(t, DeploySFNError{}, err)
+	assertNoRootLock(t, awsc, release)
+
+	awsc.SFN.UpdateStateMachineError = nil
+	awsc.Lambda.UpdateFunctionCodeError = fmt.Errorf("AccessDenied")
+	err = release.Rollback(awsc.Lambda, awsc.SFN, s3c)
+	assert.IsType(t, DeployLambdaError{}, err)
+	assertNoRootLock(t, awsc, release)
+}
@@ -0,0 +1,71 @@
+package deployer
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go/service/kms"
+	"github.com/coinbase/step/aws"
+	"github.com/coinbase/step/aws/s3"
+	"github.com/coinbase/step/utils/to"
+)
+
+// SigningKeyEnv is the environment variable holding the KMS key id the lambda.zip must be signed with,
+// if it is not set only the SHA256 is validated
+const SigningKeyEnv = "STEP_LAMBDA_SIGNING_KEY_ID"
+
+// LambdaSigningAlgorithm is the KMS algorithm the lambda.zip digest is signed with
+const LambdaSigningAlgorithm = kms.SigningAlgorithmSpecRsassaPssSha256
+
+// LambdaSignaturePath is the detached signature of the lambda.zip
+func (release *Release) LambdaSignaturePath() *string {
+	s := fmt.Sprintf("%v.sig", *release.LambdaZipPath())
+	return &s
+}
+
+// ValidateLambdaSignature checks the lambda.zip was signed by the KMS key keyId.
+// The SHA256 digest of the zip is signed because KMS only signs raw messages up to 4KB
+func (release *Release) ValidateLambdaSignature(kmsc aws.KMSAPI, s3c aws.S3API, keyId string) error {
+	return release.ValidateLambdaSignatureWithContext(context.Background(), kmsc, s3c, keyId)
+}
+
+// ValidateLambdaSignatureWithContext is ValidateLambdaSignature with ctx passed to KMS.
+// The zip is read at the LambdaZipVersion pinned by ValidateLambdaSHA and checked against LambdaSHA256,
+// so the signed bytes are the ones that are deployed. The signature is only used here, it cannot verify another zip
+func (release *Release) ValidateLambdaSignatureWithContext(ctx context.Context, kmsc aws.KMSAPI, s3c aws.S3API, keyId string) error {
+	signature, err := s3.Get(s3c, release.LambdaZipBucket(), release.LambdaSignaturePath())
+	if err != nil {
+		return fmt.Errorf("Lambda Signature Error: %v", err.Error())
+	}
+
+	_, zip, err := s3.GetObjectVersion(s3c, release.LambdaZipBucket(), release.LambdaZipPath(), release.LambdaZipVersion)
+	if err != nil {
+		return err
+	}
+
+	if sha := to.HashAByte(release.HashAlgorithm(), zip); !release.lambdaSHAMatches(sha) {
+		return release.lambdaSHAMismatch(sha)
+	}
+
+	digest := sha256.Sum256(*zip)
+
+	out, err := kmsc.VerifyWithContext(ctx, &kms.VerifyInput{
+		KeyId:            &keyId,
+		Message:          digest[:],
+		MessageType:      to.Strp(kms.MessageTypeDigest),
+		Signature:        *signature,
+		SigningAlgorithm: to.Strp(LambdaSigningAlgorithm),
+	})
+
+	if err != nil {
+		// KMS returns KMSInvalidSignatureException for signatures that do not verify
+		return fmt.Errorf("Lambda Signature Error: %v", err.Error())
+	}
+
+	if out.SignatureValid == nil || !*out.SignatureValid {
+		return fmt.Errorf("Lambda Signature Error: signature invalid for key %v", keyId)
+	}
+
+	return nil
+}
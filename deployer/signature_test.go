@@ -0,0 +1,89 @@
+package deployer
+
+import (
+	"crypto/sha256"
+	"os"
+	"testing"
+
+	"github.com/coinbase/step/aws/mocks"
+	"github.com/coinbase/step/aws/s3"
+	"github.com/coinbase/step/utils/to"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Release_ValidateLambdaSignature(t *testing.T) {
+	release := MockRelease()
+	awsc := MockAwsClients(release)
+	release.Bucket = to.Strp("bucket")
+
+	// No signature
+	err := release.ValidateLambdaSignature(awsc.KMS, awsc.S3, "key")
+	assert.Error(t, err)
+	assert.Regexp(t, "Lambda Signature Error", err.Error())
+
+	digest := sha256.Sum256([]byte("lambda_zip"))
+
+	// Signed with the wrong key
+	awsc.S3.AddGetObject(*release.LambdaSignaturePath(), string(mocks.MockSignature("other", digest[:])), nil)
+	assert.Error(t, release.ValidateLambdaSignature(awsc.KMS, awsc.S3, "key"))
+
+	awsc.S3.AddGetObject(*release.LambdaSignaturePath(), string(mocks.MockSignature("key", digest[:])), nil)
+	assert.NoError(t, release.ValidateLambdaSignature(awsc.KMS, awsc.S3, "key"))
+
+	// Swapped zip
+	awsc.S3.AddGetObject(*release.LambdaZipPath(), "swapped", nil)
+	assert.Error(t, release.ValidateLambdaSignature(awsc.KMS, awsc.S3, "key"))
+}
+
+func Test_Release_ValidateLambdaSignature_Version(t *testing.T) {
+	release := MockRelease()
+	awsc := MockAwsClients(release)
+	release.Bucket = to.Strp("bucket")
+	awsc.S3.Versioned = true
+
+	assert.NoError(t, s3.PutStr(awsc.S3, release.Bucket, release.LambdaZipPath(), to.Strp("lambda_zip")))
+	assert.NoError(t, release.ValidateLambdaSHA(awsc.S3))
+
+	// Zip and signature swapped after the SHA was validated
+	swapped := sha256.Sum256([]byte("swapped"))
+	assert.NoError(t, s3.PutStr(awsc.S3, release.Bucket, release.LambdaZipPath(), to.Strp("swapped")))
+	awsc.S3.AddGetObject(*release.LambdaSignaturePath(), string(mocks.MockSignature("key", swapped[:])), nil)
+
+	// The pinned zip is verified, not the latest
+	err := release.ValidateLambdaSignature(awsc.KMS, awsc.S3, "key")
+	assert.Error(t, err)
+	assert.Equal(t, "v1", to.Strs(awsc.S3.GetObjectInputs[len(awsc.S3.GetObjectInputs)-1].VersionId))
+
+	digest := sha256.Sum256([]byte("lambda_zip"))
+	awsc.S3.AddGetObject(*release.LambdaSignaturePath(), string(mocks.MockSignature("key", digest[:])), nil)
+	assert.NoError(t, release.ValidateLambdaSignature(awsc.KMS, awsc.S3, "key"))
+}
+
+func Test_DeployHandler_Execution_Errors_LambdaSignature(t *testing.T) {
+	os.Setenv(SigningKeyEnv, "key")
+	defer os.Unsetenv(SigningKeyEnv)
+
+	release := MockRelease()
+	awsc := MockAwsClients(release)
+	state_machine := createTestStateMachine(t, awsc)
+
+	exec, err := state_machine.Execute(release)
+	assert.Error(t, err)
+	assert.Regexp(t, "Lambda Signature Error", exec.LastOutputJSON)
+	assert.Equal(t, []string{
+		"Validate",
+		"Lock",
+		"ValidateResources",
+		"ReleaseLockFailure",
+		"FailureClean",
+	}, exec.Path())
+
+	release = MockRelease()
+	awsc = MockAwsClients(release)
+	digest := sha256.Sum256([]byte("lambda_zip"))
+	awsc.S3.AddGetObject(*release.LambdaSignaturePath(), string(mocks.MockSignature("key", digest[:])), nil)
+	state_machine = createTestStateMachine(t, awsc)
+
+	_, err = state_machine.Execute(release)
+	assert.NoError(t, err)
+}
@@ -0,0 +1,105 @@
+package deployer
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go/service/sfn"
+	"github.com/coinbase/step/aws"
+	"github.com/coinbase/step/execution"
+	"github.com/coinbase/step/utils/to"
+)
+
+// SmokeTestPollInterval is how long SmokeTest first waits between DescribeExecution calls, see aws.WaitForExecution
+var SmokeTestPollInterval = 5 * time.Second
+
+// defaultSmokeTestTimeout is used when SmokeTestInput is set without SmokeTestTimeout
+const defaultSmokeTestTimeout = 5 * time.Minute
+
+// MaxSmokeTestTime bounds SmokeTestTimeout times the number of regions. The smoke tests run one region
+// after another inside the deploy Lambda, so they must finish well within its 15 minute timeout
+var MaxSmokeTestTime = 10 * time.Minute
+
+// smokeTestTimeout is SmokeTestTimeout, or the default if it is not set
+func (release *Release) smokeTestTimeout() time.Duration {
+	if release.SmokeTestTimeout == nil {
+		return defaultSmokeTestTimeout
+	}
+	return time.Duration(*release.SmokeTestTimeout) * time.Second
+}
+
+func (release *Release) validateSmokeTest() error {
+	if release.SmokeTestInput == nil {
+		return nil
+	}
+
+	if release.SmokeTestTimeout != nil && *release.SmokeTestTimeout <= 0 {
+		return fmt.Errorf("SmokeTestTimeout must be positive")
+	}
+
+	regions := len(release.Regions())
+	if total := release.smokeTestTimeout() * time.Duration(regions); total > MaxSmokeTestTime {
+		return fmt.Errorf("SmokeTestTimeout %v in %v regions is %v, more than the %v the deploy Lambda allows", release.smokeTestTimeout(), regions, total, MaxSmokeTestTime)
+	}
+
+	return nil
+}
+
+// SmokeTest executes the deployed Step Function with input and waits for it to finish.
+// It returns an error unless the execution SUCCEEDED, on timeout the execution is stopped.
+// EXPRESS State Machines cannot be described so are executed synchronously
+func (release *Release) SmokeTest(sfnClient aws.SFNAPI, input string, timeout time.Duration) error {
+	if err := release.ValidateInputSchema([]byte(input)); err != nil {
+		return fmt.Errorf("Smoke Test Error: %v", err.Error())
+	}
+
+	if release.StateMachineType() == sfn.StateMachineTypeExpress {
+		return release.smokeTestExpress(sfnClient, input, timeout)
+	}
+
+	exec, err := execution.StartExecutionRaw(sfnClient, release.StepArn(), to.TimeUUID("smoke-"), &input)
+	if err != nil {
+		return fmt.Errorf("Smoke Test Error: %v", err.Error())
+	}
+
+	out, err := aws.WaitForExecution(sfnClient, exec.ExecutionArn, SmokeTestPollInterval, timeout)
+
+	if timeoutErr, ok := err.(*aws.ExecutionTimeoutError); ok {
+		_, err := sfnClient.StopExecution(&sfn.StopExecutionInput{
+			ExecutionArn: exec.ExecutionArn,
+			Error:        to.Strp("SmokeTestTimeout"),
+		})
+
+		if err != nil {
+			// ignore errors
+			fmt.Printf("Warning(StopExecution) error ignored: %v\n", err.Error())
+		}
+
+		return fmt.Errorf("Smoke Test Error: timed out after %v with status %v", timeout, timeoutErr.Status)
+	}
+
+	if err != nil {
+		return fmt.Errorf("Smoke Test Error: %v", err.Error())
+	}
+
+	status := to.Strs(out.Status)
+
+	if status != sfn.ExecutionStatusSucceeded {
+		return fmt.Errorf("Smoke Test Error: execution %v finished with status %v", to.Strs(exec.ExecutionArn), status)
+	}
+
+	return nil
+}
+
+// SmokeTestRegions runs SmokeTest in every region if SmokeTestInput is set
+func (release *Release) SmokeTestRegions(awsc aws.AwsClients) error {
+	if release.SmokeTestInput == nil || release.DryRun {
+		return nil
+	}
+
+	timeout := release.smokeTestTimeout()
+
+	return release.eachRegion(func(r *Release) error {
+		return r.SmokeTest(awsc.SFNClient(r.AwsRegion, r.AwsAccountID, assumed_role), *r.SmokeTestInput, timeout)
+	})
+}
@@ -0,0 +1,74 @@
+package deployer
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/service/sfn"
+	"github.com/coinbase/step/utils/to"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Release_SmokeTest(t *testing.T) {
+	defer func(d time.Duration) { SmokeTestPollInterval = d }(SmokeTestPollInterval)
+	SmokeTestPollInterval = time.Millisecond
+
+	release := MockRelease()
+	awsc := MockAwsClients(release)
+
+	assert.NoError(t, release.SmokeTest(awsc.SFN, `{"smoke":true}`, time.Second))
+	assert.Equal(t, `{"smoke":true}`, *awsc.SFN.StartExecutionInput.Input)
+	assert.Equal(t, *release.StepArn(), *awsc.SFN.StartExecutionInput.StateMachineArn)
+
+	awsc.SFN.DescribeExecutionResp = &sfn.DescribeExecutionOutput{Status: to.Strp("FAILED")}
+	err := release.SmokeTest(awsc.SFN, "{}", time.Second)
+	assert.Error(t, err)
+	assert.Regexp(t, "finished with status FAILED", err.Error())
+
+	awsc.SFN.DescribeExecutionResp = &sfn.DescribeExecutionOutput{Status: to.Strp("RUNNING")}
+	err = release.SmokeTest(awsc.SFN, "{}", 5*time.Millisecond)
+	assert.Error(t, err)
+	assert.Regexp(t, "timed out after 5ms with status RUNNING", err.Error())
+	assert.NotNil(t, awsc.SFN.StopExecutionInput)
+}
+
+func Test_Release_ValidateSmokeTest(t *testing.T) {
+	release := MockRelease()
+	assert.NoError(t, release.validateSmokeTest())
+
+	release.SmokeTestInput = to.Strp("{}")
+	assert.NoError(t, release.validateSmokeTest())
+
+	release.SmokeTestTimeout = to.Intp(0)
+	assert.Error(t, release.validateSmokeTest())
+
+	release.SmokeTestTimeout = to.Intp(900)
+	assert.Regexp(t, "more than the 10m0s", release.validateSmokeTest().Error())
+
+	// The default timeout in three regions runs longer than the deploy Lambda allows
+	release.SmokeTestTimeout = nil
+	release.AwsRegions = []*string{to.Strp("us-east-1"), to.Strp("us-west-2"), to.Strp("eu-west-1")}
+	assert.Error(t, release.validateSmokeTest())
+
+	release.SmokeTestTimeout = to.Intp(120)
+	assert.NoError(t, release.validateSmokeTest())
+}
+
+func Test_DeployHandler_Execution_Errors_SmokeTest(t *testing.T) {
+	release := MockRelease()
+	release.SmokeTestInput = to.Strp("{}")
+	awsc := MockAwsClients(release)
+	awsc.SFN.DescribeExecutionResp = &sfn.DescribeExecutionOutput{Status: to.Strp("FAILED")}
+	state_machine := createTestStateMachine(t, awsc)
+
+	exec, err := state_machine.Execute(release)
+	assert.Error(t, err)
+	assert.Regexp(t, "SmokeTestError", exec.LastOutputJSON)
+	assert.Equal(t, []string{
+		"Validate",
+		"Lock",
+		"ValidateResources",
+		"Deploy",
+		"FailureDirty",
+	}, exec.Path())
+}
@@ -0,0 +1,107 @@
+package deployer
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/aws/aws-sdk-go/service/lambda"
+	"github.com/aws/aws-sdk-go/service/sfn"
+	"github.com/coinbase/step/aws"
+	"github.com/coinbase/step/utils/to"
+)
+
+// Tags added to the Lambda and Step Function on deploy to show which release is live
+const (
+	ReleaseIdTag = "ReleaseId"
+	UUIDTag      = "UUID"
+	CreatedAtTag = "CreatedAt"
+)
+
+// ReleaseTags returns the tags identifying this release, unset attributes are left out
+func (release *Release) ReleaseTags() map[string]*string {
+	tags := map[string]*string{}
+
+	if release.ReleaseID != nil {
+		tags[ReleaseIdTag] = release.ReleaseID
+	}
+
+	if release.UUID != nil {
+		tags[UUIDTag] = release.UUID
+	}
+
+	if release.CreatedAt != nil {
+		tags[CreatedAtTag] = to.Strp(release.CreatedAt.UTC().Format(time.RFC3339))
+	}
+
+	return tags
+}
+
+// TagLambda tags the Lambda with ReleaseTags
+func (release *Release) TagLambda(lambdac aws.LambdaAPI) error {
+	_, err := lambdac.TagResource(&lambda.TagResourceInput{
+		Resource: release.LambdaArn(),
+		Tags:     release.ReleaseTags(),
+	})
+	return err
+}
+
+// TagStepFunction tags the State Machine with ReleaseTags
+func (release *Release) TagStepFunction(sfnc aws.SFNAPI) error {
+	tags := release.ReleaseTags()
+
+	keys := []string{}
+	for key := range tags {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	sfnTags := []*sfn.Tag{}
+	for _, key := range keys {
+		sfnTags = append(sfnTags, &sfn.Tag{Key: to.Strp(key), Value: tags[key]})
+	}
+
+	_, err := sfnc.TagResource(&sfn.TagResourceInput{
+		ResourceArn: release.StepArn(),
+		Tags:        sfnTags,
+	})
+	return err
+}
+
+// LambdaTags returns every tag on the Lambda, Lambda ListTags is not paginated
+func (release *Release) LambdaTags(lambdac aws.LambdaAPI) (map[string]*string, error) {
+	return release.LambdaTagsWithContext(context.Background(), lambdac)
+}
+
+// LambdaTagsWithContext is LambdaTags with ctx passed to ListTags
+func (release *Release) LambdaTagsWithContext(ctx context.Context, lambdac aws.LambdaAPI) (map[string]*string, error) {
+	out, err := lambdac.ListTagsWithContext(ctx, &lambda.ListTagsInput{
+		Resource: release.LambdaArn(),
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	if out == nil {
+		return nil, fmt.Errorf("Unknown Lambda Tags Error")
+	}
+
+	if out.Tags == nil {
+		return map[string]*string{}, nil
+	}
+
+	return out.Tags, nil
+}
+
+// CurrentDeployedReleaseId returns the ReleaseId tag of the Lambda,
+// nil if it was never tagged e.g. deployed before tagging was added
+func (release *Release) CurrentDeployedReleaseId(lambdac aws.LambdaAPI) (*string, error) {
+	tags, err := release.LambdaTags(lambdac)
+	if err != nil {
+		return nil, err
+	}
+
+	return tags[ReleaseIdTag], nil
+}
@@ -0,0 +1,77 @@
+package deployer
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/coinbase/step/utils/to"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Release_ReleaseTags(t *testing.T) {
+	r := MockRelease()
+	r.UUID = to.Strp("uuid")
+	r.CreatedAt = to.Timep(time.Date(2019, 1, 2, 3, 4, 5, 6, time.UTC))
+
+	tags := r.ReleaseTags()
+	assert.Equal(t, "release-1", *tags[ReleaseIdTag])
+	assert.Equal(t, "uuid", *tags[UUIDTag])
+	assert.Equal(t, "2019-01-02T03:04:05Z", *tags[CreatedAtTag])
+
+	r.UUID = nil
+	assert.Nil(t, r.ReleaseTags()[UUIDTag])
+}
+
+func Test_Release_DeployRegions_Tags(t *testing.T) {
+	r := MockRelease()
+	awsc := MockAwsClients(r)
+	r.Bucket = to.Strp("bucket")
+
+	id, err := r.CurrentDeployedReleaseId(awsc.Lambda)
+	assert.NoError(t, err)
+	assert.Nil(t, id)
+
+	assert.NoError(t, r.DeployLambdaRegions(awsc, awsc.S3, nil, nil))
+	assert.NoError(t, r.DeployStepFunctionRegions(awsc))
+
+	id, err = r.CurrentDeployedReleaseId(awsc.Lambda)
+	assert.NoError(t, err)
+	assert.Equal(t, "release-1", *id)
+
+	// Existing tags are kept for ValidateLambdaFunctionTags
+	assert.NoError(t, r.ValidateLambdaFunctionTags(awsc.Lambda))
+
+	assert.Equal(t, *r.StepArn(), *awsc.SFN.TagResourceInput.ResourceArn)
+	assert.Equal(t, CreatedAtTag, *awsc.SFN.TagResourceInput.Tags[0].Key)
+	assert.Equal(t, ReleaseIdTag, *awsc.SFN.TagResourceInput.Tags[1].Key)
+	assert.Equal(t, "release-1", *awsc.SFN.TagResourceInput.Tags[1].Value)
+
+	// Tag errors do not fail the deploy
+	awsc.Lambda.TagResourceError = fmt.Errorf("AccessDenied")
+	r.UUID = to.Strp("uuid-2")
+	assert.NoError(t, r.DeployLambdaRegions(awsc, awsc.S3, nil, nil))
+	assert.Nil(t, awsc.Lambda.ListTagsResp.Tags[UUIDTag])
+}
+
+func Test_Release_LambdaTags(t *testing.T) {
+	r := MockRelease()
+	awsc := MockAwsClients(r)
+	awsc.Lambda.ListTagsResp.Tags["CostCenter"] = to.Strp("platform")
+
+	tags, err := r.LambdaTags(awsc.Lambda)
+	assert.NoError(t, err)
+	assert.Equal(t, "platform", *tags["CostCenter"])
+	assert.Equal(t, "project", *tags["ProjectName"])
+	assert.Equal(t, *r.LambdaArn(), *awsc.Lambda.ListTagsInputs[0].Resource)
+
+	awsc.Lambda.ListTagsResp.Tags = nil
+	tags, err = r.LambdaTags(awsc.Lambda)
+	assert.NoError(t, err)
+	assert.Nil(t, tags["ProjectName"])
+
+	awsc.Lambda.ListTagsError = fmt.Errorf("AccessDenied")
+	_, err = r.LambdaTags(awsc.Lambda)
+	assert.Error(t, err)
+	assert.Error(t, r.ValidateLambdaFunctionTags(awsc.Lambda))
+}
@@ -0,0 +1,23 @@
+package deployer
+
+import (
+	"time"
+)
+
+// WipeControlledValues is bifrost's WipeControlledValues also clearing the phase timings the deployer records
+func (release *Release) WipeControlledValues() {
+	release.Release.WipeControlledValues()
+
+	release.ValidationMillis = nil
+	release.StepDeployMillis = nil
+	release.LambdaDeployMillis = nil
+}
+
+// elapsedMillis adds the milliseconds since start to previous, previous can be nil
+func elapsedMillis(previous *int64, start time.Time) *int64 {
+	millis := time.Since(start).Milliseconds()
+	if previous != nil {
+		millis += *previous
+	}
+	return &millis
+}
@@ -0,0 +1,28 @@
+package deployer
+
+// Validator is a policy checked after the built in attribute checks of Validate and ValidateOffline,
+// e.g. that prod configs only deploy to us-east-1
+type Validator interface {
+	Validate(*Release) error
+}
+
+// ValidatorFunc is a function that is a Validator
+type ValidatorFunc func(*Release) error
+
+func (f ValidatorFunc) Validate(r *Release) error {
+	return f(r)
+}
+
+// Validators are run in order, the first error fails the release.
+// Set them before the deployer handles any releases
+var Validators []Validator
+
+func (r *Release) runValidators() error {
+	for _, v := range Validators {
+		if err := v.Validate(r); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,47 @@
+package deployer
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/coinbase/step/errors"
+	"github.com/coinbase/step/utils/to"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Release_Validators(t *testing.T) {
+	defer func(v []Validator) { Validators = v }(Validators)
+
+	release := MockRelease()
+	release.SetDefaults(to.Strp("region"), to.Strp("account"), "")
+	release.LambdaSHA256 = to.Strp(to.SHA256Str(to.Strp("code")))
+	assert.NoError(t, release.ValidateOffline())
+
+	calls := 0
+	Validators = []Validator{
+		ValidatorFunc(func(r *Release) error {
+			calls++
+			return nil
+		}),
+		ValidatorFunc(func(r *Release) error {
+			if to.Strs(r.ConfigName) == "production" && to.Strs(r.AwsRegion) != "us-east-1" {
+				return fmt.Errorf("production must deploy to us-east-1")
+			}
+			return nil
+		}),
+	}
+
+	assert.NoError(t, release.ValidateOffline())
+	assert.Equal(t, 1, calls)
+
+	release.ConfigName = to.Strp("production")
+	err := release.ValidateOffline()
+	assert.IsType(t, &errors.ValidationError{}, err)
+	assert.Regexp(t, "production must deploy to us-east-1", err.Error())
+
+	// Validators run after the built in checks
+	release.LambdaName = nil
+	calls = 0
+	assert.Regexp(t, "LambdaName must be defined", release.ValidateOffline().Error())
+	assert.Equal(t, 0, calls)
+}
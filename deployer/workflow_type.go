@@ -0,0 +1,50 @@
+package deployer
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go/service/sfn"
+	"github.com/coinbase/step/aws"
+	"github.com/coinbase/step/utils/to"
+)
+
+// StateMachineType returns the WorkflowType, STANDARD if it is not set
+func (release *Release) StateMachineType() string {
+	if release.WorkflowType == nil {
+		return sfn.StateMachineTypeStandard
+	}
+	return *release.WorkflowType
+}
+
+func (release *Release) validateWorkflowType() error {
+	switch release.StateMachineType() {
+	case sfn.StateMachineTypeStandard, sfn.StateMachineTypeExpress:
+		return nil
+	}
+
+	return fmt.Errorf("WorkflowType must be %v or %v, got %q", sfn.StateMachineTypeStandard, sfn.StateMachineTypeExpress, release.StateMachineType())
+}
+
+// smokeTestExpress runs an EXPRESS State Machine with StartSyncExecution, which returns when it finishes
+func (release *Release) smokeTestExpress(sfnClient aws.SFNAPI, input string, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	out, err := sfnClient.StartSyncExecutionWithContext(ctx, &sfn.StartSyncExecutionInput{
+		StateMachineArn: release.StepArn(),
+		Name:            to.TimeUUID("smoke-"),
+		Input:           &input,
+	})
+
+	if err != nil {
+		return fmt.Errorf("Smoke Test Error: %v", err.Error())
+	}
+
+	if status := to.Strs(out.Status); status != sfn.SyncExecutionStatusSucceeded {
+		return fmt.Errorf("Smoke Test Error: execution %v finished with status %v %v", to.Strs(out.ExecutionArn), status, to.Strs(out.Error))
+	}
+
+	return nil
+}
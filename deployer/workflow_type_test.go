@@ -0,0 +1,62 @@
+package deployer
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/sfn"
+	"github.com/coinbase/step/aws/mocks"
+	"github.com/coinbase/step/utils/to"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Release_WorkflowType_ValidateAttributes(t *testing.T) {
+	release := MockRelease()
+	release.LambdaSHA256 = to.Strp(to.SHA256Str(to.Strp("zip")))
+	assert.Equal(t, sfn.StateMachineTypeStandard, release.StateMachineType())
+	assert.NoError(t, release.validateAttributes())
+
+	release.WorkflowType = to.Strp(sfn.StateMachineTypeExpress)
+	assert.NoError(t, release.validateAttributes())
+
+	release.WorkflowType = to.Strp("express")
+	assert.Regexp(t, "WorkflowType must be STANDARD or EXPRESS", release.validateAttributes().Error())
+}
+
+func Test_Release_WorkflowType_EnsureStepFunction(t *testing.T) {
+	sfnClient := &mocks.MockSFNClient{}
+	sfnClient.DescribeStateMachineError = awserr.New(sfn.ErrCodeStateMachineDoesNotExist, "does not exist", nil)
+
+	r := MockRelease()
+	r.WorkflowType = to.Strp(sfn.StateMachineTypeExpress)
+
+	assert.NoError(t, r.EnsureStepFunction(sfnClient, "arn:aws:iam::000000000000:role/step/project/development/role"))
+	assert.Equal(t, sfn.StateMachineTypeExpress, *sfnClient.CreateStateMachineInput.Type)
+}
+
+func Test_Release_WorkflowType_ValidateStepFunctionPath(t *testing.T) {
+	r := MockRelease()
+	awsc := MockAwsClients(r)
+	awsc.SFN.DescribeStateMachineResp.Type = to.Strp(sfn.StateMachineTypeStandard)
+	assert.NoError(t, r.ValidateStepFunctionPath(awsc.SFN))
+
+	r.WorkflowType = to.Strp(sfn.StateMachineTypeExpress)
+	assert.Regexp(t, "type is STANDARD expecting EXPRESS", r.ValidateStepFunctionPath(awsc.SFN).Error())
+}
+
+func Test_Release_WorkflowType_SmokeTest_Express(t *testing.T) {
+	release := MockRelease()
+	release.WorkflowType = to.Strp(sfn.StateMachineTypeExpress)
+	awsc := MockAwsClients(release)
+
+	assert.NoError(t, release.SmokeTest(awsc.SFN, `{"smoke":true}`, time.Second))
+	assert.Equal(t, `{"smoke":true}`, *awsc.SFN.StartSyncExecutionInput.Input)
+	assert.Equal(t, *release.StepArn(), *awsc.SFN.StartSyncExecutionInput.StateMachineArn)
+	assert.Nil(t, awsc.SFN.StartExecutionInput)
+
+	awsc.SFN.StartSyncExecutionResp = &sfn.StartSyncExecutionOutput{Status: to.Strp(sfn.SyncExecutionStatusFailed), Error: to.Strp("States.TaskFailed")}
+	err := release.SmokeTest(awsc.SFN, "{}", time.Second)
+	assert.Error(t, err)
+	assert.Regexp(t, "finished with status FAILED States.TaskFailed", err.Error())
+}
@@ -75,6 +75,9 @@ func (e LockExistsError) Error() string {
 	return fmt.Sprintf("LockExistsError: %v", e.Cause)
 }
 
+// LockHeldError is the LockExistsError returned when another release holds the lock
+type LockHeldError = LockExistsError
+
 // LockError error
 type LockError struct {
 	Cause string
@@ -84,6 +87,33 @@ func (e LockError) Error() string {
 	return fmt.Sprintf("LockError: %v", e.Cause)
 }
 
+// LockHolderError error
+type LockHolderError struct {
+	Cause string
+}
+
+func (e LockHolderError) Error() string {
+	return fmt.Sprintf("LockHolderError: %v", e.Cause)
+}
+
+// LockTimeoutError error
+type LockTimeoutError struct {
+	Cause string
+}
+
+func (e LockTimeoutError) Error() string {
+	return fmt.Sprintf("LockTimeoutError: %v", e.Cause)
+}
+
+// ReplayError error
+type ReplayError struct {
+	Cause string
+}
+
+func (e ReplayError) Error() string {
+	return fmt.Sprintf("ReplayError: %v", e.Cause)
+}
+
 // DeployError error
 type DeployError struct {
 	Cause string
@@ -120,6 +150,42 @@ func (e CleanUpError) Error() string {
 	return fmt.Sprintf("CleanUpError: %v", e.Cause)
 }
 
+//
+// Validation errors, their message is only the Cause so they can replace fmt.Errorf
+//
+
+// ValidationError is returned when release attributes are invalid
+type ValidationError struct {
+	Cause string
+}
+
+func (e ValidationError) Error() string {
+	return e.Cause
+}
+
+// SHAMismatchError is returned when the uploaded release or lambda.zip does not hash to the expected SHA
+type SHAMismatchError struct {
+	Cause    string
+	Expected string
+	Actual   string
+}
+
+func (e SHAMismatchError) Error() string {
+	return e.Cause
+}
+
+// TagMismatchError is returned when a resource is not tagged to be deployed by the release
+type TagMismatchError struct {
+	Cause    string
+	Tag      string
+	Expected string
+	Actual   string
+}
+
+func (e TagMismatchError) Error() string {
+	return e.Cause
+}
+
 func throw(err error) error {
 	fmt.Printf(err.Error())
 	return err
@@ -2,9 +2,9 @@ module github.com/coinbase/step
 
 require (
 	github.com/aws/aws-lambda-go v1.11.1
-	github.com/aws/aws-sdk-go v1.20.2
+	github.com/aws/aws-sdk-go v1.36.0
 	github.com/davecgh/go-spew v1.1.1 // indirect
 	github.com/google/gofuzz v0.0.0-20170612174753-24818f796faf
 	github.com/stretchr/testify v1.2.2
-	golang.org/x/net v0.0.0-20190613194153-d28f0bde5980 // indirect
+	golang.org/x/net v0.0.0-20201110031124-69a78807bb2b // indirect
 )
@@ -6,6 +6,7 @@ import (
 	"errors"
 	"fmt"
 	"reflect"
+	"strconv"
 	"strings"
 	"time"
 )
@@ -25,14 +26,33 @@ nil for JSON null
 var NOT_FOUND_ERROR = errors.New("Not Found")
 
 type Path struct {
-	path []string
+	path []step
+}
+
+// step is one element of a Path, a key of an object, an index of an array, or [*] every element of an array
+type step struct {
+	key      string
+	index    int
+	isIndex  bool
+	wildcard bool
+}
+
+func (s step) String() string {
+	switch {
+	case s.wildcard:
+		return "[*]"
+	case s.isIndex:
+		return fmt.Sprintf("[%v]", s.index)
+	default:
+		return s.key
+	}
 }
 
 // NewPath takes string returns JSONPath Object
 func NewPath(path_string string) (*Path, error) {
 	path := Path{}
-	path_array, err := ParsePathString(path_string)
-	path.path = path_array
+	steps, err := parsePath(path_string)
+	path.path = steps
 	return &path, err
 }
 
@@ -45,13 +65,13 @@ func (path *Path) UnmarshalJSON(b []byte) error {
 		return err
 	}
 
-	path_array, err := ParsePathString(path_string)
+	steps, err := parsePath(path_string)
 
 	if err != nil {
 		return err
 	}
 
-	path.path = path_array
+	path.path = steps
 	return nil
 }
 
@@ -63,38 +83,160 @@ func (path *Path) MarshalJSON() ([]byte, error) {
 	return json.Marshal(path.String())
 }
 
+// String uses dot notation, and bracket notation for keys that cannot be written with dots
 func (path *Path) String() string {
-	return fmt.Sprintf("$.%v", strings.Join(path.path[:], "."))
+	var b strings.Builder
+	b.WriteString("$")
+
+	for _, s := range path.path {
+		key := s.key
+		switch {
+		case s.isIndex || s.wildcard:
+			b.WriteString(s.String())
+		case !strings.ContainsAny(key, ".[]'\""):
+			b.WriteString("." + key)
+		case !strings.Contains(key, "'"):
+			b.WriteString("['" + key + "']")
+		default:
+			b.WriteString(`["` + key + `"]`)
+		}
+	}
+
+	return b.String()
 }
 
-// ParsePathString parses a path string
+// ParsePathString parses a path string of keys, e.g. $, $.a.b or $['a b'].c
+// Array indexes and wildcards are returned as [0] and [*], e.g. $.a[0].b is a, [0], b
 func ParsePathString(path_string string) ([]string, error) {
+	steps, err := parsePath(path_string)
+	if err != nil {
+		return nil, err
+	}
+
+	path_array := []string{}
+	for _, s := range steps {
+		path_array = append(path_array, s.String())
+	}
+
+	return path_array, nil
+}
+
+// parsePath parses keys in dot or quoted bracket notation, array indexes like [0], and [*]
+func parsePath(path_string string) ([]step, error) {
 	// must start with $.<value> otherwise empty path
 	if path_string == "" || path_string[0:1] != "$" {
 		return nil, fmt.Errorf("Bad JSON path: must start with $")
 	}
 
-	if path_string == "$" {
-		// Default is no path
-		return []string{}, nil
+	steps := []step{}
+	rest := path_string[1:]
+
+	for rest != "" {
+		switch rest[0] {
+		case '.':
+			end := strings.IndexAny(rest[1:], ".[")
+			if end == -1 {
+				end = len(rest) - 1
+			}
+
+			key := rest[1 : end+1]
+			if key == "" {
+				return nil, fmt.Errorf("Bad JSON path: has empty element")
+			}
+
+			steps = append(steps, step{key: key})
+			rest = rest[end+1:]
+		case '[':
+			if len(rest) >= 2 && (rest[1] == '\'' || rest[1] == '"') {
+				closing := string(rest[1]) + "]"
+				end := strings.Index(rest[2:], closing)
+				if end == -1 {
+					return nil, fmt.Errorf("Bad JSON path: unterminated bracket")
+				}
+
+				key := rest[2 : end+2]
+				if key == "" {
+					return nil, fmt.Errorf("Bad JSON path: has empty element")
+				}
+
+				steps = append(steps, step{key: key})
+				rest = rest[end+2+len(closing):]
+				continue
+			}
+
+			end := strings.Index(rest, "]")
+			if end == -1 {
+				return nil, fmt.Errorf("Bad JSON path: unterminated bracket")
+			}
+
+			s, err := parseBracket(rest[1:end])
+			if err != nil {
+				return nil, err
+			}
+
+			steps = append(steps, s)
+			rest = rest[end+1:]
+		default:
+			return nil, fmt.Errorf("Bad JSON path: expected . or [ at %q", rest)
+		}
+	}
+
+	return steps, nil
+}
+
+// parseBracket parses the unquoted contents of brackets, * or an array index
+func parseBracket(contents string) (step, error) {
+	if contents == "*" {
+		return step{wildcard: true}, nil
 	}
 
-	if len(path_string) < 2 {
-		// This handles the case for $. or $* which are invalid
-		return nil, fmt.Errorf("Bad JSON path: cannot not be 2 characters")
+	index, err := strconv.Atoi(contents)
+	if err != nil || index < 0 || contents[0] == '+' {
+		return step{}, fmt.Errorf("Bad JSON path: brackets must contain a quoted key, an index or *, got %q", contents)
 	}
 
-	head := path_string[2:len(path_string)]
-	path_array := strings.Split(head, ".")
+	return step{index: index, isIndex: true}, nil
+}
 
-	// if path contains an "" error
-	for _, p := range path_array {
-		if p == "" {
-			return nil, fmt.Errorf("Bad JSON path: has empty element")
+// IsReference returns true if the path selects at most one value, i.e. has no [*].
+// ResultPath and Choice Rule Variables must be reference paths
+func (path *Path) IsReference() bool {
+	if path == nil {
+		return true
+	}
+
+	for _, s := range path.path {
+		if s.wildcard {
+			return false
 		}
 	}
-	// Simple Path Builder
-	return path_array, nil
+
+	return true
+}
+
+// Get returns the value at path_string in data
+func Get(data interface{}, path_string string) (interface{}, error) {
+	path, err := NewPath(path_string)
+	if err != nil {
+		return nil, err
+	}
+
+	return path.Get(data)
+}
+
+// Set returns data with value set at path_string, creating maps along the path
+func Set(data interface{}, path_string string, value interface{}) (interface{}, error) {
+	path, err := NewPath(path_string)
+	if err != nil {
+		return nil, err
+	}
+
+	output, err := path.Set(data, value)
+	if err != nil {
+		return nil, err
+	}
+
+	return output, nil
 }
 
 // PUBLIC METHODS
@@ -208,9 +350,9 @@ func (path *Path) Get(input interface{}) (value interface{}, err error) {
 
 // Set sets a Value in a map with Path
 func (path *Path) Set(input interface{}, value interface{}) (output map[string]interface{}, err error) {
-	var set_path []string
+	var set_path []step
 	if path == nil {
-		set_path = []string{} // default "$"
+		set_path = []step{} // default "$"
 	} else {
 		set_path = path.path
 	}
@@ -225,12 +367,48 @@ func (path *Path) Set(input interface{}, value interface{}) (output map[string]i
 			return nil, fmt.Errorf("Cannot Set value %q type %q in root JSON path $", value, reflect.TypeOf(value))
 		}
 	}
-	return recursiveSet(input, value, set_path), nil
+	if set_path[0].isIndex || set_path[0].wildcard {
+		return nil, fmt.Errorf("Cannot Set value at %v, the root JSON path $ must be an object", path)
+	}
+
+	set, err := recursiveSet(input, value, set_path)
+	if err != nil {
+		return nil, err
+	}
+
+	return set.(map[string]interface{}), nil
 }
 
 // PRIVATE METHODS
 
-func recursiveSet(data interface{}, value interface{}, path []string) (output map[string]interface{}) {
+func recursiveSet(data interface{}, value interface{}, path []step) (interface{}, error) {
+	s := path[0]
+
+	if s.wildcard {
+		return nil, fmt.Errorf("Cannot Set value at [*], it selects many values")
+	}
+
+	if s.isIndex {
+		// Only elements of existing arrays can be set
+		data_array, ok := data.([]interface{})
+		if !ok || s.index >= len(data_array) {
+			return nil, fmt.Errorf("Cannot Set value at index %v, not in an array", s.index)
+		}
+
+		if len(path) == 1 {
+			data_array[s.index] = value
+			return data_array, nil
+		}
+
+		element, err := recursiveSet(data_array[s.index], value, path[1:])
+		if err != nil {
+			return nil, err
+		}
+
+		data_array[s.index] = element
+		return data_array, nil
+	}
+
 	var data_map map[string]interface{}
 
 	switch data.(type) {
@@ -243,15 +421,20 @@ func recursiveSet(data interface{}, value interface{}, path []string) (output ma
 	}
 
 	if len(path) == 1 {
-		data_map[path[0]] = value
-	} else {
-		data_map[path[0]] = recursiveSet(data_map[path[0]], value, path[1:])
+		data_map[s.key] = value
+		return data_map, nil
 	}
 
-	return data_map
+	element, err := recursiveSet(data_map[s.key], value, path[1:])
+	if err != nil {
+		return nil, err
+	}
+
+	data_map[s.key] = element
+	return data_map, nil
 }
 
-func recursiveGet(data interface{}, path []string) (interface{}, error) {
+func recursiveGet(data interface{}, path []step) (interface{}, error) {
 	if len(path) == 0 {
 		return data, nil
 	}
@@ -260,9 +443,15 @@ func recursiveGet(data interface{}, path []string) (interface{}, error) {
 		return nil, errors.New("Not Found")
 	}
 
+	s := path[0]
+
 	switch data.(type) {
 	case map[string]interface{}:
-		value, ok := data.(map[string]interface{})[path[0]]
+		if s.isIndex || s.wildcard {
+			return data, NOT_FOUND_ERROR
+		}
+
+		value, ok := data.(map[string]interface{})[s.key]
 
 		if !ok {
 			return data, NOT_FOUND_ERROR
@@ -270,6 +459,27 @@ func recursiveGet(data interface{}, path []string) (interface{}, error) {
 
 		return recursiveGet(value, path[1:])
 
+	case []interface{}:
+		data_array := data.([]interface{})
+
+		if s.wildcard {
+			// Elements without the rest of the path are skipped
+			values := []interface{}{}
+			for _, element := range data_array {
+				value, err := recursiveGet(element, path[1:])
+				if err == nil {
+					values = append(values, value)
+				}
+			}
+			return values, nil
+		}
+
+		if !s.isIndex || s.index >= len(data_array) {
+			return data, NOT_FOUND_ERROR
+		}
+
+		return recursiveGet(data_array[s.index], path[1:])
+
 	default:
 		return data, NOT_FOUND_ERROR
 	}
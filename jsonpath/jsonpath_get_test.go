@@ -1,6 +1,7 @@
 package jsonpath
 
 import (
+	"encoding/json"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -122,3 +123,66 @@ func Test_JSONPath_GetString(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Equal(t, *out, test)
 }
+
+// Examples from the Amazon States Language reference paths
+func Test_JSONPath_Get_AWSExamples(t *testing.T) {
+	var input interface{}
+	assert.NoError(t, json.Unmarshal([]byte(`{"foo": 123, "bar": ["a", "b", "c"], "car": {"cdr": true}}`), &input))
+
+	out, err := Get(input, "$.foo")
+	assert.NoError(t, err)
+	assert.Equal(t, 123.0, out)
+
+	out, err = Get(input, "$.bar")
+	assert.NoError(t, err)
+	assert.Equal(t, []interface{}{"a", "b", "c"}, out)
+
+	out, err = Get(input, "$.car.cdr")
+	assert.NoError(t, err)
+	assert.Equal(t, true, out)
+
+	out, err = Get(input, "$['car']['cdr']")
+	assert.NoError(t, err)
+	assert.Equal(t, true, out)
+
+	out, err = Get(input, "$")
+	assert.NoError(t, err)
+	assert.Equal(t, input, out)
+
+	_, err = Get(input, "$.car.missing")
+	assert.Equal(t, NOT_FOUND_ERROR, err)
+
+	_, err = Get(input, "foo")
+	assert.Regexp(t, "must start with", err.Error())
+}
+
+func Test_JSONPath_Get_Indexes(t *testing.T) {
+	var input interface{}
+	assert.NoError(t, json.Unmarshal([]byte(`{"items": [{"b": 1}, {"b": 2}, {"c": 3}], "nested": [[1, 2], [3]]}`), &input))
+
+	out, err := Get(input, "$.items[0]")
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"b": 1.0}, out)
+
+	out, err = Get(input, "$.items[1].b")
+	assert.NoError(t, err)
+	assert.Equal(t, 2.0, out)
+
+	out, err = Get(input, "$.nested[1][0]")
+	assert.NoError(t, err)
+	assert.Equal(t, 3.0, out)
+
+	// Elements without b are skipped
+	out, err = Get(input, "$.items[*].b")
+	assert.NoError(t, err)
+	assert.Equal(t, []interface{}{1.0, 2.0}, out)
+
+	out, err = Get(input, "$.nested[*]")
+	assert.NoError(t, err)
+	assert.Equal(t, input.(map[string]interface{})["nested"], out)
+
+	for _, missing := range []string{"$.items[3]", "$.items.b", "$.nested[0].a", "$[0]", "$.items[0][0]"} {
+		_, err = Get(input, missing)
+		assert.Equal(t, NOT_FOUND_ERROR, err, missing)
+	}
+}
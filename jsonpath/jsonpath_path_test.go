@@ -32,9 +32,9 @@ func Test_JSONPath_NewPath(t *testing.T) {
 
 	assert.Equal(t, len(path.path), 3)
 
-	assert.Equal(t, path.path[0], "a")
-	assert.Equal(t, path.path[1], "b")
-	assert.Equal(t, path.path[2], "c")
+	assert.Equal(t, path.path[0].key, "a")
+	assert.Equal(t, path.path[1].key, "b")
+	assert.Equal(t, path.path[2].key, "c")
 }
 
 type testPathStruct struct {
@@ -51,7 +51,45 @@ func Test_JSONPath_Parsing(t *testing.T) {
 
 	assert.Equal(t, len(pathstr.path), 3)
 
-	assert.Equal(t, pathstr.path[0], "a")
-	assert.Equal(t, pathstr.path[1], "b")
-	assert.Equal(t, pathstr.path[2], "c")
+	assert.Equal(t, pathstr.path[0].key, "a")
+	assert.Equal(t, pathstr.path[1].key, "b")
+	assert.Equal(t, pathstr.path[2].key, "c")
+}
+
+func Test_JSONPath_Parse_Brackets(t *testing.T) {
+	out, err := ParsePathString("$['a'].b[\"c.d\"]['e f']")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"a", "b", "c.d", "e f"}, out)
+
+	for _, bad := range []string{"", "a", "$.", "$a", "$..a", "$.a.", "$['a'", "$['']", "$[a]", "$[-1]", "$[+1]", "$[0", "$[]"} {
+		_, err := ParsePathString(bad)
+		assert.Error(t, err, bad)
+	}
+}
+
+func Test_JSONPath_Parse_Indexes(t *testing.T) {
+	out, err := ParsePathString("$.items[0].b[*]['c']")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"items", "[0]", "b", "[*]", "c"}, out)
+
+	path, err := NewPath("$.items[12]")
+	assert.NoError(t, err)
+	assert.Equal(t, 12, path.path[1].index)
+	assert.True(t, path.IsReference())
+
+	path, err = NewPath("$.a[*].b")
+	assert.NoError(t, err)
+	assert.False(t, path.IsReference())
+}
+
+func Test_JSONPath_String_RoundTrip(t *testing.T) {
+	for _, str := range []string{"$", "$.a.b", "$['a.b'].c", `$["it's"]`, "$.items[0]", "$.a[*].b", "$[1]"} {
+		path, err := NewPath(str)
+		assert.NoError(t, err)
+		assert.Equal(t, str, path.String())
+	}
+
+	path, err := NewPath("$['a']['b']")
+	assert.NoError(t, err)
+	assert.Equal(t, "$.a.b", path.String())
 }
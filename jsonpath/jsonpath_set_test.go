@@ -75,3 +75,39 @@ func Test_JSONPath_Set_Overwrite(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Equal(t, "s", out)
 }
+
+func Test_JSONPath_Set_String(t *testing.T) {
+	input := map[string]interface{}{"a": "b"}
+
+	out, err := Set(input, "$['x.y'].z", 1.0)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"a": "b", "x.y": map[string]interface{}{"z": 1.0}}, out)
+
+	out, err = Set(input, "$", map[string]interface{}{"c": "d"})
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"c": "d"}, out)
+
+	_, err = Set(input, "$", "not a map")
+	assert.Error(t, err)
+
+	_, err = Set(input, "$.", "s")
+	assert.Error(t, err)
+}
+
+func Test_JSONPath_Set_Indexes(t *testing.T) {
+	input := map[string]interface{}{"items": []interface{}{map[string]interface{}{"a": 1.0}, 2.0}}
+
+	out, err := Set(input, "$.items[0].b", "s")
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"a": 1.0, "b": "s"}, out.(map[string]interface{})["items"].([]interface{})[0])
+
+	out, err = Set(input, "$.items[1]", 3.0)
+	assert.NoError(t, err)
+	assert.Equal(t, 3.0, out.(map[string]interface{})["items"].([]interface{})[1])
+
+	// Only elements of existing arrays can be set
+	for _, bad := range []string{"$.items[2]", "$.missing[0]", "$.items[*]", "$[0]"} {
+		_, err = Set(input, bad, "s")
+		assert.Error(t, err, bad)
+	}
+}
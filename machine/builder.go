@@ -0,0 +1,263 @@
+package machine
+
+import (
+	"fmt"
+
+	"github.com/coinbase/step/utils/to"
+)
+
+// Builder builds a State Machine in Go instead of raw JSON, e.g.
+//
+//	machine.NewStateMachine().
+//		Task("Hello", "arn:aws:lambda:...").Retry(3, "States.ALL").Next("Done").
+//		Succeed("Done").
+//		Build()
+//
+// The first state added is the StartAt state unless StartAt is called.
+// Methods like Next, End, Catch and Retry apply to the last state added.
+type Builder struct {
+	startAt *string
+	names   []string
+	states  map[string]map[string]interface{}
+	current string
+	err     error
+}
+
+// Rule is a Choice Rule, e.g. StringEquals("$.env", "production")
+type Rule map[string]interface{}
+
+// NewStateMachine returns a Builder for a State Machine
+func NewStateMachine() *Builder {
+	return &Builder{
+		states: map[string]map[string]interface{}{},
+	}
+}
+
+// StartAt sets the state the State Machine starts at
+func (b *Builder) StartAt(name string) *Builder {
+	b.startAt = &name
+	return b
+}
+
+// Task adds a Task state that calls resource
+func (b *Builder) Task(name string, resource string) *Builder {
+	return b.addState(name, map[string]interface{}{"Type": "Task", "Resource": resource})
+}
+
+// Pass adds a Pass state
+func (b *Builder) Pass(name string) *Builder {
+	return b.addState(name, map[string]interface{}{"Type": "Pass"})
+}
+
+// Wait adds a Wait state that waits for seconds
+func (b *Builder) Wait(name string, seconds float64) *Builder {
+	return b.addState(name, map[string]interface{}{"Type": "Wait", "Seconds": seconds})
+}
+
+// Succeed adds a Succeed state
+func (b *Builder) Succeed(name string) *Builder {
+	return b.addState(name, map[string]interface{}{"Type": "Succeed"})
+}
+
+// Fail adds a Fail state with an Error and Cause
+func (b *Builder) Fail(name string, errorName string, cause string) *Builder {
+	s := map[string]interface{}{"Type": "Fail", "Error": errorName}
+	if cause != "" {
+		s["Cause"] = cause
+	}
+	return b.addState(name, s)
+}
+
+// Choice adds a Choice state, add its choices with When and Otherwise
+func (b *Builder) Choice(name string) *Builder {
+	return b.addState(name, map[string]interface{}{"Type": "Choice", "Choices": []Rule{}})
+}
+
+// Parallel adds a Parallel state with each branch built from a Builder
+func (b *Builder) Parallel(name string, branches ...*Builder) *Builder {
+	asls := []map[string]interface{}{}
+	for i, branch := range branches {
+		if branch.err != nil {
+			b.setErr(fmt.Errorf("Branch %v %v", i, branch.err))
+		}
+		asls = append(asls, branch.asl())
+	}
+
+	return b.addState(name, map[string]interface{}{"Type": "Parallel", "Branches": asls})
+}
+
+// Next sets the next state
+func (b *Builder) Next(next string) *Builder {
+	if s := b.currentState("Next", "Task", "Pass", "Wait", "Parallel"); s != nil {
+		s["Next"] = next
+	}
+	return b
+}
+
+// End makes the state terminal
+func (b *Builder) End() *Builder {
+	if s := b.currentState("End", "Task", "Pass", "Wait", "Parallel"); s != nil {
+		s["End"] = true
+	}
+	return b
+}
+
+// ResultPath sets where the result of the state is put in the input
+func (b *Builder) ResultPath(path string) *Builder {
+	if s := b.currentState("ResultPath", "Task", "Pass", "Parallel"); s != nil {
+		s["ResultPath"] = path
+	}
+	return b
+}
+
+// Catch adds a Catcher that goes to next on errorEquals
+func (b *Builder) Catch(next string, errorEquals ...string) *Builder {
+	if s := b.currentState("Catch", "Task", "Parallel"); s != nil {
+		catch, _ := s["Catch"].([]map[string]interface{})
+		s["Catch"] = append(catch, map[string]interface{}{"ErrorEquals": errorEquals, "Next": next})
+	}
+	return b
+}
+
+// Retry adds a Retrier that retries maxAttempts times on errorEquals
+func (b *Builder) Retry(maxAttempts int, errorEquals ...string) *Builder {
+	if s := b.currentState("Retry", "Task", "Parallel"); s != nil {
+		retry, _ := s["Retry"].([]map[string]interface{})
+		s["Retry"] = append(retry, map[string]interface{}{"ErrorEquals": errorEquals, "MaxAttempts": maxAttempts})
+	}
+	return b
+}
+
+// When adds a choice going to next if the rule matches
+func (b *Builder) When(rule Rule, next string) *Builder {
+	if s := b.currentState("When", "Choice"); s != nil {
+		choice := Rule{"Next": next}
+		for k, v := range rule {
+			choice[k] = v
+		}
+		s["Choices"] = append(s["Choices"].([]Rule), choice)
+	}
+	return b
+}
+
+// Otherwise sets the Default state when no choice matches
+func (b *Builder) Otherwise(next string) *Builder {
+	if s := b.currentState("Otherwise", "Choice"); s != nil {
+		s["Default"] = next
+	}
+	return b
+}
+
+// Build returns the State Machine JSON, and an error if it is invalid
+func (b *Builder) Build() (string, error) {
+	if b.err != nil {
+		return "", b.err
+	}
+
+	smJSON, err := to.PrettyJSON(b.asl())
+	if err != nil {
+		return "", err
+	}
+
+	if err := Validate(&smJSON); err != nil {
+		return "", err
+	}
+
+	return smJSON, nil
+}
+
+func (b *Builder) asl() map[string]interface{} {
+	startAt := b.startAt
+	if startAt == nil && len(b.names) > 0 {
+		startAt = &b.names[0]
+	}
+
+	return map[string]interface{}{
+		"StartAt": to.Strs(startAt),
+		"States":  b.states,
+	}
+}
+
+func (b *Builder) addState(name string, s map[string]interface{}) *Builder {
+	if _, ok := b.states[name]; ok {
+		b.setErr(fmt.Errorf("State %q already defined", name))
+		return b
+	}
+
+	b.names = append(b.names, name)
+	b.states[name] = s
+	b.current = name
+	return b
+}
+
+// currentState returns the last state added if it is one of types
+func (b *Builder) currentState(method string, types ...string) map[string]interface{} {
+	s, ok := b.states[b.current]
+	if !ok {
+		b.setErr(fmt.Errorf("%v called before adding a State", method))
+		return nil
+	}
+
+	for _, t := range types {
+		if s["Type"] == t {
+			return s
+		}
+	}
+
+	b.setErr(fmt.Errorf("%v not allowed on %v State %q", method, s["Type"], b.current))
+	return nil
+}
+
+// setErr keeps the first error so Build returns it
+func (b *Builder) setErr(err error) {
+	if b.err == nil {
+		b.err = err
+	}
+}
+
+// Choice Rules
+
+// Compare returns a Rule comparing variable with value using operator, e.g. Compare("$.n", "NumericLessThan", 10)
+func Compare(variable string, operator string, value interface{}) Rule {
+	return Rule{"Variable": variable, operator: value}
+}
+
+// StringEquals returns a Rule that matches when variable equals value
+func StringEquals(variable string, value string) Rule {
+	return Compare(variable, "StringEquals", value)
+}
+
+// NumericEquals returns a Rule that matches when variable equals value
+func NumericEquals(variable string, value float64) Rule {
+	return Compare(variable, "NumericEquals", value)
+}
+
+// NumericLessThan returns a Rule that matches when variable is less than value
+func NumericLessThan(variable string, value float64) Rule {
+	return Compare(variable, "NumericLessThan", value)
+}
+
+// NumericGreaterThan returns a Rule that matches when variable is greater than value
+func NumericGreaterThan(variable string, value float64) Rule {
+	return Compare(variable, "NumericGreaterThan", value)
+}
+
+// BooleanEquals returns a Rule that matches when variable equals value
+func BooleanEquals(variable string, value bool) Rule {
+	return Compare(variable, "BooleanEquals", value)
+}
+
+// And returns a Rule that matches when all rules match
+func And(rules ...Rule) Rule {
+	return Rule{"And": rules}
+}
+
+// Or returns a Rule that matches when any rule matches
+func Or(rules ...Rule) Rule {
+	return Rule{"Or": rules}
+}
+
+// Not returns a Rule that matches when rule does not match
+func Not(rule Rule) Rule {
+	return Rule{"Not": rule}
+}
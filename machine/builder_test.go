@@ -0,0 +1,66 @@
+package machine
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Builder_Build(t *testing.T) {
+	smJSON, err := NewStateMachine().
+		Pass("Start").Next("Env?").
+		Choice("Env?").
+		When(And(StringEquals("$.env", "production"), Not(BooleanEquals("$.dry_run", true))), "Deploy").
+		When(NumericGreaterThan("$.retries", 3), "Failed").
+		Otherwise("Wait").
+		Wait("Wait", 10).Next("Start").
+		Task("Deploy", "deploy").Retry(2, "States.TaskFailed").Catch("Failed", "States.ALL").ResultPath("$.result").Next("Both").
+		Parallel("Both",
+			NewStateMachine().Task("Notify", "notify").End(),
+			NewStateMachine().Pass("Log").End(),
+		).Next("Done").
+		Succeed("Done").
+		Fail("Failed", "DeployFailed", "Deploy failed").
+		Build()
+
+	assert.NoError(t, err)
+
+	sm, err := FromJSON([]byte(smJSON))
+	assert.NoError(t, err)
+	assert.Equal(t, "Start", *sm.StartAt)
+	assert.Equal(t, 7, len(sm.States))
+	assert.Equal(t, 2, len(sm.AllTasks()))
+
+	output, err := Execute(&smJSON, map[string]interface{}{"env": "production", "dry_run": false}, func(resource string, input interface{}) (interface{}, error) {
+		return map[string]interface{}{"resource": resource}, nil
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(output.([]interface{})))
+}
+
+func Test_Builder_Errors(t *testing.T) {
+	_, err := NewStateMachine().Succeed("Done").Next("Other").Build()
+	assert.Error(t, err)
+	assert.Regexp(t, `Next not allowed on Succeed State "Done"`, err.Error())
+
+	_, err = NewStateMachine().Pass("A").End().Pass("A").End().Build()
+	assert.Error(t, err)
+	assert.Regexp(t, `State "A" already defined`, err.Error())
+
+	_, err = NewStateMachine().Pass("A").When(StringEquals("$.a", "a"), "A").Build()
+	assert.Error(t, err)
+	assert.Regexp(t, `When not allowed on Pass State "A"`, err.Error())
+
+	_, err = NewStateMachine().Parallel("P", NewStateMachine().End()).End().Build()
+	assert.Error(t, err)
+	assert.Regexp(t, `Branch 0 End called before adding a State`, err.Error())
+
+	// Validated like JSON
+	_, err = NewStateMachine().Pass("A").Next("Missing").Build()
+	assert.Error(t, err)
+
+	_, err = NewStateMachine().Choice("C").When(Compare("$.a", "StringEquls", "a"), "Done").Otherwise("Done").Succeed("Done").Build()
+	assert.Error(t, err)
+	assert.Regexp(t, `Unknown comparison Operator`, err.Error())
+}
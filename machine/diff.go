@@ -0,0 +1,129 @@
+package machine
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// FieldChange is the value of a field before and after, nil if it is not defined
+type FieldChange struct {
+	Old interface{} `json:"old,omitempty"`
+	New interface{} `json:"new,omitempty"`
+}
+
+// StateChange is a state in both definitions whose fields, e.g. Resource or Next, changed
+type StateChange struct {
+	Name   string                 `json:"name"`
+	Fields map[string]FieldChange `json:"fields"`
+}
+
+// DefinitionChanges is the difference between two State Machine definitions.
+// States nested in Parallel Branches or a Map Iterator are compared as their Branches or Iterator field
+type DefinitionChanges struct {
+	AddedStates    []string               `json:"added_states"`    // sorted state names
+	RemovedStates  []string               `json:"removed_states"`  // sorted state names
+	ModifiedStates []StateChange          `json:"modified_states"` // sorted by Name
+	Fields         map[string]FieldChange `json:"fields"`          // top level fields, e.g. StartAt, except States
+}
+
+// Empty returns true if the definitions are equivalent
+func (c *DefinitionChanges) Empty() bool {
+	return len(c.AddedStates) == 0 && len(c.RemovedStates) == 0 && len(c.ModifiedStates) == 0 && len(c.Fields) == 0
+}
+
+// Diff parses both definitions and returns what changed from oldDef to newDef.
+// Definitions are compared after Normalize, so whitespace, key order and key casing are not changes
+func Diff(oldDef *string, newDef *string) (*DefinitionChanges, error) {
+	oldSM, err := diffDefinition(oldDef)
+	if err != nil {
+		return nil, fmt.Errorf("Diff Error: old definition: %v", err.Error())
+	}
+
+	newSM, err := diffDefinition(newDef)
+	if err != nil {
+		return nil, fmt.Errorf("Diff Error: new definition: %v", err.Error())
+	}
+
+	oldStates, _ := oldSM["States"].(map[string]interface{})
+	newStates, _ := newSM["States"].(map[string]interface{})
+	delete(oldSM, "States")
+	delete(newSM, "States")
+
+	changes := &DefinitionChanges{
+		AddedStates:    []string{},
+		RemovedStates:  []string{},
+		ModifiedStates: []StateChange{},
+		Fields:         diffFields(oldSM, newSM),
+	}
+
+	for name, s := range newStates {
+		old, ok := oldStates[name]
+		if !ok {
+			changes.AddedStates = append(changes.AddedStates, name)
+			continue
+		}
+
+		oldFields, _ := old.(map[string]interface{})
+		newFields, _ := s.(map[string]interface{})
+		if fields := diffFields(oldFields, newFields); len(fields) != 0 {
+			changes.ModifiedStates = append(changes.ModifiedStates, StateChange{Name: name, Fields: fields})
+		}
+	}
+
+	for name := range oldStates {
+		if _, ok := newStates[name]; !ok {
+			changes.RemovedStates = append(changes.RemovedStates, name)
+		}
+	}
+
+	sort.Strings(changes.AddedStates)
+	sort.Strings(changes.RemovedStates)
+	sort.Slice(changes.ModifiedStates, func(i, j int) bool {
+		return changes.ModifiedStates[i].Name < changes.ModifiedStates[j].Name
+	})
+
+	return changes, nil
+}
+
+// diffDefinition validates the definition parses as a State Machine and returns it normalized
+func diffDefinition(definition *string) (map[string]interface{}, error) {
+	normalized, err := Normalize(definition)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := FromJSON([]byte(*normalized)); err != nil {
+		return nil, err
+	}
+
+	decoder := json.NewDecoder(strings.NewReader(*normalized))
+	decoder.UseNumber()
+
+	var sm map[string]interface{}
+	if err := decoder.Decode(&sm); err != nil {
+		return nil, err
+	}
+
+	return sm, nil
+}
+
+func diffFields(before map[string]interface{}, after map[string]interface{}) map[string]FieldChange {
+	fields := map[string]FieldChange{}
+
+	for key, value := range after {
+		if !reflect.DeepEqual(before[key], value) {
+			fields[key] = FieldChange{Old: before[key], New: value}
+		}
+	}
+
+	for key, value := range before {
+		if _, ok := after[key]; !ok {
+			fields[key] = FieldChange{Old: value}
+		}
+	}
+
+	return fields
+}
@@ -0,0 +1,96 @@
+package machine
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/coinbase/step/utils/to"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Machine_Diff(t *testing.T) {
+	old := to.Strp(`{
+		"StartAt": "Start",
+		"States": {
+			"Start": {"Type": "Task", "Resource": "arn:aws:lambda:us-east-1:000000000000:function:old", "Next": "Check"},
+			"Check": {"Type": "Pass", "Next": "Done"},
+			"Done": {"Type": "Succeed"}
+		}
+	}`)
+
+	newDef := to.Strp(`{
+		"Comment": "v2",
+		"StartAt": "Start",
+		"States": {
+			"Start": {"Type": "Task", "Resource": "arn:aws:lambda:us-east-1:000000000000:function:new", "Next": "Wait"},
+			"Wait": {"Type": "Wait", "Seconds": 1, "Next": "Done"},
+			"Done": {"Type": "Succeed"}
+		}
+	}`)
+
+	changes, err := Diff(old, newDef)
+	assert.NoError(t, err)
+	assert.False(t, changes.Empty())
+
+	assert.Equal(t, []string{"Wait"}, changes.AddedStates)
+	assert.Equal(t, []string{"Check"}, changes.RemovedStates)
+
+	assert.Equal(t, 1, len(changes.ModifiedStates))
+	start := changes.ModifiedStates[0]
+	assert.Equal(t, "Start", start.Name)
+	assert.Equal(t, 2, len(start.Fields))
+	assert.Equal(t, "arn:aws:lambda:us-east-1:000000000000:function:old", start.Fields["Resource"].Old)
+	assert.Equal(t, "arn:aws:lambda:us-east-1:000000000000:function:new", start.Fields["Resource"].New)
+	assert.Equal(t, "Wait", start.Fields["Next"].New)
+
+	assert.Equal(t, map[string]FieldChange{"Comment": {New: "v2"}}, changes.Fields)
+
+	// Reversed
+	changes, err = Diff(newDef, old)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"Check"}, changes.AddedStates)
+	assert.Equal(t, []string{"Wait"}, changes.RemovedStates)
+	assert.Equal(t, map[string]FieldChange{"Comment": {Old: "v2"}}, changes.Fields)
+
+	raw, err := json.Marshal(changes)
+	assert.NoError(t, err)
+	assert.Regexp(t, `"added_states":\["Check"\]`, string(raw))
+}
+
+func Test_Machine_Diff_Equivalent(t *testing.T) {
+	changes, err := Diff(
+		to.Strp(`{"StartAt": "A", "States": {"A": {"Type": "Pass", "Result": {"n": 1.0}, "End": true}}}`),
+		to.Strp(`{"states": {"A": {"end": true, "result": {"n": 1.0}, "type": "pass"}}, "startAt": "A"}`),
+	)
+	assert.NoError(t, err)
+	assert.True(t, changes.Empty())
+
+	// Payloads are compared exactly
+	changes, err = Diff(
+		to.Strp(`{"StartAt": "A", "States": {"A": {"Type": "Pass", "Result": {"n": 1.0}, "End": true}}}`),
+		to.Strp(`{"StartAt": "A", "States": {"A": {"Type": "Pass", "Result": {"N": 1.0}, "End": true}}}`),
+	)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"Result"}, keys(changes.ModifiedStates[0].Fields))
+}
+
+func Test_Machine_Diff_Errors(t *testing.T) {
+	valid := to.Strp(`{"StartAt": "A", "States": {"A": {"Type": "Succeed"}}}`)
+
+	_, err := Diff(to.Strp("{"), valid)
+	assert.Regexp(t, "old definition", err.Error())
+
+	_, err = Diff(valid, to.Strp(`{"StartAt": "A", "States": {"A": {"Type": "Unknown"}}}`))
+	assert.Regexp(t, "new definition", err.Error())
+
+	_, err = Diff(nil, valid)
+	assert.Error(t, err)
+}
+
+func keys(m map[string]FieldChange) []string {
+	ks := []string{}
+	for k := range m {
+		ks = append(ks, k)
+	}
+	return ks
+}
@@ -0,0 +1,264 @@
+package machine
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/coinbase/step/machine/state"
+	"github.com/coinbase/step/utils/to"
+)
+
+// Output Dot Format For State Machine
+
+// ToDOT parses a State Machine definition and returns it as a Graphviz DOT graph
+func ToDOT(sm_json *string) (string, error) {
+	stateMachine, err := FromJSON([]byte(*sm_json))
+	if err != nil {
+		return "", err
+	}
+
+	if err := stateMachine.Validate(); err != nil {
+		return "", err
+	}
+
+	return stateMachine.ToDOT(), nil
+}
+
+// ToDOT returns the State Machine as a Graphviz DOT graph
+func (stateMachine *StateMachine) ToDOT() string {
+	return fmt.Sprintf(`digraph StateMachine {
+    node      [style="rounded,filled,bold", shape=box, width=2, fontname="Arial" fontcolor="#183153", color="#183153"];
+    edge      [style=bold, fontname="Arial", fontcolor="#183153", color="#183153"];
+    _Start    [fillcolor="#183153", shape=circle, label="", width=0.25];
+    _End      [fillcolor="#183153", shape=doublecircle, label="", width=0.3];
+
+    _Start -> "%v" [weight=1000];
+    %v
+}`, *stateMachine.StartAt, processStates(*stateMachine.StartAt, stateMachine.States))
+}
+
+func processStates(start string, states map[string]state.State) string {
+	orderedStates := orderStates(start, states)
+
+	var stateStrings []string
+	for _, stateNode := range orderedStates {
+		stateStrings = append(stateStrings, processState(stateNode))
+	}
+	return strings.Join(stateStrings, "\n\n    ")
+}
+
+// Order states from start to end consistently to generate deterministic graphs.
+func orderStates(start string, states map[string]state.State) []state.State {
+	var orderedStates []state.State
+	startState, ok := states[start]
+	if !ok {
+		return orderedStates
+	}
+
+	stateQueue := []state.State{startState}
+	seenStates := map[string]struct{}{start: struct{}{}}
+
+	for len(stateQueue) > 0 {
+		var stateNode state.State
+		stateNode, stateQueue = stateQueue[0], stateQueue[1:]
+
+		orderedStates = append(orderedStates, stateNode)
+
+		for _, next := range nextStates(stateNode) {
+			connectedState, ok := states[next]
+			if !ok {
+				continue
+			}
+
+			if _, seen := seenStates[next]; !seen {
+				stateQueue = append(stateQueue, connectedState)
+				seenStates[next] = struct{}{}
+			}
+		}
+	}
+
+	return orderedStates
+}
+
+// nextStates returns the names of the states a state can transition to, in order
+func nextStates(stateNode state.State) []string {
+	var next []string
+
+	switch stateNode.(type) {
+	case *state.PassState:
+		stateNode := stateNode.(*state.PassState)
+		if stateNode.Next != nil {
+			next = append(next, *stateNode.Next)
+		}
+	case *state.TaskState:
+		stateNode := stateNode.(*state.TaskState)
+
+		for _, catch := range stateNode.Catch {
+			if catch.Next != nil {
+				next = append(next, *catch.Next)
+			}
+		}
+
+		if stateNode.Next != nil {
+			next = append(next, *stateNode.Next)
+		}
+	case *state.ChoiceState:
+		stateNode := stateNode.(*state.ChoiceState)
+
+		for _, choice := range stateNode.Choices {
+			if choice.Next != nil {
+				next = append(next, *choice.Next)
+			}
+		}
+
+		if stateNode.Default != nil {
+			next = append(next, *stateNode.Default)
+		}
+	case *state.ParallelState:
+		stateNode := stateNode.(*state.ParallelState)
+
+		for _, catch := range stateNode.Catch {
+			if catch.Next != nil {
+				next = append(next, *catch.Next)
+			}
+		}
+
+		if stateNode.Next != nil {
+			next = append(next, *stateNode.Next)
+		}
+	case *state.MapState:
+		stateNode := stateNode.(*state.MapState)
+
+		for _, catch := range stateNode.Catch {
+			if catch.Next != nil {
+				next = append(next, *catch.Next)
+			}
+		}
+
+		if stateNode.Next != nil {
+			next = append(next, *stateNode.Next)
+		}
+	case *state.WaitState:
+		stateNode := stateNode.(*state.WaitState)
+
+		if stateNode.Next != nil {
+			next = append(next, *stateNode.Next)
+		}
+	}
+
+	return next
+}
+
+// Terminal states have a double border
+func terminal(end *bool) string {
+	if end != nil && *end {
+		return ", peripheries=2"
+	}
+	return ""
+}
+
+func processState(stateNode state.State) string {
+	var lines []string
+	name := *stateNode.Name()
+	switch stateNode.(type) {
+	case *state.PassState:
+		stateNode := stateNode.(*state.PassState)
+		lines = append(lines, fmt.Sprintf(`%q [fillcolor="#FBFBFB"%v];`, name, terminal(stateNode.End)))
+		if stateNode.Next != nil {
+			lines = append(lines, fmt.Sprintf(`%q -> %q [weight=100];`, name, *stateNode.Next))
+		}
+		if stateNode.End != nil {
+			lines = append(lines, fmt.Sprintf(`%q -> _End;`, name))
+		}
+	case *state.TaskState:
+		stateNode := stateNode.(*state.TaskState)
+		lines = append(lines, fmt.Sprintf(`%q [fillcolor="#FBFBFB"%v];`, name, terminal(stateNode.End)))
+
+		for _, catch := range stateNode.Catch {
+			catchName := strings.Join(to.StrSlice(catch.ErrorEquals), ",")
+			if len(catch.ErrorEquals) == 1 && *catch.ErrorEquals[0] == "States.ALL" {
+				catchName = ""
+			}
+			lines = append(lines, fmt.Sprintf(`%q -> %q [color="#949494", label=%q, style=solid];`, name, to.Strs(catch.Next), catchName))
+		}
+
+		if stateNode.Next != nil {
+			lines = append(lines, fmt.Sprintf(`%q -> %q [weight=100];`, name, *stateNode.Next))
+		}
+
+		if stateNode.End != nil {
+			lines = append(lines, fmt.Sprintf(`%q -> _End;`, name))
+		}
+	case *state.ChoiceState:
+		stateNode := stateNode.(*state.ChoiceState)
+		lines = append(lines, fmt.Sprintf(`%q [shape=egg, fillcolor="#FBFBFB"];`, name))
+
+		for _, choice := range stateNode.Choices {
+			lines = append(lines, fmt.Sprintf(`%q -> %q [weight=100, label=%q];`, name, to.Strs(choice.Next), choice.ChoiceRule.String()))
+		}
+
+		if stateNode.Default != nil {
+			lines = append(lines, fmt.Sprintf(`%q -> %q [style=dashed, label="Default"];`, name, *stateNode.Default))
+		}
+	case *state.MapState:
+		stateNode := stateNode.(*state.MapState)
+		lines = append(lines, fmt.Sprintf(`%q [shape=box3d, fillcolor="#FBFBFB"%v];`, name, terminal(stateNode.End)))
+
+		for _, catch := range stateNode.Catch {
+			catchName := strings.Join(to.StrSlice(catch.ErrorEquals), ",")
+			if len(catch.ErrorEquals) == 1 && *catch.ErrorEquals[0] == "States.ALL" {
+				catchName = ""
+			}
+			lines = append(lines, fmt.Sprintf(`%q -> %q [color="#949494", label=%q, style=solid];`, name, to.Strs(catch.Next), catchName))
+		}
+
+		if stateNode.Next != nil {
+			lines = append(lines, fmt.Sprintf(`%q -> %q [weight=100];`, name, *stateNode.Next))
+		}
+
+		if stateNode.End != nil {
+			lines = append(lines, fmt.Sprintf(`%q -> _End;`, name))
+		}
+	case *state.ParallelState:
+		stateNode := stateNode.(*state.ParallelState)
+		lines = append(lines, fmt.Sprintf(`%q [shape=component, fillcolor="#FBFBFB"%v];`, name, terminal(stateNode.End)))
+
+		for _, catch := range stateNode.Catch {
+			catchName := strings.Join(to.StrSlice(catch.ErrorEquals), ",")
+			if len(catch.ErrorEquals) == 1 && *catch.ErrorEquals[0] == "States.ALL" {
+				catchName = ""
+			}
+			lines = append(lines, fmt.Sprintf(`%q -> %q [color="#949494", label=%q, style=solid];`, name, to.Strs(catch.Next), catchName))
+		}
+
+		if stateNode.Next != nil {
+			lines = append(lines, fmt.Sprintf(`%q -> %q [weight=100];`, name, *stateNode.Next))
+		}
+
+		if stateNode.End != nil {
+			lines = append(lines, fmt.Sprintf(`%q -> _End;`, name))
+		}
+	case *state.WaitState:
+		stateNode := stateNode.(*state.WaitState)
+
+		lines = append(lines, fmt.Sprintf(`%q [width=0.5, shape=doublecircle, fillcolor="#FBFBFB", label="Wait"];`, name))
+
+		if stateNode.Next != nil {
+			lines = append(lines, fmt.Sprintf(`%q -> %q [weight=100];`, name, *stateNode.Next))
+		}
+
+		if stateNode.End != nil {
+			lines = append(lines, fmt.Sprintf(`%q -> _End;`, name))
+		}
+	case *state.FailState:
+		lines = append(lines, fmt.Sprintf(`%q [fillcolor="#F9E4D1", peripheries=2];`, name))
+		lines = append(lines, fmt.Sprintf(`%q -> _End [weight=1000];`, name))
+	case *state.SucceedState:
+		lines = append(lines, fmt.Sprintf(`%q [fillcolor="#e5eddb", peripheries=2];`, name))
+		lines = append(lines, fmt.Sprintf(`%q -> _End [weight=1000];`, name))
+	default:
+		lines = append(lines, fmt.Sprintf(`%q [fillcolor="#FBFBFB"];`, name))
+	}
+
+	return strings.Join(lines, "\n    ")
+}
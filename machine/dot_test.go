@@ -0,0 +1,48 @@
+package machine
+
+import (
+	"testing"
+
+	"github.com/coinbase/step/utils/to"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Machine_ToDOT(t *testing.T) {
+	dot, err := ToDOT(to.Strp(`{
+    "StartAt": "Task",
+    "States": {
+      "Task": {
+        "Type": "Task",
+        "Resource": "arn:aws:lambda:us-east-1:00000000:function:a",
+        "Next": "Choice",
+        "Catch": [{"ErrorEquals": ["States.ALL"], "Next": "Fail"}]
+      },
+      "Choice": {
+        "Type": "Choice",
+        "Choices": [{"Variable": "$.a", "StringEquals": "b", "Next": "Pass"}],
+        "Default": "Succeed"
+      },
+      "Pass": {"Type": "Pass", "End": true},
+      "Fail": {"Type": "Fail", "Error": "Error"},
+      "Succeed": {"Type": "Succeed"}
+    }
+  }`))
+
+	assert.NoError(t, err)
+	assert.Contains(t, dot, `_Start -> "Task"`)
+	assert.Contains(t, dot, `"Task" -> "Choice" [weight=100];`)
+	assert.Contains(t, dot, `"Task" -> "Fail" [color="#949494", label="", style=solid];`)
+	assert.Contains(t, dot, `"Choice" -> "Pass" [weight=100, label="$.a=b"];`)
+	assert.Contains(t, dot, `"Choice" -> "Succeed" [style=dashed, label="Default"];`)
+	assert.Contains(t, dot, `"Pass" [fillcolor="#FBFBFB", peripheries=2];`)
+	assert.Contains(t, dot, `"Succeed" [fillcolor="#e5eddb", peripheries=2];`)
+	assert.Contains(t, dot, `"Fail" [fillcolor="#F9E4D1", peripheries=2];`)
+
+	// Deterministic
+	dot2, _ := ToDOT(to.Strp(EmptyStateMachine))
+	dot3, _ := ToDOT(to.Strp(EmptyStateMachine))
+	assert.Equal(t, dot2, dot3)
+
+	_, err = ToDOT(to.Strp(`{}`))
+	assert.Error(t, err)
+}
@@ -6,6 +6,7 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"sort"
 
 	"github.com/aws/aws-lambda-go/lambdacontext"
 	"github.com/coinbase/step/handler"
@@ -40,6 +41,10 @@ type StateMachine struct {
 
 // Global Methods
 func Validate(sm_json *string) error {
+	if err := ValidateDefinitionSize(sm_json); err != nil {
+		return err
+	}
+
 	state_machine, err := FromJSON([]byte(*sm_json))
 	if err != nil {
 		return err
@@ -52,6 +57,55 @@ func Validate(sm_json *string) error {
 	return nil
 }
 
+// MaxDefinitionBytes is the largest State Machine definition AWS accepts
+const MaxDefinitionBytes = 1024 * 1024
+
+// ValidateDefinitionSize returns an error with the actual size if the definition is over MaxDefinitionBytes
+func ValidateDefinitionSize(sm_json *string) error {
+	if sm_json == nil {
+		return nil
+	}
+
+	if size := len(*sm_json); size > MaxDefinitionBytes {
+		return fmt.Errorf("definition is %v bytes, over the %v byte limit", size, MaxDefinitionBytes)
+	}
+
+	return nil
+}
+
+// ResourceFn is called by Execute with the Resource of a Task state and its input
+type ResourceFn func(resource string, input interface{}) (interface{}, error)
+
+// Execute simulates a State Machine definition locally, calling resourceFn for every Task
+func Execute(sm_json *string, input interface{}, resourceFn ResourceFn) (interface{}, error) {
+	state_machine, err := FromJSON([]byte(*sm_json))
+	if err != nil {
+		return nil, err
+	}
+
+	for _, task := range state_machine.AllTasks() {
+		resource := to.Strs(task.Resource)
+		task.SetTaskHandler(func(_ context.Context, in interface{}) (interface{}, error) {
+			return resourceFn(resource, in)
+		})
+	}
+
+	if err := state_machine.Validate(); err != nil {
+		return nil, err
+	}
+
+	input, err = processInput(input)
+	if err != nil {
+		return nil, err
+	}
+
+	// Output can be any JSON value, not just the map recorded by an Execution
+	exec := &Execution{}
+	exec.Start()
+
+	return state_machine.stateLoop(exec, state_machine.StartAt, input)
+}
+
 func (sm *StateMachine) FindTask(name string) (*state.TaskState, error) {
 	task, ok := sm.Tasks()[name]
 
@@ -73,6 +127,28 @@ func (sm *StateMachine) Tasks() map[string]*state.TaskState {
 	return tasks
 }
 
+// AllTasks returns the Tasks of the State Machine and of every nested Machine, e.g. Map Iterators and Parallel Branches
+func (sm *StateMachine) AllTasks() []*state.TaskState {
+	tasks := []*state.TaskState{}
+	for _, s := range sm.States {
+		switch s := s.(type) {
+		case *state.TaskState:
+			tasks = append(tasks, s)
+		case *state.MapState:
+			if iterator, ok := s.GetIterator().(*StateMachine); ok {
+				tasks = append(tasks, iterator.AllTasks()...)
+			}
+		case *state.ParallelState:
+			for _, branch := range s.GetBranches() {
+				if branch, ok := branch.(*StateMachine); ok {
+					tasks = append(tasks, branch.AllTasks()...)
+				}
+			}
+		}
+	}
+	return tasks
+}
+
 func (sm *StateMachine) SetResource(lambda_arn *string) {
 	for _, task := range sm.Tasks() {
 		if task.Resource == nil {
@@ -82,7 +158,7 @@ func (sm *StateMachine) SetResource(lambda_arn *string) {
 }
 
 func (sm *StateMachine) SetDefaultHandler() {
-	for _, task := range sm.Tasks() {
+	for _, task := range sm.AllTasks() {
 		task.SetTaskHandler(DefaultHandler)
 	}
 }
@@ -116,16 +192,12 @@ func (sm *StateMachine) SetTaskHandler(task_name string, resource_fn interface{}
 }
 
 func (sm *StateMachine) Validate() error {
-	if is.EmptyStr(sm.StartAt) {
-		return errors.New("State Machine requires StartAt")
-	}
-
-	if sm.States == nil {
+	if len(sm.States) == 0 {
 		return errors.New("State Machine must have States")
 	}
 
-	if len(sm.States) == 0 {
-		return errors.New("State Machine must have States")
+	if err := sm.validateStartAt(); err != nil {
+		return err
 	}
 
 	state_errors := []string{}
@@ -134,6 +206,11 @@ func (sm *StateMachine) Validate() error {
 		err := state.Validate()
 		if err != nil {
 			state_errors = append(state_errors, err.Error())
+			continue
+		}
+
+		if err := sm.validateTargets(state); err != nil {
+			state_errors = append(state_errors, err.Error())
 		}
 	}
 
@@ -141,7 +218,143 @@ func (sm *StateMachine) Validate() error {
 		return fmt.Errorf("State Errors %q", state_errors)
 	}
 
-	// TODO: validate all states are reachable
+	if err := sm.validateReachable(); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// validateStartAt checks StartAt names a state in States. Map Iterators and Parallel Branches
+// are State Machines validated by their states, so their StartAt is checked here too
+func (sm *StateMachine) validateStartAt() error {
+	if is.EmptyStr(sm.StartAt) {
+		return errors.New("State Machine requires StartAt")
+	}
+
+	if _, ok := sm.States[*sm.StartAt]; !ok {
+		return fmt.Errorf("Unknown State: StartAt %q is not in States", *sm.StartAt)
+	}
+
+	return nil
+}
+
+// validateReachable checks every state can be reached from StartAt,
+// and that every reachable state has a path to an end, e.g. no infinite loops
+func (sm *StateMachine) validateReachable() error {
+	reachable := map[string]bool{}
+	for _, s := range orderStates(*sm.StartAt, sm.States) {
+		reachable[*s.Name()] = true
+	}
+
+	unreachable := []string{}
+	for name := range sm.States {
+		if !reachable[name] {
+			unreachable = append(unreachable, name)
+		}
+	}
+
+	if len(unreachable) != 0 {
+		sort.Strings(unreachable)
+		return fmt.Errorf("Unreachable States %q", unreachable)
+	}
+
+	// Walk backwards from terminal states until nothing changes
+	canEnd := map[string]bool{}
+	for changed := true; changed; {
+		changed = false
+		for name, s := range sm.States {
+			if canEnd[name] {
+				continue
+			}
+
+			if isTerminal(s) {
+				canEnd[name] = true
+				changed = true
+				continue
+			}
+
+			for _, next := range nextStates(s) {
+				if canEnd[next] {
+					canEnd[name] = true
+					changed = true
+					break
+				}
+			}
+		}
+	}
+
+	noEnd := []string{}
+	for name := range sm.States {
+		if !canEnd[name] {
+			noEnd = append(noEnd, name)
+		}
+	}
+
+	if len(noEnd) != 0 {
+		sort.Strings(noEnd)
+		return fmt.Errorf("States without a path to an End %q", noEnd)
+	}
+
+	return nil
+}
+
+// isTerminal returns true for states that can end an execution
+func isTerminal(s state.State) bool {
+	switch s := s.(type) {
+	case *state.SucceedState, *state.FailState:
+		return true
+	case *state.PassState:
+		return s.End != nil && *s.End
+	case *state.TaskState:
+		return s.End != nil && *s.End
+	case *state.WaitState:
+		return s.End != nil && *s.End
+	case *state.ParallelState:
+		return s.End != nil && *s.End
+	case *state.MapState:
+		return s.End != nil && *s.End
+	}
+	return false
+}
+
+// validateTargets checks a Choice states Default and Choice Next,
+// and every Catch Next, are states in the machine
+func (sm *StateMachine) validateTargets(s state.State) error {
+	var catchers []*state.Catcher
+	switch s := s.(type) {
+	case *state.ChoiceState:
+		return sm.validateChoiceTargets(s)
+	case *state.TaskState:
+		catchers = s.Catch
+	case *state.ParallelState:
+		catchers = s.Catch
+	case *state.MapState:
+		catchers = s.Catch
+	}
+
+	for i, c := range catchers {
+		if _, ok := sm.States[*c.Next]; !ok {
+			return fmt.Errorf("%vState(%v) Error: Catch[%v].Next %q is not a State", *s.GetType(), *s.Name(), i, *c.Next)
+		}
+	}
+
+	return nil
+}
+
+func (sm *StateMachine) validateChoiceTargets(choice *state.ChoiceState) error {
+	if choice.Default != nil {
+		if _, ok := sm.States[*choice.Default]; !ok {
+			return fmt.Errorf("ChoiceState(%v) Error: Default %q is not a State", *choice.Name(), *choice.Default)
+		}
+	}
+
+	for _, c := range choice.Choices {
+		if _, ok := sm.States[*c.Next]; !ok {
+			return fmt.Errorf("ChoiceState(%v) Error: Next %q is not a State", *choice.Name(), *c.Next)
+		}
+	}
+
 	return nil
 }
 
@@ -201,6 +414,14 @@ func (sm *StateMachine) Execute(input interface{}) (*Execution, error) {
 	return exec, err
 }
 
+// Run executes the State Machine as a nested Machine, e.g. a Map Iterator, and returns its output
+func (sm *StateMachine) Run(_ context.Context, input interface{}) (interface{}, error) {
+	exec := &Execution{}
+	exec.Start()
+
+	return sm.stateLoop(exec, sm.StartAt, input)
+}
+
 func (sm *StateMachine) stateLoop(exec *Execution, next *string, input interface{}) (output interface{}, err error) {
 	// Flat loop instead of recursion to better implement timeouts
 	for {
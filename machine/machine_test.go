@@ -2,7 +2,9 @@ package machine
 
 import (
 	"encoding/json"
+	"fmt"
 	"io/ioutil"
+	"strings"
 	"testing"
 
 	"github.com/coinbase/step/utils/to"
@@ -95,3 +97,431 @@ func Test_Machine_MarshallAllTypes(t *testing.T) {
 
 	assert.JSONEq(t, string(raw_json), string(marshalled_json))
 }
+
+func Test_Machine_Execute_ResourceFn(t *testing.T) {
+	sm := to.Strp(`{
+    "StartAt": "Count",
+    "States": {
+      "Count": {
+        "Type": "Task",
+        "Resource": "arn:aws:lambda:us-east-1:00000000:function:count",
+        "InputPath": "$.count",
+        "ResultPath": "$.count",
+        "Next": "Done?"
+      },
+      "Done?": {
+        "Type": "Choice",
+        "Choices": [{"Variable": "$.count", "NumericGreaterThanEquals": 3, "Next": "Success"}],
+        "Default": "Count"
+      },
+      "Success": {"Type": "Succeed", "OutputPath": "$.count"}
+    }
+  }`)
+
+	calls := 0
+	output, err := Execute(sm, map[string]interface{}{"count": 0}, func(resource string, input interface{}) (interface{}, error) {
+		assert.Equal(t, "arn:aws:lambda:us-east-1:00000000:function:count", resource)
+		calls++
+		return input.(float64) + 1, nil
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, 3, calls)
+	assert.Equal(t, float64(3), output)
+}
+
+var mapStateMachine = `{
+  "StartAt": "Each",
+  "States": {
+    "Each": {
+      "Type": "Map",
+      "ItemsPath": "$.items",
+      "ResultPath": "$.results",
+      "MaxConcurrency": 2,
+      "Iterator": {
+        "StartAt": "Double",
+        "States": {
+          "Double": {
+            "Type": "Task",
+            "Resource": "arn:aws:lambda:us-east-1:00000000:function:double",
+            "End": true
+          }
+        }
+      },
+      "Catch": [{"ErrorEquals": ["States.ALL"], "Next": "Failed"}],
+      "End": true
+    },
+    "Failed": {"Type": "Fail", "Error": "MapFailed"}
+  }
+}`
+
+func Test_Machine_Map_Validate(t *testing.T) {
+	assert.NoError(t, Validate(to.Strp(mapStateMachine)))
+
+	sm, err := FromJSON([]byte(mapStateMachine))
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(sm.AllTasks()))
+
+	// Iterator is validated
+	err = Validate(to.Strp(`{
+    "StartAt": "Each",
+    "States": {
+      "Each": {"Type": "Map", "Iterator": {"StartAt": "Missing", "States": {"A": {"Type": "Pass"}}}, "End": true}
+    }
+  }`))
+	assert.Error(t, err)
+	assert.Regexp(t, "MapState\\(Each\\) Error: Iterator", err.Error())
+
+	err = Validate(to.Strp(`{"StartAt": "Each", "States": {"Each": {"Type": "Map", "End": true}}}`))
+	assert.Error(t, err)
+	assert.Regexp(t, "Requires Iterator", err.Error())
+}
+
+func Test_Machine_Map_Execute(t *testing.T) {
+	output, err := Execute(to.Strp(mapStateMachine), map[string]interface{}{"items": []interface{}{1, 2, 3}}, func(resource string, input interface{}) (interface{}, error) {
+		return map[string]interface{}{"doubled": input.(float64) * 2}, nil
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, []interface{}{
+		map[string]interface{}{"doubled": float64(2)},
+		map[string]interface{}{"doubled": float64(4)},
+		map[string]interface{}{"doubled": float64(6)},
+	}, output.(map[string]interface{})["results"])
+
+	// ItemsPath must be an array, the error is caught
+	sm, err := FromJSON([]byte(mapStateMachine))
+	assert.NoError(t, err)
+	sm.SetDefaultHandler()
+
+	exec, err := sm.Execute(map[string]interface{}{"items": "not array"})
+	assert.Error(t, err)
+	assert.Equal(t, []string{"Each", "Failed"}, exec.Path())
+	assert.Regexp(t, "ItemsPath Error: must return array", exec.LastOutputJSON)
+}
+
+var parallelStateMachine = `{
+  "StartAt": "Both",
+  "States": {
+    "Both": {
+      "Type": "Parallel",
+      "ResultPath": "$.results",
+      "Branches": [
+        {
+          "StartAt": "First",
+          "States": {"First": {"Type": "Task", "Resource": "first", "ResultPath": "$.branch", "End": true}}
+        },
+        {
+          "StartAt": "Second",
+          "States": {"Second": {"Type": "Pass", "Result": "second", "ResultPath": "$.branch", "End": true}}
+        }
+      ],
+      "Retry": [{"ErrorEquals": ["States.ALL"], "MaxAttempts": 1}],
+      "Catch": [{"ErrorEquals": ["States.ALL"], "Next": "Failed"}],
+      "End": true
+    },
+    "Failed": {"Type": "Fail", "Error": "ParallelFailed"}
+  }
+}`
+
+func Test_Machine_Parallel_Validate(t *testing.T) {
+	assert.NoError(t, Validate(to.Strp(parallelStateMachine)))
+
+	err := Validate(to.Strp(`{
+    "StartAt": "Both",
+    "States": {
+      "Both": {"Type": "Parallel", "Branches": [{"StartAt": "A", "States": {"A": {"Type": "Pass"}}}], "End": true}
+    }
+  }`))
+	assert.Error(t, err)
+	assert.Regexp(t, "ParallelState\\(Both\\) Error: Branch 0", err.Error())
+
+	err = Validate(to.Strp(`{"StartAt": "Both", "States": {"Both": {"Type": "Parallel", "Branches": [], "End": true}}}`))
+	assert.Error(t, err)
+	assert.Regexp(t, "Requires Branches", err.Error())
+
+	// Catch is validated
+	err = Validate(to.Strp(`{
+    "StartAt": "Both",
+    "States": {
+      "Both": {
+        "Type": "Parallel",
+        "Branches": [{"StartAt": "A", "States": {"A": {"Type": "Pass", "End": true}}}],
+        "Catch": [{"ErrorEquals": ["States.ALL"]}],
+        "End": true
+      }
+    }
+  }`))
+	assert.Error(t, err)
+	assert.Regexp(t, "Catcher requires Next", err.Error())
+}
+
+func Test_Machine_Parallel_Execute(t *testing.T) {
+	output, err := Execute(to.Strp(parallelStateMachine), map[string]interface{}{"a": "b"}, func(resource string, input interface{}) (interface{}, error) {
+		return map[string]interface{}{"from": resource}, nil
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, []interface{}{
+		map[string]interface{}{"a": "b", "branch": map[string]interface{}{"from": "first"}},
+		map[string]interface{}{"a": "b", "branch": "second"},
+	}, output.(map[string]interface{})["results"])
+
+	_, err = Execute(to.Strp(parallelStateMachine), map[string]interface{}{}, func(resource string, input interface{}) (interface{}, error) {
+		return nil, fmt.Errorf("BranchError")
+	})
+	assert.Error(t, err)
+}
+
+func Test_Machine_Parameters_ResultSelector_Validate(t *testing.T) {
+	err := Validate(to.Strp(`{
+    "StartAt": "Both",
+    "States": {
+      "Both": {
+        "Type": "Parallel",
+        "Parameters": {"a.$": "a"},
+        "Branches": [{"StartAt": "A", "States": {"A": {"Type": "Pass", "End": true}}}],
+        "End": true
+      }
+    }
+  }`))
+	assert.Error(t, err)
+	assert.Regexp(t, `ParallelState\(Both\) Error: Parameters`, err.Error())
+
+	err = Validate(to.Strp(`{
+    "StartAt": "Each",
+    "States": {
+      "Each": {
+        "Type": "Map",
+        "ResultSelector": {"count.$": 1},
+        "Iterator": {"StartAt": "A", "States": {"A": {"Type": "Pass", "End": true}}},
+        "End": true
+      }
+    }
+  }`))
+	assert.Error(t, err)
+	assert.Regexp(t, `MapState\(Each\) Error: ResultSelector value to key .*count.\$.* is not string`, err.Error())
+}
+
+func Test_Machine_Parallel_Parameters_ResultSelector_Execute(t *testing.T) {
+	output, err := Execute(to.Strp(`{
+    "StartAt": "Both",
+    "States": {
+      "Both": {
+        "Type": "Parallel",
+        "Parameters": {"value.$": "$.a"},
+        "Branches": [
+          {"StartAt": "A", "States": {"A": {"Type": "Pass", "End": true}}},
+          {"StartAt": "B", "States": {"B": {"Type": "Pass", "Result": "b", "ResultPath": "$.value", "End": true}}}
+        ],
+        "ResultSelector": {"outputs.$": "$", "second.$": "$[1].value", "values.$": "$[*].value", "count": 2},
+        "ResultPath": "$.results",
+        "End": true
+      }
+    }
+  }`), map[string]interface{}{"a": "a"}, nil)
+
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"outputs": []interface{}{
+			map[string]interface{}{"value": "a"},
+			map[string]interface{}{"value": "b"},
+		},
+		"second": "b",
+		"values": []interface{}{"a", "b"},
+		"count":  float64(2),
+	}, output.(map[string]interface{})["results"])
+}
+
+func Test_Machine_Choice_Targets_Validate(t *testing.T) {
+	err := Validate(to.Strp(`{
+    "StartAt": "Choice",
+    "States": {
+      "Choice": {
+        "Type": "Choice",
+        "Choices": [{"Variable": "$.a", "StringEquals": "a", "Next": "Missing"}],
+        "Default": "Done"
+      },
+      "Done": {"Type": "Succeed"}
+    }
+  }`))
+	assert.Error(t, err)
+	assert.Regexp(t, `ChoiceState\(Choice\) Error: Next \\"Missing\\" is not a State`, err.Error())
+
+	err = Validate(to.Strp(`{
+    "StartAt": "Choice",
+    "States": {
+      "Choice": {
+        "Type": "Choice",
+        "Choices": [{"Variable": "$.a", "StringEquals": "a", "Next": "Done"}],
+        "Default": "Missing"
+      },
+      "Done": {"Type": "Succeed"}
+    }
+  }`))
+	assert.Error(t, err)
+	assert.Regexp(t, `ChoiceState\(Choice\) Error: Default \\"Missing\\" is not a State`, err.Error())
+}
+
+func Test_Machine_Validate_DefinitionSize(t *testing.T) {
+	big := fmt.Sprintf(`{"StartAt": "WIN", "States": {"WIN": {"Type": "Succeed", "Comment": "%v"}}}`, strings.Repeat("x", MaxDefinitionBytes))
+	err := Validate(&big)
+	assert.Regexp(t, fmt.Sprintf("definition is %v bytes, over the %v byte limit", len(big), MaxDefinitionBytes), err.Error())
+
+	small := fmt.Sprintf(`{"StartAt": "WIN", "States": {"WIN": {"Type": "Succeed", "Comment": "%v"}}}`, strings.Repeat("x", MaxDefinitionBytes-100))
+	assert.NoError(t, Validate(&small))
+}
+
+func Test_Machine_Validate_WaitTimestamp(t *testing.T) {
+	sm_json := `{"StartAt": "Sleep", "States": {
+		"Sleep": {"Type": "Wait", "Timestamp": "tomorrow", "Next": "WIN"},
+		"WIN": {"Type": "Succeed"}
+	}}`
+
+	err := Validate(&sm_json)
+	assert.Error(t, err)
+	assert.Regexp(t, `WaitState\(Sleep\) Error: parsing time`, err.Error())
+}
+
+func Test_Machine_Validate_TaskTimeoutPath(t *testing.T) {
+	sm_json := `{"StartAt": "Work", "States": {
+		"Work": {"Type": "Task", "Resource": "arn", "TimeoutSecondsPath": "timeout", "End": true}
+	}}`
+
+	err := Validate(&sm_json)
+	assert.Error(t, err)
+	assert.Regexp(t, `TaskState\(Work\) Error:`, err.Error())
+}
+
+func Test_Machine_Validate_Unreachable(t *testing.T) {
+	err := Validate(to.Strp(`{
+    "StartAt": "Start",
+    "States": {
+      "Start": {"Type": "Pass", "End": true},
+      "Orphan": {"Type": "Pass", "Next": "Orphaned"},
+      "Orphaned": {"Type": "Succeed"}
+    }
+  }`))
+	assert.Error(t, err)
+	assert.Regexp(t, `Unreachable States \["Orphan" "Orphaned"\]`, err.Error())
+
+	// Catch and Choice Default count as reachable
+	assert.NoError(t, Validate(to.Strp(`{
+    "StartAt": "Start",
+    "States": {
+      "Start": {"Type": "Task", "Resource": "r", "Catch": [{"ErrorEquals": ["States.ALL"], "Next": "Choice"}], "End": true},
+      "Choice": {"Type": "Choice", "Choices": [{"Variable": "$.a", "StringEquals": "a", "Next": "Start"}], "Default": "Failed"},
+      "Failed": {"Type": "Fail", "Error": "Failed"}
+    }
+  }`)))
+
+	// Orphaned states in a Parallel Branch
+	err = Validate(to.Strp(`{
+    "StartAt": "Both",
+    "States": {
+      "Both": {
+        "Type": "Parallel",
+        "Branches": [{"StartAt": "A", "States": {"A": {"Type": "Pass", "End": true}, "B": {"Type": "Pass", "End": true}}}],
+        "End": true
+      }
+    }
+  }`))
+	assert.Error(t, err)
+	assert.Regexp(t, `Unreachable States \[\\"B\\"\]`, err.Error())
+}
+
+func Test_Machine_Validate_NoPathToEnd(t *testing.T) {
+	err := Validate(to.Strp(`{
+    "StartAt": "Start",
+    "States": {
+      "Start": {"Type": "Pass", "Next": "Loop"},
+      "Loop": {"Type": "Wait", "Seconds": 1, "Next": "Start"}
+    }
+  }`))
+	assert.Error(t, err)
+	assert.Regexp(t, `States without a path to an End \["Loop" "Start"\]`, err.Error())
+
+	// A loop with an exit is fine
+	assert.NoError(t, Validate(to.Strp(`{
+    "StartAt": "Start",
+    "States": {
+      "Start": {"Type": "Wait", "Seconds": 1, "Next": "Done?"},
+      "Done?": {"Type": "Choice", "Choices": [{"Variable": "$.done", "BooleanEquals": true, "Next": "Done"}], "Default": "Start"},
+      "Done": {"Type": "Succeed"}
+    }
+  }`)))
+}
+
+func Test_Machine_Catch_Targets_Validate(t *testing.T) {
+	err := Validate(to.Strp(`{
+    "StartAt": "Start",
+    "States": {
+      "Start": {"Type": "Task", "Resource": "r", "Catch": [{"ErrorEquals": ["States.ALL"], "Next": "Missing"}], "End": true}
+    }
+  }`))
+	assert.Error(t, err)
+	assert.Regexp(t, `TaskState\(Start\) Error: Catch\[0\].Next \\"Missing\\" is not a State`, err.Error())
+}
+
+func Test_Machine_Terminal_States_Validate(t *testing.T) {
+	err := Validate(to.Strp(`{"StartAt": "Failed", "States": {"Failed": {"Type": "Fail"}}}`))
+	assert.Error(t, err)
+	assert.Regexp(t, `FailState\(Failed\) Error: must contain Error`, err.Error())
+
+	err = Validate(to.Strp(`{"StartAt": "Failed", "States": {"Failed": {"Type": "Fail", "Error": "Bad\tError"}}}`))
+	assert.Error(t, err)
+	assert.Regexp(t, `FailState\(Failed\) Error: Error .* must not contain control characters`, err.Error())
+
+	err = Validate(to.Strp(`{"StartAt": "Done", "States": {"Done": {"Type": "Succeed", "End": true}}}`))
+	assert.Error(t, err)
+	assert.Regexp(t, `SucceedState\(Done\) Error: End not allowed`, err.Error())
+}
+
+func Test_Machine_StartAt_Validate(t *testing.T) {
+	err := Validate(to.Strp(`{"States": {"A": {"Type": "Succeed"}}}`))
+	assert.Error(t, err)
+	assert.Regexp(t, "State Machine requires StartAt", err.Error())
+
+	err = Validate(to.Strp(`{"StartAt": "", "States": {"A": {"Type": "Succeed"}}}`))
+	assert.Error(t, err)
+	assert.Regexp(t, "State Machine requires StartAt", err.Error())
+
+	err = Validate(to.Strp(`{"StartAt": "B", "States": {"A": {"Type": "Succeed"}}}`))
+	assert.Error(t, err)
+	assert.Regexp(t, `Unknown State: StartAt "B" is not in States`, err.Error())
+
+	// Map Iterator
+	err = Validate(to.Strp(`{
+    "StartAt": "Each",
+    "States": {
+      "Each": {"Type": "Map", "Iterator": {"StartAt": "Missing", "States": {"A": {"Type": "Succeed"}}}, "End": true}
+    }
+  }`))
+	assert.Error(t, err)
+	assert.Regexp(t, `MapState\(Each\) Error: Iterator Unknown State: StartAt .*Missing.* is not in States`, err.Error())
+
+	// Parallel Branch
+	err = Validate(to.Strp(`{
+    "StartAt": "Both",
+    "States": {
+      "Both": {"Type": "Parallel", "Branches": [
+        {"StartAt": "A", "States": {"A": {"Type": "Succeed"}}},
+        {"States": {"B": {"Type": "Succeed"}}}
+      ], "End": true}
+    }
+  }`))
+	assert.Error(t, err)
+	assert.Regexp(t, `ParallelState\(Both\) Error: Branch 1 State Machine requires StartAt`, err.Error())
+
+	// Nested in a Branch Iterator
+	err = Validate(to.Strp(`{
+    "StartAt": "Both",
+    "States": {
+      "Both": {"Type": "Parallel", "Branches": [
+        {"StartAt": "Each", "States": {"Each": {"Type": "Map", "Iterator": {"StartAt": "X", "States": {"A": {"Type": "Succeed"}}}, "End": true}}}
+      ], "End": true}
+    }
+  }`))
+	assert.Error(t, err)
+	assert.Regexp(t, `Branch 0 .*MapState\(Each\) Error: Iterator Unknown State: StartAt .*X.* is not in States`, err.Error())
+}
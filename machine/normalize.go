@@ -0,0 +1,213 @@
+package machine
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/coinbase/step/machine/state"
+)
+
+// Normalize parses definition and re-emits it with ASL keys and state Types spelled as the specification,
+// e.g. "startAt" as "StartAt" and "task" as "Task", keys sorted and indented with two spaces.
+// State names and the values of Parameters, Result and other payloads are not changed
+func Normalize(definition *string) (*string, error) {
+	return normalize(definition, false)
+}
+
+// NormalizeWithoutComments is Normalize also removing every Comment, for a minimal deploy artifact
+func NormalizeWithoutComments(definition *string) (*string, error) {
+	return normalize(definition, true)
+}
+
+func normalize(definition *string, stripComments bool) (*string, error) {
+	if definition == nil {
+		return nil, fmt.Errorf("Normalize Error: definition is nil")
+	}
+
+	decoder := json.NewDecoder(strings.NewReader(*definition))
+	decoder.UseNumber() // Numbers are not rounded through float64
+
+	var doc interface{}
+	if err := decoder.Decode(&doc); err != nil {
+		return nil, fmt.Errorf("Normalize Error: %v", err.Error())
+	}
+
+	n := &normalizer{stripComments: stripComments}
+	normalized, err := n.machine(doc)
+	if err != nil {
+		return nil, fmt.Errorf("Normalize Error: %v", err.Error())
+	}
+
+	b := new(bytes.Buffer)
+	encoder := json.NewEncoder(b)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+
+	// Encoding maps sorts their keys
+	if err := encoder.Encode(normalized); err != nil {
+		return nil, fmt.Errorf("Normalize Error: %v", err.Error())
+	}
+
+	s := strings.TrimSuffix(b.String(), "\n")
+	return &s, nil
+}
+
+var machineKeys = []string{"Comment", "StartAt", "States", "TimeoutSeconds", "Version"}
+
+var stateKeys = []string{
+	"Type", "Comment", "Next", "End",
+	"InputPath", "OutputPath", "ResultPath", "Parameters", "ResultSelector", "Result",
+	"Resource", "Retry", "Catch",
+	"TimeoutSeconds", "TimeoutSecondsPath", "HeartbeatSeconds", "HeartbeatSecondsPath",
+	"Choices", "Default",
+	"Seconds", "SecondsPath", "Timestamp", "TimestampPath",
+	"Error", "Cause",
+	"Branches", "Iterator", "ItemsPath", "MaxConcurrency",
+}
+
+var retrierKeys = []string{"Comment", "ErrorEquals", "IntervalSeconds", "MaxAttempts", "BackoffRate"}
+var catcherKeys = []string{"Comment", "ErrorEquals", "ResultPath", "Next"}
+var choiceRuleKeys = append([]string{"Variable", "Next"}, state.ComparisonOperators...)
+
+var stateTypes = []string{"Pass", "Task", "Choice", "Wait", "Succeed", "Fail", "Map", "Parallel", "TaskFn"}
+
+type normalizer struct {
+	stripComments bool
+}
+
+// object respells the keys of v that match canonical ignoring case,
+// two keys spelled differently that are the same key is an error
+func (n *normalizer) object(v interface{}, canonical []string) (map[string]interface{}, error) {
+	obj, ok := v.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("expected an object, got %v", v)
+	}
+
+	out := map[string]interface{}{}
+	for key, value := range obj {
+		for _, c := range canonical {
+			if strings.EqualFold(key, c) {
+				key = c
+				break
+			}
+		}
+
+		if _, ok := out[key]; ok {
+			return nil, fmt.Errorf("%q is defined more than once", key)
+		}
+
+		if n.stripComments && key == "Comment" {
+			continue
+		}
+
+		out[key] = value
+	}
+
+	return out, nil
+}
+
+// eachIn replaces the array at key in obj with fn of every element, other values are left for Validate
+func eachIn(obj map[string]interface{}, key string, fn func(interface{}) (interface{}, error)) error {
+	arr, ok := obj[key].([]interface{})
+	if !ok {
+		return nil
+	}
+
+	out := make([]interface{}, len(arr))
+	for i, el := range arr {
+		var err error
+		if out[i], err = fn(el); err != nil {
+			return err
+		}
+	}
+
+	obj[key] = out
+	return nil
+}
+
+func (n *normalizer) machine(v interface{}) (interface{}, error) {
+	sm, err := n.object(v, machineKeys)
+	if err != nil {
+		return nil, err
+	}
+
+	states, ok := sm["States"].(map[string]interface{})
+	if !ok {
+		return sm, nil
+	}
+
+	normalized := map[string]interface{}{}
+	for name, s := range states {
+		if normalized[name], err = n.state(s); err != nil {
+			return nil, fmt.Errorf("State %v: %v", name, err.Error())
+		}
+	}
+	sm["States"] = normalized
+
+	return sm, nil
+}
+
+func (n *normalizer) state(v interface{}) (interface{}, error) {
+	s, err := n.object(v, stateKeys)
+	if err != nil {
+		return nil, err
+	}
+
+	if t, ok := s["Type"].(string); ok {
+		for _, c := range stateTypes {
+			if strings.EqualFold(t, c) {
+				s["Type"] = c
+			}
+		}
+	}
+
+	retrier := func(r interface{}) (interface{}, error) { return n.object(r, retrierKeys) }
+	catcher := func(c interface{}) (interface{}, error) { return n.object(c, catcherKeys) }
+
+	if err := eachIn(s, "Retry", retrier); err != nil {
+		return nil, err
+	}
+
+	if err := eachIn(s, "Catch", catcher); err != nil {
+		return nil, err
+	}
+
+	if err := eachIn(s, "Choices", n.choiceRule); err != nil {
+		return nil, err
+	}
+
+	if err := eachIn(s, "Branches", n.machine); err != nil {
+		return nil, err
+	}
+
+	if iterator, ok := s["Iterator"]; ok {
+		if s["Iterator"], err = n.machine(iterator); err != nil {
+			return nil, err
+		}
+	}
+
+	return s, nil
+}
+
+func (n *normalizer) choiceRule(v interface{}) (interface{}, error) {
+	rule, err := n.object(v, choiceRuleKeys)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, key := range []string{"And", "Or"} {
+		if err := eachIn(rule, key, n.choiceRule); err != nil {
+			return nil, err
+		}
+	}
+
+	if not, ok := rule["Not"]; ok {
+		if rule["Not"], err = n.choiceRule(not); err != nil {
+			return nil, err
+		}
+	}
+
+	return rule, nil
+}
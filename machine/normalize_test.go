@@ -0,0 +1,128 @@
+package machine
+
+import (
+	"testing"
+
+	"github.com/coinbase/step/utils/to"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Machine_Normalize(t *testing.T) {
+	legacy := to.Strp(`{
+		"startAt": "Start",
+		"comment": "legacy <casing>",
+		"States": {
+			"Start": {
+				"type": "task",
+				"resource": "arn:aws:lambda:us-east-1:000000000000:function:fn",
+				"parameters": {"input.$": "$", "count": 1.50},
+				"retry": [{"comment": "retry", "errorEquals": ["States.ALL"], "maxAttempts": 2}],
+				"catch": [{"COMMENT": "catch", "ErrorEquals": ["States.ALL"], "next": "Fail"}],
+				"next": "Choose"
+			},
+			"Choose": {
+				"Type": "CHOICE",
+				"choices": [{"not": {"variable": "$.x", "stringEquals": "y"}, "Next": "Done"}],
+				"default": "Fail"
+			},
+			"Fail": {"Type": "Fail", "Comment": "failed", "error": "Failed"},
+			"Done": {"Type": "Succeed"}
+		}
+	}`)
+
+	normalized, err := Normalize(legacy)
+	assert.NoError(t, err)
+
+	assert.Equal(t, `{
+  "Comment": "legacy <casing>",
+  "StartAt": "Start",
+  "States": {
+    "Choose": {
+      "Choices": [
+        {
+          "Next": "Done",
+          "Not": {
+            "StringEquals": "y",
+            "Variable": "$.x"
+          }
+        }
+      ],
+      "Default": "Fail",
+      "Type": "Choice"
+    },
+    "Done": {
+      "Type": "Succeed"
+    },
+    "Fail": {
+      "Comment": "failed",
+      "Error": "Failed",
+      "Type": "Fail"
+    },
+    "Start": {
+      "Catch": [
+        {
+          "Comment": "catch",
+          "ErrorEquals": [
+            "States.ALL"
+          ],
+          "Next": "Fail"
+        }
+      ],
+      "Next": "Choose",
+      "Parameters": {
+        "count": 1.50,
+        "input.$": "$"
+      },
+      "Resource": "arn:aws:lambda:us-east-1:000000000000:function:fn",
+      "Retry": [
+        {
+          "Comment": "retry",
+          "ErrorEquals": [
+            "States.ALL"
+          ],
+          "MaxAttempts": 2
+        }
+      ],
+      "Type": "Task"
+    }
+  }
+}`, *normalized)
+
+	assert.NoError(t, Validate(normalized))
+
+	// Normalizing is idempotent
+	again, err := Normalize(normalized)
+	assert.NoError(t, err)
+	assert.Equal(t, *normalized, *again)
+
+	minimal, err := NormalizeWithoutComments(legacy)
+	assert.NoError(t, err)
+	assert.NotRegexp(t, "(?i)comment", *minimal)
+	assert.NoError(t, Validate(minimal))
+}
+
+func Test_Machine_Normalize_Nested(t *testing.T) {
+	normalized, err := Normalize(to.Strp(`{"StartAt": "P", "States": {"P": {
+		"Type": "parallel", "End": true,
+		"branches": [{"startat": "A", "states": {"A": {"type": "pass", "end": true}}}]
+	}}}`))
+	assert.NoError(t, err)
+	assert.Regexp(t, `"Branches"`, *normalized)
+	assert.Regexp(t, `"StartAt": "A"`, *normalized)
+	assert.Regexp(t, `"Type": "Pass"`, *normalized)
+	assert.NoError(t, Validate(normalized))
+}
+
+func Test_Machine_Normalize_Errors(t *testing.T) {
+	_, err := Normalize(nil)
+	assert.Error(t, err)
+
+	_, err = Normalize(to.Strp("{not json"))
+	assert.Error(t, err)
+
+	_, err = Normalize(to.Strp(`{"StartAt": "A", "startAt": "B", "States": {}}`))
+	assert.Regexp(t, `"StartAt" is defined more than once`, err.Error())
+
+	_, err = Normalize(to.Strp(`{"StartAt": "A", "States": {"A": "not a state"}}`))
+	assert.Regexp(t, "State A: expected an object", err.Error())
+}
@@ -92,9 +92,21 @@ func unmarshallState(name string, raw_json *json.RawMessage) ([]state.State, err
 		var s state.FailState
 		err = json.Unmarshal(*raw_json, &s)
 		newState = &s
+	case "Map":
+		var s state.MapState
+		err = json.Unmarshal(*raw_json, &s)
+		if err == nil && s.Iterator != nil {
+			var iterator *StateMachine
+			iterator, err = FromJSON(*s.Iterator)
+			s.SetIterator(iterator)
+		}
+		newState = &s
 	case "Parallel":
 		var s state.ParallelState
 		err = json.Unmarshal(*raw_json, &s)
+		if err == nil {
+			err = setBranches(&s)
+		}
 		newState = &s
 	case "TaskFn":
 		// This is a custom state that adds values to Task to be handled
@@ -110,6 +122,10 @@ func unmarshallState(name string, raw_json *json.RawMessage) ([]state.State, err
 
 	// End of loop return error
 	if err != nil {
+		if state_type.Type != "" && newState != nil {
+			// e.g. a Wait Timestamp that is not RFC3339
+			return nil, fmt.Errorf("%vState(%v) Error: %v", state_type.Type, name, err.Error())
+		}
 		return nil, err
 	}
 
@@ -119,3 +135,21 @@ func unmarshallState(name string, raw_json *json.RawMessage) ([]state.State, err
 
 	return []state.State{newState}, nil
 }
+
+func setBranches(s *state.ParallelState) error {
+	branches := []state.Machine{}
+	for _, raw := range s.Branches {
+		if raw == nil {
+			return fmt.Errorf("Parallel Branch must not be null")
+		}
+
+		branch, err := FromJSON(*raw)
+		if err != nil {
+			return err
+		}
+		branches = append(branches, branch)
+	}
+
+	s.SetBranches(branches)
+	return nil
+}
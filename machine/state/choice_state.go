@@ -2,7 +2,10 @@ package state
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"regexp"
+	"sort"
 	"strings"
 	"time"
 
@@ -30,6 +33,33 @@ type Choice struct {
 	Next *string `json:",omitempty"`
 }
 
+// UnmarshalJSON parses the ChoiceRule and Next, Next is the only key a Choice allows that a nested rule does not
+func (c *Choice) UnmarshalJSON(data []byte) error {
+	if err := json.Unmarshal(data, &c.ChoiceRule); err != nil {
+		return err
+	}
+
+	var next struct {
+		Next *string
+	}
+
+	if err := json.Unmarshal(data, &next); err != nil {
+		return err
+	}
+
+	c.Next = next.Next
+
+	unknown := []string{}
+	for _, key := range c.unknownKeys {
+		if key != "Next" {
+			unknown = append(unknown, key)
+		}
+	}
+	c.unknownKeys = unknown
+
+	return nil
+}
+
 type ChoiceRule struct {
 	Variable *jsonpath.Path `json:",omitempty"`
 
@@ -39,6 +69,9 @@ type ChoiceRule struct {
 	StringLessThanEquals    *string `json:",omitempty"`
 	StringGreaterThanEquals *string `json:",omitempty"`
 
+	// StringMatches is a pattern where * matches any string, \* is a literal * and \\ a literal \
+	StringMatches *string `json:",omitempty"`
+
 	NumericEquals            *float64 `json:",omitempty"`
 	NumericLessThan          *float64 `json:",omitempty"`
 	NumericGreaterThan       *float64 `json:",omitempty"`
@@ -53,9 +86,228 @@ type ChoiceRule struct {
 	TimestampLessThanEquals    *time.Time `json:",omitempty"`
 	TimestampGreaterThanEquals *time.Time `json:",omitempty"`
 
+	// Path variants compare the Variable to the value at another path in the input
+	StringEqualsPath            *jsonpath.Path `json:",omitempty"`
+	StringLessThanPath          *jsonpath.Path `json:",omitempty"`
+	StringGreaterThanPath       *jsonpath.Path `json:",omitempty"`
+	StringLessThanEqualsPath    *jsonpath.Path `json:",omitempty"`
+	StringGreaterThanEqualsPath *jsonpath.Path `json:",omitempty"`
+
+	NumericEqualsPath            *jsonpath.Path `json:",omitempty"`
+	NumericLessThanPath          *jsonpath.Path `json:",omitempty"`
+	NumericGreaterThanPath       *jsonpath.Path `json:",omitempty"`
+	NumericLessThanEqualsPath    *jsonpath.Path `json:",omitempty"`
+	NumericGreaterThanEqualsPath *jsonpath.Path `json:",omitempty"`
+
+	BooleanEqualsPath *jsonpath.Path `json:",omitempty"`
+
+	TimestampEqualsPath            *jsonpath.Path `json:",omitempty"`
+	TimestampLessThanPath          *jsonpath.Path `json:",omitempty"`
+	TimestampGreaterThanPath       *jsonpath.Path `json:",omitempty"`
+	TimestampLessThanEqualsPath    *jsonpath.Path `json:",omitempty"`
+	TimestampGreaterThanEqualsPath *jsonpath.Path `json:",omitempty"`
+
+	// Type tests match if the Variable's value is (true) or is not (false) of the type,
+	// IsPresent false matches if the Variable is not found in the input
+	IsNull      *bool `json:",omitempty"`
+	IsPresent   *bool `json:",omitempty"`
+	IsNumeric   *bool `json:",omitempty"`
+	IsString    *bool `json:",omitempty"`
+	IsBoolean   *bool `json:",omitempty"`
+	IsTimestamp *bool `json:",omitempty"`
+
 	And []*ChoiceRule `json:",omitempty"`
 	Or  []*ChoiceRule `json:",omitempty"`
 	Not *ChoiceRule   `json:",omitempty"`
+
+	// Keys that are not Variable or a comparison operator, e.g. typos like "StringEquls"
+	unknownKeys []string
+}
+
+// UnmarshalJSON records any keys that are not valid in a ChoiceRule,
+// because encoding/json would otherwise silently ignore them
+func (cr *ChoiceRule) UnmarshalJSON(data []byte) error {
+	type choiceRule ChoiceRule // Without methods to not recurse
+	var rule choiceRule
+	if err := json.Unmarshal(data, &rule); err != nil {
+		return err
+	}
+
+	var keys map[string]json.RawMessage
+	if err := json.Unmarshal(data, &keys); err != nil {
+		return err
+	}
+
+	*cr = ChoiceRule(rule)
+
+	cr.unknownKeys = []string{}
+	for key := range keys {
+		if key != "Variable" && !isComparisonOperator(key) {
+			cr.unknownKeys = append(cr.unknownKeys, key)
+		}
+	}
+	sort.Strings(cr.unknownKeys)
+
+	return nil
+}
+
+// ComparisonOperators are all the operators allowed in a ChoiceRule
+var ComparisonOperators = []string{
+	"StringEquals",
+	"StringLessThan",
+	"StringGreaterThan",
+	"StringLessThanEquals",
+	"StringGreaterThanEquals",
+	"StringEqualsPath",
+	"StringLessThanPath",
+	"StringGreaterThanPath",
+	"StringLessThanEqualsPath",
+	"StringGreaterThanEqualsPath",
+	"StringMatches",
+	"NumericEquals",
+	"NumericLessThan",
+	"NumericGreaterThan",
+	"NumericLessThanEquals",
+	"NumericGreaterThanEquals",
+	"NumericEqualsPath",
+	"NumericLessThanPath",
+	"NumericGreaterThanPath",
+	"NumericLessThanEqualsPath",
+	"NumericGreaterThanEqualsPath",
+	"BooleanEquals",
+	"BooleanEqualsPath",
+	"TimestampEquals",
+	"TimestampLessThan",
+	"TimestampGreaterThan",
+	"TimestampLessThanEquals",
+	"TimestampGreaterThanEquals",
+	"TimestampEqualsPath",
+	"TimestampLessThanPath",
+	"TimestampGreaterThanPath",
+	"TimestampLessThanEqualsPath",
+	"TimestampGreaterThanEqualsPath",
+	"IsNull",
+	"IsPresent",
+	"IsNumeric",
+	"IsString",
+	"IsBoolean",
+	"IsTimestamp",
+	"And",
+	"Or",
+	"Not",
+}
+
+func isComparisonOperator(key string) bool {
+	for _, op := range ComparisonOperators {
+		if op == key {
+			return true
+		}
+	}
+	return false
+}
+
+// operators returns the names of the comparison operators defined on the rule
+func (cr *ChoiceRule) operators() []string {
+	defined := map[string]bool{
+		"StringEquals":                   cr.StringEquals != nil,
+		"StringLessThan":                 cr.StringLessThan != nil,
+		"StringGreaterThan":              cr.StringGreaterThan != nil,
+		"StringLessThanEquals":           cr.StringLessThanEquals != nil,
+		"StringGreaterThanEquals":        cr.StringGreaterThanEquals != nil,
+		"StringEqualsPath":               cr.StringEqualsPath != nil,
+		"StringLessThanPath":             cr.StringLessThanPath != nil,
+		"StringGreaterThanPath":          cr.StringGreaterThanPath != nil,
+		"StringLessThanEqualsPath":       cr.StringLessThanEqualsPath != nil,
+		"StringGreaterThanEqualsPath":    cr.StringGreaterThanEqualsPath != nil,
+		"StringMatches":                  cr.StringMatches != nil,
+		"NumericEquals":                  cr.NumericEquals != nil,
+		"NumericLessThan":                cr.NumericLessThan != nil,
+		"NumericGreaterThan":             cr.NumericGreaterThan != nil,
+		"NumericLessThanEquals":          cr.NumericLessThanEquals != nil,
+		"NumericGreaterThanEquals":       cr.NumericGreaterThanEquals != nil,
+		"NumericEqualsPath":              cr.NumericEqualsPath != nil,
+		"NumericLessThanPath":            cr.NumericLessThanPath != nil,
+		"NumericGreaterThanPath":         cr.NumericGreaterThanPath != nil,
+		"NumericLessThanEqualsPath":      cr.NumericLessThanEqualsPath != nil,
+		"NumericGreaterThanEqualsPath":   cr.NumericGreaterThanEqualsPath != nil,
+		"BooleanEquals":                  cr.BooleanEquals != nil,
+		"BooleanEqualsPath":              cr.BooleanEqualsPath != nil,
+		"TimestampEquals":                cr.TimestampEquals != nil,
+		"TimestampLessThan":              cr.TimestampLessThan != nil,
+		"TimestampGreaterThan":           cr.TimestampGreaterThan != nil,
+		"TimestampLessThanEquals":        cr.TimestampLessThanEquals != nil,
+		"TimestampGreaterThanEquals":     cr.TimestampGreaterThanEquals != nil,
+		"TimestampEqualsPath":            cr.TimestampEqualsPath != nil,
+		"TimestampLessThanPath":          cr.TimestampLessThanPath != nil,
+		"TimestampGreaterThanPath":       cr.TimestampGreaterThanPath != nil,
+		"TimestampLessThanEqualsPath":    cr.TimestampLessThanEqualsPath != nil,
+		"TimestampGreaterThanEqualsPath": cr.TimestampGreaterThanEqualsPath != nil,
+		"IsNull":                         cr.IsNull != nil,
+		"IsPresent":                      cr.IsPresent != nil,
+		"IsNumeric":                      cr.IsNumeric != nil,
+		"IsString":                       cr.IsString != nil,
+		"IsBoolean":                      cr.IsBoolean != nil,
+		"IsTimestamp":                    cr.IsTimestamp != nil,
+		"And":                            cr.And != nil,
+		"Or":                             cr.Or != nil,
+		"Not":                            cr.Not != nil,
+	}
+
+	ops := []string{}
+	for _, op := range ComparisonOperators {
+		if defined[op] {
+			ops = append(ops, op)
+		}
+	}
+	return ops
+}
+
+// withPathValues returns a copy of the rule with a Path operator replaced by
+// the equivalent operator set to the value found at that path
+func (cr *ChoiceRule) withPathValues(input interface{}) (*ChoiceRule, error) {
+	r := *cr
+	var err error
+
+	switch {
+	case cr.StringEqualsPath != nil:
+		r.StringEquals, err = cr.StringEqualsPath.GetString(input)
+	case cr.StringLessThanPath != nil:
+		r.StringLessThan, err = cr.StringLessThanPath.GetString(input)
+	case cr.StringGreaterThanPath != nil:
+		r.StringGreaterThan, err = cr.StringGreaterThanPath.GetString(input)
+	case cr.StringLessThanEqualsPath != nil:
+		r.StringLessThanEquals, err = cr.StringLessThanEqualsPath.GetString(input)
+	case cr.StringGreaterThanEqualsPath != nil:
+		r.StringGreaterThanEquals, err = cr.StringGreaterThanEqualsPath.GetString(input)
+	case cr.NumericEqualsPath != nil:
+		r.NumericEquals, err = cr.NumericEqualsPath.GetNumber(input)
+	case cr.NumericLessThanPath != nil:
+		r.NumericLessThan, err = cr.NumericLessThanPath.GetNumber(input)
+	case cr.NumericGreaterThanPath != nil:
+		r.NumericGreaterThan, err = cr.NumericGreaterThanPath.GetNumber(input)
+	case cr.NumericLessThanEqualsPath != nil:
+		r.NumericLessThanEquals, err = cr.NumericLessThanEqualsPath.GetNumber(input)
+	case cr.NumericGreaterThanEqualsPath != nil:
+		r.NumericGreaterThanEquals, err = cr.NumericGreaterThanEqualsPath.GetNumber(input)
+	case cr.BooleanEqualsPath != nil:
+		r.BooleanEquals, err = cr.BooleanEqualsPath.GetBool(input)
+	case cr.TimestampEqualsPath != nil:
+		r.TimestampEquals, err = cr.TimestampEqualsPath.GetTime(input)
+	case cr.TimestampLessThanPath != nil:
+		r.TimestampLessThan, err = cr.TimestampLessThanPath.GetTime(input)
+	case cr.TimestampGreaterThanPath != nil:
+		r.TimestampGreaterThan, err = cr.TimestampGreaterThanPath.GetTime(input)
+	case cr.TimestampLessThanEqualsPath != nil:
+		r.TimestampLessThanEquals, err = cr.TimestampLessThanEqualsPath.GetTime(input)
+	case cr.TimestampGreaterThanEqualsPath != nil:
+		r.TimestampGreaterThanEquals, err = cr.TimestampGreaterThanEqualsPath.GetTime(input)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &r, nil
 }
 
 func (cr *ChoiceRule) String() string {
@@ -91,6 +343,8 @@ func (cr *ChoiceRule) String() string {
 		op = fmt.Sprintf("<=%v", *cr.StringLessThanEquals)
 	} else if cr.StringGreaterThanEquals != nil {
 		op = fmt.Sprintf(">=%v", *cr.StringGreaterThanEquals)
+	} else if cr.StringMatches != nil {
+		op = fmt.Sprintf("~%v", *cr.StringMatches)
 	} else if cr.NumericEquals != nil {
 		op = fmt.Sprintf("=%v", *cr.NumericEquals)
 	} else if cr.NumericLessThan != nil {
@@ -113,11 +367,65 @@ func (cr *ChoiceRule) String() string {
 		op = fmt.Sprintf("<=%v", *cr.TimestampLessThanEquals)
 	} else if cr.TimestampGreaterThanEquals != nil {
 		op = fmt.Sprintf(">=%v", *cr.TimestampGreaterThanEquals)
+	} else if cr.IsNull != nil {
+		op = fmt.Sprintf(" IsNull=%v", *cr.IsNull)
+	} else if cr.IsPresent != nil {
+		op = fmt.Sprintf(" IsPresent=%v", *cr.IsPresent)
+	} else if cr.IsNumeric != nil {
+		op = fmt.Sprintf(" IsNumeric=%v", *cr.IsNumeric)
+	} else if cr.IsString != nil {
+		op = fmt.Sprintf(" IsString=%v", *cr.IsString)
+	} else if cr.IsBoolean != nil {
+		op = fmt.Sprintf(" IsBoolean=%v", *cr.IsBoolean)
+	} else if cr.IsTimestamp != nil {
+		op = fmt.Sprintf(" IsTimestamp=%v", *cr.IsTimestamp)
+	}
+
+	if op == "" {
+		op = cr.pathOperatorString()
 	}
 
 	return fmt.Sprintf("%v%v", cr.Variable.String(), op)
 }
 
+func (cr *ChoiceRule) pathOperatorString() string {
+	switch {
+	case cr.StringEqualsPath != nil:
+		return fmt.Sprintf("=%v", cr.StringEqualsPath.String())
+	case cr.StringLessThanPath != nil:
+		return fmt.Sprintf("<%v", cr.StringLessThanPath.String())
+	case cr.StringGreaterThanPath != nil:
+		return fmt.Sprintf(">%v", cr.StringGreaterThanPath.String())
+	case cr.StringLessThanEqualsPath != nil:
+		return fmt.Sprintf("<=%v", cr.StringLessThanEqualsPath.String())
+	case cr.StringGreaterThanEqualsPath != nil:
+		return fmt.Sprintf(">=%v", cr.StringGreaterThanEqualsPath.String())
+	case cr.NumericEqualsPath != nil:
+		return fmt.Sprintf("=%v", cr.NumericEqualsPath.String())
+	case cr.NumericLessThanPath != nil:
+		return fmt.Sprintf("<%v", cr.NumericLessThanPath.String())
+	case cr.NumericGreaterThanPath != nil:
+		return fmt.Sprintf(">%v", cr.NumericGreaterThanPath.String())
+	case cr.NumericLessThanEqualsPath != nil:
+		return fmt.Sprintf("<=%v", cr.NumericLessThanEqualsPath.String())
+	case cr.NumericGreaterThanEqualsPath != nil:
+		return fmt.Sprintf(">=%v", cr.NumericGreaterThanEqualsPath.String())
+	case cr.BooleanEqualsPath != nil:
+		return fmt.Sprintf("=%v", cr.BooleanEqualsPath.String())
+	case cr.TimestampEqualsPath != nil:
+		return fmt.Sprintf("=%v", cr.TimestampEqualsPath.String())
+	case cr.TimestampLessThanPath != nil:
+		return fmt.Sprintf("<%v", cr.TimestampLessThanPath.String())
+	case cr.TimestampGreaterThanPath != nil:
+		return fmt.Sprintf(">%v", cr.TimestampGreaterThanPath.String())
+	case cr.TimestampLessThanEqualsPath != nil:
+		return fmt.Sprintf("<=%v", cr.TimestampLessThanEqualsPath.String())
+	case cr.TimestampGreaterThanEqualsPath != nil:
+		return fmt.Sprintf(">=%v", cr.TimestampGreaterThanEqualsPath.String())
+	}
+	return ""
+}
+
 func (s *ChoiceState) process(ctx context.Context, input interface{}) (interface{}, *string, error) {
 	next := chooseNextState(input, s.Default, s.Choices)
 	if next == nil {
@@ -170,6 +478,16 @@ func choiceRulePositive(input interface{}, cr *ChoiceRule) bool {
 		return !choiceRulePositive(input, cr.Not)
 	}
 
+	if cr.IsPresent != nil {
+		_, err := cr.Variable.Get(input)
+		return (err == nil) == *cr.IsPresent
+	}
+
+	cr, err := cr.withPathValues(input)
+	if err != nil {
+		return false // either not found or bad type
+	}
+
 	if cr.StringEquals != nil {
 		vstr, err := cr.Variable.GetString(input)
 		if err != nil {
@@ -210,6 +528,18 @@ func choiceRulePositive(input interface{}, cr *ChoiceRule) bool {
 		return *vstr >= *cr.StringGreaterThanEquals
 	}
 
+	if cr.StringMatches != nil {
+		vstr, err := cr.Variable.GetString(input)
+		if err != nil {
+			return false // either not found or bad type
+		}
+		matcher, err := stringMatchesRegexp(*cr.StringMatches)
+		if err != nil {
+			return false
+		}
+		return matcher.MatchString(*vstr)
+	}
+
 	// NUMBERs
 	if cr.NumericEquals != nil {
 		vnum, err := cr.Variable.GetNumber(input)
@@ -299,9 +629,71 @@ func choiceRulePositive(input interface{}, cr *ChoiceRule) bool {
 		return *vtime == *cr.TimestampGreaterThanEquals || vtime.After(*cr.TimestampGreaterThanEquals)
 	}
 
+	// TYPE TESTs
+	value, err := cr.Variable.Get(input)
+	if err != nil {
+		return false // not found
+	}
+
+	if cr.IsNull != nil {
+		return (value == nil) == *cr.IsNull
+	}
+
+	if cr.IsNumeric != nil {
+		_, err := cr.Variable.GetNumber(input)
+		return (err == nil) == *cr.IsNumeric
+	}
+
+	if cr.IsString != nil {
+		_, ok := value.(string)
+		return ok == *cr.IsString
+	}
+
+	if cr.IsBoolean != nil {
+		_, ok := value.(bool)
+		return ok == *cr.IsBoolean
+	}
+
+	if cr.IsTimestamp != nil {
+		_, err := cr.Variable.GetTime(input)
+		return (err == nil) == *cr.IsTimestamp
+	}
+
 	return false
 }
 
+// stringMatchesRegexp returns the regexp matching the StringMatches pattern,
+// where * matches any string and a backslash escapes a * or backslash
+func stringMatchesRegexp(pattern string) (*regexp.Regexp, error) {
+	var expr strings.Builder
+	expr.WriteString("(?s)^")
+
+	escaped := false
+	for _, c := range pattern {
+		switch {
+		case escaped:
+			if c != '*' && c != '\\' {
+				return nil, fmt.Errorf("StringMatches %q can only escape * or \\", pattern)
+			}
+			expr.WriteString(regexp.QuoteMeta(string(c)))
+			escaped = false
+		case c == '\\':
+			escaped = true
+		case c == '*':
+			expr.WriteString(".*")
+		default:
+			expr.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+
+	if escaped {
+		return nil, fmt.Errorf("StringMatches %q ends with an escape \\", pattern)
+	}
+
+	expr.WriteString("$")
+	return regexp.Compile(expr.String())
+}
+
 // VALIDATION LOGIC
 
 func (s *ChoiceState) Validate() error {
@@ -350,7 +742,7 @@ func recursiveAllChoiceRule(c *ChoiceRule) []*ChoiceRule {
 	crs := []*ChoiceRule{c}
 
 	if c.Not != nil {
-		crs = append(crs, c.Not)
+		crs = append(crs, recursiveAllChoiceRule(c.Not)...)
 	}
 
 	if c.And != nil {
@@ -369,38 +761,13 @@ func recursiveAllChoiceRule(c *ChoiceRule) []*ChoiceRule {
 }
 
 func validateChoiceRule(c *ChoiceRule) error {
+	if len(c.unknownKeys) != 0 {
+		return fmt.Errorf("Unknown comparison Operator %q", c.unknownKeys)
+	}
+
 	// Exactly One Comparison Operator
-	all_comparison_operators := []bool{
-		c.Not != nil,
-		c.And != nil,
-		c.Or != nil,
-		c.StringEquals != nil,
-		c.StringLessThan != nil,
-		c.StringGreaterThan != nil,
-		c.StringLessThanEquals != nil,
-		c.StringGreaterThanEquals != nil,
-		c.NumericEquals != nil,
-		c.NumericLessThan != nil,
-		c.NumericGreaterThan != nil,
-		c.NumericLessThanEquals != nil,
-		c.NumericGreaterThanEquals != nil,
-		c.BooleanEquals != nil,
-		c.TimestampEquals != nil,
-		c.TimestampLessThan != nil,
-		c.TimestampGreaterThan != nil,
-		c.TimestampLessThanEquals != nil,
-		c.TimestampGreaterThanEquals != nil,
-	}
-
-	count := 0
-	for _, co := range all_comparison_operators {
-		if co {
-			count += 1
-		}
-	}
-
-	if count != 1 {
-		return fmt.Errorf("Not Exactly One comparison Operator")
+	if ops := c.operators(); len(ops) != 1 {
+		return fmt.Errorf("Not Exactly One comparison Operator %q", ops)
 	}
 
 	// Variable must be defined, UNLESS AND NOT OR, in which case error if defined
@@ -414,6 +781,16 @@ func validateChoiceRule(c *ChoiceRule) error {
 		if c.Variable == nil {
 			return fmt.Errorf("Variable Not defined")
 		}
+
+		if !c.Variable.IsReference() {
+			return fmt.Errorf("Variable %v must select a single value", c.Variable)
+		}
+	}
+
+	if c.StringMatches != nil {
+		if _, err := stringMatchesRegexp(*c.StringMatches); err != nil {
+			return err
+		}
 	}
 
 	if c.And != nil && len(c.And) == 0 {
@@ -298,6 +298,36 @@ func Test_ChoiceState_StringEquals(t *testing.T) {
 	}, t)
 }
 
+func Test_ChoiceState_Variable_Index(t *testing.T) {
+	state := parseChoiceState([]byte(`{
+		"Choices": [
+			{
+				"Variable": "$.items[0].value",
+				"StringEquals": "public",
+				"Next": "Pass"
+			}
+		],
+		"Default": "Fail"
+	}`), t)
+
+	testState(state, stateTestData{
+		Input: map[string]interface{}{"items": []interface{}{map[string]interface{}{"value": "public"}}},
+		Next:  to.Strp("Pass"),
+	}, t)
+
+	state = parseChoiceState([]byte(`{"Default": "Fail", "Choices": [
+	{
+		"Variable": "$.items[*].value",
+		"StringEquals": "public",
+		"Next": "Pass"
+	}
+	]}`), t)
+
+	err := state.Validate()
+	assert.Error(t, err)
+	assert.Regexp(t, `Variable \$.items\[\*\].value must select a single value`, err.Error())
+}
+
 // Logical Comparisons
 
 func Test_ChoiceState_Not(t *testing.T) {
@@ -492,3 +522,184 @@ func Test_ChoiceState_NotAllowed2DeeplyEmbeddedComparisonOperators(t *testing.T)
 	assert.Error(t, err)
 	assert.Regexp(t, "Not Exactly One comparison Operator", err.Error())
 }
+
+func Test_ChoiceState_UnknownComparisonOperator(t *testing.T) {
+	state := parseChoiceState([]byte(`{"Default": "Fail", "Choices": [
+	{
+		"Variable": "$.value",
+		"StringEquls": "Private",
+		"Next": "Public"
+	}
+	]}`), t)
+
+	err := state.Validate()
+	assert.Error(t, err)
+	assert.Regexp(t, `ChoiceState\(TestState\) Error: Unknown comparison Operator \["StringEquls"\]`, err.Error())
+}
+
+func Test_ChoiceState_UnknownEmbeddedComparisonOperator(t *testing.T) {
+	state := parseChoiceState([]byte(`{"Default": "Fail", "Choices": [
+	{
+		"Not": {
+			"Variable": "$.value",
+			"stringequals": "Private",
+			"Next": "Public"
+		},
+		"Next": "Public"
+	}
+	]}`), t)
+
+	err := state.Validate()
+	assert.Error(t, err)
+	assert.Regexp(t, `Unknown comparison Operator \["Next" "stringequals"\]`, err.Error())
+}
+
+func Test_ChoiceState_PathComparisons(t *testing.T) {
+	state := parseChoiceState([]byte(`{"Default": "Fail", "Choices": [
+	{
+		"Variable": "$.value",
+		"StringEqualsPath": "$.expected",
+		"Next": "Pass"
+	},
+	{
+		"Variable": "$.count",
+		"NumericGreaterThanPath": "$.limit",
+		"Next": "TooMany"
+	}
+	]}`), t)
+
+	testState(state, stateTestData{
+		Input: map[string]interface{}{"value": "a", "expected": "a"},
+		Next:  to.Strp("Pass"),
+	}, t)
+
+	testState(state, stateTestData{
+		Input: map[string]interface{}{"value": "a", "expected": "b", "count": 3.0, "limit": 2.0},
+		Next:  to.Strp("TooMany"),
+	}, t)
+
+	// Missing path does not match
+	testState(state, stateTestData{
+		Input: map[string]interface{}{"value": "a", "count": 3.0},
+		Next:  to.Strp("Fail"),
+	}, t)
+}
+
+func Test_ChoiceState_StringMatches(t *testing.T) {
+	state := parseChoiceState([]byte(`{"Default": "Fail", "Choices": [
+	{
+		"Variable": "$.file",
+		"StringMatches": "log-*.t\\*t",
+		"Next": "Pass"
+	}
+	]}`), t)
+
+	testState(state, stateTestData{
+		Input: map[string]interface{}{"file": "log-2006.t*t"},
+		Next:  to.Strp("Pass"),
+	}, t)
+
+	testState(state, stateTestData{
+		Input: map[string]interface{}{"file": "log-2006.txt"},
+		Next:  to.Strp("Fail"),
+	}, t)
+
+	testState(state, stateTestData{
+		Input: map[string]interface{}{"file": 1.0},
+		Next:  to.Strp("Fail"),
+	}, t)
+
+	state = parseChoiceState([]byte(`{"Default": "Fail", "Choices": [
+	{
+		"Variable": "$.file",
+		"StringMatches": "log-\\d",
+		"Next": "Pass"
+	}
+	]}`), t)
+
+	err := state.Validate()
+	assert.Error(t, err)
+	assert.Regexp(t, `can only escape`, err.Error())
+}
+
+func Test_ChoiceState_TypeTests(t *testing.T) {
+	state := parseChoiceState([]byte(`{"Default": "Fail", "Choices": [
+	{
+		"Variable": "$.missing",
+		"IsPresent": false,
+		"Next": "Missing"
+	},
+	{
+		"Variable": "$.value",
+		"IsNull": true,
+		"Next": "Null"
+	},
+	{
+		"Variable": "$.value",
+		"IsNumeric": true,
+		"Next": "Numeric"
+	},
+	{
+		"Variable": "$.value",
+		"IsTimestamp": true,
+		"Next": "Timestamp"
+	},
+	{
+		"Variable": "$.value",
+		"IsString": true,
+		"Next": "String"
+	},
+	{
+		"Variable": "$.value",
+		"IsBoolean": true,
+		"Next": "Boolean"
+	}
+	]}`), t)
+
+	assert.NoError(t, state.Validate())
+
+	testState(state, stateTestData{
+		Input: map[string]interface{}{"value": "a"},
+		Next:  to.Strp("Missing"),
+	}, t)
+
+	tests := map[string]interface{}{
+		"Null":      nil,
+		"Numeric":   1.0,
+		"Timestamp": "2006-01-02T15:04:05Z",
+		"String":    "a",
+		"Boolean":   false,
+		"Fail":      map[string]interface{}{},
+	}
+
+	for next, value := range tests {
+		testState(state, stateTestData{
+			Input: map[string]interface{}{"missing": true, "value": value},
+			Next:  to.Strp(next),
+		}, t)
+	}
+
+	// Not found does not match a type test
+	testState(state, stateTestData{
+		Input: map[string]interface{}{"missing": true},
+		Next:  to.Strp("Fail"),
+	}, t)
+
+	state = parseChoiceState([]byte(`{"Default": "Fail", "Choices": [
+	{
+		"Variable": "$.value",
+		"IsString": false,
+		"Next": "Pass"
+	}
+	]}`), t)
+
+	testState(state, stateTestData{
+		Input: map[string]interface{}{"value": 1.0},
+		Next:  to.Strp("Pass"),
+	}, t)
+
+	testState(state, stateTestData{
+		Input: map[string]interface{}{"value": "a"},
+		Next:  to.Strp("Fail"),
+	}, t)
+}
@@ -3,6 +3,8 @@ package state
 import (
 	"context"
 	"fmt"
+	"strings"
+	"unicode"
 
 	"github.com/coinbase/step/utils/is"
 	"github.com/coinbase/step/utils/to"
@@ -16,8 +18,18 @@ type FailState struct {
 
 	Error *string `json:",omitempty"`
 	Cause *string `json:",omitempty"`
+
+	// Next and End are not allowed on a Fail State, they are parsed so Validate can reject them
+	Next *string `json:",omitempty"`
+	End  *bool   `json:",omitempty"`
 }
 
+// Fail State Error and Cause length limits, AWS rejects definitions that exceed them
+const (
+	MaxFailErrorLength = 256
+	MaxFailCauseLength = 32768
+)
+
 func (s *FailState) Execute(_ context.Context, input interface{}) (output interface{}, next *string, err error) {
 	return errorOutput(s.Error, s.Cause), nil, fmt.Errorf("Fail")
 }
@@ -33,6 +45,22 @@ func (s *FailState) Validate() error {
 		return fmt.Errorf("%v %v", errorPrefix(s), "must contain Error")
 	}
 
+	if len(*s.Error) > MaxFailErrorLength {
+		return fmt.Errorf("%v Error must be at most %v characters, got %v", errorPrefix(s), MaxFailErrorLength, len(*s.Error))
+	}
+
+	if strings.IndexFunc(*s.Error, unicode.IsControl) != -1 {
+		return fmt.Errorf("%v Error %q must not contain control characters", errorPrefix(s), *s.Error)
+	}
+
+	if s.Cause != nil && len(*s.Cause) > MaxFailCauseLength {
+		return fmt.Errorf("%v Cause must be at most %v characters, got %v", errorPrefix(s), MaxFailCauseLength, len(*s.Cause))
+	}
+
+	if err := terminalValid(s.Next, s.End); err != nil {
+		return fmt.Errorf("%v %v", errorPrefix(s), err)
+	}
+
 	return nil
 }
 
@@ -0,0 +1,74 @@
+package state
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// Validations
+
+func Test_FailState_Valid(t *testing.T) {
+	state := parseFailState([]byte(`{"Error": "DeployFailed", "Cause": "Deploy failed"}`), t)
+	assert.NoError(t, state.Validate())
+}
+
+func Test_FailState_ErrorRequired(t *testing.T) {
+	state := parseFailState([]byte(`{"Cause": "Deploy failed"}`), t)
+	err := state.Validate()
+	assert.Error(t, err)
+	assert.Regexp(t, `FailState\(TestState\) Error: must contain Error`, err.Error())
+
+	state = parseFailState([]byte(`{"Error": ""}`), t)
+	assert.Error(t, state.Validate())
+}
+
+func Test_FailState_ErrorLength(t *testing.T) {
+	state := parseFailState([]byte(`{"Error": "`+strings.Repeat("a", MaxFailErrorLength)+`"}`), t)
+	assert.NoError(t, state.Validate())
+
+	state = parseFailState([]byte(`{"Error": "`+strings.Repeat("a", MaxFailErrorLength+1)+`"}`), t)
+	err := state.Validate()
+	assert.Error(t, err)
+	assert.Regexp(t, `FailState\(TestState\) Error: Error must be at most 256 characters, got 257`, err.Error())
+}
+
+func Test_FailState_ErrorControlCharacters(t *testing.T) {
+	state := parseFailState([]byte(`{"Error": "Deploy\nFailed"}`), t)
+	err := state.Validate()
+	assert.Error(t, err)
+	assert.Regexp(t, `FailState\(TestState\) Error: Error .* must not contain control characters`, err.Error())
+}
+
+func Test_FailState_CauseLength(t *testing.T) {
+	state := parseFailState([]byte(`{"Error": "E", "Cause": "`+strings.Repeat("a", MaxFailCauseLength)+`"}`), t)
+	assert.NoError(t, state.Validate())
+
+	state = parseFailState([]byte(`{"Error": "E", "Cause": "`+strings.Repeat("a", MaxFailCauseLength+1)+`"}`), t)
+	err := state.Validate()
+	assert.Error(t, err)
+	assert.Regexp(t, `FailState\(TestState\) Error: Cause must be at most 32768 characters`, err.Error())
+}
+
+func Test_FailState_NextEndNotAllowed(t *testing.T) {
+	state := parseFailState([]byte(`{"Error": "E", "Next": "Other"}`), t)
+	err := state.Validate()
+	assert.Error(t, err)
+	assert.Regexp(t, `FailState\(TestState\) Error: Next not allowed`, err.Error())
+
+	state = parseFailState([]byte(`{"Error": "E", "End": true}`), t)
+	err = state.Validate()
+	assert.Error(t, err)
+	assert.Regexp(t, `FailState\(TestState\) Error: End not allowed`, err.Error())
+}
+
+// Execution
+
+func Test_FailState_Execute(t *testing.T) {
+	state := parseFailState([]byte(`{"Error": "DeployFailed", "Cause": "Deploy failed"}`), t)
+	output, next, err := state.Execute(nil, map[string]interface{}{})
+	assert.Error(t, err)
+	assert.Nil(t, next)
+	assert.Equal(t, map[string]interface{}{"Error": "DeployFailed", "Cause": "Deploy failed"}, output)
+}
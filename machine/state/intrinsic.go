@@ -0,0 +1,349 @@
+package state
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/coinbase/step/jsonpath"
+)
+
+// intrinsicFunctions are the ASL intrinsic functions, only some can be evaluated by the executor
+var intrinsicFunctions = map[string]bool{
+	"States.Format":         true,
+	"States.StringToJson":   true,
+	"States.JsonToString":   true,
+	"States.Array":          true,
+	"States.ArrayPartition": false,
+	"States.ArrayContains":  false,
+	"States.ArrayRange":     false,
+	"States.ArrayGetItem":   false,
+	"States.ArrayLength":    false,
+	"States.ArrayUnique":    false,
+	"States.Base64Encode":   false,
+	"States.Base64Decode":   false,
+	"States.Hash":           false,
+	"States.JsonMerge":      false,
+	"States.MathRandom":     false,
+	"States.MathAdd":        false,
+	"States.StringSplit":    false,
+	"States.UUID":           false,
+}
+
+// intrinsic is a parsed intrinsic function call, e.g. States.Format('Hello {}', $.name)
+type intrinsic struct {
+	Name string
+	Args []interface{} // intrinsicString, float64, nil, *jsonpath.Path or *intrinsic
+}
+
+// intrinsicString is a string literal, Raw keeps the escaped \{ and \} for States.Format
+type intrinsicString struct {
+	Raw string
+}
+
+func (s intrinsicString) value() string {
+	return unescapeIntrinsic(s.Raw)
+}
+
+func unescapeIntrinsic(raw string) string {
+	var b strings.Builder
+	for i := 0; i < len(raw); i++ {
+		if raw[i] == '\\' && i+1 < len(raw) {
+			i++
+		}
+		b.WriteByte(raw[i])
+	}
+	return b.String()
+}
+
+func isIntrinsic(str string) bool {
+	return strings.HasPrefix(str, "States.")
+}
+
+// parseIntrinsic parses an intrinsic function call, it does not check the function can be evaluated
+func parseIntrinsic(str string) (*intrinsic, error) {
+	p := &intrinsicParser{str: str}
+
+	fn, err := p.function()
+	if err != nil {
+		return nil, fmt.Errorf("Bad intrinsic function %q: %v", str, err)
+	}
+
+	if p.skipSpace(); p.pos != len(p.str) {
+		return nil, fmt.Errorf("Bad intrinsic function %q: unexpected %q", str, p.str[p.pos:])
+	}
+
+	return fn, nil
+}
+
+type intrinsicParser struct {
+	str string
+	pos int
+}
+
+func (p *intrinsicParser) skipSpace() {
+	for p.pos < len(p.str) && p.str[p.pos] == ' ' {
+		p.pos++
+	}
+}
+
+func (p *intrinsicParser) function() (*intrinsic, error) {
+	open := strings.IndexByte(p.str[p.pos:], '(')
+	if open == -1 {
+		return nil, fmt.Errorf("missing (")
+	}
+
+	name := p.str[p.pos : p.pos+open]
+	if _, ok := intrinsicFunctions[name]; !ok {
+		return nil, fmt.Errorf("unknown function %v", name)
+	}
+	p.pos += open + 1
+
+	fn := &intrinsic{Name: name, Args: []interface{}{}}
+
+	p.skipSpace()
+	if p.pos < len(p.str) && p.str[p.pos] == ')' {
+		p.pos++
+		return fn, nil
+	}
+
+	for {
+		arg, err := p.arg()
+		if err != nil {
+			return nil, err
+		}
+		fn.Args = append(fn.Args, arg)
+
+		p.skipSpace()
+		if p.pos == len(p.str) {
+			return nil, fmt.Errorf("missing )")
+		}
+
+		switch p.str[p.pos] {
+		case ',':
+			p.pos++
+		case ')':
+			p.pos++
+			return fn, nil
+		default:
+			return nil, fmt.Errorf("unexpected %q", p.str[p.pos:])
+		}
+	}
+}
+
+func (p *intrinsicParser) arg() (interface{}, error) {
+	p.skipSpace()
+	rest := p.str[p.pos:]
+
+	switch {
+	case rest == "":
+		return nil, fmt.Errorf("missing argument")
+	case rest[0] == '\'':
+		return p.stringLiteral()
+	case isIntrinsic(rest):
+		return p.function()
+	case rest[0] == '$':
+		end := p.argEnd()
+		path, err := jsonpath.NewPath(strings.TrimSpace(p.str[p.pos:end]))
+		if err != nil {
+			return nil, err
+		}
+		p.pos = end
+		return path, nil
+	default:
+		end := p.argEnd()
+		token := strings.TrimSpace(p.str[p.pos:end])
+		p.pos = end
+
+		if token == "null" {
+			return nil, nil
+		}
+
+		number, err := strconv.ParseFloat(token, 64)
+		if err != nil {
+			return nil, fmt.Errorf("unexpected argument %q", token)
+		}
+		return number, nil
+	}
+}
+
+// argEnd returns the position of the , or ) ending an unquoted argument, skipping quoted path keys
+func (p *intrinsicParser) argEnd() int {
+	var quote byte
+	for i := p.pos; i < len(p.str); i++ {
+		c := p.str[i]
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '\'' || c == '"':
+			quote = c
+		case c == ',' || c == ')':
+			return i
+		}
+	}
+	return len(p.str)
+}
+
+func (p *intrinsicParser) stringLiteral() (interface{}, error) {
+	for i := p.pos + 1; i < len(p.str); i++ {
+		switch p.str[i] {
+		case '\\':
+			i++
+		case '\'':
+			raw := p.str[p.pos+1 : i]
+			p.pos = i + 1
+			return intrinsicString{raw}, nil
+		}
+	}
+	return nil, fmt.Errorf("unterminated string")
+}
+
+// evaluate calls the intrinsic function with its arguments from input
+func (fn *intrinsic) evaluate(input interface{}) (interface{}, error) {
+	if !intrinsicFunctions[fn.Name] {
+		return nil, fmt.Errorf("Intrinsic function %v is not supported by the executor", fn.Name)
+	}
+
+	if fn.Name == "States.Format" {
+		return fn.format(input)
+	}
+
+	args, err := fn.evaluateArgs(input, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	switch fn.Name {
+	case "States.Array":
+		return args, nil
+	case "States.StringToJson":
+		str, err := fn.singleStringArg(args)
+		if err != nil {
+			return nil, err
+		}
+
+		var output interface{}
+		if err := json.Unmarshal([]byte(str), &output); err != nil {
+			return nil, fmt.Errorf("%v Error: %v", fn.Name, err)
+		}
+		return output, nil
+	default: // States.JsonToString
+		if len(args) != 1 {
+			return nil, fmt.Errorf("%v takes 1 argument, got %v", fn.Name, len(args))
+		}
+
+		raw, err := json.Marshal(args[0])
+		if err != nil {
+			return nil, fmt.Errorf("%v Error: %v", fn.Name, err)
+		}
+		return string(raw), nil
+	}
+}
+
+func (fn *intrinsic) evaluateArgs(input interface{}, from int) ([]interface{}, error) {
+	args := []interface{}{}
+
+	for _, arg := range fn.Args[from:] {
+		switch arg := arg.(type) {
+		case intrinsicString:
+			args = append(args, arg.value())
+		case *jsonpath.Path:
+			value, err := arg.Get(input)
+			if err != nil {
+				return nil, fmt.Errorf("%v argument %v: %v", fn.Name, arg.String(), err)
+			}
+			args = append(args, value)
+		case *intrinsic:
+			value, err := arg.evaluate(input)
+			if err != nil {
+				return nil, err
+			}
+			args = append(args, value)
+		default:
+			args = append(args, arg)
+		}
+	}
+
+	return args, nil
+}
+
+func (fn *intrinsic) singleStringArg(args []interface{}) (string, error) {
+	if len(args) != 1 {
+		return "", fmt.Errorf("%v takes 1 argument, got %v", fn.Name, len(args))
+	}
+
+	str, ok := args[0].(string)
+	if !ok {
+		return "", fmt.Errorf("%v argument must be a string", fn.Name)
+	}
+
+	return str, nil
+}
+
+// format replaces each unescaped {} in the template with the next argument
+func (fn *intrinsic) format(input interface{}) (interface{}, error) {
+	if len(fn.Args) == 0 {
+		return nil, fmt.Errorf("%v requires a template", fn.Name)
+	}
+
+	template, ok := fn.Args[0].(intrinsicString)
+	if !ok {
+		return nil, fmt.Errorf("%v template must be a string literal", fn.Name)
+	}
+
+	args, err := fn.evaluateArgs(input, 1)
+	if err != nil {
+		return nil, err
+	}
+
+	var b strings.Builder
+	raw := template.Raw
+	used := 0
+
+	for i := 0; i < len(raw); i++ {
+		switch {
+		case raw[i] == '\\' && i+1 < len(raw):
+			i++
+			b.WriteByte(raw[i])
+		case strings.HasPrefix(raw[i:], "{}"):
+			if used == len(args) {
+				return nil, fmt.Errorf("%v has more {} than arguments", fn.Name)
+			}
+
+			str, err := formatArg(args[used])
+			if err != nil {
+				return nil, fmt.Errorf("%v %v", fn.Name, err)
+			}
+
+			b.WriteString(str)
+			used++
+			i++
+		default:
+			b.WriteByte(raw[i])
+		}
+	}
+
+	if used != len(args) {
+		return nil, fmt.Errorf("%v has %v arguments for %v {}", fn.Name, len(args), used)
+	}
+
+	return b.String(), nil
+}
+
+func formatArg(arg interface{}) (string, error) {
+	switch arg := arg.(type) {
+	case string:
+		return arg, nil
+	case float64:
+		return strconv.FormatFloat(arg, 'f', -1, 64), nil
+	case bool:
+		return strconv.FormatBool(arg), nil
+	case nil:
+		return "null", nil
+	default:
+		return "", fmt.Errorf("argument must be a string, number, boolean or null")
+	}
+}
@@ -0,0 +1,82 @@
+package state
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func evaluateIntrinsic(t *testing.T, str string, input string) (interface{}, error) {
+	var in interface{}
+	assert.NoError(t, json.Unmarshal([]byte(input), &in))
+
+	fn, err := parseIntrinsic(str)
+	assert.NoError(t, err)
+
+	return fn.evaluate(in)
+}
+
+func Test_Intrinsic_Evaluate(t *testing.T) {
+	input := `{"name": "Alice", "count": 3, "ok": true, "obj": {"a": [1, "b"]}, "raw": "{\"x\": 1}"}`
+
+	tests := map[string]interface{}{
+		`States.Format('Hello {}', $.name)`:                              "Hello Alice",
+		`States.Format('{} has {} items, ok={}', $.name, $.count, $.ok)`: "Alice has 3 items, ok=true",
+		`States.Format('\{\} {} \'quoted\'', 'x')`:                       "{} x 'quoted'",
+		`States.Format('{}', null)`:                                      "null",
+		`States.JsonToString($.obj)`:                                     `{"a":[1,"b"]}`,
+		`States.StringToJson($.raw)`:                                     map[string]interface{}{"x": 1.0},
+		`States.Array('a', 1, $.name, States.Array())`:                   []interface{}{"a", 1.0, "Alice", []interface{}{}},
+		`States.Format('{}', States.JsonToString($.obj.a))`:              `[1,"b"]`,
+		`States.Format('{}', $['name'])`:                                 "Alice",
+	}
+
+	for str, expected := range tests {
+		output, err := evaluateIntrinsic(t, str, input)
+		assert.NoError(t, err, str)
+		assert.Equal(t, expected, output, str)
+	}
+}
+
+func Test_Intrinsic_Evaluate_Errors(t *testing.T) {
+	input := `{"name": "Alice", "obj": {}}`
+
+	tests := map[string]string{
+		`States.Format('{} {}', $.name)`:     "more {} than arguments",
+		`States.Format('{}', $.name, 'x')`:   "2 arguments for 1 {}",
+		`States.Format('{}', $.obj)`:         "must be a string, number, boolean or null",
+		`States.Format($.name)`:              "template must be a string literal",
+		`States.StringToJson($.name)`:        "States.StringToJson Error",
+		`States.JsonToString($.missing)`:     "Not Found",
+		`States.UUID()`:                      "not supported by the executor",
+		`States.Array(States.MathAdd(1, 2))`: "States.MathAdd is not supported",
+	}
+
+	for str, expected := range tests {
+		_, err := evaluateIntrinsic(t, str, input)
+		if assert.Error(t, err, str) {
+			assert.Regexp(t, expected, err.Error(), str)
+		}
+	}
+}
+
+func Test_Intrinsic_Parse_Errors(t *testing.T) {
+	for _, str := range []string{
+		`States.Format`,
+		`States.Unknown('x')`,
+		`States.Format('x'`,
+		`States.Format('x)`,
+		`States.Format('x') extra`,
+		`States.Format('x',)`,
+		`States.Format(notanarg)`,
+		`States.Format($.)`,
+	} {
+		_, err := parseIntrinsic(str)
+		assert.Error(t, err, str)
+	}
+
+	// Functions the executor cannot evaluate still parse
+	_, err := parseIntrinsic(`States.MathAdd($.a, -1)`)
+	assert.NoError(t, err)
+}
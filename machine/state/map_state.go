@@ -0,0 +1,134 @@
+package state
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/coinbase/step/jsonpath"
+	"github.com/coinbase/step/utils/to"
+)
+
+// Machine is a nested State Machine, e.g. the Iterator of a Map State or a Branch of a Parallel State
+type Machine interface {
+	Validate() error
+	Run(context.Context, interface{}) (interface{}, error)
+}
+
+type MapState struct {
+	stateStr // Include Defaults
+
+	Type    *string
+	Comment *string `json:",omitempty"`
+
+	InputPath  *jsonpath.Path `json:",omitempty"`
+	OutputPath *jsonpath.Path `json:",omitempty"`
+	ResultPath *jsonpath.Path `json:",omitempty"`
+	ItemsPath  *jsonpath.Path `json:",omitempty"`
+
+	MaxConcurrency *int `json:",omitempty"`
+
+	ResultSelector interface{} `json:",omitempty"` // Built from the array of Iterator outputs before ResultPath
+
+	// Iterator is parsed by the machine package and set with SetIterator
+	Iterator *json.RawMessage `json:",omitempty"`
+	iterator Machine
+
+	Catch []*Catcher `json:",omitempty"`
+	Retry []*Retrier `json:",omitempty"`
+
+	Next *string `json:",omitempty"`
+	End  *bool   `json:",omitempty"`
+}
+
+func (s *MapState) SetIterator(iterator Machine) {
+	s.iterator = iterator
+}
+
+func (s *MapState) GetIterator() Machine {
+	return s.iterator
+}
+
+// Execute runs the Iterator over every item at ItemsPath, one at a time, so executions are deterministic
+func (s *MapState) Execute(ctx context.Context, input interface{}) (output interface{}, next *string, err error) {
+	return processError(s,
+		processCatcher(s.Catch,
+			processRetrier(s.Name(), s.Retry,
+				inputOutput(
+					s.InputPath,
+					s.OutputPath,
+					arrayResult(s.ResultPath, selectResult(s.ResultSelector, s.process)),
+				),
+			),
+		),
+	)(ctx, input)
+}
+
+func (s *MapState) process(ctx context.Context, input interface{}) (interface{}, *string, error) {
+	items, err := s.ItemsPath.Get(input)
+	if err != nil {
+		return nil, nil, fmt.Errorf("ItemsPath Error: %v", err)
+	}
+
+	itemsArray, ok := items.([]interface{})
+	if !ok {
+		return nil, nil, fmt.Errorf("ItemsPath Error: must return array")
+	}
+
+	outputs := []interface{}{}
+	for _, item := range itemsArray {
+		output, err := s.iterator.Run(ctx, item)
+		if err != nil {
+			return nil, nil, err
+		}
+		outputs = append(outputs, output)
+	}
+
+	return outputs, nextState(s.Next, s.End), nil
+}
+
+func (s *MapState) Validate() error {
+	s.SetType(to.Strp("Map"))
+
+	if err := ValidateNameAndType(s); err != nil {
+		return fmt.Errorf("%v %v", errorPrefix(s), err)
+	}
+
+	if err := endValid(s.Next, s.End); err != nil {
+		return fmt.Errorf("%v %v", errorPrefix(s), err)
+	}
+
+	if s.iterator == nil {
+		return fmt.Errorf("%v Requires Iterator", errorPrefix(s))
+	}
+
+	if err := s.iterator.Validate(); err != nil {
+		return fmt.Errorf("%v Iterator %v", errorPrefix(s), err)
+	}
+
+	if s.MaxConcurrency != nil && *s.MaxConcurrency < 0 {
+		return fmt.Errorf("%v MaxConcurrency must be positive", errorPrefix(s))
+	}
+
+	if err := paramsValid("ResultSelector", s.ResultSelector); err != nil {
+		return fmt.Errorf("%v %v", errorPrefix(s), err)
+	}
+
+	if err := catchValid(s.Catch); err != nil {
+		return fmt.Errorf("%v %v", errorPrefix(s), err)
+	}
+
+	if err := retryValid(s.Retry); err != nil {
+		return fmt.Errorf("%v %v", errorPrefix(s), err)
+	}
+
+	return nil
+}
+
+func (s *MapState) SetType(t *string) {
+	s.Type = t
+}
+
+func (s *MapState) GetType() *string {
+	return s.Type
+}
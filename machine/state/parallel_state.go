@@ -2,8 +2,10 @@ package state
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 
+	"github.com/coinbase/step/jsonpath"
 	"github.com/coinbase/step/utils/to"
 )
 
@@ -12,10 +14,69 @@ type ParallelState struct {
 
 	Type    *string
 	Comment *string `json:",omitempty"`
+
+	InputPath  *jsonpath.Path `json:",omitempty"`
+	OutputPath *jsonpath.Path `json:",omitempty"`
+	ResultPath *jsonpath.Path `json:",omitempty"`
+
+	Parameters     interface{} `json:",omitempty"` // The input of every Branch
+	ResultSelector interface{} `json:",omitempty"` // Built from the array of Branch outputs before ResultPath
+
+	// Branches are parsed by the machine package and set with SetBranches
+	Branches []*json.RawMessage `json:",omitempty"`
+	branches []Machine
+
+	Catch []*Catcher `json:",omitempty"`
+	Retry []*Retrier `json:",omitempty"`
+
+	Next *string `json:",omitempty"`
+	End  *bool   `json:",omitempty"`
+}
+
+func (s *ParallelState) SetBranches(branches []Machine) {
+	s.branches = branches
+}
+
+func (s *ParallelState) GetBranches() []Machine {
+	return s.branches
 }
 
-func (s *ParallelState) Execute(_ context.Context, input interface{}) (output interface{}, next *string, err error) {
-	return input, nil, nil
+// Execute runs each Branch with the input one after another, so executions are deterministic.
+// The result is the array of Branch outputs in Branch order
+func (s *ParallelState) Execute(ctx context.Context, input interface{}) (output interface{}, next *string, err error) {
+	return processError(s,
+		processCatcher(s.Catch,
+			processRetrier(s.Name(), s.Retry,
+				inputOutput(
+					s.InputPath,
+					s.OutputPath,
+					withParams(
+						s.Parameters,
+						arrayResult(s.ResultPath, selectResult(s.ResultSelector, s.process)),
+					),
+				),
+			),
+		),
+	)(ctx, input)
+}
+
+func (s *ParallelState) process(ctx context.Context, input interface{}) (interface{}, *string, error) {
+	outputs := []interface{}{}
+	for _, branch := range s.branches {
+		// Each Branch gets its own copy of the input so Branches cannot modify each other
+		branchInput, err := to.FromJSON(input)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		output, err := branch.Run(ctx, branchInput)
+		if err != nil {
+			return nil, nil, err
+		}
+		outputs = append(outputs, output)
+	}
+
+	return outputs, nextState(s.Next, s.End), nil
 }
 
 func (s *ParallelState) Validate() error {
@@ -25,6 +86,36 @@ func (s *ParallelState) Validate() error {
 		return fmt.Errorf("%v %v", errorPrefix(s), err)
 	}
 
+	if err := endValid(s.Next, s.End); err != nil {
+		return fmt.Errorf("%v %v", errorPrefix(s), err)
+	}
+
+	if len(s.branches) == 0 {
+		return fmt.Errorf("%v Requires Branches", errorPrefix(s))
+	}
+
+	for i, branch := range s.branches {
+		if err := branch.Validate(); err != nil {
+			return fmt.Errorf("%v Branch %v %v", errorPrefix(s), i, err)
+		}
+	}
+
+	if err := paramsValid("Parameters", s.Parameters); err != nil {
+		return fmt.Errorf("%v %v", errorPrefix(s), err)
+	}
+
+	if err := paramsValid("ResultSelector", s.ResultSelector); err != nil {
+		return fmt.Errorf("%v %v", errorPrefix(s), err)
+	}
+
+	if err := catchValid(s.Catch); err != nil {
+		return fmt.Errorf("%v %v", errorPrefix(s), err)
+	}
+
+	if err := retryValid(s.Retry); err != nil {
+		return fmt.Errorf("%v %v", errorPrefix(s), err)
+	}
+
 	return nil
 }
 
@@ -18,7 +18,8 @@ type PassState struct {
 	OutputPath *jsonpath.Path `json:",omitempty"`
 	ResultPath *jsonpath.Path `json:",omitempty"`
 
-	Result interface{} `json:",omitempty"`
+	Parameters interface{} `json:",omitempty"`
+	Result     interface{} `json:",omitempty"`
 
 	Next *string `json:",omitempty"`
 	End  *bool   `json:",omitempty"`
@@ -29,7 +30,10 @@ func (s *PassState) Execute(ctx context.Context, input interface{}) (output inte
 		inputOutput(
 			s.InputPath,
 			s.OutputPath,
-			result(s.ResultPath, s.process),
+			withParams(
+				s.Parameters,
+				result(s.ResultPath, s.process),
+			),
 		),
 	)(ctx, input)
 }
@@ -50,6 +54,10 @@ func (s *PassState) Validate() error {
 		return fmt.Errorf("%v %v", errorPrefix(s), err)
 	}
 
+	if err := paramsValid("Parameters", s.Parameters); err != nil {
+		return fmt.Errorf("%v %v", errorPrefix(s), err)
+	}
+
 	return nil
 }
 
@@ -36,8 +36,26 @@ func Test_PassState_EndNextBothUnDefined(t *testing.T) {
 	assert.Regexp(t, "End and Next both undefined", err.Error())
 }
 
+func Test_PassState_Parameters_Valid(t *testing.T) {
+	state := parsePassState([]byte(`{"Next": "Pass", "Parameters": {"a.$": "$.b", "c": "literal"}}`), t)
+	assert.NoError(t, state.Validate())
+
+	state = parsePassState([]byte(`{"Next": "Pass", "Parameters": {"a.$": "literal"}}`), t)
+	err := state.Validate()
+	assert.Error(t, err)
+	assert.Regexp(t, `PassState\(TestState\) Error: Parameters "a.\$"`, err.Error())
+}
+
 // Execution
 
+func Test_PassState_Parameters(t *testing.T) {
+	state := parsePassState([]byte(`{"Next": "Pass", "Parameters": {"a.$": "$.b", "c": "literal"}}`), t)
+	testState(state, stateTestData{
+		Input:  map[string]interface{}{"b": "value"},
+		Output: map[string]interface{}{"a": "value", "c": "literal"},
+	}, t)
+}
+
 func Test_PassState_ResultPath(t *testing.T) {
 	state := parsePassState([]byte(`{ "Next": "Pass", "Result": "b", "ResultPath": "$.a"}`), t)
 	testState(state, stateTestData{Output: map[string]interface{}{"a": "b"}}, t)
@@ -220,12 +220,7 @@ func replaceParamsJSONPath(params interface{}, input interface{}) (interface{},
 				default:
 					return nil, fmt.Errorf("value to key %q is not string", key)
 				}
-				valueStr := value.(string)
-				path, err := jsonpath.NewPath(valueStr)
-				if err != nil {
-					return nil, err
-				}
-				newValue, err := path.Get(input)
+				newValue, err := paramValue(value.(string), input)
 				if err != nil {
 					return nil, err
 				}
@@ -243,6 +238,40 @@ func replaceParamsJSONPath(params interface{}, input interface{}) (interface{},
 	return params, nil
 }
 
+// selectResult replaces the result of exec with selector, like Parameters with the result as the input
+func selectResult(selector interface{}, exec Execution) Execution {
+	return func(ctx context.Context, input interface{}) (interface{}, *string, error) {
+		output, next, err := exec(ctx, input)
+		if err != nil || selector == nil {
+			return output, next, err
+		}
+
+		output, err = replaceParamsJSONPath(selector, output)
+		if err != nil {
+			return nil, nil, fmt.Errorf("ResultSelector %v", err)
+		}
+
+		return output, next, nil
+	}
+}
+
+// paramValue is the value of a JSON path or intrinsic function in input
+func paramValue(valueStr string, input interface{}) (interface{}, error) {
+	if isIntrinsic(valueStr) {
+		fn, err := parseIntrinsic(valueStr)
+		if err != nil {
+			return nil, err
+		}
+		return fn.evaluate(input)
+	}
+
+	path, err := jsonpath.NewPath(valueStr)
+	if err != nil {
+		return nil, err
+	}
+	return path.Get(input)
+}
+
 func result(resultPath *jsonpath.Path, exec Execution) Execution {
 	return func(ctx context.Context, input interface{}) (interface{}, *string, error) {
 		result, next, err := exec(ctx, input)
@@ -265,6 +294,25 @@ func result(resultPath *jsonpath.Path, exec Execution) Execution {
 	}
 }
 
+// arrayResult is result for states that return arrays, which can replace the root input
+func arrayResult(resultPath *jsonpath.Path, exec Execution) Execution {
+	return func(ctx context.Context, input interface{}) (interface{}, *string, error) {
+		output, next, err := exec(ctx, input)
+
+		if err != nil {
+			return nil, nil, err
+		}
+
+		if resultPath == nil {
+			return output, next, nil
+		}
+
+		return result(resultPath, func(context.Context, interface{}) (interface{}, *string, error) {
+			return output, next, nil
+		})(ctx, input)
+	}
+}
+
 //////
 // Shared Validity Methods
 //////
@@ -285,6 +333,19 @@ func endValid(next *string, end *bool) error {
 	return nil
 }
 
+// terminalValid checks a Succeed or Fail State, which always ends the execution, has neither Next nor End
+func terminalValid(next *string, end *bool) error {
+	if next != nil {
+		return fmt.Errorf("Next not allowed on a terminal state")
+	}
+
+	if end != nil {
+		return fmt.Errorf("End not allowed on a terminal state")
+	}
+
+	return nil
+}
+
 func errorPrefix(s State) string {
 	if !is.EmptyStr(s.Name()) {
 		return fmt.Sprintf("%vState(%v) Error:", *s.GetType(), *s.Name())
@@ -305,6 +366,51 @@ func ValidateNameAndType(s State) error {
 	return nil
 }
 
+// paramsValid checks every ".$" key in params, the Parameters or ResultSelector field, is a JSON path,
+// context object path or intrinsic function. A ".$" key with a literal value is an error
+func paramsValid(field string, params interface{}) error {
+	switch params := params.(type) {
+	case map[string]interface{}:
+		for key, value := range params {
+			if !strings.HasSuffix(key, ".$") {
+				if err := paramsValid(field, value); err != nil {
+					return err
+				}
+				continue
+			}
+
+			valueStr, ok := value.(string)
+			if !ok {
+				return fmt.Errorf("%v value to key %q is not string", field, key)
+			}
+
+			switch {
+			case strings.HasPrefix(valueStr, "$$"):
+				// Context object values are not known until execution, but the path must parse
+				if _, err := jsonpath.NewPath(valueStr[1:]); err != nil {
+					return fmt.Errorf("%v %q %v", field, key, err)
+				}
+			case isIntrinsic(valueStr):
+				if _, err := parseIntrinsic(valueStr); err != nil {
+					return fmt.Errorf("%v %q %v", field, key, err)
+				}
+			default:
+				if _, err := jsonpath.NewPath(valueStr); err != nil {
+					return fmt.Errorf("%v %q %v", field, key, err)
+				}
+			}
+		}
+	case []interface{}:
+		for _, value := range params {
+			if err := paramsValid(field, value); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
 func retryValid(retry []*Retrier) error {
 	if retry == nil {
 		return nil
@@ -312,7 +418,19 @@ func retryValid(retry []*Retrier) error {
 
 	for i, r := range retry {
 		if err := errorEqualsValid(r.ErrorEquals, len(retry)-1 == i); err != nil {
-			return err
+			return fmt.Errorf("Retry[%v].ErrorEquals %v", i, err)
+		}
+
+		if r.IntervalSeconds != nil && *r.IntervalSeconds < 1 {
+			return fmt.Errorf("Retry[%v].IntervalSeconds must be at least 1, got %v", i, *r.IntervalSeconds)
+		}
+
+		if r.MaxAttempts != nil && *r.MaxAttempts < 0 {
+			return fmt.Errorf("Retry[%v].MaxAttempts must not be negative, got %v", i, *r.MaxAttempts)
+		}
+
+		if r.BackoffRate != nil && *r.BackoffRate < 1.0 {
+			return fmt.Errorf("Retry[%v].BackoffRate must be at least 1.0, got %v", i, *r.BackoffRate)
 		}
 	}
 
@@ -326,11 +444,11 @@ func catchValid(catch []*Catcher) error {
 
 	for i, c := range catch {
 		if err := errorEqualsValid(c.ErrorEquals, len(catch)-1 == i); err != nil {
-			return err
+			return fmt.Errorf("Catch[%v].ErrorEquals %v", i, err)
 		}
 
 		if is.EmptyStr(c.Next) {
-			return fmt.Errorf("Catcher requires Next")
+			return fmt.Errorf("Catch[%v].Next Catcher requires Next", i)
 		}
 	}
 	return nil
@@ -62,6 +62,24 @@ func parsePassState(b []byte, t *testing.T) *PassState {
 	return &p
 }
 
+func parseFailState(b []byte, t *testing.T) *FailState {
+	var p FailState
+	err := json.Unmarshal(b, &p)
+	assert.NoError(t, err)
+	p.SetName(to.Strp("TestState"))
+	p.SetType(to.Strp("Fail"))
+	return &p
+}
+
+func parseSucceedState(b []byte, t *testing.T) *SucceedState {
+	var p SucceedState
+	err := json.Unmarshal(b, &p)
+	assert.NoError(t, err)
+	p.SetName(to.Strp("TestState"))
+	p.SetType(to.Strp("Succeed"))
+	return &p
+}
+
 func parseWaitState(b []byte, t *testing.T) *WaitState {
 	var p WaitState
 	err := json.Unmarshal(b, &p)
@@ -16,6 +16,10 @@ type SucceedState struct {
 
 	InputPath  *jsonpath.Path `json:",omitempty"`
 	OutputPath *jsonpath.Path `json:",omitempty"`
+
+	// Next and End are not allowed on a Succeed State, they are parsed so Validate can reject them
+	Next *string `json:",omitempty"`
+	End  *bool   `json:",omitempty"`
 }
 
 func (s *SucceedState) process(ctx context.Context, input interface{}) (interface{}, *string, error) {
@@ -39,6 +43,10 @@ func (s *SucceedState) Validate() error {
 		return fmt.Errorf("%v %v", errorPrefix(s), err)
 	}
 
+	if err := terminalValid(s.Next, s.End); err != nil {
+		return fmt.Errorf("%v %v", errorPrefix(s), err)
+	}
+
 	return nil
 }
 
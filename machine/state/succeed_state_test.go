@@ -0,0 +1,36 @@
+package state
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// Validations
+
+func Test_SucceedState_Valid(t *testing.T) {
+	state := parseSucceedState([]byte(`{}`), t)
+	assert.NoError(t, state.Validate())
+}
+
+func Test_SucceedState_NextEndNotAllowed(t *testing.T) {
+	state := parseSucceedState([]byte(`{"Next": "Other"}`), t)
+	err := state.Validate()
+	assert.Error(t, err)
+	assert.Regexp(t, `SucceedState\(TestState\) Error: Next not allowed`, err.Error())
+
+	state = parseSucceedState([]byte(`{"End": true}`), t)
+	err = state.Validate()
+	assert.Error(t, err)
+	assert.Regexp(t, `SucceedState\(TestState\) Error: End not allowed`, err.Error())
+}
+
+// Execution
+
+func Test_SucceedState_OutputPath(t *testing.T) {
+	state := parseSucceedState([]byte(`{"OutputPath": "$.a"}`), t)
+	testState(state, stateTestData{
+		Input:  map[string]interface{}{"a": map[string]interface{}{"b": "c"}},
+		Output: map[string]interface{}{"b": "c"},
+	}, t)
+}
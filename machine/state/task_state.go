@@ -20,6 +20,8 @@ type TaskState struct {
 	ResultPath *jsonpath.Path `json:",omitempty"`
 	Parameters interface{}    `json:",omitempty"`
 
+	ResultSelector interface{} `json:",omitempty"` // Built from the Resource result before ResultPath
+
 	Resource *string `json:",omitempty"`
 
 	Catch []*Catcher `json:",omitempty"`
@@ -33,6 +35,10 @@ type TaskState struct {
 
 	TimeoutSeconds   int `json:",omitempty"`
 	HeartbeatSeconds int `json:",omitempty"`
+
+	// Read the timeouts from the input, instead of TimeoutSeconds and HeartbeatSeconds
+	TimeoutSecondsPath   *jsonpath.Path `json:",omitempty"`
+	HeartbeatSecondsPath *jsonpath.Path `json:",omitempty"`
 }
 
 func (s *TaskState) SetTaskHandler(resourcefn interface{}) {
@@ -65,7 +71,7 @@ func (s *TaskState) Execute(ctx context.Context, input interface{}) (output inte
 					s.OutputPath,
 					withParams(
 						s.Parameters,
-						result(s.ResultPath, s.process),
+						result(s.ResultPath, selectResult(s.ResultSelector, s.process)),
 					),
 				),
 			),
@@ -94,12 +100,46 @@ func (s *TaskState) Validate() error {
 		}
 	}
 
+	if err := paramsValid("Parameters", s.Parameters); err != nil {
+		return fmt.Errorf("%v %v", errorPrefix(s), err)
+	}
+
+	if err := paramsValid("ResultSelector", s.ResultSelector); err != nil {
+		return fmt.Errorf("%v %v", errorPrefix(s), err)
+	}
+
+	if err := s.timeoutsValid(); err != nil {
+		return fmt.Errorf("%v %v", errorPrefix(s), err)
+	}
+
 	if err := catchValid(s.Catch); err != nil {
-		return err
+		return fmt.Errorf("%v %v", errorPrefix(s), err)
 	}
 
 	if err := retryValid(s.Retry); err != nil {
-		return err
+		return fmt.Errorf("%v %v", errorPrefix(s), err)
+	}
+
+	return nil
+}
+
+// timeoutsValid checks each timeout is set at most one way,
+// and a static heartbeat is less than a static timeout so it can be sent before the task times out
+func (s *TaskState) timeoutsValid() error {
+	if s.TimeoutSeconds < 0 || s.HeartbeatSeconds < 0 {
+		return fmt.Errorf("TimeoutSeconds and HeartbeatSeconds must be positive")
+	}
+
+	if s.TimeoutSeconds != 0 && s.TimeoutSecondsPath != nil {
+		return fmt.Errorf("At most one of TimeoutSeconds or TimeoutSecondsPath")
+	}
+
+	if s.HeartbeatSeconds != 0 && s.HeartbeatSecondsPath != nil {
+		return fmt.Errorf("At most one of HeartbeatSeconds or HeartbeatSecondsPath")
+	}
+
+	if s.TimeoutSeconds != 0 && s.HeartbeatSeconds != 0 && s.HeartbeatSeconds >= s.TimeoutSeconds {
+		return fmt.Errorf("HeartbeatSeconds %v must be less than TimeoutSeconds %v", s.HeartbeatSeconds, s.TimeoutSeconds)
 	}
 
 	return nil
@@ -2,6 +2,7 @@ package state
 
 import (
 	"context"
+	"encoding/json"
 	"testing"
 
 	"github.com/coinbase/step/utils/to"
@@ -70,6 +71,7 @@ func Test_TaskState_Valid_ErrorEquals_StatesAll(t *testing.T) {
 		"Next": "Pass",
 		"Retry": [{ "ErrorEquals": ["States.ALL"] }, { "ErrorEquals": ["NotLast"] }]
 	}`), t)
+	assert.Error(t, state.Validate())
 
 	state = parseTaskState([]byte(`{
 		"Resource": "asd",
@@ -80,6 +82,71 @@ func Test_TaskState_Valid_ErrorEquals_StatesAll(t *testing.T) {
 	assert.Error(t, state.Validate())
 }
 
+func Test_TaskState_Valid_Retry_Ranges(t *testing.T) {
+	state := parseTaskState([]byte(`{
+		"Resource": "asd",
+		"Next": "Pass",
+		"Retry": [{ "ErrorEquals": ["Error"], "IntervalSeconds": 1, "MaxAttempts": 0, "BackoffRate": 1.0 }]
+	}`), t)
+	assert.NoError(t, state.Validate())
+
+	tests := map[string]string{
+		`{ "ErrorEquals": ["Error"], "IntervalSeconds": 0 }`: `TaskState\(TestState\) Error: Retry\[0\].IntervalSeconds`,
+		`{ "ErrorEquals": ["Error"], "MaxAttempts": -1 }`:    `TaskState\(TestState\) Error: Retry\[0\].MaxAttempts`,
+		`{ "ErrorEquals": ["Error"], "BackoffRate": 0.5 }`:   `TaskState\(TestState\) Error: Retry\[0\].BackoffRate`,
+		`{ "ErrorEquals": ["States.Unknown"] }`:              `TaskState\(TestState\) Error: Retry\[0\].ErrorEquals Unknown States`,
+	}
+
+	for retry, errRegexp := range tests {
+		state = parseTaskState([]byte(`{"Resource": "asd", "Next": "Pass", "Retry": [`+retry+`]}`), t)
+		err := state.Validate()
+		assert.Error(t, err)
+		assert.Regexp(t, errRegexp, err.Error())
+	}
+
+	state = parseTaskState([]byte(`{
+		"Resource": "asd",
+		"Next": "Pass",
+		"Catch": [{ "ErrorEquals": ["States.ALL"], "Next": "Fail" }, { "ErrorEquals": ["Error"], "Next": "Fail" }]
+	}`), t)
+	err := state.Validate()
+	assert.Error(t, err)
+	assert.Regexp(t, `TaskState\(TestState\) Error: Catch\[0\].ErrorEquals "States.ALL" must be last`, err.Error())
+}
+
+func Test_TaskState_Valid_Timeouts(t *testing.T) {
+	valid := []string{
+		`"TimeoutSeconds": 60, "HeartbeatSeconds": 10`,
+		`"HeartbeatSeconds": 10`,
+		`"TimeoutSecondsPath": "$.timeout", "HeartbeatSeconds": 10`,
+		`"TimeoutSeconds": 60, "HeartbeatSecondsPath": "$.heartbeat"`,
+	}
+
+	for _, timeouts := range valid {
+		state := parseTaskState([]byte(`{"Resource": "asd", "Next": "Pass", `+timeouts+`}`), t)
+		assert.NoError(t, state.Validate())
+	}
+
+	tests := map[string]string{
+		`"TimeoutSeconds": 60, "HeartbeatSeconds": 60`:          `TaskState\(TestState\) Error: HeartbeatSeconds 60 must be less than TimeoutSeconds 60`,
+		`"TimeoutSeconds": 60, "HeartbeatSeconds": 120`:         `HeartbeatSeconds 120 must be less than TimeoutSeconds 60`,
+		`"TimeoutSeconds": -1`:                                  `must be positive`,
+		`"TimeoutSeconds": 60, "TimeoutSecondsPath": "$.a"`:     `At most one of TimeoutSeconds or TimeoutSecondsPath`,
+		`"HeartbeatSeconds": 10, "HeartbeatSecondsPath": "$.a"`: `At most one of HeartbeatSeconds or HeartbeatSecondsPath`,
+	}
+
+	for timeouts, errRegexp := range tests {
+		state := parseTaskState([]byte(`{"Resource": "asd", "Next": "Pass", `+timeouts+`}`), t)
+		err := state.Validate()
+		assert.Error(t, err)
+		assert.Regexp(t, errRegexp, err.Error())
+	}
+
+	// Paths are parsed when the state is
+	var state TaskState
+	assert.Error(t, json.Unmarshal([]byte(`{"Resource": "asd", "TimeoutSecondsPath": "timeout"}`), &state))
+}
+
 func Test_TaskState_TaskHandler(t *testing.T) {
 	th, calls := countCalls(ReturnMapTestHandler)
 
@@ -272,3 +339,71 @@ func Test_TaskState_InputPath_and_Parameters(t *testing.T) {
 		Output: map[string]interface{}{"Task": "Noop", "Input": "AHAH"},
 	}, t)
 }
+
+func Test_TaskState_Parameters_Intrinsics(t *testing.T) {
+	state := parseValidTaskState([]byte(`{
+		"Next": "Pass",
+		"Resource": "test",
+		"Parameters": {
+			"Greeting.$": "States.Format('Hello {}', $.name)",
+			"Nested": {"Names.$": "States.Array($.name, 'Bob')"}
+		}
+	}`), ReturnInputHandler, t)
+
+	testState(state, stateTestData{
+		Input: map[string]interface{}{"name": "Alice"},
+		Output: map[string]interface{}{
+			"Greeting": "Hello Alice",
+			"Nested":   map[string]interface{}{"Names": []interface{}{"Alice", "Bob"}},
+		},
+	}, t)
+}
+
+func Test_TaskState_Valid_Parameters(t *testing.T) {
+	state := parseTaskState([]byte(`{
+		"Resource": "asd",
+		"Next": "Pass",
+		"Parameters": {"A.$": "States.UUID()", "B.$": "$$.Execution.Id", "C": [{"D.$": "$.d"}]}
+	}`), t)
+	assert.NoError(t, state.Validate())
+
+	for _, params := range []string{
+		`{"A.$": "States.Unknown()"}`,
+		`{"A.$": "States.Format('x'"}`,
+		`{"A.$": "not a path"}`,
+		`{"A.$": 1}`,
+		`{"A": [{"B.$": "$."}]}`,
+		`{"A.$": "$$."}`,
+	} {
+		state = parseTaskState([]byte(`{"Resource": "asd", "Next": "Pass", "Parameters": `+params+`}`), t)
+		assert.Error(t, state.Validate(), params)
+
+		state = parseTaskState([]byte(`{"Resource": "asd", "Next": "Pass", "ResultSelector": `+params+`}`), t)
+		err := state.Validate()
+		assert.Error(t, err, params)
+		assert.Regexp(t, `TaskState\(TestState\) Error: ResultSelector`, err.Error())
+	}
+}
+
+func Test_TaskState_ResultSelector(t *testing.T) {
+	state := parseValidTaskState([]byte(`{
+		"Next": "Pass",
+		"Resource": "test",
+		"ResultSelector": {"Kept.$": "$.x", "Static": "s"},
+		"ResultPath": "$.result"
+	}`), ReturnInputHandler, t)
+
+	testState(state, stateTestData{
+		Input: map[string]interface{}{"x": "AHAH", "y": "dropped"},
+		Output: map[string]interface{}{
+			"x":      "AHAH",
+			"y":      "dropped",
+			"result": map[string]interface{}{"Kept": "AHAH", "Static": "s"},
+		},
+	}, t)
+
+	testState(state, stateTestData{
+		Input: map[string]interface{}{},
+		Error: to.Strp("ResultSelector"),
+	}, t)
+}
@@ -3,6 +3,7 @@ package state
 import (
 	"context"
 	"fmt"
+	"math"
 	"time"
 
 	"github.com/coinbase/step/jsonpath"
@@ -73,22 +74,26 @@ func (s *WaitState) Validate() error {
 		return fmt.Errorf("%v %v", errorPrefix(s), err)
 	}
 
-	exactly_one := []bool{
-		s.Seconds != nil,
-		s.SecondsPath != nil,
-		s.Timestamp != nil,
-		s.TimestampPath != nil,
+	set := []string{}
+	if s.Seconds != nil {
+		set = append(set, "Seconds")
+	}
+	if s.SecondsPath != nil {
+		set = append(set, "SecondsPath")
+	}
+	if s.Timestamp != nil {
+		set = append(set, "Timestamp")
+	}
+	if s.TimestampPath != nil {
+		set = append(set, "TimestampPath")
 	}
 
-	count := 0
-	for _, c := range exactly_one {
-		if c {
-			count += 1
-		}
+	if len(set) != 1 {
+		return fmt.Errorf("%v Exactly One (Seconds,SecondsPath,Timestamp,TimestampPath) must be set, has %q", errorPrefix(s), set)
 	}
 
-	if count != 1 {
-		return fmt.Errorf("%v Exactly One (Seconds,SecondsPath,TimeStamp,TimeStampPath)", errorPrefix(s))
+	if s.Seconds != nil && (*s.Seconds <= 0 || *s.Seconds != math.Trunc(*s.Seconds)) {
+		return fmt.Errorf("%v Seconds must be a positive integer, is %v", errorPrefix(s), *s.Seconds)
 	}
 
 	return nil
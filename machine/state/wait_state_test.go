@@ -34,3 +34,35 @@ func Test_WaitState_SecondsPath(t *testing.T) {
 	_, _, err = state.Execute(nil, map[string]interface{}{})
 	assert.Error(t, err)
 }
+
+func Test_WaitState_SecondsAndSecondsPath(t *testing.T) {
+	state := parseWaitState([]byte(`
+  {
+    "Seconds": 10,
+    "SecondsPath": "$.a",
+    "Next": "Public"
+  }`), t)
+
+	err := state.Validate()
+	assert.Error(t, err)
+	assert.Regexp(t, `WaitState\(TestState\) Error: Exactly One`, err.Error())
+	assert.Regexp(t, `\["Seconds" "SecondsPath"\]`, err.Error())
+
+	state = parseWaitState([]byte(`{"Next": "Public"}`), t)
+	assert.Regexp(t, "Exactly One", state.Validate().Error())
+}
+
+func Test_WaitState_Seconds(t *testing.T) {
+	for _, seconds := range []string{"0", "-1", "1.5"} {
+		state := parseWaitState([]byte(`{"Seconds": `+seconds+`, "Next": "Public"}`), t)
+		err := state.Validate()
+		assert.Error(t, err)
+		assert.Regexp(t, `WaitState\(TestState\) Error: Seconds must be a positive integer`, err.Error())
+	}
+
+	state := parseWaitState([]byte(`{"Seconds": 10, "Next": "Public"}`), t)
+	assert.NoError(t, state.Validate())
+
+	state = parseWaitState([]byte(`{"Timestamp": "2006-01-02T15:04:05Z", "Next": "Public"}`), t)
+	assert.NoError(t, state.Validate())
+}
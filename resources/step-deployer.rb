@@ -27,7 +27,10 @@ project = project('coinbase', 'step-deployer') {
 context = {
   assumed_role_name: "coinbase-step-deployer-assumed",
   assumable_from: [ ENV['AWS_ACCOUNT_ID'] ],
-  assumed_policy_file: "#{__dir__}/step_assumed_policy.json.erb"
+  assumed_policy_file: "#{__dir__}/step_assumed_policy.json.erb",
+  notify_topic_arn: ENV['STEP_NOTIFY_TOPIC_ARN'], # Deploy events are published here, see deployer.NotifyTopicEnv
+  artifact_bucket_name: ENV['STEP_ARTIFACT_BUCKET'], # lambda.zips can be read from this ArtifactBucket, see deployer.ArtifactBucketEnv
+  signing_key_arn: ENV['STEP_LAMBDA_SIGNING_KEY_ARN'] # lambda.zip signatures are verified with this key, see deployer.SigningKeyEnv
 }
 
 project.from_template('bifrost_deployer', 'step-deployer', {
@@ -20,7 +20,7 @@ func main() {
 	region, account_id := to.RegionAccount()
 	def_step_arn := to.Strp("")
 	if region != nil && account_id != nil {
-		def_step_arn = to.StepArn(region, account_id, &default_name)
+		def_step_arn = to.StepArn(to.Strp(to.PartitionForRegion(region)), region, account_id, &default_name)
 	}
 
 	// Step Subcommands
@@ -51,6 +51,10 @@ func main() {
 	deployBucket := deployCommand.String("bucket", "", "s3 bucket to upload release to")
 	deployDeployer := deployCommand.String("deployer", *def_step_arn, "step function deployer name or arn")
 	deployZip := deployCommand.String("zip", "lambda.zip", "zip of lambda")
+	deployImage := deployCommand.String("image", "", "ECR image URI pinned by digest, deployed instead of zip")
+	deployGitSHA := deployCommand.String("git_sha", "", "git commit SHA the release was built from")
+	deployBuildURL := deployCommand.String("build_url", "", "URL of the build that created the release")
+	deployBuilder := deployCommand.String("builder", "", "who or what built the release")
 	deployProject := deployCommand.String("project", "", "project name")
 	deployConfig := deployCommand.String("config", "", "config name")
 	deployRegion := deployCommand.String("region", "", "AWS region")
@@ -114,7 +118,19 @@ func main() {
 			deployRegion,
 			deployAccount,
 		)
-		arn := to.StepArn(region, account_id, deployDeployer)
+		if *deployImage != "" {
+			r.ImageUri = deployImage
+		}
+		if *deployGitSHA != "" {
+			r.GitSHA = deployGitSHA
+		}
+		if *deployBuildURL != "" {
+			r.BuildURL = deployBuildURL
+		}
+		if *deployBuilder != "" {
+			r.Builder = deployBuilder
+		}
+		arn := to.StepArn(to.Strp(to.PartitionForRegion(region)), region, account_id, deployDeployer)
 		deployRun(r, deployZip, arn)
 	} else {
 		fmt.Println("ERROR: Command Line Not Parsed")
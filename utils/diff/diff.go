@@ -0,0 +1,173 @@
+// diff computes line based diffs between strings
+package diff
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Unified returns a unified diff of from and to with a single hunk of full context
+// It returns "" if from and to are equal
+func Unified(fromName string, toName string, from string, to string) string {
+	if from == to {
+		return ""
+	}
+
+	a := splitLines(from)
+	b := splitLines(to)
+
+	lines := []string{
+		fmt.Sprintf("--- %v", fromName),
+		fmt.Sprintf("+++ %v", toName),
+		fmt.Sprintf("@@ -%v +%v @@", hunkRange(len(a)), hunkRange(len(b))),
+	}
+
+	for _, l := range Lines(a, b) {
+		lines = append(lines, l.String())
+	}
+
+	return strings.Join(lines, "\n") + "\n"
+}
+
+// Line is a line in a diff, Op is one of ' ', '-', '+'
+type Line struct {
+	Op   byte
+	Text string
+}
+
+func (l Line) String() string {
+	return fmt.Sprintf("%c%v", l.Op, l.Text)
+}
+
+// Lines returns the diff of a and b using the longest common subsequence,
+// found with Hirschberg's algorithm so memory is linear in the number of lines
+func Lines(a []string, b []string) []Line {
+	return appendLines([]Line{}, a, b)
+}
+
+// appendLines appends the diff of a and b to lines
+func appendLines(lines []Line, a []string, b []string) []Line {
+	// Common prefix and suffix are unchanged
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		lines = append(lines, Line{' ', a[prefix]})
+		prefix++
+	}
+	a, b = a[prefix:], b[prefix:]
+
+	suffix := 0
+	for suffix < len(a) && suffix < len(b) && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+	common := a[len(a)-suffix:]
+	a, b = a[:len(a)-suffix], b[:len(b)-suffix]
+
+	switch {
+	case len(a) == 0:
+		for _, l := range b {
+			lines = append(lines, Line{'+', l})
+		}
+	case len(b) == 0:
+		for _, l := range a {
+			lines = append(lines, Line{'-', l})
+		}
+	case len(a) == 1:
+		lines = appendLine(lines, a[0], b)
+	default:
+		// Split b where the LCS of the halves of a with the two parts of b is longest
+		mid := len(a) / 2
+		forward := lcsLengths(a[:mid], b, false)
+		backward := lcsLengths(a[mid:], b, true)
+
+		split, longest := 0, -1
+		for j := 0; j <= len(b); j++ {
+			if l := forward[j] + backward[len(b)-j]; l > longest {
+				split, longest = j, l
+			}
+		}
+
+		lines = appendLines(lines, a[:mid], b[:split])
+		lines = appendLines(lines, a[mid:], b[split:])
+	}
+
+	for _, l := range common {
+		lines = append(lines, Line{' ', l})
+	}
+
+	return lines
+}
+
+// appendLine appends the diff of the single line a and b to lines
+func appendLine(lines []Line, a string, b []string) []Line {
+	for j, l := range b {
+		if l != a {
+			continue
+		}
+
+		for _, added := range b[:j] {
+			lines = append(lines, Line{'+', added})
+		}
+
+		lines = append(lines, Line{' ', a})
+
+		for _, added := range b[j+1:] {
+			lines = append(lines, Line{'+', added})
+		}
+
+		return lines
+	}
+
+	lines = append(lines, Line{'-', a})
+	for _, added := range b {
+		lines = append(lines, Line{'+', added})
+	}
+
+	return lines
+}
+
+// lcsLengths returns the LCS length of a and b[:j] for every j, keeping only two rows.
+// If reverse a and b are read from the end, so it is the LCS length of a and b[len(b)-j:]
+func lcsLengths(a []string, b []string, reverse bool) []int {
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+
+	for i := range a {
+		ai := a[i]
+		if reverse {
+			ai = a[len(a)-1-i]
+		}
+
+		for j := range b {
+			bj := b[j]
+			if reverse {
+				bj = b[len(b)-1-j]
+			}
+
+			if ai == bj {
+				cur[j+1] = prev[j] + 1
+			} else if prev[j+1] >= cur[j] {
+				cur[j+1] = prev[j+1]
+			} else {
+				cur[j+1] = cur[j]
+			}
+		}
+
+		prev, cur = cur, prev
+	}
+
+	return prev
+}
+
+func splitLines(s string) []string {
+	if s == "" {
+		return []string{}
+	}
+	return strings.Split(strings.TrimSuffix(s, "\n"), "\n")
+}
+
+func hunkRange(n int) string {
+	if n == 0 {
+		return "0,0"
+	}
+	return fmt.Sprintf("1,%v", n)
+}
@@ -0,0 +1,101 @@
+package diff
+
+import (
+	"fmt"
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Unified_Equal(t *testing.T) {
+	assert.Equal(t, "", Unified("a", "b", "x\ny", "x\ny"))
+}
+
+func Test_Unified(t *testing.T) {
+	assert.Equal(t, `--- live
++++ release
+@@ -1,3 +1,3 @@
+ a
+-b
++c
+ d
+`, Unified("live", "release", "a\nb\nd", "a\nc\nd"))
+
+	assert.Equal(t, `--- live
++++ release
+@@ -0,0 +1,1 @@
++a
+`, Unified("live", "release", "", "a"))
+}
+
+// lcsLength is the O(n·m) memory LCS length the diff must match
+func lcsLength(a []string, b []string) int {
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+
+	return lcs[0][0]
+}
+
+func randomLines(r *rand.Rand, n int) []string {
+	lines := make([]string, n)
+	for i := range lines {
+		lines[i] = fmt.Sprintf("%c", 'a'+r.Intn(4))
+	}
+	return lines
+}
+
+func Test_Lines_LongestCommonSubsequence(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+
+	for n := 0; n < 200; n++ {
+		a := randomLines(r, r.Intn(20))
+		b := randomLines(r, r.Intn(20))
+
+		from, to, unchanged := []string{}, []string{}, 0
+		for _, l := range Lines(a, b) {
+			switch l.Op {
+			case ' ':
+				from = append(from, l.Text)
+				to = append(to, l.Text)
+				unchanged++
+			case '-':
+				from = append(from, l.Text)
+			case '+':
+				to = append(to, l.Text)
+			}
+		}
+
+		assert.Equal(t, a, from)
+		assert.Equal(t, b, to)
+		assert.Equal(t, lcsLength(a, b), unchanged, "%q %q", a, b)
+	}
+}
+
+func Test_Lines_Large(t *testing.T) {
+	// The full LCS table of these would be 5000x5000 ints
+	a, b := []string{}, []string{}
+	for i := 0; i < 5000; i++ {
+		a = append(a, fmt.Sprintf("a%v", i))
+		b = append(b, fmt.Sprintf("b%v", i))
+	}
+
+	lines := Lines(a, b)
+	assert.Equal(t, 10000, len(lines))
+	assert.Equal(t, Line{'-', "a0"}, lines[0])
+	assert.Equal(t, Line{'+', "b4999"}, lines[9999])
+}
@@ -24,10 +24,15 @@ func UniqueStrp(strs []*string) bool {
 
 // WithinTimeFrame returns if a time is after and before time from now
 func WithinTimeFrame(tt *time.Time, diff_back time.Duration, diff_forward time.Duration) bool {
+	return WithinTimeFrameAt(tt, diff_back, diff_forward, time.Now())
+}
+
+// WithinTimeFrameAt is WithinTimeFrame with now passed in, times exactly on the boundaries are outside
+func WithinTimeFrameAt(tt *time.Time, diff_back time.Duration, diff_forward time.Duration, now time.Time) bool {
 	// -1 make it subtract
-	ago := time.Now().Add(-1 * diff_back)
+	ago := now.Add(-1 * diff_back)
 
-	ahead := time.Now().Add(diff_forward)
+	ahead := now.Add(diff_forward)
 
 	return tt.After(ago) && tt.Before(ahead)
 }
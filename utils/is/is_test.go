@@ -23,3 +23,12 @@ func Test_WithinTimeFrame(t *testing.T) {
 	assert.False(t, WithinTimeFrame(to.Timep(time.Now().Add(10*time.Minute)), 10*time.Second, 10*time.Second))
 	assert.False(t, WithinTimeFrame(to.Timep(time.Now().Add(-10*time.Minute)), 10*time.Second, 10*time.Second))
 }
+
+func Test_WithinTimeFrameAt(t *testing.T) {
+	now := time.Date(2019, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	assert.True(t, WithinTimeFrameAt(&now, 10*time.Second, 10*time.Second, now))
+	assert.True(t, WithinTimeFrameAt(to.Timep(now.Add(-9*time.Second)), 10*time.Second, 10*time.Second, now))
+	assert.False(t, WithinTimeFrameAt(to.Timep(now.Add(-10*time.Second)), 10*time.Second, 10*time.Second, now))
+	assert.False(t, WithinTimeFrameAt(to.Timep(now.Add(10*time.Second)), 10*time.Second, 10*time.Second, now))
+}
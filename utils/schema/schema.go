@@ -0,0 +1,265 @@
+// Package schema generates a JSON Schema from a structs json tags,
+// and validates raw JSON against the subset of JSON Schema it generates
+package schema
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const Draft = "http://json-schema.org/draft-07/schema#"
+
+// Schema is a JSON Schema with only the keywords Generate and Validate use
+type Schema struct {
+	Schema               string             `json:"$schema,omitempty"`
+	Title                string             `json:"title,omitempty"`
+	Type                 []string           `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	AdditionalProperties interface{}        `json:"additionalProperties,omitempty"` // false or *Schema
+	Items                *Schema            `json:"items,omitempty"`
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// Generate returns the Schema for v using the same field names as encoding/json.
+// Pointers can be null, structs do not allow unknown fields
+func Generate(title string, v interface{}) *Schema {
+	s := generate(reflect.TypeOf(v))
+	s.Schema = Draft
+	s.Title = title
+	return s
+}
+
+func generate(t reflect.Type) *Schema {
+	if t.Kind() == reflect.Ptr {
+		s := generate(t.Elem())
+		if len(s.Type) != 0 {
+			s.Type = append(s.Type, "null")
+		}
+		return s
+	}
+
+	if t == timeType {
+		return &Schema{Type: []string{"string"}, Format: "date-time"}
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return &Schema{Type: []string{"string"}}
+	case reflect.Bool:
+		return &Schema{Type: []string{"boolean"}}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &Schema{Type: []string{"integer"}}
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: []string{"number"}}
+	case reflect.Slice, reflect.Array:
+		return &Schema{Type: []string{"array", "null"}, Items: generate(t.Elem())}
+	case reflect.Map:
+		return &Schema{Type: []string{"object", "null"}, AdditionalProperties: generate(t.Elem())}
+	case reflect.Struct:
+		s := &Schema{Type: []string{"object"}, Properties: map[string]*Schema{}, AdditionalProperties: false}
+		addProperties(s, t)
+		return s
+	}
+
+	// interface{} and anything else can be any value
+	return &Schema{}
+}
+
+// addProperties adds the fields of t, including embedded structs fields like encoding/json
+func addProperties(s *Schema, t reflect.Type) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		name := strings.Split(tag, ",")[0]
+
+		if tag == "-" {
+			continue
+		}
+
+		if field.Anonymous && name == "" && field.Type.Kind() == reflect.Struct {
+			addProperties(s, field.Type)
+			continue
+		}
+
+		if field.PkgPath != "" {
+			continue // unexported
+		}
+
+		if name == "" {
+			name = field.Name
+		}
+
+		s.Properties[name] = generate(field.Type)
+	}
+}
+
+// Parse reads a JSON Schema, keywords other than those on Schema are ignored
+func Parse(raw []byte) (*Schema, error) {
+	var s Schema
+	if err := json.Unmarshal(raw, &s); err != nil {
+		return nil, fmt.Errorf("Schema Error: invalid schema %v", err)
+	}
+	return &s, nil
+}
+
+// UnmarshalJSON allows type to be a string or list, and additionalProperties to be a boolean or schema
+func (s *Schema) UnmarshalJSON(raw []byte) error {
+	type schemaAlias Schema
+	var parsed struct {
+		schemaAlias
+		Type                 json.RawMessage `json:"type"`
+		AdditionalProperties json.RawMessage `json:"additionalProperties"`
+	}
+
+	if err := json.Unmarshal(raw, &parsed); err != nil {
+		return err
+	}
+
+	*s = Schema(parsed.schemaAlias)
+
+	if len(parsed.Type) != 0 {
+		var t string
+		if err := json.Unmarshal(parsed.Type, &t); err == nil {
+			s.Type = []string{t}
+		} else if err := json.Unmarshal(parsed.Type, &s.Type); err != nil {
+			return fmt.Errorf("type must be a string or list of strings")
+		}
+	}
+
+	if len(parsed.AdditionalProperties) != 0 {
+		var allowed bool
+		if err := json.Unmarshal(parsed.AdditionalProperties, &allowed); err == nil {
+			// true is the same as not set
+			if !allowed {
+				s.AdditionalProperties = false
+			}
+		} else {
+			var additional Schema
+			if err := json.Unmarshal(parsed.AdditionalProperties, &additional); err != nil {
+				return err
+			}
+			s.AdditionalProperties = &additional
+		}
+	}
+
+	return nil
+}
+
+// JSON returns the Schema as indented JSON
+func (s *Schema) JSON() string {
+	b, _ := json.MarshalIndent(s, "", "  ")
+	return string(b)
+}
+
+// Validate checks raw JSON against the Schema, returning an error listing every invalid field
+func (s *Schema) Validate(raw []byte) error {
+	decoder := json.NewDecoder(bytes.NewReader(raw))
+	decoder.UseNumber()
+
+	var v interface{}
+	if err := decoder.Decode(&v); err != nil {
+		return fmt.Errorf("Schema Error: invalid JSON %v", err)
+	}
+
+	errs := s.validate("$", v)
+	if len(errs) != 0 {
+		sort.Strings(errs)
+		return fmt.Errorf("Schema Errors %q", errs)
+	}
+
+	return nil
+}
+
+func (s *Schema) validate(path string, v interface{}) []string {
+	if s == nil {
+		return nil
+	}
+
+	vType := jsonType(v)
+	if len(s.Type) != 0 && !s.allows(vType) {
+		return []string{fmt.Sprintf("%v expected %v got %v", path, strings.Join(s.Type, " or "), vType)}
+	}
+
+	errs := []string{}
+	switch v := v.(type) {
+	case string:
+		if s.Format == "date-time" {
+			if _, err := time.Parse(time.RFC3339Nano, v); err != nil {
+				errs = append(errs, fmt.Sprintf("%v expected date-time got %q", path, v))
+			}
+		}
+	case []interface{}:
+		for i, item := range v {
+			errs = append(errs, s.Items.validate(fmt.Sprintf("%v[%v]", path, i), item)...)
+		}
+	case map[string]interface{}:
+		for _, key := range s.Required {
+			if _, ok := v[key]; !ok {
+				errs = append(errs, fmt.Sprintf("%v.%v is required", path, key))
+			}
+		}
+
+		for key, value := range v {
+			keyPath := fmt.Sprintf("%v.%v", path, key)
+			if prop, ok := s.Properties[key]; ok {
+				errs = append(errs, prop.validate(keyPath, value)...)
+				continue
+			}
+
+			if s.AdditionalProperties == false {
+				errs = append(errs, fmt.Sprintf("%v is not a known field", keyPath))
+				continue
+			}
+
+			if additional, ok := s.AdditionalProperties.(*Schema); ok {
+				errs = append(errs, additional.validate(keyPath, value)...)
+			}
+		}
+	}
+
+	return errs
+}
+
+func (s *Schema) allows(vType string) bool {
+	for _, t := range s.Type {
+		if t == vType {
+			return true
+		}
+
+		if t == "number" && vType == "integer" {
+			return true
+		}
+	}
+	return false
+}
+
+func jsonType(v interface{}) string {
+	switch v := v.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case string:
+		return "string"
+	case json.Number:
+		if _, err := strconv.ParseInt(string(v), 10, 64); err == nil {
+			return "integer"
+		}
+		return "number"
+	case []interface{}:
+		return "array"
+	case map[string]interface{}:
+		return "object"
+	}
+	return "unknown"
+}
@@ -0,0 +1,99 @@
+package schema
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type embedded struct {
+	Inner string `json:"inner"`
+}
+
+type example struct {
+	embedded
+
+	Name     *string           `json:"name,omitempty"`
+	Count    int               `json:"count"`
+	Ratio    float64           `json:"ratio"`
+	Ok       bool              `json:"ok"`
+	At       *time.Time        `json:"at,omitempty"`
+	Tags     map[string]string `json:"tags"`
+	List     []*string         `json:"list"`
+	Any      interface{}       `json:"any"`
+	Untagged *string
+	Skipped  string `json:"-"`
+	private  string
+}
+
+func Test_Schema_Generate(t *testing.T) {
+	s := Generate("Example", example{})
+
+	assert.Equal(t, Draft, s.Schema)
+	assert.Equal(t, false, s.AdditionalProperties)
+	assert.Equal(t, []string{"string"}, s.Properties["inner"].Type)
+	assert.Equal(t, []string{"string", "null"}, s.Properties["name"].Type)
+	assert.Equal(t, []string{"integer"}, s.Properties["count"].Type)
+	assert.Equal(t, []string{"number"}, s.Properties["ratio"].Type)
+	assert.Equal(t, "date-time", s.Properties["at"].Format)
+	assert.Equal(t, []string{"string"}, s.Properties["tags"].AdditionalProperties.(*Schema).Type)
+	assert.NotNil(t, s.Properties["Untagged"])
+	assert.Nil(t, s.Properties["Skipped"])
+	assert.Nil(t, s.Properties["private"])
+}
+
+func Test_Schema_Validate(t *testing.T) {
+	s := Generate("Example", &example{})
+
+	assert.NoError(t, s.Validate([]byte(`{
+		"inner": "a", "name": null, "count": 1, "ratio": 1, "ok": true, "at": "2019-01-01T00:00:00Z",
+		"tags": {"a": "b"}, "list": ["a", null], "any": [1, "2"], "Untagged": "u"
+	}`)))
+
+	err := s.Validate([]byte(`{"count": 1.5, "ratio": "1", "tags": {"a": 1}, "unknown": 1}`))
+	assert.Error(t, err)
+	assert.Regexp(t, `\$.count expected integer got number`, err.Error())
+	assert.Regexp(t, `\$.ratio expected number got string`, err.Error())
+	assert.Regexp(t, `\$.tags.a expected string got integer`, err.Error())
+	assert.Regexp(t, `\$.unknown is not a known field`, err.Error())
+
+	err = s.Validate([]byte(`[]`))
+	assert.Error(t, err)
+	assert.Regexp(t, `\$ expected object or null got array`, err.Error())
+
+	err = s.Validate([]byte(`{`))
+	assert.Error(t, err)
+	assert.Regexp(t, `invalid JSON`, err.Error())
+}
+
+func Test_Schema_Parse(t *testing.T) {
+	s, err := Parse([]byte(`{
+		"type": "object",
+		"required": ["env"],
+		"properties": {
+			"env": {"type": "string"},
+			"count": {"type": ["integer", "null"]},
+			"strict": {"type": "object", "additionalProperties": false},
+			"tags": {"additionalProperties": {"type": "string"}},
+			"items": {"type": "array", "items": {"type": "number"}}
+		}
+	}`))
+	assert.NoError(t, err)
+
+	assert.NoError(t, s.Validate([]byte(`{"env": "prod", "count": null, "other": 1, "strict": {}, "tags": {"a": "b"}, "items": [1.5]}`)))
+
+	err = s.Validate([]byte(`{"count": "1", "strict": {"a": 1}, "tags": {"a": 1}, "items": ["1"]}`))
+	assert.Error(t, err)
+	assert.Regexp(t, `\$.env is required`, err.Error())
+	assert.Regexp(t, `\$.count expected integer or null got string`, err.Error())
+	assert.Regexp(t, `\$.strict.a is not a known field`, err.Error())
+	assert.Regexp(t, `\$.tags.a expected string got integer`, err.Error())
+	assert.Regexp(t, `\$.items\[0\] expected number got string`, err.Error())
+
+	_, err = Parse([]byte(`{"type": 1}`))
+	assert.Error(t, err)
+
+	_, err = Parse([]byte(`not json`))
+	assert.Error(t, err)
+}
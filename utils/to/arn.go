@@ -3,54 +3,139 @@ package to
 import (
 	"context"
 	"fmt"
+	"regexp"
 	"strings"
 
 	"github.com/aws/aws-lambda-go/lambdacontext"
 	"github.com/aws/aws-sdk-go/aws/arn"
 )
 
+// DefaultPartition is the standard AWS partition
+const DefaultPartition = "aws"
+
+// PartitionForRegion returns the partition of region, us-gov-* is aws-us-gov and cn-* is aws-cn
+func PartitionForRegion(region *string) string {
+	switch {
+	case region == nil:
+		return DefaultPartition
+	case strings.HasPrefix(*region, "us-gov-"):
+		return "aws-us-gov"
+	case strings.HasPrefix(*region, "cn-"):
+		return "aws-cn"
+	}
+	return DefaultPartition
+}
+
 // LambdaArn takes a name OR arn and returns Arn defaulting to AWS Environment variables
-func LambdaArn(region *string, account_id *string, name_or_arn *string) *string {
-	return createArn("arn:aws:lambda:%v:%v:function:%v", region, account_id, name_or_arn)
+func LambdaArn(partition *string, region *string, account_id *string, name_or_arn *string) *string {
+	return createArn(fmt.Sprintf("arn:%v:lambda:%%v:%%v:function:%%v", partitionOrDefault(partition)), region, account_id, name_or_arn)
 }
 
 // StepArn takes a name OR arn and returns Arn defaulting to AWS Environment variables
-func StepArn(region *string, account_id *string, name_or_arn *string) *string {
-	return createArn("arn:aws:states:%v:%v:stateMachine:%v", region, account_id, name_or_arn)
+func StepArn(partition *string, region *string, account_id *string, name_or_arn *string) *string {
+	return createArn(fmt.Sprintf("arn:%v:states:%%v:%%v:stateMachine:%%v", partitionOrDefault(partition)), region, account_id, name_or_arn)
+}
+
+func RoleArn(partition *string, account_id *string, name_or_arn *string) *string {
+	return createArn(fmt.Sprintf("arn:%v:iam::%%v%%v:role/%%v", partitionOrDefault(partition)), account_id, Strp(""), name_or_arn)
 }
 
-func RoleArn(account_id *string, name_or_arn *string) *string {
-	return createArn("arn:aws:iam::%v%v:role/%v", account_id, Strp(""), name_or_arn)
+func partitionOrDefault(partition *string) string {
+	if partition == nil || *partition == "" {
+		return DefaultPartition
+	}
+	return *partition
 }
 
 // InterpolateArnVariables replaces any resource parameter templates with the appropriate values
 func InterpolateArnVariables(state_machine *string, region *string, account_id *string, name_or_arn *string) *string {
 	variableTemplate := map[string]*string{
-		"{{aws_account}}": account_id,
-		"{{aws_region}}":  region,
-		"{{lambda_name}}": name_or_arn,
+		"{{aws_account}}":   account_id,
+		"{{aws_region}}":    region,
+		"{{aws_partition}}": Strp(PartitionForRegion(region)),
+		"{{lambda_name}}":   name_or_arn,
 	}
 	for k, v := range variableTemplate {
+		if v == nil {
+			// e.g. no lambda_name for a release that does not deploy a Lambda
+			continue
+		}
 		*state_machine = strings.Replace(*state_machine, k, *v, -1)
 	}
 	return state_machine
 }
 
-func ArnPath(arn string) string {
-	_, _, res := ArnRegionAccountResource(arn)
+// Arn is a parsed ARN arn:partition:service:region:account-id:resource
+type Arn struct {
+	Partition string
+	Service   string
+	Region    string
+	AccountID string
+	Resource  string
+}
 
-	path := strings.Split(res, "/")
+// ParseArn parses arnstr into its parts, it supports all partitions e.g. aws-cn and aws-us-gov
+func ParseArn(arnstr string) (*Arn, error) {
+	a, err := arn.Parse(arnstr)
+	if err != nil {
+		return nil, err
+	}
 
-	switch len(path) {
-	case 0:
-		return "/"
-	case 1:
+	return &Arn{
+		Partition: a.Partition,
+		Service:   a.Service,
+		Region:    a.Region,
+		AccountID: a.AccountID,
+		Resource:  a.Resource,
+	}, nil
+}
+
+var layerVersionResource = regexp.MustCompile(`^layer:[a-zA-Z0-9_-]{1,140}:[1-9][0-9]*$`)
+
+// ParseLayerVersionArn parses a Lambda layer version ARN, e.g. arn:aws:lambda:us-east-1:000000:layer:name:1,
+// a layer ARN without a version is an error
+func ParseLayerVersionArn(arnstr string) (*Arn, error) {
+	a, err := ParseArn(arnstr)
+	if err != nil {
+		return nil, err
+	}
+
+	if a.Service != "lambda" || a.Region == "" || a.AccountID == "" || !layerVersionResource.MatchString(a.Resource) {
+		return nil, fmt.Errorf("%q is not a Lambda layer version ARN", arnstr)
+	}
+
+	return a, nil
+}
+
+// String reconstructs the ARN
+func (a *Arn) String() string {
+	return arn.ARN{
+		Partition: a.Partition,
+		Service:   a.Service,
+		Region:    a.Region,
+		AccountID: a.AccountID,
+		Resource:  a.Resource,
+	}.String()
+}
+
+// Path returns the path of resources like IAM roles "role/path/name" -> "/path/", defaulting to "/"
+func (a *Arn) Path() string {
+	path := strings.Split(a.Resource, "/")
+
+	if len(path) <= 2 {
 		return "/"
-	case 2:
+	}
+
+	return fmt.Sprintf("/%v/", strings.Join(path[1:len(path)-1], "/"))
+}
+
+func ArnPath(arnstr string) string {
+	a, err := ParseArn(arnstr)
+	if err != nil {
 		return "/"
-	default:
-		return fmt.Sprintf("/%v/", strings.Join(path[1:len(path)-1], "/"))
 	}
+
+	return a.Path()
 }
 
 func LambdaArnFromContext(ctx context.Context) (string, error) {
@@ -39,3 +39,71 @@ func Test_to_InterpolateArnVariables(t *testing.T) {
 	)
 	assert.Equal(t, *resultStateMachine, DesiredStateMachine)
 }
+
+func Test_to_ParseArn(t *testing.T) {
+	a, err := ParseArn("arn:aws:iam::000000:role/bla/foo/bar")
+	assert.NoError(t, err)
+	assert.Equal(t, "aws", a.Partition)
+	assert.Equal(t, "iam", a.Service)
+	assert.Equal(t, "", a.Region)
+	assert.Equal(t, "000000", a.AccountID)
+	assert.Equal(t, "role/bla/foo/bar", a.Resource)
+	assert.Equal(t, "/bla/foo/", a.Path())
+	assert.Equal(t, "arn:aws:iam::000000:role/bla/foo/bar", a.String())
+
+	a, err = ParseArn("arn:aws:iam::000000:role/bar")
+	assert.NoError(t, err)
+	assert.Equal(t, "/", a.Path())
+
+	a, err = ParseArn("arn:aws-us-gov:states:us-gov-west-1:000000:stateMachine:name")
+	assert.NoError(t, err)
+	assert.Equal(t, "aws-us-gov", a.Partition)
+	assert.Equal(t, "us-gov-west-1", a.Region)
+	assert.Equal(t, "stateMachine:name", a.Resource)
+
+	a, err = ParseArn("arn:aws-cn:iam::000000:role/step/project/config/role")
+	assert.NoError(t, err)
+	assert.Equal(t, "/step/project/config/", a.Path())
+
+	_, err = ParseArn("not:an:arn")
+	assert.Error(t, err)
+}
+
+func Test_to_ParseLayerVersionArn(t *testing.T) {
+	a, err := ParseLayerVersionArn("arn:aws:lambda:us-east-1:000000:layer:shared-code_1:12")
+	assert.NoError(t, err)
+	assert.Equal(t, "us-east-1", a.Region)
+	assert.Equal(t, "layer:shared-code_1:12", a.Resource)
+
+	_, err = ParseLayerVersionArn("arn:aws-cn:lambda:cn-north-1:000000:layer:name:1")
+	assert.NoError(t, err)
+
+	for _, bad := range []string{
+		"arn:aws:lambda:us-east-1:000000:layer:name",        // no version
+		"arn:aws:lambda:us-east-1:000000:layer:name:0",      // versions start at 1
+		"arn:aws:lambda:us-east-1:000000:layer:name:latest", // not a number
+		"arn:aws:lambda:us-east-1:000000:function:name:1",   // not a layer
+		"arn:aws:iam::000000:layer:name:1",                  // not lambda
+		"arn:aws:lambda::000000:layer:name:1",               // no region
+		"arn:aws:lambda:us-east-1:000000:layer:bad/name:1",  // invalid name
+		"layer:name:1", // not an ARN
+	} {
+		_, err := ParseLayerVersionArn(bad)
+		assert.Error(t, err, bad)
+	}
+}
+
+func Test_to_PartitionArns(t *testing.T) {
+	assert.Equal(t, "aws", PartitionForRegion(Strp("us-east-1")))
+	assert.Equal(t, "aws-us-gov", PartitionForRegion(Strp("us-gov-west-1")))
+	assert.Equal(t, "aws-cn", PartitionForRegion(Strp("cn-north-1")))
+	assert.Equal(t, "aws", PartitionForRegion(nil))
+
+	assert.Equal(t, "arn:aws:lambda:us-east-1:0000:function:fname", *LambdaArn(nil, Strp("us-east-1"), Strp("0000"), Strp("fname")))
+	assert.Equal(t, "arn:aws-us-gov:lambda:us-gov-west-1:0000:function:fname", *LambdaArn(Strp("aws-us-gov"), Strp("us-gov-west-1"), Strp("0000"), Strp("fname")))
+	assert.Equal(t, "arn:aws-cn:states:cn-north-1:0000:stateMachine:fname", *StepArn(Strp("aws-cn"), Strp("cn-north-1"), Strp("0000"), Strp("fname")))
+	assert.Equal(t, "arn:aws-us-gov:iam::0000:role/fname", *RoleArn(Strp("aws-us-gov"), Strp("0000"), Strp("fname")))
+
+	sm := "arn:{{aws_partition}}:lambda:{{aws_region}}"
+	assert.Equal(t, "arn:aws-us-gov:lambda:us-gov-east-1", *InterpolateArnVariables(&sm, Strp("us-gov-east-1"), Strp("0000"), Strp("fname")))
+}
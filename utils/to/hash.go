@@ -0,0 +1,88 @@
+package to
+
+import (
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"hash"
+	"io"
+	"os"
+)
+
+// Hash algorithms supported by the Hash helpers
+const (
+	SHA256 = "sha256"
+	SHA512 = "sha512"
+)
+
+// DefaultHashAlgo is used when no algorithm is selected
+const DefaultHashAlgo = SHA256
+
+// newHash returns the hash for algo, an empty algo is DefaultHashAlgo
+func newHash(algo string) (hash.Hash, bool) {
+	switch algo {
+	case "", SHA256:
+		return sha256.New(), true
+	case SHA512:
+		return sha512.New(), true
+	}
+	return nil, false
+}
+
+// ValidHashAlgo returns true if algo is supported, empty is valid and means DefaultHashAlgo
+func ValidHashAlgo(algo string) bool {
+	_, ok := newHash(algo)
+	return ok
+}
+
+// HashStruct returns a hex string of the algo hash of the struct as CanonicalJSON
+func HashStruct(algo string, str interface{}) string {
+	raw, err := CanonicalJSON(str)
+	if err != nil {
+		// No deterministic error
+		return RandomString(10)
+	}
+
+	return HashAByte(algo, &raw)
+}
+
+// HashAByte returns a hex string of the algo hash of a byte array
+func HashAByte(algo string, b *[]byte) string {
+	hasher, ok := newHash(algo)
+	if !ok {
+		// No deterministic error
+		return RandomString(10)
+	}
+
+	hasher.Write(*b)
+	return hex.EncodeToString(hasher.Sum(nil))
+}
+
+// HashFile returns a hex string of the algo hash of a file
+func HashFile(algo string, file_path string) (string, error) {
+	hasher, ok := newHash(algo)
+	if !ok {
+		// No deterministic error
+		return RandomString(10), UnknownHashAlgoError(algo)
+	}
+
+	f, err := os.Open(file_path)
+	if err != nil {
+		// No deterministic error
+		return RandomString(10), err
+	}
+	defer f.Close()
+
+	if _, err := io.Copy(hasher, f); err != nil {
+		// No deterministic error
+		return RandomString(10), err
+	}
+	return hex.EncodeToString(hasher.Sum(nil)), nil
+}
+
+// UnknownHashAlgoError is returned for unsupported hash algorithms
+type UnknownHashAlgoError string
+
+func (e UnknownHashAlgoError) Error() string {
+	return "Unknown hash algorithm " + string(e)
+}
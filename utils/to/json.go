@@ -68,6 +68,27 @@ func PrettyJSONStr(input interface{}) string {
 	return str
 }
 
+// CanonicalJSON marshals input with every object's keys sorted, including keys from
+// json.RawMessage and custom MarshalJSON output, so equal values always give equal bytes
+func CanonicalJSON(input interface{}) ([]byte, error) {
+	raw, err := json.Marshal(input)
+	if err != nil {
+		return nil, err
+	}
+
+	// UseNumber so numbers are not rounded through float64
+	decoder := json.NewDecoder(bytes.NewReader(raw))
+	decoder.UseNumber()
+
+	var v interface{}
+	if err := decoder.Decode(&v); err != nil {
+		return nil, err
+	}
+
+	// json.Marshal sorts map keys
+	return json.Marshal(v)
+}
+
 func CompactJSON(input interface{}) (string, error) {
 	raw, err := AByte(input)
 	if err != nil {
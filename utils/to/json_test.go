@@ -1,6 +1,7 @@
 package to
 
 import (
+	"encoding/json"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -38,3 +39,14 @@ func Test_AByte(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Equal(t, raw, []byte(`{"Name":"asd"}`))
 }
+
+func Test_CanonicalJSON(t *testing.T) {
+	raw, err := CanonicalJSON(struct {
+		B   string
+		A   json.RawMessage
+		Num int64
+	}{"b", json.RawMessage(`{"z": 1, "a": 2}`), 9007199254740993})
+
+	assert.NoError(t, err)
+	assert.Equal(t, `{"A":{"a":2,"z":1},"B":"b","Num":9007199254740993}`, string(raw))
+}
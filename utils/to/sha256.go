@@ -2,20 +2,14 @@ package to
 
 import (
 	"crypto/sha256"
+	"encoding/base64"
 	"encoding/hex"
-	"encoding/json"
-	"io"
-	"os"
+	"fmt"
 )
 
+// SHA256Struct returns a hex string of the SHA256 of the struct as JSON
 func SHA256Struct(str interface{}) string {
-	raw, err := json.Marshal(str)
-	if err != nil {
-		// No deterministic error
-		return RandomString(10)
-	}
-
-	return SHA256AByte(&raw)
+	return HashStruct(SHA256, str)
 }
 
 // SHA256Str returns a hex string of the SHA256 of a string
@@ -26,25 +20,33 @@ func SHA256Str(str *string) string {
 
 // SHA256AByte returns a hex string of the SHA256 of a byte array
 func SHA256AByte(b *[]byte) string {
-	sum := sha256.Sum256(*b)
-	sha := hex.EncodeToString(sum[:])
-	return sha
+	return HashAByte(SHA256, b)
 }
 
 // SHA256File returns a hex string of the SHA256 of a file
 func SHA256File(file_path string) (string, error) {
-	f, err := os.Open(file_path)
+	return HashFile(SHA256, file_path)
+}
+
+// HexToBase64 converts a hex SHA (like LambdaSHA256) into the base64 format AWS Lambda uses for CodeSha256
+func HexToBase64(hexStr string) (string, error) {
+	raw, err := hex.DecodeString(hexStr)
 	if err != nil {
-		// No deterministic error
-		return RandomString(10), err
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(raw), nil
+}
+
+// NormalizeSHA256 converts a hex or base64 SHA256 into base64, the format AWS Lambda uses for CodeSha256,
+// so SHAs can be compared whichever encoding a client sent
+func NormalizeSHA256(sha string) (string, error) {
+	if raw, err := hex.DecodeString(sha); err == nil && len(raw) == sha256.Size {
+		return base64.StdEncoding.EncodeToString(raw), nil
 	}
-	defer f.Close()
 
-	hasher := sha256.New()
-	if _, err := io.Copy(hasher, f); err != nil {
-		// No deterministic error
-		return RandomString(10), err
+	if raw, err := base64.StdEncoding.DecodeString(sha); err == nil && len(raw) == sha256.Size {
+		return sha, nil
 	}
-	sha := hex.EncodeToString(hasher.Sum(nil))
-	return sha, nil
+
+	return "", fmt.Errorf("%q is not a hex or base64 encoded 32 byte SHA256", sha)
 }
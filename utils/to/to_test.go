@@ -1,6 +1,7 @@
 package to
 
 import (
+	"encoding/json"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -25,3 +26,58 @@ func Test_to_ArnRegionAccountResource(t *testing.T) {
 	assert.Equal(t, "000000", a)
 	assert.Equal(t, "instance-profile/bla/foo/bar", res)
 }
+
+func Test_to_HexToBase64(t *testing.T) {
+	b64, err := HexToBase64("00ff")
+	assert.NoError(t, err)
+	assert.Equal(t, "AP8=", b64)
+
+	_, err = HexToBase64("not hex")
+	assert.Error(t, err)
+}
+
+func Test_to_NormalizeSHA256(t *testing.T) {
+	b := []byte("abc")
+	hexSHA := SHA256AByte(&b)
+	b64, _ := HexToBase64(hexSHA)
+
+	sha, err := NormalizeSHA256(hexSHA)
+	assert.NoError(t, err)
+	assert.Equal(t, "ungWv48Bz+pBQUDeXa4iI7ADYaOWF3qctBD/YfIAFa0=", sha)
+
+	sha, err = NormalizeSHA256(b64)
+	assert.NoError(t, err)
+	assert.Equal(t, b64, sha)
+
+	for _, bad := range []string{"", "sha", "00ff", "AP8=", HashAByte(SHA512, &b)} {
+		_, err = NormalizeSHA256(bad)
+		assert.Error(t, err, bad)
+	}
+}
+
+func Test_to_HashAByte(t *testing.T) {
+	b := []byte("abc")
+	assert.Equal(t, SHA256AByte(&b), HashAByte("", &b))
+	assert.Equal(t, "ba7816bf8f01cfea414140de5dae2223b00361a396177a9cb410ff61f20015ad", HashAByte(SHA256, &b))
+	assert.Equal(t, 128, len(HashAByte(SHA512, &b)))
+	assert.True(t, ValidHashAlgo(SHA512))
+	assert.False(t, ValidHashAlgo("md5"))
+}
+
+func Test_to_SHA256Struct_MapOrder(t *testing.T) {
+	type tagged struct {
+		Tags map[string]string
+		Raw  json.RawMessage
+	}
+
+	first := tagged{Tags: map[string]string{}, Raw: json.RawMessage(`{"a":1,"b":2}`)}
+	second := tagged{Tags: map[string]string{}, Raw: json.RawMessage(`{"b":2,"a":1}`)}
+	keys := []string{"ProjectName", "ConfigName", "DeployWith", "Env", "a", "z"}
+	for i := range keys {
+		first.Tags[keys[i]] = keys[i]
+		second.Tags[keys[len(keys)-1-i]] = keys[len(keys)-1-i]
+	}
+
+	assert.Equal(t, SHA256Struct(&first), SHA256Struct(&second))
+	assert.Equal(t, SHA256Struct(first), SHA256Struct(&second))
+}